@@ -0,0 +1,16 @@
+// Package api embeds the OpenAPI 3 document of the app and the Swagger UI page rendering it
+package api
+
+import (
+	_ "embed"
+)
+
+// OpenAPI is the OpenAPI 3 document of every route in app/routes.go
+//
+//go:embed openapi.json
+var OpenAPI []byte
+
+// SwaggerUI is the page rendering OpenAPI at /openapi.json, Swagger UI assets are loaded from unpkg
+//
+//go:embed swagger.html
+var SwaggerUI []byte
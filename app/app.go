@@ -2,44 +2,47 @@ package app
 
 import (
 	"context"
+	"errors"
+	"github.com/gosnmp/gosnmp"
 	"github.com/megadata-dev/go-snmp-olt-zte-c320/config"
 	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/handler"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/middleware"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/model"
 	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/repository"
 	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/usecase"
-	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/utils"
 	"github.com/megadata-dev/go-snmp-olt-zte-c320/pkg/graceful"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/pkg/jwt"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/pkg/oltcli"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/pkg/pubsub"
 	"github.com/megadata-dev/go-snmp-olt-zte-c320/pkg/redis"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/pkg/server"
 	"github.com/megadata-dev/go-snmp-olt-zte-c320/pkg/snmp"
 	rds "github.com/redis/go-redis/v9"
 	"github.com/rs/zerolog/log"
+	"net"
 	"net/http"
-	"os"
+	"time"
 )
 
 type App struct {
+	cfg    *config.Config
 	router http.Handler
 }
 
-func New() *App {
-	return &App{}
+// New creates the application of a loaded and validated config
+func New(cfg *config.Config) *App {
+	return &App{cfg: cfg}
 }
 
 func (a *App) Start(ctx context.Context) error {
 
-	// Get config path from APP_ENV environment variable
-	configPath := utils.GetConfigPath(os.Getenv("APP_ENV"))
-
-	// Load configuration file from config path
-	cfg, err := config.LoadConfig(configPath)
-	if err != nil {
-		log.Error().Err(err).Msg("Failed to load config")
-	}
+	cfg := a.cfg
 
 	// Initialize Redis client
 	redisClient := redis.NewRedisClient(cfg)
 
 	// Check Redis connection
-	err = redisClient.Ping(ctx).Err()
+	err := redisClient.Ping(ctx).Err()
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to ping Redis server")
 	} else {
@@ -84,29 +87,206 @@ func (a *App) Start(ctx context.Context) error {
 		}
 	}()
 
+	// Initialize OLT CLI driver, the connection is opened on the first command
+	var cliRepo repository.OltCliRepositoryInterface
+	cliDriver, err := oltcli.SetupCliDriver(cfg)
+	if errors.Is(err, oltcli.ErrNotConfigured) {
+		log.Info().Msg("OLT CLI is not configured, CLI based endpoints are disabled")
+	} else if err != nil {
+		log.Error().Err(err).Msg("Failed to setup OLT CLI driver")
+	} else {
+		cliRepo = repository.NewOltCliRepository(cliDriver)
+
+		// Close OLT CLI session after application shutdown
+		defer func() {
+			if err := cliDriver.Close(); err != nil {
+				log.Error().Err(err).Msg("Failed to close OLT CLI driver")
+			}
+		}()
+	}
+
 	// Initialize repository
-	snmpRepo := repository.NewPonRepository(snmpConn)
 	redisRepo := repository.NewOnuRedisRepo(redisClient)
+	actionLogRepo := repository.NewOnuActionLogRedisRepo(redisClient)
+	alarmAckRepo := repository.NewAlarmAckRedisRepo(redisClient)
+	changeRepo := repository.NewOnuChangeRedisRepo(redisClient)
+	customerRepo := repository.NewCustomerRedisRepo(redisClient)
+	statusRepo := repository.NewOnuStatusRedisRepo(redisClient)
+	cacheRepo := repository.NewCacheRedisRepo(redisClient)
+	apiKeyRepo := repository.NewApiKeyRedisRepo(redisClient)
+	rateLimitRepo := repository.NewRateLimitRedisRepo(redisClient)
+
+	// Initialize audit log, the audit routes respond 503 when it isn't enabled
+	var auditRepo repository.AuditRepositoryInterface
+	if !cfg.AuditCfg.Enabled {
+		log.Warn().Msg("Audit log is disabled, changes are not recorded")
+	} else if auditFileRepo, err := repository.NewAuditFileRepository(cfg.AuditCfg.Path); err != nil {
+		log.Error().Err(err).Msg("Failed to open audit log, changes are not recorded")
+	} else {
+		auditRepo = auditFileRepo
+
+		// Close audit log after application shutdown
+		defer func() {
+			if err := auditFileRepo.Close(); err != nil {
+				log.Error().Err(err).Msg("Failed to close audit log")
+			}
+		}()
+	}
+	auditUsecase := usecase.NewAuditUsecase(auditRepo)
+
+	// SNMP requests of every usecase take tokens of the SNMP budget of the OLT
+	rateLimitUsecase := usecase.NewRateLimitUsecase(rateLimitRepo, cfg)
+	snmpRepo := rateLimitUsecase.LimitSnmp(repository.NewPonRepository(snmpConn))
 
 	// Initialize usecase
-	onuUsecase := usecase.NewOnuUsecase(snmpRepo, redisRepo, cfg)
+	onuUsecase := usecase.NewOnuUsecase(snmpRepo, redisRepo, changeRepo, customerRepo, cfg)
+	provisionUsecase := usecase.NewOnuProvisionUsecase(onuUsecase, snmpRepo, redisRepo, actionLogRepo, cfg)
+	serviceUsecase := usecase.NewOnuServiceUsecase(cliRepo)
+	alarmUsecase := usecase.NewAlarmUsecase(snmpRepo, alarmAckRepo, cfg)
+	changeUsecase := usecase.NewOnuChangeUsecase(changeRepo)
+	customerUsecase := usecase.NewCustomerUsecase(customerRepo)
+	mapUsecase := usecase.NewOnuMapUsecase(onuUsecase, cfg)
+	onuV2Usecase := usecase.NewOnuV2Usecase(onuUsecase)
+	cacheUsecase := usecase.NewCacheUsecase(onuUsecase, cacheRepo, cfg)
+	apiKeyUsecase := usecase.NewApiKeyUsecase(apiKeyRepo, cfg)
+
+	// Initialize ONU event broker, events are published by the SNMP trap listener and poller
+	onuEventBroker := pubsub.NewBroker[model.OnuEvent]()
+	defer onuEventBroker.Close()
+	streamUsecase := usecase.NewOnuStreamUsecase(onuEventBroker, cfg.StreamCfg.HistorySize)
+	trapUsecase := usecase.NewOnuTrapUsecase(redisRepo, onuEventBroker, cfg)
+	pollerUsecase := usecase.NewOnuPollerUsecase(snmpRepo, redisRepo, onuEventBroker, cfg)
+	anomalyUsecase := usecase.NewAnomalyUsecase(onuUsecase, onuEventBroker, cfg)
+	slaUsecase := usecase.NewSlaUsecase(onuUsecase, snmpRepo, statusRepo, onuEventBroker, cfg)
+
+	// Event context is cancelled on server shutdown, so open streams are closed
+	eventCtx, cancelEvents := context.WithCancel(ctx)
+	defer cancelEvents()
+
+	// Start ONU event stream
+	go streamUsecase.Run(eventCtx)
+
+	// Start ONU change poller
+	if cfg.StreamCfg.PollInterval > 0 {
+		go pollerUsecase.Run(eventCtx, time.Duration(cfg.StreamCfg.PollInterval)*time.Second)
+	} else {
+		log.Info().Msg("ONU change poller is disabled")
+	}
+
+	// Start anomaly detection, serial numbers of all PON are checked every check_interval
+	go anomalyUsecase.Run(eventCtx, time.Duration(cfg.AnomalyCfg.CheckInterval)*time.Second)
+
+	// Start SLA recording, ONU status transitions are kept in Redis
+	go slaUsecase.Run(eventCtx)
+
+	// Initialize SNMP trap listener
+	trapListener, err := snmp.SetupTrapListener(cfg, func(packet *gosnmp.SnmpPacket, _ *net.UDPAddr) {
+		trapUsecase.HandleTrap(eventCtx, packet)
+	})
+	if errors.Is(err, snmp.ErrTrapNotConfigured) {
+		log.Info().Msg("SNMP trap listener is not enabled")
+	} else if err != nil {
+		log.Error().Err(err).Msg("Failed to setup SNMP trap listener")
+	} else {
+		trapErr := make(chan error, 1)
+		go func() {
+			trapErr <- trapListener.Listen()
+		}()
+
+		// Wait until the listener is ready, Close must not be called before
+		select {
+		case <-trapListener.Listening():
+			log.Info().Msg("SNMP trap listener started")
+
+			// Stop SNMP trap listener after application shutdown
+			defer trapListener.Close()
+		case err := <-trapErr:
+			log.Error().Err(err).Msg("Failed to start SNMP trap listener")
+		}
+	}
 
 	// Initialize handler
 	onuHandler := handler.NewOnuHandler(onuUsecase)
+	provisionHandler := handler.NewOnuProvisionHandler(provisionUsecase)
+	serviceHandler := handler.NewOnuServiceHandler(serviceUsecase)
+	streamHandler := handler.NewOnuStreamHandler(streamUsecase,
+		time.Duration(cfg.StreamCfg.HeartbeatInterval)*time.Second)
+
+	alarmHandler := handler.NewAlarmHandler(alarmUsecase)
+	anomalyHandler := handler.NewAnomalyHandler(anomalyUsecase)
+	changeHandler := handler.NewOnuChangeHandler(changeUsecase)
+	customerHandler := handler.NewCustomerHandler(customerUsecase)
+	mapHandler := handler.NewOnuMapHandler(mapUsecase)
+	slaHandler := handler.NewSlaHandler(slaUsecase)
+	onuV2Handler := handler.NewOnuV2Handler(onuV2Usecase)
+	docsHandler := handler.NewDocsHandler()
+	cacheHandler := handler.NewCacheHandler(cacheUsecase)
+	apiKeyHandler := handler.NewApiKeyHandler(apiKeyUsecase)
+	auditHandler := handler.NewAuditHandler(auditUsecase)
+
+	// Initialize bearer token verifier, tokens are accepted next to API keys when enabled
+	var jwtAuthUsecase usecase.JwtAuthUseCaseInterface
+	jwtVerifier, err := jwt.SetupVerifier(cfg)
+	if errors.Is(err, jwt.ErrNotConfigured) {
+		log.Info().Msg("JWT authentication is not enabled")
+	} else if err != nil {
+		log.Error().Err(err).Msg("Failed to setup JWT verifier, bearer tokens are rejected")
+	} else {
+		jwtAuthUsecase = usecase.NewJwtAuthUsecase(jwtVerifier, cfg)
+	}
+
+	// Initialize authentication, every route except the root and docs needs an API key or bearer token when enabled
+	var middlewares routeMiddlewares
+	serverCfg := cfg.ServerCfg
+	trustedProxies, err := server.ParseTrustedProxies(serverCfg.TrustedProxies)
+	if err != nil {
+		return err
+	}
+	if len(trustedProxies) > 0 {
+		middlewares.realIP = middleware.RealIP(trustedProxies)
+	}
+	if len(serverCfg.CorsAllowedOrigins) > 0 {
+		middlewares.cors = middleware.CorsMiddleware(serverCfg.CorsAllowedOrigins)
+	} else {
+		log.Info().Msg("No CORS origin is allowed, browsers only call the API from its own origin")
+	}
+	if auditRepo != nil {
+		middlewares.audit = middleware.Audit(auditUsecase)
+	}
+	if cfg.AuthCfg.Enabled {
+		middlewares.auth = middleware.Auth(apiKeyUsecase, jwtAuthUsecase, cfg.StreamCfg.OltName)
+	} else {
+		log.Warn().Msg("API key authentication is disabled, every route is public")
+	}
+
+	// Initialize rate limiting of clients and of SNMP requests to the OLT
+	if cfg.RateLimitCfg.Enabled {
+		middlewares.ipLimit = middleware.RateLimitIP(rateLimitUsecase)
+		middlewares.rateLimit = middleware.RateLimit(rateLimitUsecase)
+		middlewares.snmpBudget = middleware.SnmpBudget(rateLimitUsecase)
+	} else {
+		log.Warn().Msg("Rate limiting is disabled")
+	}
 
 	// Initialize router
-	a.router = loadRoutes(onuHandler)
+	a.router = loadRoutes(onuHandler, provisionHandler, serviceHandler, streamHandler, alarmHandler, anomalyHandler,
+		changeHandler, customerHandler, mapHandler, slaHandler, onuV2Handler, docsHandler, cacheHandler, apiKeyHandler,
+		auditHandler, middlewares)
 
 	// Start server
-	addr := "8081"
-	server := &http.Server{
-		Addr:    ":" + addr,
-		Handler: a.router,
+	httpServer, err := server.NewServer(serverCfg, a.router)
+	if err != nil {
+		return err
 	}
+	httpServer.RegisterOnShutdown(cancelEvents)
 
 	// Start server at given address
-	log.Info().Msgf("Application started at %s", addr)
+	if httpServer.TLSConfig != nil {
+		log.Info().Msgf("Application started at %s with TLS", httpServer.Addr)
+	} else {
+		log.Info().Msgf("Application started at %s", httpServer.Addr)
+	}
 
 	// Graceful shutdown
-	return graceful.Shutdown(ctx, server)
+	return graceful.Shutdown(ctx, httpServer)
 }
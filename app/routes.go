@@ -10,7 +10,25 @@ import (
 	"os"
 )
 
-func loadRoutes(onuHandler *handler.OnuHandler) http.Handler {
+// routeMiddlewares are the middlewares of optional features, nil when a feature is disabled
+type routeMiddlewares struct {
+	realIP     func(http.Handler) http.Handler // Takes the client IP of requests of trusted proxies from their headers
+	cors       func(http.Handler) http.Handler // Allows browser requests of the allowed origins
+	audit      func(http.Handler) http.Handler // Records requests that change something in the audit log
+	ipLimit    func(http.Handler) http.Handler // Limits requests of each client IP
+	auth       func(http.Handler) http.Handler // Authenticates requests with an API key or bearer token
+	rateLimit  func(http.Handler) http.Handler // Limits requests of each API key or bearer token subject
+	snmpBudget func(http.Handler) http.Handler // Rejects requests reading the OLT while its SNMP budget is saturated
+}
+
+func loadRoutes(
+	onuHandler *handler.OnuHandler, provisionHandler *handler.OnuProvisionHandler,
+	serviceHandler *handler.OnuServiceHandler, streamHandler *handler.OnuStreamHandler, alarmHandler *handler.AlarmHandler,
+	anomalyHandler *handler.AnomalyHandler, changeHandler *handler.OnuChangeHandler,
+	customerHandler *handler.CustomerHandler, mapHandler *handler.OnuMapHandler, slaHandler *handler.SlaHandler,
+	onuV2Handler *handler.OnuV2Handler, docsHandler *handler.DocsHandler, cacheHandler *handler.CacheHandler,
+	apiKeyHandler *handler.ApiKeyHandler, auditHandler *handler.AuditHandler, middlewares routeMiddlewares,
+) http.Handler {
 
 	// Initialize logger
 	l := log.Output(zerolog.ConsoleWriter{
@@ -20,38 +38,155 @@ func loadRoutes(onuHandler *handler.OnuHandler) http.Handler {
 	// Initialize router using chi
 	router := chi.NewRouter()
 
+	// Middleware for the client IP, before logging so requests are logged with the client IP
+	router.Use(optional(middlewares.realIP))
+
 	// Middleware for logging requests
 	router.Use(middleware.Logger(l))
 
 	// Middleware for CORS
-	router.Use(middleware.CorsMiddleware())
+	router.Use(optional(middlewares.cors))
+
+	// Middleware for rate limiting by client IP, before authentication so failed attempts are limited too
+	router.Use(optional(middlewares.ipLimit))
+
+	// Middleware for the audit log, after the client IP rate limit so clients can't flood it, before authentication
+	// so rejected requests are recorded too
+	router.Use(optional(middlewares.audit))
+
+	// Middleware for authentication
+	router.Use(optional(middlewares.auth))
+
+	// Middleware for rate limiting, after authentication so clients are also limited by API key or token
+	router.Use(optional(middlewares.rateLimit))
+
+	// Middleware for routes reading the OLT over SNMP
+	snmpBudget := optional(middlewares.snmpBudget)
 
 	// Define a simple root endpoint
 	router.Get("/", rootHandler)
 
+	// Define routes for the OpenAPI document and the Swagger UI rendering it
+	router.Get("/openapi.json", docsHandler.GetOpenAPI)
+	router.Get("/docs", docsHandler.GetSwaggerUI)
+
 	// Create a group for /api/v1/
 	apiV1Group := chi.NewRouter()
 
 	// Define routes for /api/v1/
 	apiV1Group.Route("/board", func(r chi.Router) {
+		r.Use(snmpBudget)
 		r.Get("/{board_id}/pon/{pon_id}", onuHandler.GetByBoardIDAndPonID)
 		r.Get("/{board_id}/pon/{pon_id}/onu/{onu_id}", onuHandler.GetByBoardIDPonIDAndOnuID)
+		r.Get("/{board_id}/pon/{pon_id}/onu/{onu_id}/uni", onuHandler.GetOnuUniInfo)
+		r.Get("/{board_id}/pon/{pon_id}/onu/{onu_id}/services", serviceHandler.GetOnuServices)
 		r.Get("/{board_id}/pon/{pon_id}/onu_id/empty", onuHandler.GetEmptyOnuID)
 		r.Get("/{board_id}/pon/{pon_id}/onu_id_sn", onuHandler.GetOnuIDAndSerialNumber)
-		r.Get("/{board_id}/pon/{pon_id}/onu_id/update", onuHandler.UpdateEmptyOnuID)
+		r.With(middleware.Deprecated("/api/v1/cache/refresh")).
+			Get("/{board_id}/pon/{pon_id}/onu_id/update", onuHandler.UpdateEmptyOnuID)
+		r.Get("/{board_id}/pon/{pon_id}/firmware", onuHandler.GetFirmwareByBoardIDAndPonID)
+		r.Get("/{board_id}/pon/{pon_id}/unconfigured", onuHandler.GetUnconfiguredByBoardIDAndPonID)
+		r.Post("/{board_id}/pon/{pon_id}/onu", provisionHandler.RegisterOnu)
+		r.Delete("/{board_id}/pon/{pon_id}/onu/{onu_id}", provisionHandler.DeregisterOnu)
+		r.Patch("/{board_id}/pon/{pon_id}/onu/{onu_id}", provisionHandler.UpdateOnu)
+		r.Post("/{board_id}/pon/{pon_id}/onu/{onu_id}/reboot", provisionHandler.RebootOnu)
+		r.Get("/{board_id}/pon/{pon_id}/onu/{onu_id}/actions", provisionHandler.GetOnuActionLog)
+	})
+
+	// Define route for unconfigured ONU of all PON
+	apiV1Group.With(snmpBudget).Get("/unconfigured", onuHandler.GetUnconfigured)
+
+	// Define route for live ONU events as Server-Sent Events
+	apiV1Group.Get("/stream", streamHandler.Stream)
+
+	// Define route for ONU added, removed, renamed or moved
+	apiV1Group.Get("/changes", changeHandler.GetChanges)
+
+	// Define routes for /api/v1/customers, customer metadata is keyed by ONU serial number
+	apiV1Group.Route("/customers", func(r chi.Router) {
+		r.Get("/", customerHandler.GetCustomers)
+		r.Post("/import", customerHandler.ImportCustomers)
+		r.Get("/{serial_number}", customerHandler.GetCustomer)
+		r.Put("/{serial_number}", customerHandler.SaveCustomer)
+		r.Delete("/{serial_number}", customerHandler.DeleteCustomer)
+	})
+
+	// Define route for the ONU map as GeoJSON
+	apiV1Group.With(snmpBudget).Get("/map.geojson", mapHandler.GetOnuMap)
+
+	// Define routes for /api/v1/alarms
+	apiV1Group.Route("/alarms", func(r chi.Router) {
+		r.Use(snmpBudget)
+		r.Get("/", alarmHandler.GetAlarms)
+		r.Post("/{alarm_id}/ack", alarmHandler.AcknowledgeAlarm)
+		r.Delete("/{alarm_id}/ack", alarmHandler.UnacknowledgeAlarm)
+	})
+
+	// Define routes for /api/v1/inventory
+	apiV1Group.Route("/inventory", func(r chi.Router) {
+		r.Use(snmpBudget)
+		r.Get("/firmware", onuHandler.GetFirmwareReport)
+	})
+
+	// Define routes for /api/v1/reports
+	apiV1Group.Route("/reports", func(r chi.Router) {
+		r.Get("/anomalies", anomalyHandler.GetAnomalyReport)
+		r.Get("/sla", slaHandler.GetSlaReport)
+	})
+
+	// Define routes for /api/v1/cache, cached ONU list, empty ONU ID and firmware of each PON
+	apiV1Group.Route("/cache", func(r chi.Router) {
+		r.Delete("/", cacheHandler.PurgeCache)
+		r.With(snmpBudget).Post("/refresh", cacheHandler.RefreshCache)
+		r.Get("/status", cacheHandler.GetCacheStatus)
+	})
+
+	// Define routes for /api/v1/auth/keys, keys are only returned when created
+	apiV1Group.Route("/auth/keys", func(r chi.Router) {
+		r.Get("/", apiKeyHandler.GetApiKeys)
+		r.Post("/", apiKeyHandler.CreateApiKey)
+		r.Delete("/{key_id}", apiKeyHandler.RevokeApiKey)
+	})
+
+	// Define routes for /api/v1/audit, records are newest first and the export is JSON lines oldest first
+	apiV1Group.Route("/audit", func(r chi.Router) {
+		r.Get("/", auditHandler.GetAuditRecords)
+		r.Get("/export", auditHandler.ExportAuditRecords)
 	})
 
 	// Define routes for /api/v1/paginate
 	apiV1Group.Route("/paginate", func(r chi.Router) {
+		r.Use(snmpBudget)
 		r.Get("/board/{board_id}/pon/{pon_id}", onuHandler.GetByBoardIDAndPonIDWithPaginate)
 	})
 
 	// Mount /api/v1/ to root router
 	router.Mount("/api/v1", apiV1Group)
 
+	// Create a group for /api/v2/, ONU with typed values
+	apiV2Group := chi.NewRouter()
+
+	// Define routes for /api/v2/
+	apiV2Group.Route("/board", func(r chi.Router) {
+		r.Use(snmpBudget)
+		r.Get("/{board_id}/pon/{pon_id}", onuV2Handler.GetByBoardIDAndPonID)
+		r.Get("/{board_id}/pon/{pon_id}/onu/{onu_id}", onuV2Handler.GetByBoardIDPonIDAndOnuID)
+	})
+
+	// Mount /api/v2/ to root router
+	router.Mount("/api/v2", apiV2Group)
+
 	return router
 }
 
+// optional is a function to get a middleware passing requests through when the middleware of a feature is nil
+func optional(middleware func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	if middleware == nil {
+		return func(next http.Handler) http.Handler { return next }
+	}
+	return middleware
+}
+
 // rootHandler is a simple handler for root endpoint
 func rootHandler(w http.ResponseWriter, _ *http.Request) {
 	w.WriteHeader(http.StatusOK)                                // Set HTTP status code to 200
@@ -0,0 +1,394 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/go-chi/chi/v5"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/api"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/handler"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/middleware"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/model"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/usecase"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeApiKeyUsecase authenticates the keys of a map
+type fakeApiKeyUsecase struct {
+	usecase.ApiKeyUseCaseInterface
+	keys map[string]model.ApiKey
+}
+
+func (f *fakeApiKeyUsecase) Authenticate(_ context.Context, key string) (model.ApiKey, error) {
+	apiKey, ok := f.keys[key]
+	if !ok {
+		return model.ApiKey{}, usecase.ErrApiKeyInvalid
+	}
+	return apiKey, nil
+}
+
+// fakeJwtAuthUsecase authenticates the bearer tokens of a map
+type fakeJwtAuthUsecase struct {
+	tokens map[string]model.ApiKey
+}
+
+func (f *fakeJwtAuthUsecase) Authenticate(_ context.Context, token string) (model.ApiKey, error) {
+	identity, ok := f.tokens[token]
+	if !ok {
+		return model.ApiKey{}, usecase.ErrJwtInvalid
+	}
+	return identity, nil
+}
+
+// fakeRateLimitUsecase limits the clients of a set and reports the SNMP budget as saturated or not
+type fakeRateLimitUsecase struct {
+	usecase.RateLimitUseCaseInterface
+	limited   map[string]bool
+	saturated bool
+}
+
+func (f *fakeRateLimitUsecase) AllowIP(ctx context.Context, ip string) (time.Duration, error) {
+	return f.AllowClient(ctx, "ip:"+ip)
+}
+
+func (f *fakeRateLimitUsecase) AllowClient(_ context.Context, clientID string) (time.Duration, error) {
+	if f.limited[clientID] {
+		return 1500 * time.Millisecond, usecase.ErrRateLimited
+	}
+	return 0, nil
+}
+
+func (f *fakeRateLimitUsecase) SnmpSaturated() (time.Duration, bool) {
+	return 200 * time.Millisecond, f.saturated
+}
+
+// fakeAuditUsecase keeps the records of the audit middleware
+type fakeAuditUsecase struct {
+	usecase.AuditUseCaseInterface
+	records []model.AuditRecord
+}
+
+func (f *fakeAuditUsecase) Record(record model.AuditRecord) error {
+	f.records = append(f.records, record)
+	return nil
+}
+
+// newTestRouter builds the routes of the app without authentication, handlers are never called
+func newTestRouter() http.Handler {
+	return newTestRouterWithMiddlewares(routeMiddlewares{})
+}
+
+// newTestRouterWithMiddlewares builds the routes of the app with the middlewares of optional features
+func newTestRouterWithMiddlewares(middlewares routeMiddlewares) http.Handler {
+	return loadRoutes(
+		handler.NewOnuHandler(nil), handler.NewOnuProvisionHandler(nil), handler.NewOnuServiceHandler(nil),
+		handler.NewOnuStreamHandler(nil, 0), handler.NewAlarmHandler(nil), handler.NewAnomalyHandler(nil),
+		handler.NewOnuChangeHandler(nil), handler.NewCustomerHandler(nil), handler.NewOnuMapHandler(nil),
+		handler.NewSlaHandler(nil), handler.NewOnuV2Handler(nil), handler.NewDocsHandler(),
+		handler.NewCacheHandler(nil), handler.NewApiKeyHandler(nil), handler.NewAuditHandler(nil), middlewares,
+	)
+}
+
+// getOpenAPIOperations returns the methods of every path in the OpenAPI document, e.g. "GET /api/v1/changes"
+func getOpenAPIOperations(t *testing.T) map[string]bool {
+	var doc struct {
+		OpenAPI string                                `json:"openapi"`
+		Paths   map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	require.NoError(t, json.Unmarshal(api.OpenAPI, &doc))
+	require.True(t, strings.HasPrefix(doc.OpenAPI, "3."))
+
+	operations := make(map[string]bool)
+	for path, item := range doc.Paths {
+		for method := range item {
+			operations[strings.ToUpper(method)+" "+path] = true
+		}
+	}
+	return operations
+}
+
+func TestRoutesDocumentedInOpenAPI(t *testing.T) {
+	operations := getOpenAPIOperations(t)
+
+	routes := make(map[string]bool)
+	err := chi.Walk(newTestRouter().(chi.Routes), func(method, route string, _ http.Handler,
+		_ ...func(http.Handler) http.Handler) error {
+		// Sub-router index routes are registered with a trailing slash, e.g. /api/v1/alarms/
+		if route != "/" {
+			route = strings.TrimSuffix(route, "/")
+		}
+		routes[method+" "+route] = true
+		return nil
+	})
+	require.NoError(t, err)
+
+	for route := range routes {
+		assert.True(t, operations[route], "route %s is missing from api/openapi.json", route)
+	}
+	for operation := range operations {
+		assert.True(t, routes[operation], "operation %s of api/openapi.json isn't a route", operation)
+	}
+}
+
+func TestDocsRoutes(t *testing.T) {
+	router := newTestRouter()
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+	assert.True(t, json.Valid(recorder.Body.Bytes()))
+
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `url: "/openapi.json"`)
+}
+
+func TestDeprecatedRoutes(t *testing.T) {
+	router := newTestRouter()
+
+	// Board 9 is rejected before the usecase is called, the headers are set anyway
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/v1/board/9/pon/1/onu_id/update", nil))
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Equal(t, "true", recorder.Header().Get("Deprecation"))
+	assert.Equal(t, `</api/v1/cache/refresh>; rel="successor-version"`, recorder.Header().Get("Link"))
+}
+
+func TestAuthRoutes(t *testing.T) {
+	router := newTestRouterWithMiddlewares(routeMiddlewares{auth: middleware.Auth(&fakeApiKeyUsecase{keys: map[string]model.ApiKey{
+		"reader":  {ID: "reader", Scopes: []string{"read"}},
+		"board-2": {ID: "board-2", Scopes: []string{"admin"}, Boards: []int{2}},
+	}}, &fakeJwtAuthUsecase{tokens: map[string]model.ApiKey{
+		"viewer-token": {ID: "jwt:alice", Scopes: []string{"read"}},
+		"no-role":      {ID: "jwt:bob"},
+	}}, "olt-1")})
+
+	tests := []struct {
+		method string
+		target string
+		key    string
+		token  string
+		code   int
+	}{
+		{http.MethodGet, "/openapi.json", "", "", http.StatusOK}, // Public
+		{http.MethodGet, "/api/v1/board/9/pon/1", "", "", http.StatusUnauthorized},
+		{http.MethodGet, "/api/v1/board/9/pon/1", "revoked", "", http.StatusUnauthorized},
+		{http.MethodGet, "/api/v1/board/9/pon/1", "reader", "", http.StatusBadRequest}, // Rejected by the handler
+		{http.MethodPost, "/api/v1/board/9/pon/1/onu", "reader", "", http.StatusForbidden},
+		{http.MethodGet, "/api/v1/auth/keys", "reader", "", http.StatusForbidden},
+		{http.MethodGet, "/api/v1/audit", "reader", "", http.StatusForbidden},
+		{http.MethodGet, "/api/v1/board/1/pon/1", "board-2", "", http.StatusForbidden},
+		{http.MethodGet, "/api/v1/alarms", "board-2", "", http.StatusForbidden}, // Selects no board
+		{http.MethodGet, "/api/v1/cache/status?board=1", "board-2", "", http.StatusForbidden},
+		{http.MethodGet, "/api/v1/board/9/pon/1/onu_id/update", "reader", "", http.StatusForbidden}, // Refreshes the cache
+		{http.MethodGet, "/api/v1/board/9/pon/1/onu_id/update", "", "viewer-token", http.StatusForbidden},
+		{http.MethodPost, "/api/v1/board/2/pon/9/onu", "board-2", "", http.StatusBadRequest},
+		{http.MethodGet, "/api/v1/board/9/pon/1", "", "viewer-token", http.StatusBadRequest},
+		{http.MethodPost, "/api/v1/board/9/pon/1/onu", "", "viewer-token", http.StatusForbidden},
+		{http.MethodGet, "/api/v1/board/9/pon/1", "", "no-role", http.StatusForbidden},
+		{http.MethodGet, "/api/v1/board/9/pon/1", "reader", "expired", http.StatusUnauthorized}, // Token first
+	}
+	for _, test := range tests {
+		request := httptest.NewRequest(test.method, test.target, nil)
+		if test.key != "" {
+			request.Header.Set(middleware.ApiKeyHeader, test.key)
+		}
+		if test.token != "" {
+			request.Header.Set("Authorization", "Bearer "+test.token)
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		assert.Equal(t, test.code, recorder.Code, "%s %s with key %s and token %s",
+			test.method, test.target, test.key, test.token)
+		if test.code == http.StatusUnauthorized {
+			assert.Equal(t, `Bearer realm="api"`, recorder.Header().Get("WWW-Authenticate"))
+		}
+	}
+}
+
+func TestRateLimitRoutes(t *testing.T) {
+	rateLimit := &fakeRateLimitUsecase{limited: map[string]bool{"ip:192.0.2.1": true, "key:reader": true}}
+	router := newTestRouterWithMiddlewares(routeMiddlewares{
+		auth: middleware.Auth(&fakeApiKeyUsecase{keys: map[string]model.ApiKey{
+			"reader": {ID: "reader", Scopes: []string{"read"}},
+			"noc":    {ID: "noc", Scopes: []string{"read"}},
+		}}, nil, "olt-1"),
+		ipLimit:    middleware.RateLimitIP(rateLimit),
+		rateLimit:  middleware.RateLimit(rateLimit),
+		snmpBudget: middleware.SnmpBudget(rateLimit),
+	})
+
+	// Requests are limited by IP before authentication, httptest requests come from 192.0.2.1
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	assert.Equal(t, "2", recorder.Header().Get("Retry-After"))
+
+	request := httptest.NewRequest(http.MethodGet, "/api/v1/board/9/pon/1", nil)
+	request.Header.Set(middleware.ApiKeyHeader, "unknown")
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusTooManyRequests, recorder.Code) // Not authenticated
+
+	// Then by API key
+	request = httptest.NewRequest(http.MethodGet, "/api/v1/board/9/pon/1", nil)
+	request.RemoteAddr = "198.51.100.7:40000"
+	request.Header.Set(middleware.ApiKeyHeader, "unknown")
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	request = httptest.NewRequest(http.MethodGet, "/api/v1/board/9/pon/1", nil)
+	request.RemoteAddr = "198.51.100.7:40000"
+	request.Header.Set(middleware.ApiKeyHeader, "reader")
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusTooManyRequests, recorder.Code)
+
+	request = httptest.NewRequest(http.MethodGet, "/api/v1/board/9/pon/1", nil)
+	request.RemoteAddr = "198.51.100.7:40000"
+	request.Header.Set(middleware.ApiKeyHeader, "noc")
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code) // Rejected by the handler
+
+	// Routes reading the OLT are rejected while the SNMP budget is saturated
+	rateLimit.saturated = true
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	assert.Equal(t, "1", recorder.Header().Get("Retry-After"))
+
+	request = httptest.NewRequest(http.MethodGet, "/openapi.json", nil)
+	request.RemoteAddr = "198.51.100.7:40000"
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestAuditRoutes(t *testing.T) {
+	audit := &fakeAuditUsecase{}
+	rateLimit := &fakeRateLimitUsecase{limited: map[string]bool{"ip:198.51.100.7": true}}
+	router := newTestRouterWithMiddlewares(routeMiddlewares{
+		ipLimit: middleware.RateLimitIP(rateLimit),
+		audit:   middleware.Audit(audit),
+		auth: middleware.Auth(&fakeApiKeyUsecase{keys: map[string]model.ApiKey{
+			"noc":    {ID: "noc", Scopes: []string{"provision"}},
+			"reader": {ID: "reader", Scopes: []string{"read"}},
+		}}, nil, "olt-1"),
+	})
+
+	// Reads aren't recorded
+	request := httptest.NewRequest(http.MethodGet, "/api/v1/board/9/pon/1", nil)
+	request.Header.Set(middleware.ApiKeyHeader, "noc")
+	router.ServeHTTP(httptest.NewRecorder(), request)
+	assert.Empty(t, audit.records)
+
+	request = httptest.NewRequest(http.MethodPost, "/api/v1/board/9/pon/3/onu?dry_run=true",
+		strings.NewReader(`{"onu_id": 12, "serial_number": "ZTEGC0000001"}`))
+	request.Header.Set(middleware.ApiKeyHeader, "noc")
+	request.Header.Set("X-Actor", "alice")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code) // Rejected by the handler
+
+	request = httptest.NewRequest(http.MethodDelete, "/api/v1/board/1/pon/2/onu/5", nil)
+	request.Header.Set(middleware.ApiKeyHeader, "reader")
+	router.ServeHTTP(httptest.NewRecorder(), request)
+
+	// The X-Actor header of a request failing authentication isn't its actor
+	request = httptest.NewRequest(http.MethodDelete, "/api/v1/board/1/pon/2/onu/5", nil)
+	request.Header.Set("X-Actor", "admin")
+	router.ServeHTTP(httptest.NewRecorder(), request)
+
+	// The deprecated GET alias of a cache refresh is recorded
+	request = httptest.NewRequest(http.MethodGet, "/api/v1/board/1/pon/2/onu_id/update", nil)
+	request.Header.Set(middleware.ApiKeyHeader, "reader")
+	router.ServeHTTP(httptest.NewRecorder(), request)
+
+	// Rate limited clients aren't recorded
+	request = httptest.NewRequest(http.MethodDelete, "/api/v1/board/1/pon/2/onu/5", nil)
+	request.RemoteAddr = "198.51.100.7:40000"
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusTooManyRequests, recorder.Code)
+
+	require.Len(t, audit.records, 4)
+	record := audit.records[0]
+	assert.Equal(t, "noc (alice)", record.Actor)
+	assert.Equal(t, "alice", record.ClaimedActor)
+	assert.Equal(t, "noc", record.KeyID)
+	assert.Equal(t, "192.0.2.1", record.SourceIP)
+	assert.Equal(t, "/api/v1/board/{board_id}/pon/{pon_id}/onu", record.Route)
+	assert.Equal(t, map[string]string{"board_id": "9", "pon_id": "3", "dry_run": "true"}, record.Params)
+	assert.JSONEq(t, `{"onu_id": 12, "serial_number": "ZTEGC0000001"}`, string(record.Body))
+	assert.Equal(t, []int{9, 3, 12}, []int{record.Board, record.PON, record.OnuID})
+	assert.Equal(t, http.StatusBadRequest, record.Status)
+	assert.NotEmpty(t, record.Error)
+
+	// Rejected requests are recorded too
+	record = audit.records[1]
+	assert.Equal(t, "reader", record.KeyID)
+	assert.Equal(t, http.StatusForbidden, record.Status)
+	assert.Equal(t, []int{1, 2, 5}, []int{record.Board, record.PON, record.OnuID})
+
+	record = audit.records[2]
+	assert.Empty(t, record.Actor)
+	assert.Equal(t, "admin", record.ClaimedActor)
+	assert.Equal(t, http.StatusUnauthorized, record.Status)
+
+	record = audit.records[3]
+	assert.Equal(t, http.MethodGet, record.Method)
+	assert.Equal(t, "reader", record.KeyID)
+	assert.Equal(t, http.StatusForbidden, record.Status)
+	assert.Equal(t, []int{1, 2}, []int{record.Board, record.PON})
+}
+
+func TestServerRoutes(t *testing.T) {
+	_, proxies, err := net.ParseCIDR("10.0.0.0/8")
+	require.NoError(t, err)
+	rateLimit := &fakeRateLimitUsecase{limited: map[string]bool{"ip:203.0.113.9": true}}
+	router := newTestRouterWithMiddlewares(routeMiddlewares{
+		realIP:  middleware.RealIP([]*net.IPNet{proxies}),
+		cors:    middleware.CorsMiddleware([]string{"https://*.example.com"}),
+		ipLimit: middleware.RateLimitIP(rateLimit),
+	})
+
+	tests := []struct {
+		remoteAddr   string
+		forwardedFor string
+		code         int
+	}{
+		// The client is left of the last trusted proxy
+		{"10.0.0.5:40000", "203.0.113.9, 10.0.0.7", http.StatusTooManyRequests},
+		{"10.0.0.5:40000", "203.0.113.9, 198.51.100.4", http.StatusOK},
+		// Headers of clients that aren't trusted proxies are ignored
+		{"198.51.100.4:40000", "203.0.113.9", http.StatusOK},
+		{"203.0.113.9:40000", "", http.StatusTooManyRequests},
+	}
+	for _, test := range tests {
+		request := httptest.NewRequest(http.MethodGet, "/openapi.json", nil)
+		request.RemoteAddr = test.remoteAddr
+		request.Header.Set("X-Forwarded-For", test.forwardedFor)
+		request.Header.Set("Origin", "https://noc.example.com")
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		assert.Equal(t, test.code, recorder.Code, "%s forwarding %s", test.remoteAddr, test.forwardedFor)
+		assert.Equal(t, "https://noc.example.com", recorder.Header().Get("Access-Control-Allow-Origin"))
+	}
+
+	// Origins that aren't allowed get no CORS headers
+	request := httptest.NewRequest(http.MethodGet, "/openapi.json", nil)
+	request.Header.Set("Origin", "https://evil.example.org")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	assert.Empty(t, recorder.Header().Get("Access-Control-Allow-Origin"))
+}
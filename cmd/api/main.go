@@ -2,13 +2,29 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"github.com/megadata-dev/go-snmp-olt-zte-c320/app"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/config"
 	"github.com/rs/zerolog/log"
+	"os"
+	_ "time/tzdata" // OLT timezones are loaded by name, also on images without a zoneinfo database
 )
 
 func main() {
+	// Load config from defaults, config file, environment variables and flags, the app doesn't start without it
+	cfg, err := config.Load(os.Args[1:])
+	if errors.Is(err, config.ErrHelp) {
+		return
+	}
+	if err != nil {
+		log.Error().Msg("Failed to load config")
+		fmt.Fprintln(os.Stderr, err) // Report of every invalid field
+		os.Exit(1)
+	}
+
 	// Initialize application
-	server := app.New()                                     // Create a new instance of application
+	server := app.New(cfg)                                  // Create a new instance of application
 	ctx, cancel := context.WithCancel(context.Background()) // Create a new context with cancel function
 	defer cancel()                                          // Cancel context when the main function is finished
 
@@ -1,36 +1,62 @@
 package config
 
-import (
-	"errors"
-	"github.com/spf13/viper"
-)
-
 type Config struct {
-	SnmpCfg    SnmpConfig
-	RedisCfg   RedisConfig
-	OltCfg     OltConfig
-	Board1Pon1 Board1Pon1
-	Board1Pon2 Board1Pon2
-	Board1Pon3 Board1Pon3
-	Board1Pon4 Board1Pon4
-	Board1Pon5 Board1Pon5
-	Board1Pon6 Board1Pon6
-	Board1Pon7 Board1Pon7
-	Board1Pon8 Board1Pon8
-	Board2Pon1 Board2Pon1
-	Board2Pon2 Board2Pon2
-	Board2Pon3 Board2Pon3
-	Board2Pon4 Board2Pon4
-	Board2Pon5 Board2Pon5
-	Board2Pon6 Board2Pon6
-	Board2Pon7 Board2Pon7
-	Board2Pon8 Board2Pon8
+	ServerCfg    ServerConfig
+	SnmpCfg      SnmpConfig
+	RedisCfg     RedisConfig
+	CliCfg       CliConfig
+	TrapCfg      TrapConfig
+	StreamCfg    StreamConfig
+	AnomalyCfg   AnomalyConfig
+	AuthCfg      AuthConfig
+	RateLimitCfg RateLimitConfig
+	AuditCfg     AuditConfig
+	OltCfg       OltConfig
+	Board1Pon1   Board1Pon1
+	Board1Pon2   Board1Pon2
+	Board1Pon3   Board1Pon3
+	Board1Pon4   Board1Pon4
+	Board1Pon5   Board1Pon5
+	Board1Pon6   Board1Pon6
+	Board1Pon7   Board1Pon7
+	Board1Pon8   Board1Pon8
+	Board2Pon1   Board2Pon1
+	Board2Pon2   Board2Pon2
+	Board2Pon3   Board2Pon3
+	Board2Pon4   Board2Pon4
+	Board2Pon5   Board2Pon5
+	Board2Pon6   Board2Pon6
+	Board2Pon7   Board2Pon7
+	Board2Pon8   Board2Pon8
+}
+
+// ServerConfig is the HTTP server, timeouts are in seconds and 0 disables a timeout
+type ServerConfig struct {
+	Host               string          `mapstructure:"host"` // Listen address, every interface when empty
+	Port               string          `mapstructure:"port"`
+	ReadTimeout        int             `mapstructure:"read_timeout"`
+	ReadHeaderTimeout  int             `mapstructure:"read_header_timeout"`
+	WriteTimeout       int             `mapstructure:"write_timeout"` // Event streams clear it for their connection
+	IdleTimeout        int             `mapstructure:"idle_timeout"`
+	MaxHeaderBytes     int             `mapstructure:"max_header_bytes"`
+	TLS                ServerTLSConfig `mapstructure:"tls"`
+	CorsAllowedOrigins []string        `mapstructure:"cors_allowed_origins"` // e.g. https://*.example.com, none when empty
+	TrustedProxies     []string        `mapstructure:"trusted_proxies"`      // IPs or CIDRs whose X-Forwarded-For is used
+}
+
+// ServerTLSConfig serves HTTPS, the certificate and key are loaded again when their files change
+type ServerTLSConfig struct {
+	Enabled        bool   `mapstructure:"enabled"`
+	CertFile       string `mapstructure:"cert_file"`
+	KeyFile        string `mapstructure:"key_file"`
+	ReloadInterval int    `mapstructure:"reload_interval"` // seconds between checks of the files
 }
 
 type SnmpConfig struct {
 	Ip        string `mapstructure:"ip"`
 	Port      uint16 `mapstructure:"port"`
 	Community string `mapstructure:"community"`
+	Debug     bool   `mapstructure:"debug"` // Logs every SNMP packet
 }
 
 type RedisConfig struct {
@@ -44,11 +70,173 @@ type RedisConfig struct {
 	PoolTimeout        int    `mapstructure:"pool_timeout"`
 }
 
+type CliConfig struct {
+	Protocol              string `mapstructure:"protocol"`
+	Host                  string `mapstructure:"host"`
+	Port                  int    `mapstructure:"port"`
+	Username              string `mapstructure:"username"`
+	Password              string `mapstructure:"password"`
+	HostKeyFingerprint    string `mapstructure:"host_key_fingerprint"`
+	InsecureIgnoreHostKey bool   `mapstructure:"insecure_ignore_host_key"`
+	DialTimeout           int    `mapstructure:"dial_timeout"`
+	CommandTimeout        int    `mapstructure:"command_timeout"`
+}
+
+type TrapConfig struct {
+	Enabled        bool   `mapstructure:"enabled"`
+	Address        string `mapstructure:"address"`
+	Community      string `mapstructure:"community"`
+	Username       string `mapstructure:"username"`
+	AuthProtocol   string `mapstructure:"auth_protocol"`
+	AuthPassphrase string `mapstructure:"auth_passphrase"`
+	PrivProtocol   string `mapstructure:"priv_protocol"`
+	PrivPassphrase string `mapstructure:"priv_passphrase"`
+	EngineID       string `mapstructure:"engine_id"`
+}
+
+type StreamConfig struct {
+	OltName           string  `mapstructure:"olt_name"`
+	PollInterval      int     `mapstructure:"poll_interval"`
+	RxPowerThreshold  float64 `mapstructure:"rx_power_threshold"`
+	HeartbeatInterval int     `mapstructure:"heartbeat_interval"`
+	HistorySize       int     `mapstructure:"history_size"`
+}
+
+type AnomalyConfig struct {
+	CheckInterval int `mapstructure:"check_interval"`
+	FlapWindow    int `mapstructure:"flap_window"`
+	FlapThreshold int `mapstructure:"flap_threshold"`
+	DropWindow    int `mapstructure:"drop_window"`
+	DropThreshold int `mapstructure:"drop_threshold"`
+	Retention     int `mapstructure:"retention"`
+}
+
+type AuthConfig struct {
+	Enabled bool           `mapstructure:"enabled"`
+	Keys    []ApiKeyConfig `mapstructure:"keys"`
+	Jwt     JwtConfig      `mapstructure:"jwt"`
+}
+
+// ApiKeyConfig is an API key defined in config, it can't be revoked through the API
+type ApiKeyConfig struct {
+	ID     string   `mapstructure:"id"`
+	Name   string   `mapstructure:"name"`
+	Hash   string   `mapstructure:"hash"`   // SHA-256 of the key, hex encoded
+	Scopes []string `mapstructure:"scopes"` // read, provision or admin
+	Olts   []string `mapstructure:"olts"`   // OLT names, any when empty
+	Boards []int    `mapstructure:"boards"` // Board IDs, any when empty
+	Pons   []string `mapstructure:"pons"`   // PON as board/pon, e.g. 2/7, any when empty
+}
+
+// RateLimitConfig limits requests of each client and SNMP requests to the OLT, buckets are kept in Redis
+// so limits hold across replicas
+type RateLimitConfig struct {
+	Enabled       bool    `mapstructure:"enabled"`
+	IpRate        float64 `mapstructure:"ip_rate"`         // Requests per second of each client IP, before authentication
+	IpBurst       int     `mapstructure:"ip_burst"`        // Requests a client IP can make at once
+	ClientRate    float64 `mapstructure:"client_rate"`     // Requests per second of each API key or token
+	ClientBurst   int     `mapstructure:"client_burst"`    // Requests a client can make at once
+	SnmpRate      float64 `mapstructure:"snmp_rate"`       // SNMP requests per second to the OLT
+	SnmpBurst     int     `mapstructure:"snmp_burst"`      // SNMP requests the OLT gets at once
+	SnmpWalkCost  int     `mapstructure:"snmp_walk_cost"`  // Tokens of an SNMP walk, a walk sends many requests
+	SnmpMaxWait   int     `mapstructure:"snmp_max_wait"`   // seconds an SNMP request waits for tokens
+	SnmpQueueSize int     `mapstructure:"snmp_queue_size"` // SNMP requests waiting before routes reading the OLT get 429
+}
+
+// AuditConfig records mutating and administrative requests in an append-only JSON lines file
+type AuditConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Path    string `mapstructure:"path"` // Audit log file, created when missing
+}
+
+// JwtConfig validates bearer tokens of an OIDC provider, keys come from jwks_url or keys
+type JwtConfig struct {
+	Enabled             bool            `mapstructure:"enabled"`
+	JwksURL             string          `mapstructure:"jwks_url"`
+	JwksRefreshInterval int             `mapstructure:"jwks_refresh_interval"` // seconds
+	Keys                []JwtKeyConfig  `mapstructure:"keys"`
+	Issuer              string          `mapstructure:"issuer"`     // Required iss claim, any when empty
+	Audience            string          `mapstructure:"audience"`   // Required aud claim, any when empty
+	ClockSkew           int             `mapstructure:"clock_skew"` // seconds
+	RolesClaim          string          `mapstructure:"roles_claim"`
+	Roles               []JwtRoleConfig `mapstructure:"roles"`
+}
+
+// JwtKeyConfig is a static key verifying tokens with kid, a PEM public key or an HMAC secret
+type JwtKeyConfig struct {
+	Kid       string `mapstructure:"kid"`
+	PublicKey string `mapstructure:"public_key"`
+	Secret    string `mapstructure:"secret"`
+}
+
+// JwtRoleConfig maps a value of the roles claim to viewer, operator or admin
+type JwtRoleConfig struct {
+	Value string `mapstructure:"value"`
+	Role  string `mapstructure:"role"`
+}
+
 type OltConfig struct {
 	BaseOID1        string `mapstructure:"base_oid_1"`
 	BaseOID2        string `mapstructure:"base_oid_2"`
 	OnuIDNameAllPon string `mapstructure:"onu_id_name"`
 	OnuTypeAllPon   string `mapstructure:"onu_type"`
+
+	// OLT clock timezone, an IANA name such as Asia/Jakarta or an offset such as +07:00, UTC when empty
+	Timezone string `mapstructure:"timezone"`
+
+	// Remote ONU management tables (base_oid_2), indexed by PON port index and ONU ID
+	OnuUniEthAdminStateOID  string `mapstructure:"onu_uni_eth_admin_state"`
+	OnuUniEthOperStateOID   string `mapstructure:"onu_uni_eth_oper_state"`
+	OnuUniEthSpeedDuplexOID string `mapstructure:"onu_uni_eth_speed_duplex"`
+	OnuUniMacAddressOID     string `mapstructure:"onu_uni_mac_address"`
+
+	// ONU version tables (base_oid_2), indexed by PON port index and ONU ID (and image ID for software images)
+	OnuVendorIDOID             string `mapstructure:"onu_vendor_id"`
+	OnuEquipmentIDOID          string `mapstructure:"onu_equipment_id"`
+	OnuHardwareVersionOID      string `mapstructure:"onu_hardware_version"`
+	OnuSoftwareImageVersionOID string `mapstructure:"onu_software_image_version"`
+	OnuSoftwareImageActiveOID  string `mapstructure:"onu_software_image_active"`
+
+	// Unconfigured ONU (autofind) table (base_oid_2), indexed by PON port index and discovery sequence
+	OnuUncfgSerialNumberOID  string `mapstructure:"onu_uncfg_serial_number"`
+	OnuUncfgEquipmentIDOID   string `mapstructure:"onu_uncfg_equipment_id"`
+	OnuUncfgDiscoveryTimeOID string `mapstructure:"onu_uncfg_discovery_time"`
+
+	// ONU registration table (base_oid_2), indexed by PON port index and ONU ID
+	OnuRegisterTypeOID         string `mapstructure:"onu_register_type"`
+	OnuRegisterNameOID         string `mapstructure:"onu_register_name"`
+	OnuRegisterSerialNumberOID string `mapstructure:"onu_register_serial_number"`
+	OnuRegisterRowStatusOID    string `mapstructure:"onu_register_row_status"`
+
+	// ONU remote action table (base_oid_2), indexed by PON port index and ONU ID
+	OnuRebootOID string `mapstructure:"onu_reboot"`
+
+	// ONU phase state column (base_oid_1) for all PON, indexed by gpon-olt ifIndex and ONU ID
+	OnuStatusAllPon string `mapstructure:"onu_status_all_pon"`
+
+	// ONU last offline reason column (base_oid_1) for all PON, indexed by gpon-olt ifIndex and ONU ID
+	OnuLastOfflineReasonAllPon string `mapstructure:"onu_last_offline_reason_all_pon"`
+
+	// ONU RX power column (base_oid_1) for all PON, indexed by gpon-olt ifIndex, ONU ID and 1
+	OnuRxPowerAllPon string `mapstructure:"onu_rx_power_all_pon"`
+
+	// ONU alarm table (base_oid_2), indexed by PON port index and ONU ID
+	OnuAlarmTypeOID     string `mapstructure:"onu_alarm_type"`
+	OnuAlarmSeverityOID string `mapstructure:"onu_alarm_severity"`
+
+	// Notification OIDs sent as snmpTrapOID, state change is under base_oid_1 and alarm under base_oid_2
+	TrapOnuStateChangeOID string `mapstructure:"trap_onu_state_change"`
+	TrapOnuAlarmOID       string `mapstructure:"trap_onu_alarm"`
+
+	// OLT active alarm table (base_oid_2), indexed by alarm sequence number
+	OltAlarmTableOID         string `mapstructure:"olt_alarm_table"`
+	OltAlarmCodeOID          string `mapstructure:"olt_alarm_code"`
+	OltAlarmSeverityOID      string `mapstructure:"olt_alarm_severity"`
+	OltAlarmSourceIfIndexOID string `mapstructure:"olt_alarm_source_ifindex"`
+	OltAlarmSourceOnuOID     string `mapstructure:"olt_alarm_source_onu"`
+	OltAlarmSourceSlotOID    string `mapstructure:"olt_alarm_source_slot"`
+	OltAlarmRaiseTimeOID     string `mapstructure:"olt_alarm_raise_time"`
+	OltAlarmDescriptionOID   string `mapstructure:"olt_alarm_description"`
 }
 
 type Board1Pon1 struct {
@@ -290,37 +478,3 @@ type Board2Pon8 struct {
 	OnuLastOfflineReasonOID   string `mapstructure:"onu_last_offline_reason"`
 	OnuGponOpticalDistanceOID string `mapstructure:"onu_gpon_optical_distance"`
 }
-
-// LoadConfig file from given path using viper
-func LoadConfig(filename string) (*Config, error) {
-
-	// Initialize viper
-	v := viper.New()
-
-	// Set config file name
-	v.SetConfigName(filename)
-
-	// Set config path in current directory
-	v.AddConfigPath(".")
-
-	// Allow environment variables to override config
-	v.AutomaticEnv()
-
-	// Read config file
-	if err := v.ReadInConfig(); err != nil {
-		var configFileNotFoundError viper.ConfigFileNotFoundError // Initialize config file not found error
-		if errors.As(err, &configFileNotFoundError) {
-			return nil, errors.New("config file not found")
-		}
-		return nil, err
-	}
-
-	var cfg Config // Initialize config variable
-
-	// Unmarshal config
-	if err := v.Unmarshal(&cfg); err != nil {
-		return nil, err
-	}
-
-	return &cfg, nil
-}
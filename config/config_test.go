@@ -0,0 +1,131 @@
+package config
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	cfg, err := Load([]string{"--config", "cfg.yaml"})
+	require.NoError(t, err)
+	assert.Equal(t, "192.168.213.174", cfg.SnmpCfg.Ip)
+	assert.Equal(t, uint16(161), cfg.SnmpCfg.Port)
+	assert.True(t, cfg.SnmpCfg.Debug)
+	assert.Equal(t, "8081", cfg.ServerCfg.Port)
+
+	// Environment variables override the config file, a prefixed name wins over its short alias and flags win over both
+	t.Setenv("SNMP_HOST", "10.0.0.1")
+	t.Setenv("SNMP_COMMUNITY", "public")
+	t.Setenv("REDIS_PORT", "6380")
+	cfg, err = Load([]string{"--config", "cfg.yaml"})
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.0.1", cfg.SnmpCfg.Ip)
+	assert.Equal(t, "public", cfg.SnmpCfg.Community)
+	assert.Equal(t, "6380", cfg.RedisCfg.Port)
+
+	t.Setenv("OLT_SNMPCFG_IP", "10.0.0.2")
+	t.Setenv("OLT_SERVERCFG_CORS_ALLOWED_ORIGINS", "https://noc.example.com,https://ops.example.com")
+	cfg, err = Load([]string{"--config", "cfg.yaml"})
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.0.2", cfg.SnmpCfg.Ip)
+	assert.Equal(t, []string{"https://noc.example.com", "https://ops.example.com"}, cfg.ServerCfg.CorsAllowedOrigins)
+
+	cfg, err = Load([]string{"--config", "cfg.yaml", "--snmpcfg.ip", "10.0.0.3", "--servercfg.port=9090"})
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.0.3", cfg.SnmpCfg.Ip)
+	assert.Equal(t, "9090", cfg.ServerCfg.Port)
+
+	// The config file is selected with OLT_CONFIG without flag, authentication of production needs a key source
+	t.Setenv(ConfigFileEnv, "config-prod.yaml")
+	t.Setenv("OLT_AUTHCFG_JWT_ENABLED", "true")
+	t.Setenv("OLT_AUTHCFG_JWT_JWKS_URL", "https://sso.example.com/certs")
+	t.Setenv("OLT_AUTHCFG_JWT_AUDIENCE", "olt-api")
+	cfg, err = Load(nil)
+	require.NoError(t, err)
+	assert.True(t, cfg.AuthCfg.Enabled)
+	assert.Equal(t, "", cfg.ServerCfg.Host)
+	assert.Equal(t, "10.0.0.2", cfg.SnmpCfg.Ip)
+	assert.Equal(t, uint16(161), cfg.SnmpCfg.Port) // Default
+
+	_, err = Load([]string{"--config", "missing.yaml"})
+	assert.EqualError(t, err, "config file missing.yaml not found")
+
+	_, err = Load([]string{"--unknown"})
+	assert.Error(t, err)
+}
+
+func TestLoadInvalid(t *testing.T) {
+	// The development config has no SNMP section, every invalid field is reported
+	_, err := Load([]string{"--config", "config-dev.yml"})
+
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr), "%v", err)
+	assert.Equal(t, []FieldError{
+		{Field: "SnmpCfg.ip", Message: "is required"},
+		{Field: "SnmpCfg.community", Message: "is required"},
+	}, validationErr.Fields)
+}
+
+func TestValidate(t *testing.T) {
+	cfg, err := Load([]string{"--config", "cfg.yaml"})
+	require.NoError(t, err)
+
+	cfg.ServerCfg.Port = "80a"
+	cfg.ServerCfg.TrustedProxies = []string{"10.0.0.0/8", "proxy"}
+	cfg.RedisCfg.Host = "redis host"
+	cfg.OltCfg.BaseOID1 = "1.3.6.1"
+	cfg.OltCfg.Timezone = "Asia/Atlantis"
+	cfg.Board2Pon8.OnuIDNameOID = ""
+	cfg.TrapCfg.Enabled = true
+	cfg.TrapCfg.Address = "0.0.0.0:70000"
+	cfg.AuthCfg.Enabled = true
+	cfg.AuthCfg.Jwt.Enabled = true
+	cfg.AuthCfg.Jwt.JwksURL = "https://sso.example.com/certs"
+
+	err = cfg.Validate()
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr), "%v", err)
+	assert.Equal(t, []FieldError{
+		{Field: "ServerCfg.port", Message: `"80a" is not a port between 1 and 65535`},
+		{Field: "ServerCfg.trusted_proxies", Message: `"proxy" is not an IP or CIDR`},
+		{Field: "RedisCfg.host", Message: `"redis host" is not an IP or host name`},
+		{Field: "TrapCfg.address", Message: `"70000" is not a port between 1 and 65535`},
+		{Field: "AuthCfg.jwt.audience", Message: "is required with jwks_url when issuer is empty"},
+		{Field: "OltCfg.base_oid_1", Message: `"1.3.6.1" is not an OID, e.g. .1.3.6.1`},
+		{Field: "OltCfg.timezone", Message: `"Asia/Atlantis" is not a timezone name or UTC offset, e.g. Asia/Jakarta or +07:00`},
+		{Field: "Board2Pon8.onu_id_name", Message: "is required"},
+	}, validationErr.Fields)
+	assert.Contains(t, err.Error(), "invalid config, 8 invalid fields:\n  ServerCfg.port: ")
+
+	// Names of the timezone database and offsets from UTC are valid
+	for _, timezone := range []string{"", "Asia/Jakarta", "+07:00", "-03:30"} {
+		cfg.OltCfg.Timezone = timezone
+		err = cfg.Validate()
+		require.True(t, errors.As(err, &validationErr), "%v", err)
+		assert.NotContains(t, err.Error(), "OltCfg.timezone")
+	}
+
+	// Authentication without a key or bearer tokens would lock every client out
+	cfg.AuthCfg.Jwt.Enabled = false
+	err = cfg.Validate()
+	require.True(t, errors.As(err, &validationErr), "%v", err)
+	assert.Contains(t, validationErr.Fields, FieldError{
+		Field: "AuthCfg.keys", Message: "needs a key, or AuthCfg.jwt enabled with jwks_url or keys, when auth is enabled",
+	})
+
+	// SSH checks the host key unless it is explicitly ignored
+	cfg.CliCfg.Host = "10.0.0.1"
+	cfg.CliCfg.Protocol = "ssh"
+	err = cfg.Validate()
+	require.True(t, errors.As(err, &validationErr), "%v", err)
+	assert.Contains(t, validationErr.Fields, FieldError{
+		Field: "CliCfg.host_key_fingerprint", Message: "is required with ssh unless insecure_ignore_host_key is set",
+	})
+
+	cfg.CliCfg.InsecureIgnoreHostKey = true
+	err = cfg.Validate()
+	require.True(t, errors.As(err, &validationErr), "%v", err)
+	assert.NotContains(t, err.Error(), "CliCfg.host_key_fingerprint")
+}
@@ -0,0 +1,201 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+const (
+	// EnvPrefix is the prefix of environment variables, e.g. OLT_SNMPCFG_IP sets SnmpCfg.ip
+	EnvPrefix = "OLT"
+
+	// ConfigFileEnv selects the config file when the --config flag isn't given
+	ConfigFileEnv = EnvPrefix + "_CONFIG"
+
+	defaultConfigFile = "./config/cfg.yaml"
+)
+
+// ErrHelp is returned by Load when the flags ask for help, the usage is already printed
+var ErrHelp = pflag.ErrHelp
+
+// envAliases are the short environment variable names of a key or of every key of a section,
+// e.g. REDIS_HOST sets RedisCfg.host, the prefixed name wins when both are set
+var envAliases = map[string]string{
+	"servercfg":   "SERVER",
+	"snmpcfg":     "SNMP",
+	"snmpcfg.ip":  "SNMP_HOST",
+	"rediscfg":    "REDIS",
+	"clicfg":      "CLI",
+	"trapcfg":     "TRAP",
+	"authcfg.jwt": "JWT",
+}
+
+// Load loads the config in layers, defaults, then the config file, then environment variables, then flags,
+// and validates it, args are the command line arguments without the program name
+func Load(args []string) (*Config, error) {
+
+	v := viper.New()
+	setDefaults(v)
+
+	// Every key that can be set with one value gets an environment variable and a flag
+	keys := getKeys(reflect.TypeOf(Config{}), "")
+
+	flags := pflag.NewFlagSet("go-snmp-olt-zte-c320", pflag.ContinueOnError)
+	configFile := flags.String("config", "", "Config file, "+ConfigFileEnv+" or "+defaultConfigFile+" when empty")
+	for _, key := range keys {
+		flags.String(key, "", "Sets "+key)
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	// Read config file
+	if *configFile == "" {
+		*configFile = os.Getenv(ConfigFileEnv)
+	}
+	if *configFile == "" {
+		*configFile = defaultConfigFile
+	}
+	v.SetConfigFile(*configFile)
+	if err := v.ReadInConfig(); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("config file %s not found", *configFile)
+		}
+		return nil, fmt.Errorf("failed to read config file %s: %w", *configFile, err)
+	}
+
+	// Allow environment variables to override config
+	v.SetEnvPrefix(EnvPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+	for _, key := range keys {
+		envNames := []string{EnvPrefix + "_" + getEnvName(key)}
+		if alias := getEnvAlias(key); alias != "" {
+			envNames = append(envNames, alias)
+		}
+		if err := v.BindEnv(append([]string{key}, envNames...)...); err != nil {
+			return nil, err
+		}
+	}
+
+	// Allow flags that are set to override environment variables
+	for _, key := range keys {
+		if err := v.BindPFlag(key, flags.Lookup(key)); err != nil {
+			return nil, err
+		}
+	}
+
+	var cfg Config // Initialize config variable
+
+	// Unmarshal config
+	if err := v.Unmarshal(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to decode config: %w", err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+// setDefaults is a function to set the values of keys that are missing from the config file
+func setDefaults(v *viper.Viper) {
+	defaults := map[string]interface{}{
+		"servercfg.port":                    "8081",
+		"servercfg.read_timeout":            30,
+		"servercfg.read_header_timeout":     10,
+		"servercfg.write_timeout":           30,
+		"servercfg.idle_timeout":            120,
+		"servercfg.max_header_bytes":        1 << 20,
+		"servercfg.tls.reload_interval":     60,
+		"snmpcfg.port":                      161,
+		"rediscfg.host":                     "localhost",
+		"rediscfg.port":                     "6379",
+		"rediscfg.min_idle_connections":     200,
+		"rediscfg.pool_size":                12000,
+		"rediscfg.pool_timeout":             240,
+		"clicfg.protocol":                   "telnet",
+		"clicfg.dial_timeout":               10,
+		"clicfg.command_timeout":            30,
+		"trapcfg.address":                   "0.0.0.0:162",
+		"streamcfg.olt_name":                "olt-1",
+		"streamcfg.poll_interval":           60,
+		"streamcfg.rx_power_threshold":      1.0,
+		"streamcfg.heartbeat_interval":      15,
+		"streamcfg.history_size":            1000,
+		"anomalycfg.check_interval":         300,
+		"anomalycfg.flap_window":            900,
+		"anomalycfg.flap_threshold":         4,
+		"anomalycfg.drop_window":            60,
+		"anomalycfg.drop_threshold":         8,
+		"anomalycfg.retention":              86400,
+		"authcfg.jwt.jwks_refresh_interval": 900,
+		"authcfg.jwt.clock_skew":            60,
+		"authcfg.jwt.roles_claim":           "roles",
+		"ratelimitcfg.ip_rate":              20,
+		"ratelimitcfg.ip_burst":             40,
+		"ratelimitcfg.client_rate":          10,
+		"ratelimitcfg.client_burst":         20,
+		"ratelimitcfg.snmp_rate":            100,
+		"ratelimitcfg.snmp_burst":           200,
+		"ratelimitcfg.snmp_walk_cost":       10,
+		"ratelimitcfg.snmp_max_wait":        5,
+		"ratelimitcfg.snmp_queue_size":      50,
+		"auditcfg.path":                     "./data/audit.jsonl",
+	}
+	for key, value := range defaults {
+		v.SetDefault(key, value)
+	}
+}
+
+// getKeys is a function to get the keys of the fields of a config struct that are set with one value,
+// lists of structs like AuthCfg.keys are only read from the config file
+func getKeys(t reflect.Type, prefix string) []string {
+	var keys []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := field.Tag.Get("mapstructure")
+		if name == "" {
+			name = field.Name
+		}
+		key := strings.ToLower(prefix + name)
+
+		switch {
+		case field.Type.Kind() == reflect.Struct:
+			keys = append(keys, getKeys(field.Type, key+".")...)
+		case field.Type.Kind() == reflect.Slice && field.Type.Elem().Kind() == reflect.Struct:
+			continue
+		default:
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// getEnvAlias is a function to get the short environment variable name of a key, empty when it has none
+func getEnvAlias(key string) string {
+	if alias, ok := envAliases[key]; ok {
+		return alias
+	}
+
+	// The alias of the most specific section, e.g. JWT_ISSUER for authcfg.jwt.issuer
+	for section := key; strings.Contains(section, "."); {
+		section = section[:strings.LastIndex(section, ".")]
+		if prefix, ok := envAliases[section]; ok {
+			return prefix + "_" + getEnvName(key[len(section)+1:])
+		}
+	}
+	return ""
+}
+
+// getEnvName is a function to get the environment variable name of a key without prefix
+func getEnvName(key string) string {
+	return strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
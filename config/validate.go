@@ -0,0 +1,274 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	oidPattern      = regexp.MustCompile(`^(\.[0-9]+)+$`)
+	hostnamePattern = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9-]{0,61}[A-Za-z0-9])?(\.[A-Za-z0-9]([A-Za-z0-9-]{0,61}[A-Za-z0-9])?)*$`)
+)
+
+// FieldError is an invalid field of a config, Field is its key in the config file, e.g. SnmpCfg.ip
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+// ValidationError lists every invalid field of a config
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	lines := make([]string, 0, len(e.Fields)+1)
+	lines = append(lines, fmt.Sprintf("invalid config, %d invalid fields:", len(e.Fields)))
+	for _, field := range e.Fields {
+		lines = append(lines, "  "+field.Field+": "+field.Message)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// validator collects the invalid fields of a config
+type validator struct {
+	fields []FieldError
+}
+
+func (v *validator) add(field, format string, args ...interface{}) {
+	v.fields = append(v.fields, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) host(field, value string, required bool) {
+	if value == "" {
+		if required {
+			v.add(field, "is required")
+		}
+		return
+	}
+	if net.ParseIP(value) == nil && (len(value) > 253 || !hostnamePattern.MatchString(value)) {
+		v.add(field, "%q is not an IP or host name", value)
+	}
+}
+
+func (v *validator) port(field, value string, required bool) {
+	if value == "" {
+		if required {
+			v.add(field, "is required")
+		}
+		return
+	}
+	port, err := strconv.Atoi(value)
+	if err != nil || port < 1 || port > 65535 {
+		v.add(field, "%q is not a port between 1 and 65535", value)
+	}
+}
+
+func (v *validator) address(field, value string) {
+	host, port, err := net.SplitHostPort(value)
+	if err != nil {
+		v.add(field, "%q is not a host:port address", value)
+		return
+	}
+	v.host(field, host, false)
+	v.port(field, port, true)
+}
+
+func (v *validator) notNegative(field string, value float64) {
+	if value < 0 {
+		v.add(field, "must not be negative")
+	}
+}
+
+func (v *validator) oneOf(field, value string, values ...string) {
+	for _, allowed := range values {
+		if strings.EqualFold(value, allowed) {
+			return
+		}
+	}
+	v.add(field, "%q must be one of %s", value, strings.Join(values, ", "))
+}
+
+// timezone is a method to check an IANA timezone name such as Asia/Jakarta or an offset from UTC such as +07:00,
+// empty is UTC
+func (v *validator) timezone(field, value string) {
+	if value == "" {
+		return
+	}
+	if _, err := time.Parse("-07:00", value); err == nil {
+		return
+	}
+	if _, err := time.LoadLocation(value); err != nil {
+		v.add(field, "%q is not a timezone name or UTC offset, e.g. Asia/Jakarta or +07:00", value)
+	}
+}
+
+// oids is a method to check every string field of an OID table, skip lists the fields that aren't OIDs
+func (v *validator) oids(section string, table interface{}, skip ...string) {
+	value := reflect.ValueOf(table)
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		name := section + "." + field.Tag.Get("mapstructure")
+		if field.Type.Kind() != reflect.String || contains(skip, field.Tag.Get("mapstructure")) {
+			continue
+		}
+		oid := value.Field(i).String()
+		if oid == "" {
+			v.add(name, "is required")
+		} else if !oidPattern.MatchString(oid) {
+			v.add(name, "%q is not an OID, e.g. .1.3.6.1", oid)
+		}
+	}
+}
+
+// Validate checks that the hosts, ports, OIDs and other fields of the config are well-formed, the returned
+// *ValidationError lists every invalid field
+func (c *Config) Validate() error {
+
+	v := &validator{}
+
+	// HTTP server
+	v.host("ServerCfg.host", c.ServerCfg.Host, false)
+	v.port("ServerCfg.port", c.ServerCfg.Port, true)
+	v.notNegative("ServerCfg.read_timeout", float64(c.ServerCfg.ReadTimeout))
+	v.notNegative("ServerCfg.read_header_timeout", float64(c.ServerCfg.ReadHeaderTimeout))
+	v.notNegative("ServerCfg.write_timeout", float64(c.ServerCfg.WriteTimeout))
+	v.notNegative("ServerCfg.idle_timeout", float64(c.ServerCfg.IdleTimeout))
+	v.notNegative("ServerCfg.max_header_bytes", float64(c.ServerCfg.MaxHeaderBytes))
+	if c.ServerCfg.TLS.Enabled {
+		if c.ServerCfg.TLS.CertFile == "" {
+			v.add("ServerCfg.tls.cert_file", "is required when tls is enabled")
+		}
+		if c.ServerCfg.TLS.KeyFile == "" {
+			v.add("ServerCfg.tls.key_file", "is required when tls is enabled")
+		}
+	}
+	v.notNegative("ServerCfg.tls.reload_interval", float64(c.ServerCfg.TLS.ReloadInterval))
+	for _, proxy := range c.ServerCfg.TrustedProxies {
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			v.add("ServerCfg.trusted_proxies", "%q is not an IP or CIDR", proxy)
+		}
+	}
+
+	// SNMP and Redis
+	v.host("SnmpCfg.ip", c.SnmpCfg.Ip, true)
+	if c.SnmpCfg.Port == 0 {
+		v.add("SnmpCfg.port", "is not a port between 1 and 65535")
+	}
+	if c.SnmpCfg.Community == "" {
+		v.add("SnmpCfg.community", "is required")
+	}
+	v.host("RedisCfg.host", c.RedisCfg.Host, true)
+	v.port("RedisCfg.port", c.RedisCfg.Port, true)
+	v.notNegative("RedisCfg.db", float64(c.RedisCfg.DB))
+	v.notNegative("RedisCfg.min_idle_connections", float64(c.RedisCfg.MinIdleConnections))
+	v.notNegative("RedisCfg.pool_size", float64(c.RedisCfg.PoolSize))
+	v.notNegative("RedisCfg.pool_timeout", float64(c.RedisCfg.PoolTimeout))
+
+	// OLT CLI, disabled without host
+	if c.CliCfg.Host != "" {
+		v.host("CliCfg.host", c.CliCfg.Host, true)
+		v.oneOf("CliCfg.protocol", c.CliCfg.Protocol, "telnet", "ssh")
+		// Any host key would be accepted, a spoofed OLT would get the CLI password
+		if c.CliCfg.Protocol == "ssh" && c.CliCfg.HostKeyFingerprint == "" && !c.CliCfg.InsecureIgnoreHostKey {
+			v.add("CliCfg.host_key_fingerprint", "is required with ssh unless insecure_ignore_host_key is set")
+		}
+		if c.CliCfg.Port != 0 {
+			v.port("CliCfg.port", strconv.Itoa(c.CliCfg.Port), true)
+		}
+		v.notNegative("CliCfg.dial_timeout", float64(c.CliCfg.DialTimeout))
+		v.notNegative("CliCfg.command_timeout", float64(c.CliCfg.CommandTimeout))
+	}
+
+	// SNMP trap receiver
+	if c.TrapCfg.Enabled {
+		v.address("TrapCfg.address", c.TrapCfg.Address)
+		if c.TrapCfg.Community == "" && c.TrapCfg.Username == "" {
+			v.add("TrapCfg.community", "is required when trap is enabled without username")
+		}
+	}
+
+	// Events and reports
+	if c.StreamCfg.OltName == "" {
+		v.add("StreamCfg.olt_name", "is required")
+	}
+	v.notNegative("StreamCfg.poll_interval", float64(c.StreamCfg.PollInterval))
+	v.notNegative("StreamCfg.rx_power_threshold", c.StreamCfg.RxPowerThreshold)
+	v.notNegative("StreamCfg.heartbeat_interval", float64(c.StreamCfg.HeartbeatInterval))
+	v.notNegative("StreamCfg.history_size", float64(c.StreamCfg.HistorySize))
+	v.notNegative("AnomalyCfg.check_interval", float64(c.AnomalyCfg.CheckInterval))
+	v.notNegative("AnomalyCfg.flap_window", float64(c.AnomalyCfg.FlapWindow))
+	v.notNegative("AnomalyCfg.flap_threshold", float64(c.AnomalyCfg.FlapThreshold))
+	v.notNegative("AnomalyCfg.drop_window", float64(c.AnomalyCfg.DropWindow))
+	v.notNegative("AnomalyCfg.drop_threshold", float64(c.AnomalyCfg.DropThreshold))
+	v.notNegative("AnomalyCfg.retention", float64(c.AnomalyCfg.Retention))
+
+	// Authentication, rate limiting and audit, keys can only be created through the API with an admin key
+	// so enabled authentication needs a key of config or bearer tokens
+	if c.AuthCfg.Enabled && len(c.AuthCfg.Keys) == 0 &&
+		(!c.AuthCfg.Jwt.Enabled || (c.AuthCfg.Jwt.JwksURL == "" && len(c.AuthCfg.Jwt.Keys) == 0)) {
+		v.add("AuthCfg.keys", "needs a key, or AuthCfg.jwt enabled with jwks_url or keys, when auth is enabled")
+	}
+	if c.AuthCfg.Jwt.Enabled && c.AuthCfg.Jwt.JwksURL != "" {
+		jwksURL, err := url.Parse(c.AuthCfg.Jwt.JwksURL)
+		if err != nil || (jwksURL.Scheme != "https" && jwksURL.Scheme != "http") || jwksURL.Host == "" {
+			v.add("AuthCfg.jwt.jwks_url", "%q is not an http or https URL", c.AuthCfg.Jwt.JwksURL)
+		}
+		// Tokens of every client of the provider would be accepted
+		if c.AuthCfg.Jwt.Issuer == "" && c.AuthCfg.Jwt.Audience == "" {
+			v.add("AuthCfg.jwt.audience", "is required with jwks_url when issuer is empty")
+		}
+	}
+	v.notNegative("AuthCfg.jwt.jwks_refresh_interval", float64(c.AuthCfg.Jwt.JwksRefreshInterval))
+	v.notNegative("AuthCfg.jwt.clock_skew", float64(c.AuthCfg.Jwt.ClockSkew))
+	v.notNegative("RateLimitCfg.ip_rate", c.RateLimitCfg.IpRate)
+	v.notNegative("RateLimitCfg.ip_burst", float64(c.RateLimitCfg.IpBurst))
+	v.notNegative("RateLimitCfg.client_rate", c.RateLimitCfg.ClientRate)
+	v.notNegative("RateLimitCfg.client_burst", float64(c.RateLimitCfg.ClientBurst))
+	v.notNegative("RateLimitCfg.snmp_rate", c.RateLimitCfg.SnmpRate)
+	v.notNegative("RateLimitCfg.snmp_burst", float64(c.RateLimitCfg.SnmpBurst))
+	v.notNegative("RateLimitCfg.snmp_walk_cost", float64(c.RateLimitCfg.SnmpWalkCost))
+	v.notNegative("RateLimitCfg.snmp_max_wait", float64(c.RateLimitCfg.SnmpMaxWait))
+	v.notNegative("RateLimitCfg.snmp_queue_size", float64(c.RateLimitCfg.SnmpQueueSize))
+	if c.AuditCfg.Enabled && c.AuditCfg.Path == "" {
+		v.add("AuditCfg.path", "is required when audit is enabled")
+	}
+
+	// OID tables
+	v.oids("OltCfg", c.OltCfg, "timezone")
+	v.timezone("OltCfg.timezone", c.OltCfg.Timezone)
+	for _, table := range []struct {
+		section string
+		oids    interface{}
+	}{
+		{"Board1Pon1", c.Board1Pon1}, {"Board1Pon2", c.Board1Pon2}, {"Board1Pon3", c.Board1Pon3},
+		{"Board1Pon4", c.Board1Pon4}, {"Board1Pon5", c.Board1Pon5}, {"Board1Pon6", c.Board1Pon6},
+		{"Board1Pon7", c.Board1Pon7}, {"Board1Pon8", c.Board1Pon8}, {"Board2Pon1", c.Board2Pon1},
+		{"Board2Pon2", c.Board2Pon2}, {"Board2Pon3", c.Board2Pon3}, {"Board2Pon4", c.Board2Pon4},
+		{"Board2Pon5", c.Board2Pon5}, {"Board2Pon6", c.Board2Pon6}, {"Board2Pon7", c.Board2Pon7},
+		{"Board2Pon8", c.Board2Pon8},
+	} {
+		v.oids(table.section, table.oids)
+	}
+
+	if len(v.fields) > 0 {
+		return &ValidationError{Fields: v.fields}
+	}
+	return nil
+}
+
+// contains is a function to check if a list has a value
+func contains(values []string, value string) bool {
+	for _, item := range values {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,200 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/model"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/usecase"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/utils"
+	"github.com/rs/zerolog/log"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type AlarmHandlerInterface interface {
+	GetAlarms(w http.ResponseWriter, r *http.Request)
+	AcknowledgeAlarm(w http.ResponseWriter, r *http.Request)
+	UnacknowledgeAlarm(w http.ResponseWriter, r *http.Request)
+}
+
+type AlarmHandler struct {
+	alarmUsecase usecase.AlarmUseCaseInterface
+}
+
+func NewAlarmHandler(alarmUsecase usecase.AlarmUseCaseInterface) *AlarmHandler {
+	return &AlarmHandler{alarmUsecase: alarmUsecase}
+}
+
+func (a *AlarmHandler) GetAlarms(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Msg("Received a request to GetAlarms")
+
+	filter, err := parseAlarmFilter(r)
+	if err != nil {
+		log.Error().Err(err).Msg("Invalid alarm filter")
+		utils.ErrorBadRequest(w, err) // error 400
+		return
+	}
+
+	// Call usecase to get active alarms from the OLT alarm table
+	alarms, err := a.alarmUsecase.GetAlarms(r.Context(), filter)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get alarms")
+		writeSnmpError(w, err)
+		return
+	}
+
+	log.Info().Msg("Successfully retrieved active alarms")
+
+	// Convert result to JSON format according to WebResponse structure
+	response := utils.WebResponse{
+		Code:   http.StatusOK, // 200
+		Status: "OK",          // "OK"
+		Data:   alarms,        // data
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, response) // 200
+}
+
+func (a *AlarmHandler) AcknowledgeAlarm(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Msg("Received a request to AcknowledgeAlarm")
+
+	alarmIDInt, ok := parseAlarmPath(w, r)
+	if !ok {
+		return
+	}
+
+	// Decode request body, the body is optional
+	var request model.OltAlarmAckRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&request); err != nil && !errors.Is(err, io.EOF) {
+		log.Error().Err(err).Msg("Invalid request body")
+		utils.ErrorBadRequest(w, fmt.Errorf("invalid request body")) // error 400
+		return
+	}
+
+	alarm, err := a.alarmUsecase.AcknowledgeAlarm(r.Context(), alarmIDInt, request, getActor(r))
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to acknowledge alarm")
+		writeAlarmError(w, err)
+		return
+	}
+
+	log.Info().Msg("Successfully acknowledged alarm")
+
+	// Convert result to JSON format according to WebResponse structure
+	response := utils.WebResponse{
+		Code:   http.StatusOK, // 200
+		Status: "OK",          // "OK"
+		Data:   alarm,         // data
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, response) // 200
+}
+
+func (a *AlarmHandler) UnacknowledgeAlarm(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Msg("Received a request to UnacknowledgeAlarm")
+
+	alarmIDInt, ok := parseAlarmPath(w, r)
+	if !ok {
+		return
+	}
+
+	alarm, err := a.alarmUsecase.UnacknowledgeAlarm(r.Context(), alarmIDInt)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to unacknowledge alarm")
+		writeAlarmError(w, err)
+		return
+	}
+
+	log.Info().Msg("Successfully unacknowledged alarm")
+
+	// Convert result to JSON format according to WebResponse structure
+	response := utils.WebResponse{
+		Code:   http.StatusOK, // 200
+		Status: "OK",          // "OK"
+		Data:   alarm,         // data
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, response) // 200
+}
+
+// parseAlarmPath is a function to parse and validate alarm_id URL parameter
+func parseAlarmPath(w http.ResponseWriter, r *http.Request) (int, bool) {
+
+	alarmIDInt, err := strconv.Atoi(chi.URLParam(r, "alarm_id")) // convert string to int
+	if err != nil || alarmIDInt < 1 {
+		log.Error().Err(err).Msg("Invalid 'alarm_id' parameter")
+		utils.ErrorBadRequest(w, fmt.Errorf("invalid 'alarm_id' parameter. It must be a positive number")) // error 400
+		return 0, false
+	}
+
+	return alarmIDInt, true
+}
+
+// parseAlarmFilter is a function to parse severity, category, board, pon, onu and acknowledged query parameters
+func parseAlarmFilter(r *http.Request) (usecase.AlarmFilter, error) {
+
+	query := r.URL.Query()
+	filter := usecase.AlarmFilter{}
+
+	// Severity and category are comma separated, e.g. severity=Critical,Major
+	if value := query.Get("severity"); value != "" {
+		filter.Severities = strings.Split(value, ",")
+	}
+	if value := query.Get("category"); value != "" {
+		filter.Categories = strings.Split(value, ",")
+	}
+
+	parsers := []struct {
+		name  string
+		max   int
+		value *int
+	}{
+		{"board", 2, &filter.Board},
+		{"pon", 8, &filter.PON},
+		{"onu", 128, &filter.OnuID},
+	}
+	for _, parser := range parsers {
+		value := query.Get(parser.name)
+		if value == "" {
+			continue
+		}
+		valueInt, err := strconv.Atoi(value)
+		if err != nil || valueInt < 1 || valueInt > parser.max {
+			return filter, fmt.Errorf("invalid '%s' parameter. It must be between 1 and %d", parser.name, parser.max)
+		}
+		*parser.value = valueInt
+	}
+
+	if value := query.Get("acknowledged"); value != "" {
+		acknowledged, err := strconv.ParseBool(value)
+		if err != nil {
+			return filter, fmt.Errorf("invalid 'acknowledged' parameter. It must be true or false")
+		}
+		filter.Acknowledged = &acknowledged
+	}
+
+	return filter, nil
+}
+
+// writeAlarmError is a function to map alarm usecase errors to HTTP responses
+func writeAlarmError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrInvalidAlarmRequest):
+		utils.ErrorBadRequest(w, err) // error 400
+	case errors.Is(err, usecase.ErrAlarmNotFound):
+		utils.ErrorNotFound(w, err) // error 404
+	case errors.Is(err, usecase.ErrSnmpBudgetExceeded):
+		writeSnmpError(w, err) // error 429
+	default:
+		utils.ErrorInternalServerError(w, fmt.Errorf("cannot update alarm acknowledgement")) // error 500
+	}
+}
@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"fmt"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/usecase"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/utils"
+	"github.com/rs/zerolog/log"
+	"net/http"
+	"strconv"
+)
+
+type AnomalyHandlerInterface interface {
+	GetAnomalyReport(w http.ResponseWriter, r *http.Request)
+}
+
+type AnomalyHandler struct {
+	anomalyUsecase usecase.AnomalyUseCaseInterface
+}
+
+func NewAnomalyHandler(anomalyUsecase usecase.AnomalyUseCaseInterface) *AnomalyHandler {
+	return &AnomalyHandler{anomalyUsecase: anomalyUsecase}
+}
+
+func (a *AnomalyHandler) GetAnomalyReport(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Msg("Received a request to GetAnomalyReport")
+
+	// refresh=true checks serial numbers now instead of returning the last periodic check
+	refresh := false
+	if value := r.URL.Query().Get("refresh"); value != "" {
+		refreshBool, err := strconv.ParseBool(value)
+		if err != nil {
+			log.Error().Err(err).Msg("Invalid 'refresh' parameter")
+			utils.ErrorBadRequest(w, fmt.Errorf("invalid 'refresh' parameter. It must be true or false")) // error 400
+			return
+		}
+		refresh = refreshBool
+	}
+
+	// Call usecase to get the anomaly report
+	report, err := a.anomalyUsecase.GetReport(r.Context(), refresh)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get anomaly report")
+		writeSnmpError(w, err)
+		return
+	}
+
+	log.Info().Msg("Successfully retrieved anomaly report")
+
+	// Convert result to JSON format according to WebResponse structure
+	response := utils.WebResponse{
+		Code:   http.StatusOK, // 200
+		Status: "OK",          // "OK"
+		Data:   report,        // data
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, response) // 200
+}
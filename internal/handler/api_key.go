@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/model"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/usecase"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/utils"
+	"github.com/rs/zerolog/log"
+	"net/http"
+)
+
+type ApiKeyHandlerInterface interface {
+	GetApiKeys(w http.ResponseWriter, r *http.Request)
+	CreateApiKey(w http.ResponseWriter, r *http.Request)
+	RevokeApiKey(w http.ResponseWriter, r *http.Request)
+}
+
+type ApiKeyHandler struct {
+	apiKeyUsecase usecase.ApiKeyUseCaseInterface
+}
+
+func NewApiKeyHandler(apiKeyUsecase usecase.ApiKeyUseCaseInterface) *ApiKeyHandler {
+	return &ApiKeyHandler{apiKeyUsecase: apiKeyUsecase}
+}
+
+// GetApiKeys lists the API keys of config and the API keys created through the API, keys themselves are never returned
+func (a *ApiKeyHandler) GetApiKeys(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Msg("Received a request to GetApiKeys")
+
+	apiKeys, err := a.apiKeyUsecase.GetKeys(r.Context())
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get API keys")
+		writeApiKeyError(w, err)
+		return
+	}
+
+	log.Info().Msg("Successfully retrieved API keys")
+
+	// Convert result to JSON format according to WebResponse structure
+	response := utils.WebResponse{
+		Code:   http.StatusOK, // 200
+		Status: "OK",          // "OK"
+		Data:   apiKeys,       // data
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, response) // 200
+}
+
+// CreateApiKey creates an API key, the key is only returned in this response
+func (a *ApiKeyHandler) CreateApiKey(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Msg("Received a request to CreateApiKey")
+
+	// Decode request body
+	var request model.ApiKeyRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&request); err != nil {
+		log.Error().Err(err).Msg("Invalid request body")
+		utils.ErrorBadRequest(w, fmt.Errorf("invalid request body")) // error 400
+		return
+	}
+
+	apiKey, err := a.apiKeyUsecase.CreateKey(r.Context(), getActor(r), request)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create API key")
+		writeApiKeyError(w, err)
+		return
+	}
+
+	log.Info().Msg("Successfully created API key")
+
+	// Convert result to JSON format according to WebResponse structure
+	response := utils.WebResponse{
+		Code:   http.StatusCreated, // 201
+		Status: "Created",          // "Created"
+		Data:   apiKey,             // data
+	}
+
+	utils.SendJSONResponse(w, http.StatusCreated, response) // 201
+}
+
+// RevokeApiKey revokes an API key created through the API, requests with the key are rejected right away
+func (a *ApiKeyHandler) RevokeApiKey(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Msg("Received a request to RevokeApiKey")
+
+	keyID := chi.URLParam(r, "key_id")
+
+	if err := a.apiKeyUsecase.RevokeKey(r.Context(), keyID); err != nil {
+		log.Error().Err(err).Msg("Failed to revoke API key")
+		writeApiKeyError(w, err)
+		return
+	}
+
+	log.Info().Msg("Successfully revoked API key " + keyID)
+
+	// Convert result to JSON format according to WebResponse structure
+	response := utils.WebResponse{
+		Code:   http.StatusOK, // 200
+		Status: "OK",          // "OK"
+		Data:   map[string]string{"key_id": keyID},
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, response) // 200
+}
+
+// writeApiKeyError is a function to send the error response of an API key usecase error
+func writeApiKeyError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrInvalidApiKeyRequest):
+		utils.ErrorBadRequest(w, err) // error 400
+	case errors.Is(err, usecase.ErrApiKeyNotFound):
+		utils.ErrorNotFound(w, err) // error 404
+	case errors.Is(err, usecase.ErrApiKeyInConfig):
+		utils.ErrorConflict(w, err) // error 409
+	default:
+		utils.ErrorInternalServerError(w, fmt.Errorf("cannot access API keys")) // error 500
+	}
+}
@@ -0,0 +1,172 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/usecase"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/utils"
+	"github.com/rs/zerolog/log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type AuditHandlerInterface interface {
+	GetAuditRecords(w http.ResponseWriter, r *http.Request)
+	ExportAuditRecords(w http.ResponseWriter, r *http.Request)
+}
+
+type AuditHandler struct {
+	auditUsecase usecase.AuditUseCaseInterface
+}
+
+func NewAuditHandler(auditUsecase usecase.AuditUseCaseInterface) *AuditHandler {
+	return &AuditHandler{auditUsecase: auditUsecase}
+}
+
+// GetAuditRecords returns the newest audit records matching the query parameters, newest first
+func (a *AuditHandler) GetAuditRecords(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Msg("Received a request to GetAuditRecords")
+
+	filter, err := parseAuditFilter(r)
+	if err != nil {
+		log.Error().Err(err).Msg("Invalid audit filter")
+		utils.ErrorBadRequest(w, err) // error 400
+		return
+	}
+
+	records, err := a.auditUsecase.Query(r.Context(), filter)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get audit records")
+		writeAuditError(w, err)
+		return
+	}
+
+	log.Info().Msg("Successfully retrieved audit records")
+
+	// Convert result to JSON format according to WebResponse structure
+	response := utils.WebResponse{
+		Code:   http.StatusOK, // 200
+		Status: "OK",          // "OK"
+		Data:   records,       // data
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, response) // 200
+}
+
+// ExportAuditRecords streams every audit record matching the query parameters as JSON lines, oldest first
+func (a *AuditHandler) ExportAuditRecords(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Msg("Received a request to ExportAuditRecords")
+
+	filter, err := parseAuditFilter(r)
+	if err != nil {
+		log.Error().Err(err).Msg("Invalid audit filter")
+		utils.ErrorBadRequest(w, err) // error 400
+		return
+	}
+
+	// The response starts with the first record, so errors before it are still sent as error responses
+	export := &auditExportWriter{w: w, filename: "audit-" + time.Now().UTC().Format("20060102T150405Z") + ".jsonl"}
+	if err := a.auditUsecase.Export(r.Context(), filter, export); err != nil {
+		log.Error().Err(err).Msg("Failed to export audit records")
+		if !export.started {
+			writeAuditError(w, err)
+		}
+		return
+	}
+	export.start() // No record matched
+
+	log.Info().Msg("Successfully exported audit records")
+}
+
+// parseAuditFilter is a function to parse the audit filter of the query parameters, limit is ignored by export
+func parseAuditFilter(r *http.Request) (usecase.AuditFilter, error) {
+
+	query := r.URL.Query()
+	filter := usecase.AuditFilter{
+		Actor:  query.Get("actor"),
+		Method: query.Get("method"),
+		Path:   query.Get("path"),
+		Result: query.Get("result"),
+	}
+
+	parsers := []struct {
+		name  string
+		max   int
+		value *int
+	}{
+		{"board", 2, &filter.Board},
+		{"pon", 8, &filter.PON},
+		{"onu", 128, &filter.OnuID},
+		{"limit", 1000, &filter.Limit},
+	}
+	for _, parser := range parsers {
+		value := query.Get(parser.name)
+		if value == "" {
+			continue
+		}
+		valueInt, err := strconv.Atoi(value)
+		if err != nil || valueInt < 1 || valueInt > parser.max {
+			return filter, fmt.Errorf("invalid '%s' parameter. It must be between 1 and %d", parser.name, parser.max)
+		}
+		*parser.value = valueInt
+	}
+
+	// from and to are RFC 3339 timestamps or Unix seconds
+	times := []struct {
+		name  string
+		value *time.Time
+	}{
+		{"from", &filter.From},
+		{"to", &filter.To},
+	}
+	for _, parser := range times {
+		value := query.Get(parser.name)
+		if value == "" {
+			continue
+		}
+		valueTime, err := parseSince(value)
+		if err != nil {
+			return filter, fmt.Errorf("invalid '%s' parameter. It must be an RFC 3339 timestamp or Unix time", parser.name)
+		}
+		*parser.value = valueTime
+	}
+
+	return filter, nil
+}
+
+// auditExportWriter sends the headers of the export before the first record
+type auditExportWriter struct {
+	w        http.ResponseWriter
+	filename string
+	started  bool
+}
+
+func (e *auditExportWriter) start() {
+	if e.started {
+		return
+	}
+	e.started = true
+	e.w.Header().Set("Content-Type", "application/x-ndjson")
+	e.w.Header().Set("Content-Disposition", "attachment; filename=\""+e.filename+"\"")
+	e.w.WriteHeader(http.StatusOK)
+}
+
+func (e *auditExportWriter) Write(p []byte) (int, error) {
+	e.start()
+	return e.w.Write(p)
+}
+
+// writeAuditError is a function to map audit usecase errors to HTTP responses
+func writeAuditError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrInvalidAuditFilter):
+		utils.ErrorBadRequest(w, err) // error 400
+	case errors.Is(err, usecase.ErrAuditNotEnabled):
+		utils.ErrorServiceUnavailable(w, err) // error 503
+	default:
+		utils.ErrorInternalServerError(w, fmt.Errorf("cannot read audit log")) // error 500
+	}
+}
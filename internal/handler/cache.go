@@ -0,0 +1,174 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/model"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/usecase"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/utils"
+	"github.com/rs/zerolog/log"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type CacheHandlerInterface interface {
+	RefreshCache(w http.ResponseWriter, r *http.Request)
+	PurgeCache(w http.ResponseWriter, r *http.Request)
+	GetCacheStatus(w http.ResponseWriter, r *http.Request)
+}
+
+type CacheHandler struct {
+	cacheUsecase usecase.CacheUseCaseInterface
+}
+
+func NewCacheHandler(cacheUsecase usecase.CacheUseCaseInterface) *CacheHandler {
+	return &CacheHandler{cacheUsecase: cacheUsecase}
+}
+
+// RefreshCache walks the PON of the scope in the request body again, an empty body refreshes all PON
+func (c *CacheHandler) RefreshCache(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Msg("Received a request to RefreshCache")
+
+	// Decode request body, the body is optional
+	var request model.CacheRefreshRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&request); err != nil && !errors.Is(err, io.EOF) {
+		log.Error().Err(err).Msg("Invalid request body")
+		utils.ErrorBadRequest(w, fmt.Errorf("invalid request body")) // error 400
+		return
+	}
+
+	scope := usecase.CacheScope{
+		Scope: strings.ToLower(request.Scope),
+		Olt:   request.Olt,
+		Board: request.Board,
+		PON:   request.PON,
+	}
+
+	result, err := c.cacheUsecase.Refresh(r.Context(), scope)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to refresh cache")
+		writeCacheError(w, err)
+		return
+	}
+
+	log.Info().Msg("Successfully refreshed cache")
+
+	// Convert result to JSON format according to WebResponse structure
+	response := utils.WebResponse{
+		Code:   http.StatusOK, // 200
+		Status: "OK",          // "OK"
+		Data:   result,        // data
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, response) // 200
+}
+
+// PurgeCache deletes the cached data of the PON of the scope in the query, all PON without a query
+func (c *CacheHandler) PurgeCache(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Msg("Received a request to PurgeCache")
+
+	scope, err := parseCacheScope(r)
+	if err != nil {
+		log.Error().Err(err).Msg("Invalid cache scope")
+		utils.ErrorBadRequest(w, err) // error 400
+		return
+	}
+
+	result, err := c.cacheUsecase.Purge(r.Context(), scope)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to purge cache")
+		writeCacheError(w, err)
+		return
+	}
+
+	log.Info().Msg("Successfully purged cache")
+
+	// Convert result to JSON format according to WebResponse structure
+	response := utils.WebResponse{
+		Code:   http.StatusOK, // 200
+		Status: "OK",          // "OK"
+		Data:   result,        // data
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, response) // 200
+}
+
+// GetCacheStatus lists the cache keys of the PON of the scope in the query with their TTL and last refresh time
+func (c *CacheHandler) GetCacheStatus(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Msg("Received a request to GetCacheStatus")
+
+	scope, err := parseCacheScope(r)
+	if err != nil {
+		log.Error().Err(err).Msg("Invalid cache scope")
+		utils.ErrorBadRequest(w, err) // error 400
+		return
+	}
+
+	status, err := c.cacheUsecase.GetStatus(r.Context(), scope)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get cache status")
+		writeCacheError(w, err)
+		return
+	}
+
+	log.Info().Msg("Successfully retrieved cache status")
+
+	// Convert result to JSON format according to WebResponse structure
+	response := utils.WebResponse{
+		Code:   http.StatusOK, // 200
+		Status: "OK",          // "OK"
+		Data:   status,        // data
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, response) // 200
+}
+
+// parseCacheScope is a function to parse scope, olt, board and pon query parameters
+func parseCacheScope(r *http.Request) (usecase.CacheScope, error) {
+
+	query := r.URL.Query()
+	scope := usecase.CacheScope{Scope: strings.ToLower(query.Get("scope")), Olt: query.Get("olt")}
+
+	parsers := []struct {
+		name  string
+		value *int
+	}{
+		{"board", &scope.Board},
+		{"pon", &scope.PON},
+	}
+	for _, parser := range parsers {
+		value := query.Get(parser.name)
+		if value == "" {
+			continue
+		}
+		valueInt, err := strconv.Atoi(value)
+		if err != nil {
+			return scope, fmt.Errorf("invalid '%s' parameter. It must be a number", parser.name)
+		}
+		*parser.value = valueInt
+	}
+
+	return scope, nil
+}
+
+// writeCacheError is a function to send the error response of a cache usecase error
+func writeCacheError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrInvalidCacheRequest):
+		utils.ErrorBadRequest(w, err) // error 400
+	case errors.Is(err, usecase.ErrCacheOltNotFound):
+		utils.ErrorNotFound(w, err) // error 404
+	case errors.Is(err, usecase.ErrCacheRefreshInProgress):
+		utils.ErrorConflict(w, err) // error 409
+	default:
+		utils.ErrorInternalServerError(w, fmt.Errorf("cannot access cache")) // error 500
+	}
+}
@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/usecase"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/utils"
+	"github.com/rs/zerolog/log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type OnuChangeHandlerInterface interface {
+	GetChanges(w http.ResponseWriter, r *http.Request)
+}
+
+type OnuChangeHandler struct {
+	changeUsecase usecase.OnuChangeUseCaseInterface
+}
+
+func NewOnuChangeHandler(changeUsecase usecase.OnuChangeUseCaseInterface) *OnuChangeHandler {
+	return &OnuChangeHandler{changeUsecase: changeUsecase}
+}
+
+func (o *OnuChangeHandler) GetChanges(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Msg("Received a request to GetChanges")
+
+	query := r.URL.Query()
+
+	// since is an RFC 3339 timestamp or Unix seconds, without since all kept changes are returned
+	var since time.Time
+	if value := query.Get("since"); value != "" {
+		sinceTime, err := parseSince(value)
+		if err != nil {
+			log.Error().Err(err).Msg("Invalid 'since' parameter")
+			utils.ErrorBadRequest(w, fmt.Errorf("invalid 'since' parameter. It must be an RFC 3339 timestamp or Unix time")) // error 400
+			return
+		}
+		since = sinceTime
+	}
+
+	limit := 0
+	if value := query.Get("limit"); value != "" {
+		limitInt, err := strconv.Atoi(value)
+		if err != nil {
+			log.Error().Err(err).Msg("Invalid 'limit' parameter")
+			utils.ErrorBadRequest(w, fmt.Errorf("invalid 'limit' parameter. It must be a number")) // error 400
+			return
+		}
+		limit = limitInt
+	}
+
+	// Call usecase to get ONU changes since the given time
+	changes, err := o.changeUsecase.GetChanges(r.Context(), since, limit)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get ONU changes")
+		if errors.Is(err, usecase.ErrInvalidChangeRequest) {
+			utils.ErrorBadRequest(w, err) // error 400
+			return
+		}
+		utils.ErrorInternalServerError(w, fmt.Errorf("cannot get data from redis")) // error 500
+		return
+	}
+
+	log.Info().Msg("Successfully retrieved ONU changes")
+
+	// Convert result to JSON format according to WebResponse structure
+	response := utils.WebResponse{
+		Code:   http.StatusOK, // 200
+		Status: "OK",          // "OK"
+		Data:   changes,       // data
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, response) // 200
+}
+
+// parseSince is a function to parse an RFC 3339 timestamp or Unix seconds
+func parseSince(value string) (time.Time, error) {
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(seconds, 0), nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
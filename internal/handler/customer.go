@@ -0,0 +1,189 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/model"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/usecase"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/utils"
+	"github.com/rs/zerolog/log"
+	"io"
+	"mime"
+	"net/http"
+)
+
+const customerImportMaxBytes = 10 << 20 // Maximum size of a CSV import, 10 MiB
+
+type CustomerHandlerInterface interface {
+	GetCustomers(w http.ResponseWriter, r *http.Request)
+	GetCustomer(w http.ResponseWriter, r *http.Request)
+	SaveCustomer(w http.ResponseWriter, r *http.Request)
+	DeleteCustomer(w http.ResponseWriter, r *http.Request)
+	ImportCustomers(w http.ResponseWriter, r *http.Request)
+}
+
+type CustomerHandler struct {
+	customerUsecase usecase.CustomerUseCaseInterface
+}
+
+func NewCustomerHandler(customerUsecase usecase.CustomerUseCaseInterface) *CustomerHandler {
+	return &CustomerHandler{customerUsecase: customerUsecase}
+}
+
+func (c *CustomerHandler) GetCustomers(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Msg("Received a request to GetCustomers")
+
+	customers, err := c.customerUsecase.GetCustomers(r.Context())
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get customers")
+		writeCustomerError(w, err)
+		return
+	}
+
+	log.Info().Msg("Successfully retrieved customers")
+
+	// Convert result to JSON format according to WebResponse structure
+	response := utils.WebResponse{
+		Code:   http.StatusOK, // 200
+		Status: "OK",          // "OK"
+		Data:   customers,     // data
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, response) // 200
+}
+
+func (c *CustomerHandler) GetCustomer(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Msg("Received a request to GetCustomer")
+
+	customer, err := c.customerUsecase.GetCustomer(r.Context(), chi.URLParam(r, "serial_number"))
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get customer")
+		writeCustomerError(w, err)
+		return
+	}
+
+	log.Info().Msg("Successfully retrieved customer")
+
+	// Convert result to JSON format according to WebResponse structure
+	response := utils.WebResponse{
+		Code:   http.StatusOK, // 200
+		Status: "OK",          // "OK"
+		Data:   customer,      // data
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, response) // 200
+}
+
+func (c *CustomerHandler) SaveCustomer(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Msg("Received a request to SaveCustomer")
+
+	// Decode request body
+	var request model.OnuCustomerRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&request); err != nil {
+		log.Error().Err(err).Msg("Invalid request body")
+		utils.ErrorBadRequest(w, fmt.Errorf("invalid request body")) // error 400
+		return
+	}
+
+	customer, err := c.customerUsecase.SaveCustomer(r.Context(), chi.URLParam(r, "serial_number"), request)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to save customer")
+		writeCustomerError(w, err)
+		return
+	}
+
+	log.Info().Msg("Successfully saved customer")
+
+	// Convert result to JSON format according to WebResponse structure
+	response := utils.WebResponse{
+		Code:   http.StatusOK, // 200
+		Status: "OK",          // "OK"
+		Data:   customer,      // data
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, response) // 200
+}
+
+func (c *CustomerHandler) DeleteCustomer(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Msg("Received a request to DeleteCustomer")
+
+	if err := c.customerUsecase.DeleteCustomer(r.Context(), chi.URLParam(r, "serial_number")); err != nil {
+		log.Error().Err(err).Msg("Failed to delete customer")
+		writeCustomerError(w, err)
+		return
+	}
+
+	log.Info().Msg("Successfully deleted customer")
+
+	// Convert result to JSON format according to WebResponse structure
+	response := utils.WebResponse{
+		Code:   http.StatusOK, // 200
+		Status: "OK",          // "OK"
+		Data:   nil,           // data
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, response) // 200
+}
+
+// ImportCustomers imports customers from a CSV request body or the 'file' field of a multipart form
+func (c *CustomerHandler) ImportCustomers(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Msg("Received a request to ImportCustomers")
+
+	r.Body = http.MaxBytesReader(w, r.Body, customerImportMaxBytes)
+
+	var reader io.Reader = r.Body
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "multipart/form-data" {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			log.Error().Err(err).Msg("Invalid multipart form")
+			utils.ErrorBadRequest(w, fmt.Errorf("multipart form must contain a CSV 'file' field")) // error 400
+			return
+		}
+		defer file.Close()
+		reader = file
+	}
+
+	result, err := c.customerUsecase.ImportCustomers(r.Context(), reader)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to import customers")
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			utils.ErrorBadRequest(w, fmt.Errorf("CSV must be at most %d bytes", customerImportMaxBytes)) // error 400
+			return
+		}
+		writeCustomerError(w, err)
+		return
+	}
+
+	log.Info().Msg("Successfully imported customers")
+
+	// Convert result to JSON format according to WebResponse structure
+	response := utils.WebResponse{
+		Code:   http.StatusOK, // 200
+		Status: "OK",          // "OK"
+		Data:   result,        // data
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, response) // 200
+}
+
+// writeCustomerError is a function to map customer usecase errors to HTTP responses
+func writeCustomerError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrInvalidCustomerRequest):
+		utils.ErrorBadRequest(w, err) // error 400
+	case errors.Is(err, usecase.ErrCustomerNotFound):
+		utils.ErrorNotFound(w, err) // error 404
+	default:
+		utils.ErrorInternalServerError(w, fmt.Errorf("cannot access customer data")) // error 500
+	}
+}
@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/api"
+	"github.com/rs/zerolog/log"
+	"net/http"
+)
+
+type DocsHandlerInterface interface {
+	GetOpenAPI(w http.ResponseWriter, r *http.Request)
+	GetSwaggerUI(w http.ResponseWriter, r *http.Request)
+}
+
+type DocsHandler struct{}
+
+func NewDocsHandler() *DocsHandler {
+	return &DocsHandler{}
+}
+
+// GetOpenAPI serves the embedded OpenAPI 3 document as is, it isn't wrapped in WebResponse
+func (d *DocsHandler) GetOpenAPI(w http.ResponseWriter, _ *http.Request) {
+
+	log.Info().Msg("Received a request to GetOpenAPI")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK) // 200
+	if _, err := w.Write(api.OpenAPI); err != nil {
+		log.Error().Err(err).Msg("Failed to write OpenAPI document")
+	}
+}
+
+// GetSwaggerUI serves the Swagger UI page rendering /openapi.json
+func (d *DocsHandler) GetSwaggerUI(w http.ResponseWriter, _ *http.Request) {
+
+	log.Info().Msg("Received a request to GetSwaggerUI")
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK) // 200
+	if _, err := w.Write(api.SwaggerUI); err != nil {
+		log.Error().Err(err).Msg("Failed to write Swagger UI page")
+	}
+}
@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/usecase"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/utils"
+	"github.com/rs/zerolog/log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type OnuMapHandlerInterface interface {
+	GetOnuMap(w http.ResponseWriter, r *http.Request)
+}
+
+type OnuMapHandler struct {
+	mapUsecase usecase.OnuMapUseCaseInterface
+}
+
+func NewOnuMapHandler(mapUsecase usecase.OnuMapUseCaseInterface) *OnuMapHandler {
+	return &OnuMapHandler{mapUsecase: mapUsecase}
+}
+
+// GetOnuMap sends ONU with customer coordinates as a GeoJSON FeatureCollection, the body isn't wrapped in
+// WebResponse so it can be loaded directly by Leaflet or QGIS
+func (o *OnuMapHandler) GetOnuMap(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Msg("Received a request to GetOnuMap")
+
+	filter, err := parseMapFilter(r)
+	if err != nil {
+		log.Error().Err(err).Msg("Invalid map filter")
+		utils.ErrorBadRequest(w, err) // error 400
+		return
+	}
+
+	// Call usecase to get ONU map features
+	collection, err := o.mapUsecase.GetOnuMap(r.Context(), filter)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get ONU map")
+		writeSnmpError(w, err)
+		return
+	}
+
+	log.Info().Msg("Successfully retrieved ONU map")
+
+	w.Header().Set("Content-Type", "application/geo+json")
+	w.WriteHeader(http.StatusOK) // 200
+	if err := json.NewEncoder(w).Encode(collection); err != nil {
+		log.Error().Err(err).Msg("Failed to write ONU map")
+	}
+}
+
+// parseMapFilter is a function to parse olt, board, pon and status query parameters
+func parseMapFilter(r *http.Request) (usecase.OnuMapFilter, error) {
+
+	query := r.URL.Query()
+	filter := usecase.OnuMapFilter{Olt: query.Get("olt")}
+
+	parsers := []struct {
+		name  string
+		max   int
+		value *int
+	}{
+		{"board", 2, &filter.Board},
+		{"pon", 8, &filter.PON},
+	}
+	for _, parser := range parsers {
+		value := query.Get(parser.name)
+		if value == "" {
+			continue
+		}
+		valueInt, err := strconv.Atoi(value)
+		if err != nil || valueInt < 1 || valueInt > parser.max {
+			return filter, fmt.Errorf("invalid '%s' parameter. It must be between 1 and %d", parser.name, parser.max)
+		}
+		*parser.value = valueInt
+	}
+
+	// Statuses are comma separated phase states or status classes, e.g. status=los,dying_gasp
+	if value := query.Get("status"); value != "" {
+		filter.Statuses = strings.Split(value, ",")
+	}
+
+	return filter, nil
+}
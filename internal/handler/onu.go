@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
 	"github.com/go-chi/chi/v5"
 	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/usecase"
@@ -18,6 +19,11 @@ type OnuHandlerInterface interface {
 	GetOnuIDAndSerialNumber(w http.ResponseWriter, r *http.Request)
 	UpdateEmptyOnuID(w http.ResponseWriter, r *http.Request)
 	GetByBoardIDAndPonIDWithPaginate(w http.ResponseWriter, r *http.Request)
+	GetOnuUniInfo(w http.ResponseWriter, r *http.Request)
+	GetFirmwareByBoardIDAndPonID(w http.ResponseWriter, r *http.Request)
+	GetFirmwareReport(w http.ResponseWriter, r *http.Request)
+	GetUnconfiguredByBoardIDAndPonID(w http.ResponseWriter, r *http.Request)
+	GetUnconfigured(w http.ResponseWriter, r *http.Request)
 }
 
 type OnuHandler struct {
@@ -68,7 +74,7 @@ func (o *OnuHandler) GetByBoardIDAndPonID(w http.ResponseWriter, r *http.Request
 	onuInfoList, err := o.ponUsecase.GetByBoardIDAndPonID(r.Context(), boardIDInt, ponIDInt)
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to get data from SNMP")
-		utils.ErrorInternalServerError(w, fmt.Errorf("cannot get data from snmp")) // error 500
+		writeSnmpError(w, err)
 		return
 	}
 
@@ -136,7 +142,7 @@ func (o *OnuHandler) GetByBoardIDPonIDAndOnuID(w http.ResponseWriter, r *http.Re
 
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to get data from SNMP")
-		utils.ErrorInternalServerError(w, fmt.Errorf("cannot get data from snmp")) // error 500
+		writeSnmpError(w, err)
 		return
 	}
 
@@ -194,7 +200,7 @@ func (o *OnuHandler) GetEmptyOnuID(w http.ResponseWriter, r *http.Request) {
 
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to get data from SNMP")
-		utils.ErrorInternalServerError(w, fmt.Errorf("cannot get data from snmp")) // error 500
+		writeSnmpError(w, err)
 		return
 	}
 
@@ -240,7 +246,7 @@ func (o *OnuHandler) GetOnuIDAndSerialNumber(w http.ResponseWriter, r *http.Requ
 
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to get data from SNMP")
-		utils.ErrorInternalServerError(w, fmt.Errorf("cannot get data from snmp")) // error 500
+		writeSnmpError(w, err)
 		return
 	}
 
@@ -285,7 +291,7 @@ func (o *OnuHandler) UpdateEmptyOnuID(w http.ResponseWriter, r *http.Request) {
 
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to get data from SNMP")
-		utils.ErrorInternalServerError(w, fmt.Errorf("cannot get data from snmp")) // error 500
+		writeSnmpError(w, err)
 		return
 	}
 
@@ -359,3 +365,225 @@ func (o *OnuHandler) GetByBoardIDAndPonIDWithPaginate(w http.ResponseWriter, r *
 
 	utils.SendJSONResponse(w, http.StatusOK, responsePagination) // 200
 }
+
+func (o *OnuHandler) GetOnuUniInfo(w http.ResponseWriter, r *http.Request) {
+
+	boardID := chi.URLParam(r, "board_id") // 1 or 2
+	ponID := chi.URLParam(r, "pon_id")     // 1 - 8
+	onuID := chi.URLParam(r, "onu_id")     // 1 - 128
+
+	boardIDInt, err := strconv.Atoi(boardID) // convert string to int
+
+	log.Info().Msg("Received a request to GetOnuUniInfo")
+
+	// Validate boardIDInt value and return error 400 if boardIDInt is not 1 or 2
+	if err != nil || (boardIDInt != 1 && boardIDInt != 2) {
+		log.Error().Err(err).Msg("Invalid 'board_id' parameter")
+		utils.ErrorBadRequest(w, fmt.Errorf("invalid 'board_id' parameter. It must be 1 or 2")) // error 400
+		return
+	}
+
+	ponIDInt, err := strconv.Atoi(ponID) // convert string to int
+
+	// Validate ponIDInt value and return error 400 if ponIDInt is not between 1 and 8
+	if err != nil || ponIDInt < 1 || ponIDInt > 8 {
+		log.Error().Err(err).Msg("Invalid 'pon_id' parameter")
+		utils.ErrorBadRequest(w, fmt.Errorf("invalid 'pon_id' parameter. It must be between 1 and 8")) // error 400
+		return
+	}
+
+	onuIDInt, err := strconv.Atoi(onuID) // convert string to int
+
+	// Validate onuIDInt value and return error 400 if onuIDInt is not between 1 and 128
+	if err != nil || onuIDInt < 1 || onuIDInt > 128 {
+		log.Error().Err(err).Msg("Invalid 'onu_id' parameter")
+		utils.ErrorBadRequest(w, fmt.Errorf("invalid 'onu_id' parameter. It must be between 1 and 128")) // error 400
+		return
+	}
+
+	// Call usecase to get UNI port data from SNMP
+	onuUniInfo, err := o.ponUsecase.GetOnuUniInfo(boardIDInt, ponIDInt, onuIDInt)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get data from SNMP")
+		writeSnmpError(w, err)
+		return
+	}
+
+	log.Info().Msg("Successfully retrieved data from SNMP")
+
+	/*
+		Validate onuUniInfo value
+		If the ONU has no UNI port rows, the ONU is not registered or not online, return error 404
+	*/
+
+	if len(onuUniInfo.Ports) == 0 {
+		log.Warn().Msg("Data not found")
+		utils.ErrorNotFound(w, fmt.Errorf("data not found")) // error 404
+		return
+	}
+
+	// Convert a result to JSON format according to WebResponse structure
+	response := utils.WebResponse{
+		Code:   http.StatusOK, // 200
+		Status: "OK",          // "OK"
+		Data:   onuUniInfo,    // data
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, response) // 200
+}
+
+func (o *OnuHandler) GetFirmwareByBoardIDAndPonID(w http.ResponseWriter, r *http.Request) {
+
+	boardID := chi.URLParam(r, "board_id") // 1 or 2
+	ponID := chi.URLParam(r, "pon_id")     // 1 - 8
+
+	boardIDInt, err := strconv.Atoi(boardID) // convert string to int
+
+	log.Info().Msg("Received a request to GetFirmwareByBoardIDAndPonID")
+
+	// Validate boardIDInt value and return error 400 if boardIDInt is not 1 or 2
+	if err != nil || (boardIDInt != 1 && boardIDInt != 2) {
+		log.Error().Err(err).Msg("Invalid 'board_id' parameter")
+		utils.ErrorBadRequest(w, fmt.Errorf("invalid 'board_id' parameter. It must be 1 or 2")) // error 400
+		return
+	}
+
+	ponIDInt, err := strconv.Atoi(ponID) // convert string to int
+
+	// Validate ponIDInt value and return error 400 if ponIDInt is not between 1 and 8
+	if err != nil || ponIDInt < 1 || ponIDInt > 8 {
+		log.Error().Err(err).Msg("Invalid 'pon_id' parameter")
+		utils.ErrorBadRequest(w, fmt.Errorf("invalid 'pon_id' parameter. It must be between 1 and 8")) // error 400
+		return
+	}
+
+	// Call usecase to get firmware data from SNMP
+	onuFirmwareList, err := o.ponUsecase.GetFirmwareByBoardIDAndPonID(r.Context(), boardIDInt, ponIDInt)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get data from SNMP")
+		writeSnmpError(w, err)
+		return
+	}
+
+	log.Info().Msg("Successfully retrieved data from SNMP")
+
+	// If onuFirmwareList is empty, return error 404
+	if len(onuFirmwareList) == 0 {
+		log.Warn().Msg("Data not found")
+		utils.ErrorNotFound(w, fmt.Errorf("data not found")) // error 404
+		return
+	}
+
+	// Convert result to JSON format according to WebResponse structure
+	response := utils.WebResponse{
+		Code:   http.StatusOK,   // 200
+		Status: "OK",            // "OK"
+		Data:   onuFirmwareList, // data
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, response) // 200
+}
+
+func (o *OnuHandler) GetFirmwareReport(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Msg("Received a request to GetFirmwareReport")
+
+	// Call usecase to get firmware report of all PON
+	firmwareReport, err := o.ponUsecase.GetFirmwareReport(r.Context())
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get data from SNMP")
+		writeSnmpError(w, err)
+		return
+	}
+
+	log.Info().Msg("Successfully retrieved data from SNMP")
+
+	// Convert result to JSON format according to WebResponse structure
+	response := utils.WebResponse{
+		Code:   http.StatusOK,  // 200
+		Status: "OK",           // "OK"
+		Data:   firmwareReport, // data
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, response) // 200
+}
+
+func (o *OnuHandler) GetUnconfiguredByBoardIDAndPonID(w http.ResponseWriter, r *http.Request) {
+
+	boardID := chi.URLParam(r, "board_id") // 1 or 2
+	ponID := chi.URLParam(r, "pon_id")     // 1 - 8
+
+	boardIDInt, err := strconv.Atoi(boardID) // convert string to int
+
+	log.Info().Msg("Received a request to GetUnconfiguredByBoardIDAndPonID")
+
+	// Validate boardIDInt value and return error 400 if boardIDInt is not 1 or 2
+	if err != nil || (boardIDInt != 1 && boardIDInt != 2) {
+		log.Error().Err(err).Msg("Invalid 'board_id' parameter")
+		utils.ErrorBadRequest(w, fmt.Errorf("invalid 'board_id' parameter. It must be 1 or 2")) // error 400
+		return
+	}
+
+	ponIDInt, err := strconv.Atoi(ponID) // convert string to int
+
+	// Validate ponIDInt value and return error 400 if ponIDInt is not between 1 and 8
+	if err != nil || ponIDInt < 1 || ponIDInt > 8 {
+		log.Error().Err(err).Msg("Invalid 'pon_id' parameter")
+		utils.ErrorBadRequest(w, fmt.Errorf("invalid 'pon_id' parameter. It must be between 1 and 8")) // error 400
+		return
+	}
+
+	// Call usecase to get unconfigured ONU from SNMP
+	unconfiguredOnuPon, err := o.ponUsecase.GetUnconfiguredByBoardIDAndPonID(r.Context(), boardIDInt, ponIDInt)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get data from SNMP")
+		writeSnmpError(w, err)
+		return
+	}
+
+	log.Info().Msg("Successfully retrieved data from SNMP")
+
+	// Convert result to JSON format according to WebResponse structure
+	response := utils.WebResponse{
+		Code:   http.StatusOK,      // 200
+		Status: "OK",               // "OK"
+		Data:   unconfiguredOnuPon, // data
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, response) // 200
+}
+
+func (o *OnuHandler) GetUnconfigured(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Msg("Received a request to GetUnconfigured")
+
+	// Call usecase to get unconfigured ONU of all PON from SNMP
+	unconfiguredOnuPonList, err := o.ponUsecase.GetUnconfigured(r.Context())
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get data from SNMP")
+		writeSnmpError(w, err)
+		return
+	}
+
+	log.Info().Msg("Successfully retrieved data from SNMP")
+
+	// Convert result to JSON format according to WebResponse structure
+	response := utils.WebResponse{
+		Code:   http.StatusOK,          // 200
+		Status: "OK",                   // "OK"
+		Data:   unconfiguredOnuPonList, // data
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, response) // 200
+}
+
+// writeSnmpError is a function to send the error response of a usecase reading the OLT, a request that waited too
+// long for the SNMP budget of the OLT gets 429
+func writeSnmpError(w http.ResponseWriter, err error) {
+	var budgetErr *usecase.SnmpBudgetError
+	if errors.As(err, &budgetErr) {
+		utils.ErrorTooManyRequests(w, fmt.Errorf("the OLT is busy, retry later"), budgetErr.RetryAfter) // error 429
+		return
+	}
+	utils.ErrorInternalServerError(w, fmt.Errorf("cannot get data from snmp")) // error 500
+}
@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/usecase"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/utils"
+	"github.com/rs/zerolog/log"
+	"net/http"
+	"strconv"
+)
+
+type OnuV2HandlerInterface interface {
+	GetByBoardIDAndPonID(w http.ResponseWriter, r *http.Request)
+	GetByBoardIDPonIDAndOnuID(w http.ResponseWriter, r *http.Request)
+}
+
+type OnuV2Handler struct {
+	onuV2Usecase usecase.OnuV2UseCaseInterface
+}
+
+func NewOnuV2Handler(onuV2Usecase usecase.OnuV2UseCaseInterface) *OnuV2Handler {
+	return &OnuV2Handler{onuV2Usecase: onuV2Usecase}
+}
+
+func (o *OnuV2Handler) GetByBoardIDAndPonID(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Msg("Received a request to v2 GetByBoardIDAndPonID")
+
+	boardIDInt, ponIDInt, ok := parsePonPath(w, r)
+	if !ok {
+		return
+	}
+
+	// Call usecase to get typed data from SNMP
+	onuList, err := o.onuV2Usecase.GetByBoardIDAndPonID(r.Context(), boardIDInt, ponIDInt)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get data from SNMP")
+		writeSnmpError(w, err)
+		return
+	}
+
+	if len(onuList) == 0 {
+		log.Warn().Msg("Data not found")
+		utils.ErrorNotFound(w, fmt.Errorf("data not found")) // error 404
+		return
+	}
+
+	log.Info().Msg("Successfully retrieved data from SNMP")
+
+	// Convert result to JSON format according to WebResponse structure
+	response := utils.WebResponse{
+		Code:   http.StatusOK, // 200
+		Status: "OK",          // "OK"
+		Data:   onuList,       // data
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, response) // 200
+}
+
+func (o *OnuV2Handler) GetByBoardIDPonIDAndOnuID(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Msg("Received a request to v2 GetByBoardIDPonIDAndOnuID")
+
+	boardIDInt, ponIDInt, onuIDInt, ok := parseOnuPath(w, r)
+	if !ok {
+		return
+	}
+
+	// Call usecase to get typed data from SNMP
+	onuDetail, err := o.onuV2Usecase.GetByBoardIDPonIDAndOnuID(boardIDInt, ponIDInt, onuIDInt)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get data from SNMP")
+		writeSnmpError(w, err)
+		return
+	}
+
+	if onuDetail.Board == 0 && onuDetail.PON == 0 && onuDetail.ID == 0 {
+		log.Error().Msg("Data not found")
+		utils.ErrorNotFound(w, fmt.Errorf("data not found")) // error 404
+		return
+	}
+
+	log.Info().Msg("Successfully retrieved data from SNMP")
+
+	// Convert result to JSON format according to WebResponse structure
+	response := utils.WebResponse{
+		Code:   http.StatusOK, // 200
+		Status: "OK",          // "OK"
+		Data:   onuDetail,     // data
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, response) // 200
+}
+
+// parsePonPath is a function to parse and validate board_id and pon_id URL parameters
+func parsePonPath(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+
+	boardID := chi.URLParam(r, "board_id") // 1 or 2
+	ponID := chi.URLParam(r, "pon_id")     // 1 - 8
+
+	boardIDInt, err := strconv.Atoi(boardID) // convert string to int
+
+	// Validate boardIDInt value and return error 400 if boardIDInt is not 1 or 2
+	if err != nil || (boardIDInt != 1 && boardIDInt != 2) {
+		log.Error().Err(err).Msg("Invalid 'board_id' parameter")
+		utils.ErrorBadRequest(w, fmt.Errorf("invalid 'board_id' parameter. It must be 1 or 2")) // error 400
+		return 0, 0, false
+	}
+
+	ponIDInt, err := strconv.Atoi(ponID) // convert string to int
+
+	// Validate ponIDInt value and return error 400 if ponIDInt is not between 1 and 8
+	if err != nil || ponIDInt < 1 || ponIDInt > 8 {
+		log.Error().Err(err).Msg("Invalid 'pon_id' parameter")
+		utils.ErrorBadRequest(w, fmt.Errorf("invalid 'pon_id' parameter. It must be between 1 and 8")) // error 400
+		return 0, 0, false
+	}
+
+	return boardIDInt, ponIDInt, true
+}
@@ -0,0 +1,306 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/middleware"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/model"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/usecase"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/utils"
+	"github.com/rs/zerolog/log"
+	"net/http"
+	"strconv"
+)
+
+type OnuProvisionHandlerInterface interface {
+	RegisterOnu(w http.ResponseWriter, r *http.Request)
+	DeregisterOnu(w http.ResponseWriter, r *http.Request)
+	UpdateOnu(w http.ResponseWriter, r *http.Request)
+	RebootOnu(w http.ResponseWriter, r *http.Request)
+	GetOnuActionLog(w http.ResponseWriter, r *http.Request)
+}
+
+// actorHeader is the request header identifying who performs a change on the OLT
+const actorHeader = "X-Actor"
+
+type OnuProvisionHandler struct {
+	provisionUsecase usecase.OnuProvisionUseCaseInterface
+}
+
+func NewOnuProvisionHandler(provisionUsecase usecase.OnuProvisionUseCaseInterface) *OnuProvisionHandler {
+	return &OnuProvisionHandler{provisionUsecase: provisionUsecase}
+}
+
+func (o *OnuProvisionHandler) RegisterOnu(w http.ResponseWriter, r *http.Request) {
+
+	boardID := chi.URLParam(r, "board_id") // 1 or 2
+	ponID := chi.URLParam(r, "pon_id")     // 1 - 8
+
+	boardIDInt, err := strconv.Atoi(boardID) // convert string to int
+
+	log.Info().Msg("Received a request to RegisterOnu")
+
+	// Validate boardIDInt value and return error 400 if boardIDInt is not 1 or 2
+	if err != nil || (boardIDInt != 1 && boardIDInt != 2) {
+		log.Error().Err(err).Msg("Invalid 'board_id' parameter")
+		utils.ErrorBadRequest(w, fmt.Errorf("invalid 'board_id' parameter. It must be 1 or 2")) // error 400
+		return
+	}
+
+	ponIDInt, err := strconv.Atoi(ponID) // convert string to int
+
+	// Validate ponIDInt value and return error 400 if ponIDInt is not between 1 and 8
+	if err != nil || ponIDInt < 1 || ponIDInt > 8 {
+		log.Error().Err(err).Msg("Invalid 'pon_id' parameter")
+		utils.ErrorBadRequest(w, fmt.Errorf("invalid 'pon_id' parameter. It must be between 1 and 8")) // error 400
+		return
+	}
+
+	// Decode request body
+	var request model.OnuRegisterRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&request); err != nil {
+		log.Error().Err(err).Msg("Invalid request body")
+		utils.ErrorBadRequest(w, fmt.Errorf("invalid request body")) // error 400
+		return
+	}
+
+	// Call usecase to register ONU via SNMP
+	result, err := o.provisionUsecase.RegisterOnu(r.Context(), boardIDInt, ponIDInt, request)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to register ONU")
+		writeProvisionError(w, err)
+		return
+	}
+
+	log.Info().Msg("Successfully registered ONU")
+
+	// Convert result to JSON format according to WebResponse structure
+	response := utils.WebResponse{
+		Code:   http.StatusCreated, // 201
+		Status: "Created",          // "Created"
+		Data:   result,             // data
+	}
+
+	utils.SendJSONResponse(w, http.StatusCreated, response) // 201
+}
+
+func (o *OnuProvisionHandler) DeregisterOnu(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Msg("Received a request to DeregisterOnu")
+
+	boardIDInt, ponIDInt, onuIDInt, ok := parseOnuPath(w, r)
+	if !ok {
+		return
+	}
+
+	// Decode request body
+	var request model.OnuDeregisterRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&request); err != nil {
+		log.Error().Err(err).Msg("Invalid request body")
+		utils.ErrorBadRequest(w, fmt.Errorf("invalid request body")) // error 400
+		return
+	}
+
+	// Call usecase to deregister ONU via SNMP
+	result, err := o.provisionUsecase.DeregisterOnu(
+		r.Context(), boardIDInt, ponIDInt, onuIDInt, request, getActor(r),
+	)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to deregister ONU")
+		writeProvisionError(w, err)
+		return
+	}
+
+	log.Info().Msg("Successfully deregistered ONU")
+
+	// Convert result to JSON format according to WebResponse structure
+	response := utils.WebResponse{
+		Code:   http.StatusOK, // 200
+		Status: "OK",          // "OK"
+		Data:   result,        // data
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, response) // 200
+}
+
+func (o *OnuProvisionHandler) UpdateOnu(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Msg("Received a request to UpdateOnu")
+
+	boardIDInt, ponIDInt, onuIDInt, ok := parseOnuPath(w, r)
+	if !ok {
+		return
+	}
+
+	// Decode request body
+	var request model.OnuUpdateRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&request); err != nil {
+		log.Error().Err(err).Msg("Invalid request body")
+		utils.ErrorBadRequest(w, fmt.Errorf("invalid request body")) // error 400
+		return
+	}
+
+	// Call usecase to update ONU name and description via SNMP
+	result, err := o.provisionUsecase.UpdateOnu(
+		r.Context(), boardIDInt, ponIDInt, onuIDInt, request, getActor(r),
+	)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to update ONU")
+		writeProvisionError(w, err)
+		return
+	}
+
+	log.Info().Msg("Successfully updated ONU")
+
+	// Convert result to JSON format according to WebResponse structure
+	response := utils.WebResponse{
+		Code:   http.StatusOK, // 200
+		Status: "OK",          // "OK"
+		Data:   result,        // data
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, response) // 200
+}
+
+func (o *OnuProvisionHandler) RebootOnu(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Msg("Received a request to RebootOnu")
+
+	boardIDInt, ponIDInt, onuIDInt, ok := parseOnuPath(w, r)
+	if !ok {
+		return
+	}
+
+	// Decode request body
+	var request model.OnuRebootRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&request); err != nil {
+		log.Error().Err(err).Msg("Invalid request body")
+		utils.ErrorBadRequest(w, fmt.Errorf("invalid request body")) // error 400
+		return
+	}
+
+	// Call usecase to reboot ONU via SNMP
+	result, err := o.provisionUsecase.RebootOnu(
+		r.Context(), boardIDInt, ponIDInt, onuIDInt, request, getActor(r),
+	)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to reboot ONU")
+		writeProvisionError(w, err)
+		return
+	}
+
+	log.Info().Msg("Successfully triggered ONU reboot")
+
+	// Convert result to JSON format according to WebResponse structure
+	response := utils.WebResponse{
+		Code:   http.StatusAccepted, // 202, the ONU reboots asynchronously
+		Status: "Accepted",          // "Accepted"
+		Data:   result,              // data
+	}
+
+	utils.SendJSONResponse(w, http.StatusAccepted, response) // 202
+}
+
+func (o *OnuProvisionHandler) GetOnuActionLog(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Msg("Received a request to GetOnuActionLog")
+
+	boardIDInt, ponIDInt, onuIDInt, ok := parseOnuPath(w, r)
+	if !ok {
+		return
+	}
+
+	// Call usecase to get ONU action log from Redis
+	actionLogList, err := o.provisionUsecase.GetOnuActionLog(r.Context(), boardIDInt, ponIDInt, onuIDInt)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get ONU action log")
+		utils.ErrorInternalServerError(w, fmt.Errorf("cannot get onu action log")) // error 500
+		return
+	}
+
+	// Convert result to JSON format according to WebResponse structure
+	response := utils.WebResponse{
+		Code:   http.StatusOK, // 200
+		Status: "OK",          // "OK"
+		Data:   actionLogList, // data
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, response) // 200
+}
+
+// getActor is a function to get who performs a change, the API key of the request or the X-Actor header without one
+func getActor(r *http.Request) string {
+	if apiKey, ok := middleware.ApiKeyFromContext(r.Context()); ok {
+		if actor := r.Header.Get(actorHeader); actor != "" {
+			return apiKey.ID + " (" + actor + ")"
+		}
+		return apiKey.ID
+	}
+	return r.Header.Get(actorHeader)
+}
+
+// parseOnuPath is a function to parse and validate board_id, pon_id and onu_id URL parameters
+func parseOnuPath(w http.ResponseWriter, r *http.Request) (int, int, int, bool) {
+
+	boardID := chi.URLParam(r, "board_id") // 1 or 2
+	ponID := chi.URLParam(r, "pon_id")     // 1 - 8
+	onuID := chi.URLParam(r, "onu_id")     // 1 - 128
+
+	boardIDInt, err := strconv.Atoi(boardID) // convert string to int
+
+	// Validate boardIDInt value and return error 400 if boardIDInt is not 1 or 2
+	if err != nil || (boardIDInt != 1 && boardIDInt != 2) {
+		log.Error().Err(err).Msg("Invalid 'board_id' parameter")
+		utils.ErrorBadRequest(w, fmt.Errorf("invalid 'board_id' parameter. It must be 1 or 2")) // error 400
+		return 0, 0, 0, false
+	}
+
+	ponIDInt, err := strconv.Atoi(ponID) // convert string to int
+
+	// Validate ponIDInt value and return error 400 if ponIDInt is not between 1 and 8
+	if err != nil || ponIDInt < 1 || ponIDInt > 8 {
+		log.Error().Err(err).Msg("Invalid 'pon_id' parameter")
+		utils.ErrorBadRequest(w, fmt.Errorf("invalid 'pon_id' parameter. It must be between 1 and 8")) // error 400
+		return 0, 0, 0, false
+	}
+
+	onuIDInt, err := strconv.Atoi(onuID) // convert string to int
+
+	// Validate onuIDInt value and return error 400 if onuIDInt is not between 1 and 128
+	if err != nil || onuIDInt < 1 || onuIDInt > 128 {
+		log.Error().Err(err).Msg("Invalid 'onu_id' parameter")
+		utils.ErrorBadRequest(w, fmt.Errorf("invalid 'onu_id' parameter. It must be between 1 and 128")) // error 400
+		return 0, 0, 0, false
+	}
+
+	return boardIDInt, ponIDInt, onuIDInt, true
+}
+
+// writeProvisionError is a function to map provisioning usecase errors to HTTP error responses
+func writeProvisionError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrInvalidProvisionRequest),
+		errors.Is(err, usecase.ErrConfirmationMismatch):
+		utils.ErrorBadRequest(w, err) // error 400
+	case errors.Is(err, usecase.ErrOnuIDOccupied),
+		errors.Is(err, usecase.ErrSerialNumberRegistered),
+		errors.Is(err, usecase.ErrNoEmptyOnuID):
+		utils.ErrorConflict(w, err) // error 409
+	case errors.Is(err, usecase.ErrOnuNotFound):
+		utils.ErrorNotFound(w, err) // error 404
+	case errors.Is(err, usecase.ErrSnmpBudgetExceeded):
+		writeSnmpError(w, err) // error 429
+	default:
+		utils.ErrorInternalServerError(w, fmt.Errorf("cannot provision onu via snmp")) // error 500
+	}
+}
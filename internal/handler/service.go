@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/usecase"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/utils"
+	"github.com/rs/zerolog/log"
+	"net/http"
+)
+
+type OnuServiceHandlerInterface interface {
+	GetOnuServices(w http.ResponseWriter, r *http.Request)
+}
+
+type OnuServiceHandler struct {
+	serviceUsecase usecase.OnuServiceUseCaseInterface
+}
+
+func NewOnuServiceHandler(serviceUsecase usecase.OnuServiceUseCaseInterface) *OnuServiceHandler {
+	return &OnuServiceHandler{serviceUsecase: serviceUsecase}
+}
+
+func (o *OnuServiceHandler) GetOnuServices(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Msg("Received a request to GetOnuServices")
+
+	boardIDInt, ponIDInt, onuIDInt, ok := parseOnuPath(w, r)
+	if !ok {
+		return
+	}
+
+	// Call usecase to get ONU service-port, TCONT and GEM port from OLT CLI
+	onuServiceInfo, err := o.serviceUsecase.GetOnuServices(r.Context(), boardIDInt, ponIDInt, onuIDInt)
+	if err != nil {
+		switch {
+		case errors.Is(err, usecase.ErrCliNotConfigured):
+			log.Warn().Msg("OLT CLI is not configured")
+			utils.ErrorServiceUnavailable(w, err) // error 503
+		case errors.Is(err, usecase.ErrOnuNotFound):
+			log.Warn().Msg("Data not found")
+			utils.ErrorNotFound(w, fmt.Errorf("data not found")) // error 404
+		default:
+			log.Error().Err(err).Msg("Failed to get data from OLT CLI")
+			utils.ErrorInternalServerError(w, fmt.Errorf("cannot get data from olt cli")) // error 500
+		}
+		return
+	}
+
+	log.Info().Msg("Successfully retrieved data from OLT CLI")
+
+	// Convert result to JSON format according to WebResponse structure
+	response := utils.WebResponse{
+		Code:   http.StatusOK,  // 200
+		Status: "OK",           // "OK"
+		Data:   onuServiceInfo, // data
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, response) // 200
+}
@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/model"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/usecase"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/utils"
+	"github.com/rs/zerolog/log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type SlaHandlerInterface interface {
+	GetSlaReport(w http.ResponseWriter, r *http.Request)
+}
+
+type SlaHandler struct {
+	slaUsecase usecase.SlaUseCaseInterface
+}
+
+func NewSlaHandler(slaUsecase usecase.SlaUseCaseInterface) *SlaHandler {
+	return &SlaHandler{slaUsecase: slaUsecase}
+}
+
+// GetSlaReport sends the availability report of a window as JSON, or as CSV with format=csv
+func (s *SlaHandler) GetSlaReport(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Msg("Received a request to GetSlaReport")
+
+	query := r.URL.Query()
+
+	format := strings.ToLower(query.Get("format"))
+	if format != "" && format != "json" && format != "csv" {
+		log.Error().Msg("Invalid 'format' parameter")
+		utils.ErrorBadRequest(w, fmt.Errorf("invalid 'format' parameter. It must be json or csv")) // error 400
+		return
+	}
+
+	filter := usecase.SlaFilter{}
+	parsers := []struct {
+		name  string
+		value *int
+	}{
+		{"board", &filter.Board},
+		{"pon", &filter.PON},
+		{"onu", &filter.ID},
+	}
+	for _, parser := range parsers {
+		value := query.Get(parser.name)
+		if value == "" {
+			continue
+		}
+		valueInt, err := strconv.Atoi(value)
+		if err != nil {
+			log.Error().Err(err).Msgf("Invalid '%s' parameter", parser.name)
+			utils.ErrorBadRequest(w, fmt.Errorf("invalid '%s' parameter. It must be a number", parser.name)) // error 400
+			return
+		}
+		*parser.value = valueInt
+	}
+
+	// Call usecase to get the SLA report of the window, day when not given
+	report, err := s.slaUsecase.GetReport(r.Context(), strings.ToLower(query.Get("window")), filter)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get SLA report")
+		if errors.Is(err, usecase.ErrInvalidSlaRequest) {
+			utils.ErrorBadRequest(w, err) // error 400
+			return
+		}
+		writeSnmpError(w, err)
+		return
+	}
+
+	log.Info().Msg("Successfully retrieved SLA report")
+
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition",
+			fmt.Sprintf("attachment; filename=\"sla-%s-%s.csv\"", report.Olt, report.Window))
+		w.WriteHeader(http.StatusOK) // 200
+		if err := writeSlaCSV(w, report); err != nil {
+			log.Error().Err(err).Msg("Failed to write SLA report")
+		}
+		return
+	}
+
+	// Convert result to JSON format according to WebResponse structure
+	response := utils.WebResponse{
+		Code:   http.StatusOK, // 200
+		Status: "OK",          // "OK"
+		Data:   report,        // data
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, response) // 200
+}
+
+// writeSlaCSV is a function to write a row per PON followed by a row per ONU,
+// down time per reason is written as reason=seconds pairs separated by semicolons
+func writeSlaCSV(w http.ResponseWriter, report model.SlaReport) error {
+
+	writer := csv.NewWriter(w)
+
+	header := []string{
+		"level", "olt", "window", "from", "to", "board", "pon", "onu_id", "name", "serial_number", "status",
+		"onu_count", "availability_percent", "outages", "downtime_seconds", "mttr_seconds", "downtime_by_reason",
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, pon := range report.Pons {
+		row := []string{
+			"pon", report.Olt, report.Window, report.From, report.To,
+			strconv.Itoa(pon.Board), strconv.Itoa(pon.PON), "", "", "", "",
+			strconv.Itoa(pon.OnuCount),
+			strconv.FormatFloat(pon.AvailabilityPercent, 'f', 3, 64),
+			strconv.Itoa(pon.Outages),
+			strconv.FormatInt(pon.DowntimeSeconds, 10),
+			strconv.FormatInt(pon.MttrSeconds, 10),
+			formatDowntimeByReason(pon.DowntimeByReason),
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	for _, onu := range report.Onus {
+		row := []string{
+			"onu", report.Olt, report.Window, report.From, report.To,
+			strconv.Itoa(onu.Board), strconv.Itoa(onu.PON), strconv.Itoa(onu.ID),
+			onu.Name, onu.SerialNumber, onu.Status, "",
+			strconv.FormatFloat(onu.AvailabilityPercent, 'f', 3, 64),
+			strconv.Itoa(onu.Outages),
+			strconv.FormatInt(onu.DowntimeSeconds, 10),
+			strconv.FormatInt(onu.MttrSeconds, 10),
+			formatDowntimeByReason(onu.DowntimeByReason),
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// formatDowntimeByReason is a function to format down time per reason as reason=seconds pairs sorted by reason
+func formatDowntimeByReason(downtimeByReason map[string]int64) string {
+	reasons := make([]string, 0, len(downtimeByReason))
+	for reason := range downtimeByReason {
+		reasons = append(reasons, reason)
+	}
+	sort.Strings(reasons)
+
+	pairs := make([]string, 0, len(reasons))
+	for _, reason := range reasons {
+		pairs = append(pairs, fmt.Sprintf("%s=%d", reason, downtimeByReason[reason]))
+	}
+	return strings.Join(pairs, ";")
+}
@@ -0,0 +1,174 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/model"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/usecase"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/utils"
+	"github.com/rs/zerolog/log"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	streamBufferSize    = 64   // Events kept for a slow client before new events are dropped for it
+	streamRetryInterval = 3000 // Reconnect delay in milliseconds sent to EventSource clients
+)
+
+type OnuStreamHandlerInterface interface {
+	Stream(w http.ResponseWriter, r *http.Request)
+}
+
+type OnuStreamHandler struct {
+	streamUsecase     usecase.OnuStreamUseCaseInterface
+	heartbeatInterval time.Duration
+}
+
+func NewOnuStreamHandler(
+	streamUsecase usecase.OnuStreamUseCaseInterface, heartbeatInterval time.Duration,
+) *OnuStreamHandler {
+	if heartbeatInterval <= 0 {
+		heartbeatInterval = 15 * time.Second
+	}
+	return &OnuStreamHandler{streamUsecase: streamUsecase, heartbeatInterval: heartbeatInterval}
+}
+
+// Stream sends ONU events as Server-Sent Events until the client disconnects
+func (o *OnuStreamHandler) Stream(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Msg("Received a request to Stream")
+
+	filter, err := parseEventFilter(r)
+	if err != nil {
+		log.Error().Err(err).Msg("Invalid stream filter")
+		utils.ErrorBadRequest(w, err) // error 400
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		log.Error().Msg("Response writer does not support streaming")
+		utils.ErrorInternalServerError(w, fmt.Errorf("streaming is not supported")) // error 500
+		return
+	}
+
+	// EventSource sends Last-Event-ID on reconnect, last_event_id allows clients that can't set headers to resume
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+	lastEventIDInt, _ := strconv.ParseUint(lastEventID, 10, 64) // Invalid ID resumes from now
+
+	// The server write timeout would end the stream, only this connection is kept open without it
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		log.Warn().Err(err).Msg("Cannot clear the write deadline of the stream")
+	}
+
+	replay, events, cancel := o.streamUsecase.Subscribe(lastEventIDInt, streamBufferSize)
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // Disable proxy buffering
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := fmt.Fprintf(w, "retry: %d\n\n", streamRetryInterval); err != nil {
+		return
+	}
+
+	// Send events missed since Last-Event-ID
+	for _, event := range replay {
+		if !filter.Match(event) {
+			continue
+		}
+		if err := writeSSEEvent(w, event); err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(o.heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return // Stream closed on shutdown
+			}
+			if !filter.Match(event) {
+				continue
+			}
+			if err := writeSSEEvent(w, event); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			// Comment line keeps the connection open through proxies without triggering a client event
+			if _, err := io.WriteString(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
+
+// writeSSEEvent is a function to write an ONU event in Server-Sent Events format
+func writeSSEEvent(w io.Writer, event model.OnuEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.EventID, event.Type, data)
+	return err
+}
+
+// parseEventFilter is a function to parse olt, board, pon, onu and type query parameters
+func parseEventFilter(r *http.Request) (usecase.OnuEventFilter, error) {
+
+	query := r.URL.Query()
+	filter := usecase.OnuEventFilter{Olt: query.Get("olt")}
+
+	parsers := []struct {
+		name  string
+		max   int
+		value *int
+	}{
+		{"board", 2, &filter.Board},
+		{"pon", 8, &filter.PON},
+		{"onu", 128, &filter.ID},
+	}
+	for _, parser := range parsers {
+		value := query.Get(parser.name)
+		if value == "" {
+			continue
+		}
+		valueInt, err := strconv.Atoi(value)
+		if err != nil || valueInt < 1 || valueInt > parser.max {
+			return filter, fmt.Errorf("invalid '%s' parameter. It must be between 1 and %d", parser.name, parser.max)
+		}
+		*parser.value = valueInt
+	}
+
+	// Event types are comma separated, e.g. type=status,optical
+	if value := query.Get("type"); value != "" {
+		for _, eventType := range strings.Split(value, ",") {
+			eventType = strings.TrimSpace(eventType)
+			switch eventType {
+			case usecase.OnuEventStatus, usecase.OnuEventOptical, usecase.OnuEventAlarm:
+				filter.Types = append(filter.Types, eventType)
+			default:
+				return filter, fmt.Errorf("invalid 'type' parameter. It must be status, optical or alarm")
+			}
+		}
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,191 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/model"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/usecase"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	maxAuditBodySize     = 8 << 10 // Larger request bodies aren't recorded
+	maxAuditResponseSize = 4 << 10 // Response bytes kept to find the error message
+)
+
+// Audit records every request that isn't a GET, HEAD or OPTIONS request once it's served, with who made it,
+// the target ONU or PON and the result, the request is served even when the record can't be written, the
+// deprecated GET alias of a cache refresh is recorded too
+func Audit(auditUsecase usecase.AuditUseCaseInterface) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				if !isCacheRefreshAlias(r.URL.Path) {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			startTime := time.Now()
+			body := readAuditBody(r)
+
+			response := &limitedBuffer{limit: maxAuditResponseSize}
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			ww.Tee(response)
+
+			next.ServeHTTP(ww, r)
+
+			record := model.AuditRecord{
+				Time:         startTime.UTC().Format(time.RFC3339Nano),
+				ClaimedActor: r.Header.Get("X-Actor"),
+				SourceIP:     clientIP(r),
+				Method:       r.Method,
+				Path:         r.URL.Path,
+				Body:         body,
+				Status:       ww.Status(),
+				DurationMs:   time.Since(startTime).Milliseconds(),
+			}
+			if identity, ok := r.Context().Value(identityContextKey).(*requestIdentity); ok {
+				record.KeyID = identity.apiKeyID
+			}
+			record.Actor = getAuditActor(record.KeyID, record.ClaimedActor)
+			setAuditTarget(&record, r)
+			if record.Status >= http.StatusBadRequest {
+				record.Error = getErrorMessage(response.Bytes())
+			}
+
+			if err := auditUsecase.Record(record); err != nil {
+				log.Error().Err(err).Msg("Failed to write audit record of " + r.Method + " " + r.URL.Path)
+			}
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// getAuditActor is a function to get the actor of a request as handlers record it with changes, the X-Actor header
+// is only added to an authenticated key ID, a request without one has no actor
+func getAuditActor(keyID, claimedActor string) string {
+	if keyID != "" && claimedActor != "" {
+		return keyID + " (" + claimedActor + ")"
+	}
+	return keyID
+}
+
+// readAuditBody is a function to read a JSON request body of up to maxAuditBodySize, the body is restored for the
+// handler, a body that is larger or isn't JSON isn't recorded
+func readAuditBody(r *http.Request) json.RawMessage {
+
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxAuditBodySize+1))
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+	if err != nil || len(body) > maxAuditBodySize || !json.Valid(body) {
+		return nil
+	}
+
+	var compacted bytes.Buffer
+	if err := json.Compact(&compacted, body); err != nil {
+		return nil
+	}
+	return compacted.Bytes()
+}
+
+// setAuditTarget is a function to set the route, parameters and the target board, PON and ONU of a served request,
+// they are taken from route parameters, then query parameters, then the request body
+func setAuditTarget(record *model.AuditRecord, r *http.Request) {
+
+	// A request rejected before routing, e.g. by Auth, is matched here
+	routeContext := chi.RouteContext(r.Context())
+	if routeContext != nil && routeContext.RoutePattern() == "" && routeContext.Routes != nil {
+		matched := chi.NewRouteContext()
+		if routeContext.Routes.Match(matched, r.Method, r.URL.Path) {
+			routeContext = matched
+		}
+	}
+
+	params := map[string]string{}
+	if routeContext != nil {
+		record.Route = routeContext.RoutePattern()
+		for i, key := range routeContext.URLParams.Keys {
+			if key != "*" {
+				params[key] = routeContext.URLParams.Values[i]
+			}
+		}
+	}
+	for key, values := range r.URL.Query() {
+		if _, ok := params[key]; !ok && len(values) > 0 {
+			params[key] = strings.Join(values, ",")
+		}
+	}
+	if len(params) > 0 {
+		record.Params = params
+	}
+
+	var body map[string]interface{}
+	_ = json.Unmarshal(record.Body, &body) // The body is optional
+
+	getID := func(keys ...string) int {
+		for _, key := range keys {
+			if id, err := strconv.Atoi(params[key]); err == nil {
+				return id
+			}
+			if id, ok := body[key].(float64); ok {
+				return int(id)
+			}
+		}
+		return 0
+	}
+
+	record.Board = getID("board_id", "board")
+	record.PON = getID("pon_id", "pon")
+	record.OnuID = getID("onu_id")
+}
+
+// getErrorMessage is a function to get the message of an error response, the raw response is used when it isn't an
+// error response
+func getErrorMessage(response []byte) string {
+	var errorResponse struct {
+		Message interface{} `json:"message"`
+	}
+	if err := json.Unmarshal(response, &errorResponse); err == nil && errorResponse.Message != nil {
+		if message, ok := errorResponse.Message.(string); ok {
+			return message
+		}
+		if message, err := json.Marshal(errorResponse.Message); err == nil {
+			return string(message)
+		}
+	}
+	return strings.TrimSpace(string(response))
+}
+
+// limitedBuffer keeps the first bytes written to it and discards the rest
+type limitedBuffer struct {
+	bytes.Buffer
+	limit int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.Len(); room > 0 {
+		if len(p) > room {
+			b.Buffer.Write(p[:room])
+		} else {
+			b.Buffer.Write(p)
+		}
+	}
+	return len(p), nil
+}
@@ -0,0 +1,148 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/model"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/usecase"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/utils"
+	"github.com/rs/zerolog/log"
+)
+
+// ApiKeyHeader is the request header carrying the API key
+const ApiKeyHeader = "X-API-Key"
+
+type contextKey int
+
+const (
+	apiKeyContextKey contextKey = iota
+	identityContextKey
+)
+
+// publicPaths are served without an API key
+var publicPaths = map[string]bool{"/": true, "/openapi.json": true, "/docs": true}
+
+// Auth authenticates requests with the X-API-Key header or a bearer token and checks the scopes and restrictions
+// of the key or token roles against the route, jwtAuthUsecase is nil when bearer tokens are disabled,
+// the identity is available to handlers with ApiKeyFromContext
+func Auth(
+	apiKeyUsecase usecase.ApiKeyUseCaseInterface, jwtAuthUsecase usecase.JwtAuthUseCaseInterface, oltName string,
+) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if publicPaths[r.URL.Path] || r.Method == http.MethodOptions {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			apiKey, err := authenticate(r, apiKeyUsecase, jwtAuthUsecase)
+			if err != nil {
+				if errors.Is(err, usecase.ErrApiKeyInvalid) || errors.Is(err, usecase.ErrJwtInvalid) {
+					if jwtAuthUsecase != nil {
+						w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+					}
+					utils.ErrorUnauthorized(w, err) // error 401
+					return
+				}
+				log.Error().Err(err).Msg("Failed to authenticate request")
+				utils.ErrorInternalServerError(w, fmt.Errorf("cannot authenticate request")) // error 500
+				return
+			}
+
+			// Let Logger log who made the request
+			if identity, ok := r.Context().Value(identityContextKey).(*requestIdentity); ok {
+				identity.apiKeyID = apiKey.ID
+			}
+
+			scope := getRequiredScope(r)
+			if !usecase.ApiKeyHasScope(apiKey, scope) {
+				utils.ErrorForbidden(w, fmt.Errorf("API key needs scope %s", scope)) // error 403
+				return
+			}
+
+			olt, boardID, ponID := getRequestTarget(r)
+			if !usecase.ApiKeyAllows(apiKey, oltName, olt, boardID, ponID) {
+				utils.ErrorForbidden(w, fmt.Errorf("API key is not allowed to access this OLT, board or PON")) // error 403
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), apiKeyContextKey, apiKey)))
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// authenticate is a function to get the identity of the bearer token or else the API key of a request
+func authenticate(
+	r *http.Request, apiKeyUsecase usecase.ApiKeyUseCaseInterface, jwtAuthUsecase usecase.JwtAuthUseCaseInterface,
+) (model.ApiKey, error) {
+
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return apiKeyUsecase.Authenticate(r.Context(), r.Header.Get(ApiKeyHeader))
+	}
+
+	if jwtAuthUsecase == nil {
+		return model.ApiKey{}, fmt.Errorf("%w: bearer tokens are not enabled", usecase.ErrJwtInvalid)
+	}
+
+	return jwtAuthUsecase.Authenticate(r.Context(), strings.TrimSpace(token))
+}
+
+// ApiKeyFromContext returns the API key or bearer token identity of an authenticated request
+func ApiKeyFromContext(ctx context.Context) (model.ApiKey, bool) {
+	apiKey, ok := ctx.Value(apiKeyContextKey).(model.ApiKey)
+	return apiKey, ok
+}
+
+// getRequiredScope is a function to get the scope a route needs, key management and the audit log need admin,
+// reads need read, cache changes need admin and other changes need provision
+func getRequiredScope(r *http.Request) string {
+	switch {
+	case strings.HasPrefix(r.URL.Path, "/api/v1/auth/"), strings.HasPrefix(r.URL.Path, "/api/v1/audit"):
+		return usecase.ApiKeyScopeAdmin
+	case isCacheRefreshAlias(r.URL.Path):
+		return usecase.ApiKeyScopeAdmin // Refreshes the cache of a PON like POST /api/v1/cache/refresh
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		return usecase.ApiKeyScopeRead
+	case strings.HasPrefix(r.URL.Path, "/api/v1/cache"):
+		return usecase.ApiKeyScopeAdmin
+	default:
+		return usecase.ApiKeyScopeProvision
+	}
+}
+
+// isCacheRefreshAlias is a function to check if a path is the deprecated GET alias of a PON cache refresh,
+// /api/v1/board/{board_id}/pon/{pon_id}/onu_id/update
+func isCacheRefreshAlias(path string) bool {
+	return strings.HasPrefix(path, "/api/v1/board/") && strings.HasSuffix(path, "/onu_id/update")
+}
+
+// getRequestTarget is a function to get the OLT, board and PON a request selects with route or query parameters,
+// the route is matched here since middleware of the root router runs before routing
+func getRequestTarget(r *http.Request) (string, int, int) {
+
+	routeContext := chi.NewRouteContext()
+	if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.Routes != nil {
+		rctx.Routes.Match(routeContext, r.Method, r.URL.Path)
+	}
+
+	query := r.URL.Query()
+	getID := func(routeParam, queryParam string) int {
+		value := routeContext.URLParam(routeParam)
+		if value == "" {
+			value = query.Get(queryParam)
+		}
+		id, _ := strconv.Atoi(value) // 0 selects no board or PON
+		return id
+	}
+
+	return query.Get("olt"), getID("board_id", "board"), getID("pon_id", "pon")
+}
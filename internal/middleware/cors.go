@@ -5,12 +5,14 @@ import (
 	"net/http"
 )
 
-func CorsMiddleware() func(next http.Handler) http.Handler {
+// CorsMiddleware allows browser requests of the allowed origins, an origin can have one wildcard,
+// e.g. https://*.example.com, or be * to allow every origin
+func CorsMiddleware(allowedOrigins []string) func(next http.Handler) http.Handler {
 	return cors.Handler(cors.Options{
-		AllowedOrigins:   []string{"https://*", "http://*"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link"},
+		AllowedOrigins:   allowedOrigins,
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "X-API-Key", "X-Actor"},
+		ExposedHeaders:   []string{"Link", "Deprecation", "WWW-Authenticate", "Retry-After"},
 		AllowCredentials: false,
 		MaxAge:           300, // Maximum value not ignored by any of major browsers
 	})
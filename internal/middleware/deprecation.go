@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Deprecated marks responses of a deprecated route with a Deprecation header and a Link header to its successor
+func Deprecated(successor string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			log.Warn().Msg("Deprecated route " + r.URL.Path + " called, use " + successor)
+
+			w.Header().Set("Deprecation", "true")
+			w.Header().Set("Link", "<"+successor+">; rel=\"successor-version\"")
+
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
 	"runtime/debug"
 	"time"
@@ -9,12 +10,20 @@ import (
 	"github.com/rs/zerolog"
 )
 
+// requestIdentity is filled by Auth, which runs after Logger, so Logger and Audit can log who made the request
+type requestIdentity struct {
+	apiKeyID string
+}
+
 func Logger(logger zerolog.Logger) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		fn := func(w http.ResponseWriter, r *http.Request) {
 			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
 			startTime := time.Now()
 
+			identity := &requestIdentity{}
+			r = r.WithContext(context.WithValue(r.Context(), identityContextKey, identity))
+
 			defer func() {
 				endTime := time.Now()                 // End time
 				elapsedTime := endTime.Sub(startTime) // Request time
@@ -36,6 +45,7 @@ func Logger(logger zerolog.Logger) func(next http.Handler) http.Handler {
 					"bytes_in":     r.ContentLength,
 					"bytes_out":    ww.BytesWritten(),
 					"elapsed_time": elapsedTime.String(),
+					"api_key":      identity.apiKeyID,
 				}).Msg("incoming_request")
 			}()
 
@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/usecase"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/utils"
+	"github.com/rs/zerolog/log"
+)
+
+// RateLimitIP limits requests of each client IP, it runs before Auth so failed authentication attempts are limited
+// too, requests are allowed when the rate limit can't be checked
+func RateLimitIP(rateLimitUsecase usecase.RateLimitUseCaseInterface) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			ip := clientIP(r)
+			retryAfter, err := rateLimitUsecase.AllowIP(r.Context(), ip)
+			if !allowRequest(w, "ip:"+ip, retryAfter, err) {
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// RateLimit limits requests of each API key or bearer token subject, it runs after Auth, requests without one are
+// only limited by RateLimitIP, requests are allowed when the rate limit can't be checked
+func RateLimit(rateLimitUsecase usecase.RateLimitUseCaseInterface) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			apiKey, ok := ApiKeyFromContext(r.Context())
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			clientID := "key:" + apiKey.ID
+			retryAfter, err := rateLimitUsecase.AllowClient(r.Context(), clientID)
+			if !allowRequest(w, clientID, retryAfter, err) {
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// allowRequest is a function to respond 429 to a rate limited client, it returns false when the request was rejected
+func allowRequest(w http.ResponseWriter, clientID string, retryAfter time.Duration, err error) bool {
+	if errors.Is(err, usecase.ErrRateLimited) {
+		log.Warn().Msg("Rate limit exceeded by " + clientID)
+		utils.ErrorTooManyRequests(w, fmt.Errorf("rate limit exceeded, retry later"), retryAfter) // error 429
+		return false
+	}
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to check rate limit")
+	}
+	return true
+}
+
+// SnmpBudget rejects requests of routes reading the OLT while the SNMP budget of the OLT is saturated
+func SnmpBudget(rateLimitUsecase usecase.RateLimitUseCaseInterface) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if retryAfter, saturated := rateLimitUsecase.SnmpSaturated(); saturated {
+				log.Warn().Msg("SNMP budget of the OLT is saturated, rejecting " + r.URL.Path)
+				utils.ErrorTooManyRequests(w, fmt.Errorf("the OLT is busy, retry later"), retryAfter) // error 429
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// clientIP is a function to get the IP of the client of a request
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// RealIP sets the remote address of requests of trusted proxies to the client IP of X-Forwarded-For, the rightmost
+// address that isn't a trusted proxy, or of X-Real-IP, headers of other clients are ignored so they can't be spoofed
+func RealIP(trustedProxies []*net.IPNet) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if isTrustedProxy(net.ParseIP(clientIP(r)), trustedProxies) {
+				if ip := getForwardedIP(r, trustedProxies); ip != "" {
+					r.RemoteAddr = ip
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// getForwardedIP is a function to get the client IP a trusted proxy forwarded a request for, empty when unknown
+func getForwardedIP(r *http.Request, trustedProxies []*net.IPNet) string {
+
+	// Every proxy appends the address it got the request from, so the client is left of the last trusted proxy
+	var addresses []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		addresses = append(addresses, strings.Split(header, ",")...)
+	}
+	forwardedIP := ""
+	for i := len(addresses) - 1; i >= 0; i-- {
+		ip := net.ParseIP(strings.TrimSpace(addresses[i]))
+		if ip == nil {
+			break
+		}
+		forwardedIP = ip.String()
+		if !isTrustedProxy(ip, trustedProxies) {
+			break
+		}
+	}
+	if forwardedIP != "" {
+		return forwardedIP
+	}
+
+	if ip := net.ParseIP(strings.TrimSpace(r.Header.Get("X-Real-IP"))); ip != nil {
+		return ip.String()
+	}
+	return ""
+}
+
+// isTrustedProxy is a function to check if an IP is in a network of trusted proxies
+func isTrustedProxy(ip net.IP, trustedProxies []*net.IPNet) bool {
+	if ip == nil {
+		return false
+	}
+	for _, network := range trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,5 +1,9 @@
 package model
 
+import (
+	"encoding/json"
+)
+
 type OltConfig struct {
 	BaseOID                   string
 	OnuIDNameOID              string
@@ -22,34 +26,38 @@ type ONUInfo struct {
 }
 
 type ONUInfoPerBoard struct {
-	Board        int    `json:"board"`
-	PON          int    `json:"pon"`
-	ID           int    `json:"onu_id"`
-	Name         string `json:"name"`
-	OnuType      string `json:"onu_type"`
-	SerialNumber string `json:"serial_number"`
-	RXPower      string `json:"rx_power"`
-	Status       string `json:"status"`
+	Board        int          `json:"board"`
+	PON          int          `json:"pon"`
+	ID           int          `json:"onu_id"`
+	Name         string       `json:"name"`
+	OnuType      string       `json:"onu_type"`
+	SerialNumber string       `json:"serial_number"`
+	RXPower      string       `json:"rx_power"`
+	Status       string       `json:"status"`
+	Customer     *OnuCustomer `json:"customer,omitempty"`
 }
 
 type ONUCustomerInfo struct {
-	Board                int    `json:"board"`
-	PON                  int    `json:"pon"`
-	ID                   int    `json:"onu_id"`
-	Name                 string `json:"name"`
-	Description          string `json:"description"`
-	OnuType              string `json:"onu_type"`
-	SerialNumber         string `json:"serial_number"`
-	RXPower              string `json:"rx_power"`
-	TXPower              string `json:"tx_power"`
-	Status               string `json:"status"`
-	IPAddress            string `json:"ip_address"`
-	LastOnline           string `json:"last_online"`
-	LastOffline          string `json:"last_offline"`
-	Uptime               string `json:"uptime"`
-	LastDownTimeDuration string `json:"last_down_time_duration"`
-	LastOfflineReason    string `json:"offline_reason"`
-	GponOpticalDistance  string `json:"gpon_optical_distance"`
+	Board                int          `json:"board"`
+	PON                  int          `json:"pon"`
+	ID                   int          `json:"onu_id"`
+	Name                 string       `json:"name"`
+	Description          string       `json:"description"`
+	OnuType              string       `json:"onu_type"`
+	SerialNumber         string       `json:"serial_number"`
+	RXPower              string       `json:"rx_power"`
+	TXPower              string       `json:"tx_power"`
+	Status               string       `json:"status"`
+	IPAddress            string       `json:"ip_address"`
+	LastOnline           string       `json:"last_online"`  // RFC 3339
+	LastOffline          string       `json:"last_offline"` // RFC 3339
+	UptimeSeconds        *int64       `json:"uptime_seconds,omitempty"`
+	Uptime               string       `json:"uptime,omitempty"`
+	LastDownTimeSeconds  *int64       `json:"last_down_time_seconds,omitempty"`
+	LastDownTimeDuration string       `json:"last_down_time_duration,omitempty"`
+	LastOfflineReason    string       `json:"offline_reason"`
+	GponOpticalDistance  string       `json:"gpon_optical_distance"`
+	Customer             *OnuCustomer `json:"customer,omitempty"`
 }
 
 type OnuID struct {
@@ -75,3 +83,478 @@ type OnuSerialNumber struct {
 	ID           int    `json:"onu_id"`
 	SerialNumber string `json:"serial_number"`
 }
+
+type OnuUniPort struct {
+	PortID     int    `json:"port_id"`
+	AdminState string `json:"admin_state"`
+	OperState  string `json:"oper_state"`
+	Speed      string `json:"speed"`
+	Duplex     string `json:"duplex"`
+}
+
+type OnuMacAddress struct {
+	PortID     int    `json:"port_id"`
+	MacAddress string `json:"mac_address"`
+}
+
+type OnuUniInfo struct {
+	Board        int             `json:"board"`
+	PON          int             `json:"pon"`
+	ID           int             `json:"onu_id"`
+	Ports        []OnuUniPort    `json:"ports"`
+	MacAddresses []OnuMacAddress `json:"mac_addresses"`
+}
+
+type OnuFirmwareInfo struct {
+	Board                  int    `json:"board"`
+	PON                    int    `json:"pon"`
+	ID                     int    `json:"onu_id"`
+	OnuType                string `json:"onu_type"`
+	SoftwareVersionActive  string `json:"software_version_active"`
+	SoftwareVersionStandby string `json:"software_version_standby"`
+	HardwareVersion        string `json:"hardware_version"`
+	VendorID               string `json:"vendor_id"`
+	EquipmentID            string `json:"equipment_id"`
+}
+
+type FirmwareGroup struct {
+	OnuType         string  `json:"onu_type"`
+	SoftwareVersion string  `json:"software_version"`
+	Count           int     `json:"count"`
+	Onus            []OnuID `json:"onus"`
+}
+
+type FirmwareReport struct {
+	TotalOnu int             `json:"total_onu"`
+	Groups   []FirmwareGroup `json:"groups"`
+}
+
+type UnconfiguredOnu struct {
+	Board         int    `json:"board"`
+	PON           int    `json:"pon"`
+	SerialNumber  string `json:"serial_number"`
+	EquipmentID   string `json:"equipment_id"`
+	DiscoveryTime string `json:"discovery_time"`
+}
+
+type UnconfiguredOnuPon struct {
+	Board               int               `json:"board"`
+	PON                 int               `json:"pon"`
+	SuggestedOnuID      int               `json:"suggested_onu_id,omitempty"`       // 0 when the PON is full
+	SuggestedOnuIDError string            `json:"suggested_onu_id_error,omitempty"` // Failed lookup of all PON
+	UnconfiguredOnus    []UnconfiguredOnu `json:"unconfigured_onus"`
+}
+
+type OnuRegisterRequest struct {
+	OnuID        int    `json:"onu_id"`
+	SerialNumber string `json:"serial_number"`
+	OnuType      string `json:"onu_type"`
+	Name         string `json:"name"`
+	Description  string `json:"description"`
+}
+
+type OnuProvisionResult struct {
+	Board        int      `json:"board"`
+	PON          int      `json:"pon"`
+	ID           int      `json:"onu_id"`
+	SerialNumber string   `json:"serial_number"`
+	OnuType      string   `json:"onu_type"`
+	Name         string   `json:"name"`
+	Description  string   `json:"description"`
+	Warnings     []string `json:"warnings,omitempty"` // Parts of the request that failed after the ONU was registered
+}
+
+type OnuUpdateRequest struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+	Confirm     string  `json:"confirm"`
+}
+
+type OnuRebootRequest struct {
+	Confirm string `json:"confirm"`
+}
+
+type OnuDeregisterRequest struct {
+	Confirm string `json:"confirm"`
+}
+
+type OnuActionLog struct {
+	Action       string            `json:"action"`
+	Board        int               `json:"board"`
+	PON          int               `json:"pon"`
+	ID           int               `json:"onu_id"`
+	SerialNumber string            `json:"serial_number"`
+	Actor        string            `json:"actor"`
+	Changes      map[string]string `json:"changes,omitempty"`
+	Timestamp    string            `json:"timestamp"`
+}
+
+type OnuEvent struct {
+	EventID   uint64 `json:"event_id,omitempty"`
+	Type      string `json:"type"`
+	Olt       string `json:"olt,omitempty"`
+	Board     int    `json:"board"`
+	PON       int    `json:"pon"`
+	ID        int    `json:"onu_id"`
+	Status    string `json:"status,omitempty"`
+	RxPower   string `json:"rx_power,omitempty"`
+	Alarm     string `json:"alarm,omitempty"`
+	Severity  string `json:"severity,omitempty"`
+	Source    string `json:"source"`
+	Timestamp string `json:"timestamp"`
+}
+
+type OltAlarm struct {
+	ID              int          `json:"alarm_id"`
+	Code            int          `json:"code"`
+	Type            string       `json:"type"`
+	Category        string       `json:"category"`
+	Severity        string       `json:"severity"`
+	Source          string       `json:"source"`
+	Board           int          `json:"board,omitempty"`
+	PON             int          `json:"pon,omitempty"`
+	OnuID           int          `json:"onu_id,omitempty"`
+	Description     string       `json:"description,omitempty"`
+	RaisedAt        string       `json:"raised_at"`
+	Acknowledged    bool         `json:"acknowledged"`
+	Acknowledgement *OltAlarmAck `json:"acknowledgement,omitempty"`
+}
+
+type OltAlarmAck struct {
+	Actor     string `json:"actor"`
+	Comment   string `json:"comment,omitempty"`
+	Timestamp string `json:"timestamp"`
+}
+
+type OltAlarmAckRequest struct {
+	Comment string `json:"comment"`
+}
+
+type OnuInventory struct {
+	Board        int    `json:"board"`
+	PON          int    `json:"pon"`
+	ID           int    `json:"onu_id"`
+	Name         string `json:"name"`
+	OnuType      string `json:"onu_type"`
+	SerialNumber string `json:"serial_number"`
+	Description  string `json:"description"`
+}
+
+type OnuChange struct {
+	Type         string        `json:"type"`
+	Olt          string        `json:"olt,omitempty"`
+	Board        int           `json:"board"`
+	PON          int           `json:"pon"`
+	ID           int           `json:"onu_id"`
+	SerialNumber string        `json:"serial_number"`
+	Previous     *OnuInventory `json:"previous,omitempty"`
+	Current      *OnuInventory `json:"current,omitempty"`
+	Timestamp    string        `json:"timestamp"`
+}
+
+type OnuCustomer struct {
+	SerialNumber string   `json:"serial_number"`
+	CustomerID   string   `json:"customer_id,omitempty"`
+	Name         string   `json:"name,omitempty"`
+	Address      string   `json:"address,omitempty"`
+	Package      string   `json:"package,omitempty"`
+	Latitude     *float64 `json:"latitude,omitempty"`
+	Longitude    *float64 `json:"longitude,omitempty"`
+	UpdatedAt    string   `json:"updated_at"`
+}
+
+type OnuCustomerRequest struct {
+	CustomerID string   `json:"customer_id"`
+	Name       string   `json:"name"`
+	Address    string   `json:"address"`
+	Package    string   `json:"package"`
+	Latitude   *float64 `json:"latitude"`
+	Longitude  *float64 `json:"longitude"`
+}
+
+type OnuCustomerImportResult struct {
+	Imported int                      `json:"imported"`
+	Failed   int                      `json:"failed"`
+	Errors   []OnuCustomerImportError `json:"errors"`
+}
+
+type OnuCustomerImportError struct {
+	Row          int    `json:"row"`
+	SerialNumber string `json:"serial_number,omitempty"`
+	Error        string `json:"error"`
+}
+
+type OnuFeatureCollection struct {
+	Type     string       `json:"type"`
+	Features []OnuFeature `json:"features"`
+}
+
+type OnuFeature struct {
+	Type       string           `json:"type"`
+	ID         string           `json:"id"`
+	Geometry   PointGeometry    `json:"geometry"`
+	Properties OnuMapProperties `json:"properties"`
+}
+
+type PointGeometry struct {
+	Type        string     `json:"type"`
+	Coordinates [2]float64 `json:"coordinates"` // Longitude, latitude
+}
+
+type OnuMapProperties struct {
+	Olt          string `json:"olt"`
+	Board        int    `json:"board"`
+	PON          int    `json:"pon"`
+	ID           int    `json:"onu_id"`
+	Name         string `json:"name"`
+	SerialNumber string `json:"serial_number"`
+	Status       string `json:"status"`
+	RXPower      string `json:"rx_power"`
+	StatusClass  string `json:"status_class"`
+	SignalClass  string `json:"signal_class"`
+	MarkerColor  string `json:"marker-color"`
+	CustomerID   string `json:"customer_id,omitempty"`
+	CustomerName string `json:"customer_name,omitempty"`
+	Address      string `json:"address,omitempty"`
+	Package      string `json:"package,omitempty"`
+}
+
+type AnomalyReport struct {
+	Olt              string                   `json:"olt"`
+	GeneratedAt      string                   `json:"generated_at"`
+	CheckedAt        string                   `json:"checked_at,omitempty"`
+	DuplicateSerials []DuplicateSerialAnomaly `json:"duplicate_serials"`
+	FlappingOnus     []FlappingOnuAnomaly     `json:"flapping_onus"`
+	MassDrops        []MassDropAnomaly        `json:"mass_drops"`
+}
+
+type DuplicateSerialAnomaly struct {
+	SerialNumber string  `json:"serial_number"`
+	Onus         []OnuID `json:"onus"`
+}
+
+type FlappingOnuAnomaly struct {
+	Board           int    `json:"board"`
+	PON             int    `json:"pon"`
+	ID              int    `json:"onu_id"`
+	Status          string `json:"status"`
+	Transitions     int    `json:"transitions"`
+	FirstTransition string `json:"first_transition"`
+	LastTransition  string `json:"last_transition"`
+}
+
+type MassDropAnomaly struct {
+	Board     int    `json:"board"`
+	PON       int    `json:"pon"`
+	OnuCount  int    `json:"onu_count"`
+	OnuIDs    []int  `json:"onu_ids"`
+	StartedAt string `json:"started_at"`
+	EndedAt   string `json:"ended_at"`
+}
+
+type OnuStatusTransition struct {
+	Status        string `json:"status"`
+	OfflineReason string `json:"offline_reason,omitempty"`
+	Timestamp     string `json:"timestamp"`
+	FirstSeen     bool   `json:"first_seen,omitempty"` // First status of an ONU, the time before isn't counted
+}
+
+type SlaReport struct {
+	Olt         string   `json:"olt"`
+	Window      string   `json:"window"`
+	From        string   `json:"from"`
+	To          string   `json:"to"`
+	GeneratedAt string   `json:"generated_at"`
+	Pons        []PonSla `json:"pons"`
+	Onus        []OnuSla `json:"onus"`
+}
+
+type PonSla struct {
+	Board               int              `json:"board"`
+	PON                 int              `json:"pon"`
+	OnuCount            int              `json:"onu_count"`
+	AvailabilityPercent float64          `json:"availability_percent"`
+	Outages             int              `json:"outages"`
+	DowntimeSeconds     int64            `json:"downtime_seconds"`
+	MttrSeconds         int64            `json:"mttr_seconds"`
+	DowntimeByReason    map[string]int64 `json:"downtime_by_reason"`
+}
+
+type OnuSla struct {
+	Board               int              `json:"board"`
+	PON                 int              `json:"pon"`
+	ID                  int              `json:"onu_id"`
+	Name                string           `json:"name"`
+	SerialNumber        string           `json:"serial_number"`
+	Status              string           `json:"status"`
+	AvailabilityPercent float64          `json:"availability_percent"`
+	Outages             int              `json:"outages"`
+	DowntimeSeconds     int64            `json:"downtime_seconds"`
+	MttrSeconds         int64            `json:"mttr_seconds"`
+	DowntimeByReason    map[string]int64 `json:"downtime_by_reason"`
+}
+
+type OnuTcont struct {
+	ID      int    `json:"tcont_id"`
+	Name    string `json:"name,omitempty"`
+	Profile string `json:"profile"`
+}
+
+type OnuGemport struct {
+	ID                int    `json:"gemport_id"`
+	Name              string `json:"name,omitempty"`
+	Tcont             int    `json:"tcont_id"`
+	UpstreamProfile   string `json:"upstream_profile,omitempty"`
+	DownstreamProfile string `json:"downstream_profile,omitempty"`
+}
+
+type OnuServicePort struct {
+	ID          int    `json:"service_port_id"`
+	Vport       int    `json:"vport"`
+	UserVlan    int    `json:"user_vlan"`
+	UserEtype   string `json:"user_etype,omitempty"`
+	Vlan        int    `json:"vlan"`
+	Svlan       int    `json:"svlan,omitempty"`
+	Translation string `json:"translation"`
+	NewCos      *int   `json:"new_cos,omitempty"`
+}
+
+type OnuServiceInfo struct {
+	Board        int              `json:"board"`
+	PON          int              `json:"pon"`
+	ID           int              `json:"onu_id"`
+	Name         string           `json:"name"`
+	Description  string           `json:"description"`
+	Tconts       []OnuTcont       `json:"tconts"`
+	Gemports     []OnuGemport     `json:"gemports"`
+	ServicePorts []OnuServicePort `json:"service_ports"`
+}
+
+type OnuStatus struct {
+	Code  int    `json:"code"`
+	Label string `json:"label"`
+}
+
+type OnuInfoV2 struct {
+	Board        int          `json:"board"`
+	PON          int          `json:"pon"`
+	ID           int          `json:"onu_id"`
+	Name         string       `json:"name"`
+	OnuType      string       `json:"onu_type"`
+	SerialNumber string       `json:"serial_number"`
+	RXPowerDbm   *float64     `json:"rx_power_dbm"`
+	Status       OnuStatus    `json:"status"`
+	Customer     *OnuCustomer `json:"customer,omitempty"`
+}
+
+type OnuDetailV2 struct {
+	Board                int          `json:"board"`
+	PON                  int          `json:"pon"`
+	ID                   int          `json:"onu_id"`
+	Name                 string       `json:"name"`
+	Description          string       `json:"description"`
+	OnuType              string       `json:"onu_type"`
+	SerialNumber         string       `json:"serial_number"`
+	RXPowerDbm           *float64     `json:"rx_power_dbm"`
+	TXPowerDbm           *float64     `json:"tx_power_dbm"`
+	Status               OnuStatus    `json:"status"`
+	IPAddress            *string      `json:"ip_address"`
+	LastOnline           *string      `json:"last_online"`  // RFC 3339
+	LastOffline          *string      `json:"last_offline"` // RFC 3339
+	UptimeSeconds        *int64       `json:"uptime_seconds"`
+	LastDownTimeSeconds  *int64       `json:"last_down_time_seconds"`
+	LastOfflineReason    *string      `json:"offline_reason"`
+	GponOpticalDistanceM *int         `json:"gpon_optical_distance_m"`
+	Customer             *OnuCustomer `json:"customer,omitempty"`
+}
+
+type CacheRefreshRequest struct {
+	Scope string `json:"scope"`
+	Olt   string `json:"olt"`
+	Board int    `json:"board"`
+	PON   int    `json:"pon"`
+}
+
+type CacheEntry struct {
+	Key         string `json:"key"`
+	Type        string `json:"type"`
+	Board       int    `json:"board"`
+	PON         int    `json:"pon"`
+	Cached      bool   `json:"cached"`
+	TTLSeconds  *int64 `json:"ttl_seconds"`            // Null when not cached or without expiry
+	LastRefresh string `json:"last_refresh,omitempty"` // RFC 3339, last refresh by the cache API
+}
+
+type CacheStatus struct {
+	Olt     string       `json:"olt"`
+	Entries []CacheEntry `json:"entries"`
+}
+
+type CacheRefreshError struct {
+	Board int    `json:"board"`
+	PON   int    `json:"pon"`
+	Error string `json:"error"`
+}
+
+type CacheRefreshResult struct {
+	Olt       string              `json:"olt"`
+	Scope     string              `json:"scope"`
+	Refreshed int                 `json:"refreshed"`
+	Failed    int                 `json:"failed"`
+	Entries   []CacheEntry        `json:"entries"`
+	Errors    []CacheRefreshError `json:"errors"`
+}
+
+type CachePurgeResult struct {
+	Olt     string `json:"olt"`
+	Scope   string `json:"scope"`
+	Deleted int64  `json:"deleted"`
+}
+
+type ApiKey struct {
+	ID        string   `json:"key_id"`
+	Name      string   `json:"name"`
+	Scopes    []string `json:"scopes"`
+	Olts      []string `json:"olts,omitempty"`
+	Boards    []int    `json:"boards,omitempty"`
+	Pons      []string `json:"pons,omitempty"`
+	Source    string   `json:"source"` // config or api
+	CreatedBy string   `json:"created_by,omitempty"`
+	CreatedAt string   `json:"created_at,omitempty"`
+}
+
+type ApiKeyRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+	Olts   []string `json:"olts"`
+	Boards []int    `json:"boards"`
+	Pons   []string `json:"pons"`
+}
+
+// ApiKeyCreated is a created API key with the key itself, which is only returned once
+type ApiKeyCreated struct {
+	ApiKey
+	Key string `json:"key"`
+}
+
+// AuditRecord is a mutating or administrative request with who made it and its result
+type AuditRecord struct {
+	ID           int64             `json:"id"`
+	Time         string            `json:"time"`
+	Actor        string            `json:"actor,omitempty"`         // Authenticated key ID, with X-Actor in parentheses
+	ClaimedActor string            `json:"claimed_actor,omitempty"` // X-Actor header, set by the client
+	KeyID        string            `json:"key_id,omitempty"`        // API key ID or jwt:<sub> of the bearer token
+	SourceIP     string            `json:"source_ip"`
+	Method       string            `json:"method"`
+	Route        string            `json:"route"`
+	Path         string            `json:"path"`
+	Params       map[string]string `json:"params,omitempty"` // Route and query parameters
+	Body         json.RawMessage   `json:"body,omitempty"`   // JSON request body up to 8 KiB
+	Board        int               `json:"board,omitempty"`
+	PON          int               `json:"pon,omitempty"`
+	OnuID        int               `json:"onu_id,omitempty"`
+	Status       int               `json:"status"`
+	Result       string            `json:"result"` // success or failure
+	Error        string            `json:"error,omitempty"`
+	DurationMs   int64             `json:"duration_ms"`
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/model"
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+)
+
+// OnuActionLogRepositoryInterface is an interface that represent the onu action log repository contract
+type OnuActionLogRepositoryInterface interface {
+	PushOnuActionLog(ctx context.Context, key string, maxLength int, actionLog model.OnuActionLog) error
+	GetOnuActionLog(ctx context.Context, key string) ([]model.OnuActionLog, error)
+}
+
+// onuActionLogRedisRepo stores onu action logs in redis
+type onuActionLogRedisRepo struct {
+	redisClient *redis.Client
+}
+
+// NewOnuActionLogRedisRepo will create an object that represent the onu action log repository
+func NewOnuActionLogRedisRepo(redisClient *redis.Client) OnuActionLogRepositoryInterface {
+	return &onuActionLogRedisRepo{redisClient}
+}
+
+// PushOnuActionLog is a method to prepend onu action log to a capped redis list
+func (r *onuActionLogRedisRepo) PushOnuActionLog(
+	ctx context.Context, key string, maxLength int, actionLog model.OnuActionLog,
+) error {
+	logBytes, err := json.Marshal(actionLog)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to marshal onu action log")
+		return errors.Wrap(err, "onuActionLogRedisRepo.PushOnuActionLog.json.Marshal")
+	}
+
+	pipe := r.redisClient.TxPipeline()
+	pipe.LPush(ctx, key, logBytes)
+	pipe.LTrim(ctx, key, 0, int64(maxLength-1))
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Error().Err(err).Msg("Failed to push onu action log to redis")
+		return errors.Wrap(err, "onuActionLogRedisRepo.PushOnuActionLog.redisClient.LPush")
+	}
+
+	return nil
+}
+
+// GetOnuActionLog is a method to get onu action log from redis, newest first
+func (r *onuActionLogRedisRepo) GetOnuActionLog(ctx context.Context, key string) ([]model.OnuActionLog, error) {
+	logList, err := r.redisClient.LRange(ctx, key, 0, -1).Result()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get onu action log from redis")
+		return nil, errors.Wrap(err, "onuActionLogRedisRepo.GetOnuActionLog.redisClient.LRange")
+	}
+
+	actionLogList := make([]model.OnuActionLog, 0, len(logList))
+	for _, logItem := range logList {
+		var actionLog model.OnuActionLog
+		if err := json.Unmarshal([]byte(logItem), &actionLog); err != nil {
+			log.Error().Err(err).Msg("Failed to unmarshal onu action log")
+			return nil, errors.Wrap(err, "onuActionLogRedisRepo.GetOnuActionLog.json.Unmarshal")
+		}
+		actionLogList = append(actionLogList, actionLog)
+	}
+
+	return actionLogList, nil
+}
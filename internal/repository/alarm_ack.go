@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/model"
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+	"time"
+)
+
+// AlarmAckRepositoryInterface is an interface that represent the alarm acknowledgement repository contract
+type AlarmAckRepositoryInterface interface {
+	SetAlarmAck(ctx context.Context, key string, retention time.Duration, ack model.OltAlarmAck) error
+	GetAlarmAcks(ctx context.Context, keys ...string) (map[string]model.OltAlarmAck, error)
+	DeleteAlarmAck(ctx context.Context, key string) error
+}
+
+// alarmAckRedisRepo stores alarm acknowledgements in redis
+type alarmAckRedisRepo struct {
+	redisClient *redis.Client
+}
+
+// NewAlarmAckRedisRepo will create an object that represent the alarm acknowledgement repository
+func NewAlarmAckRedisRepo(redisClient *redis.Client) AlarmAckRepositoryInterface {
+	return &alarmAckRedisRepo{redisClient}
+}
+
+// SetAlarmAck is a method to save an alarm acknowledgement to redis, it expires after the retention
+func (r *alarmAckRedisRepo) SetAlarmAck(
+	ctx context.Context, key string, retention time.Duration, ack model.OltAlarmAck,
+) error {
+	ackBytes, err := json.Marshal(ack)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to marshal alarm acknowledgement")
+		return errors.Wrap(err, "alarmAckRedisRepo.SetAlarmAck.json.Marshal")
+	}
+
+	if err := r.redisClient.Set(ctx, key, ackBytes, retention).Err(); err != nil {
+		log.Error().Err(err).Msg("Failed to set alarm acknowledgement to redis")
+		return errors.Wrap(err, "alarmAckRedisRepo.SetAlarmAck.redisClient.Set")
+	}
+
+	return nil
+}
+
+// GetAlarmAcks is a method to get the alarm acknowledgements of keys from redis,
+// keys without acknowledgement are not in the result
+func (r *alarmAckRedisRepo) GetAlarmAcks(ctx context.Context, keys ...string) (map[string]model.OltAlarmAck, error) {
+	acks := make(map[string]model.OltAlarmAck, len(keys))
+	if len(keys) == 0 {
+		return acks, nil
+	}
+
+	values, err := r.redisClient.MGet(ctx, keys...).Result()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get alarm acknowledgements from redis")
+		return nil, errors.Wrap(err, "alarmAckRedisRepo.GetAlarmAcks.redisClient.MGet")
+	}
+
+	for i, value := range values {
+		ackString, ok := value.(string)
+		if !ok {
+			continue // Key doesn't exist or has expired
+		}
+
+		var ack model.OltAlarmAck
+		if err := json.Unmarshal([]byte(ackString), &ack); err != nil {
+			log.Error().Err(err).Msg("Failed to unmarshal alarm acknowledgement")
+			return nil, errors.Wrap(err, "alarmAckRedisRepo.GetAlarmAcks.json.Unmarshal")
+		}
+		acks[keys[i]] = ack
+	}
+
+	return acks, nil
+}
+
+// DeleteAlarmAck is a method to delete an alarm acknowledgement from redis
+func (r *alarmAckRedisRepo) DeleteAlarmAck(ctx context.Context, key string) error {
+	if err := r.redisClient.Del(ctx, key).Err(); err != nil {
+		log.Error().Err(err).Msg("Failed to delete alarm acknowledgement from redis")
+		return errors.Wrap(err, "alarmAckRedisRepo.DeleteAlarmAck.redisClient.Del")
+	}
+
+	return nil
+}
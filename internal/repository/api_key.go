@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/model"
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+)
+
+// ApiKeyRepositoryInterface is an interface that represent the api key repository contract
+type ApiKeyRepositoryInterface interface {
+	SetApiKey(ctx context.Context, key, hash string, apiKey model.ApiKey) error
+	GetApiKey(ctx context.Context, key, hash string) (model.ApiKey, bool, error)
+	GetApiKeys(ctx context.Context, key string) (map[string]model.ApiKey, error)
+	DeleteApiKey(ctx context.Context, key, hash string) (bool, error)
+}
+
+// apiKeyRedisRepo stores api keys in redis
+type apiKeyRedisRepo struct {
+	redisClient *redis.Client
+}
+
+// NewApiKeyRedisRepo will create an object that represent the api key repository
+func NewApiKeyRedisRepo(redisClient *redis.Client) ApiKeyRepositoryInterface {
+	return &apiKeyRedisRepo{redisClient}
+}
+
+// SetApiKey is a method to save an api key to a redis hash keyed by the hash of the key
+func (r *apiKeyRedisRepo) SetApiKey(ctx context.Context, key, hash string, apiKey model.ApiKey) error {
+	apiKeyBytes, err := json.Marshal(apiKey)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to marshal api key")
+		return errors.Wrap(err, "apiKeyRedisRepo.SetApiKey.json.Marshal")
+	}
+
+	if err := r.redisClient.HSet(ctx, key, hash, apiKeyBytes).Err(); err != nil {
+		log.Error().Err(err).Msg("Failed to set api key to redis")
+		return errors.Wrap(err, "apiKeyRedisRepo.SetApiKey.redisClient.HSet")
+	}
+
+	return nil
+}
+
+// GetApiKey is a method to get an api key by the hash of the key from a redis hash, false when not found
+func (r *apiKeyRedisRepo) GetApiKey(ctx context.Context, key, hash string) (model.ApiKey, bool, error) {
+	apiKeyBytes, err := r.redisClient.HGet(ctx, key, hash).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return model.ApiKey{}, false, nil
+	}
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get api key from redis")
+		return model.ApiKey{}, false, errors.Wrap(err, "apiKeyRedisRepo.GetApiKey.redisClient.HGet")
+	}
+
+	var apiKey model.ApiKey
+	if err := json.Unmarshal(apiKeyBytes, &apiKey); err != nil {
+		log.Error().Err(err).Msg("Failed to unmarshal api key")
+		return model.ApiKey{}, false, errors.Wrap(err, "apiKeyRedisRepo.GetApiKey.json.Unmarshal")
+	}
+
+	return apiKey, true, nil
+}
+
+// GetApiKeys is a method to get all api keys from a redis hash, keyed by the hash of the key
+func (r *apiKeyRedisRepo) GetApiKeys(ctx context.Context, key string) (map[string]model.ApiKey, error) {
+	apiKeyMap, err := r.redisClient.HGetAll(ctx, key).Result()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get api keys from redis")
+		return nil, errors.Wrap(err, "apiKeyRedisRepo.GetApiKeys.redisClient.HGetAll")
+	}
+
+	apiKeys := make(map[string]model.ApiKey, len(apiKeyMap))
+	for hash, apiKeyItem := range apiKeyMap {
+		var apiKey model.ApiKey
+		if err := json.Unmarshal([]byte(apiKeyItem), &apiKey); err != nil {
+			log.Error().Err(err).Msg("Failed to unmarshal api key")
+			return nil, errors.Wrap(err, "apiKeyRedisRepo.GetApiKeys.json.Unmarshal")
+		}
+		apiKeys[hash] = apiKey
+	}
+
+	return apiKeys, nil
+}
+
+// DeleteApiKey is a method to delete an api key by the hash of the key from a redis hash, false when not found
+func (r *apiKeyRedisRepo) DeleteApiKey(ctx context.Context, key, hash string) (bool, error) {
+	deleted, err := r.redisClient.HDel(ctx, key, hash).Result()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to delete api key from redis")
+		return false, errors.Wrap(err, "apiKeyRedisRepo.DeleteApiKey.redisClient.HDel")
+	}
+
+	return deleted > 0, nil
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"bufio"
+	"encoding/json"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/model"
+	"github.com/pkg/errors"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// maxAuditLineSize limits a line of the audit log, records are far smaller
+const maxAuditLineSize = 1 << 20
+
+// AuditRepositoryInterface is an interface that represent the audit log repository contract
+type AuditRepositoryInterface interface {
+	Append(record model.AuditRecord) (model.AuditRecord, error)
+	Scan(fn func(record model.AuditRecord) bool) error
+	Close() error
+}
+
+// auditFileRepository keeps audit records as JSON lines in a file that is only appended to
+type auditFileRepository struct {
+	path   string
+	mu     sync.Mutex
+	file   *os.File
+	lastID int64
+}
+
+// NewAuditFileRepository will create an object that represent the audit log repository, records are appended to path
+func NewAuditFileRepository(path string) (AuditRepositoryInterface, error) {
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, errors.Wrap(err, "auditFileRepository.NewAuditFileRepository.os.MkdirAll")
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, errors.Wrap(err, "auditFileRepository.NewAuditFileRepository.os.OpenFile")
+	}
+
+	r := &auditFileRepository{path: path, file: file}
+
+	// IDs continue from the last record of the file
+	err = r.Scan(func(record model.AuditRecord) bool {
+		r.lastID = record.ID
+		return true
+	})
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Append is a method to write a record with the next ID to the end of the audit log
+func (r *auditFileRepository) Append(record model.AuditRecord) (model.AuditRecord, error) {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	record.ID = r.lastID + 1
+	line, err := json.Marshal(record)
+	if err != nil {
+		return model.AuditRecord{}, errors.Wrap(err, "auditFileRepository.Append.json.Marshal")
+	}
+
+	if _, err := r.file.Write(append(line, '\n')); err != nil {
+		return model.AuditRecord{}, errors.Wrap(err, "auditFileRepository.Append.file.Write")
+	}
+	if err := r.file.Sync(); err != nil {
+		return model.AuditRecord{}, errors.Wrap(err, "auditFileRepository.Append.file.Sync")
+	}
+
+	r.lastID = record.ID
+	return record, nil
+}
+
+// Scan is a method to call fn with each record of the audit log from the oldest until fn returns false,
+// a line that can't be decoded is skipped
+func (r *auditFileRepository) Scan(fn func(record model.AuditRecord) bool) error {
+
+	file, err := os.Open(r.path)
+	if err != nil {
+		return errors.Wrap(err, "auditFileRepository.Scan.os.Open")
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), maxAuditLineSize)
+	for scanner.Scan() {
+		var record model.AuditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			continue
+		}
+		if !fn(record) {
+			return nil
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return errors.Wrap(err, "auditFileRepository.Scan.scanner.Scan")
+	}
+
+	return nil
+}
+
+// Close is a method to close the audit log file
+func (r *auditFileRepository) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.file.Close()
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+	"time"
+)
+
+// CacheRepositoryInterface is an interface that represent the cache key repository contract
+type CacheRepositoryInterface interface {
+	GetKeyTTLs(ctx context.Context, keys ...string) (map[string]time.Duration, error)
+	DeleteKeys(ctx context.Context, keys ...string) (int64, error)
+	SetCacheRefreshes(ctx context.Context, key string, refreshes map[string]string) error
+	GetCacheRefreshes(ctx context.Context, key string) (map[string]string, error)
+}
+
+// cacheRedisRepo stores cache keys in redis
+type cacheRedisRepo struct {
+	redisClient *redis.Client
+}
+
+// NewCacheRedisRepo will create an object that represent the cache key repository
+func NewCacheRedisRepo(redisClient *redis.Client) CacheRepositoryInterface {
+	return &cacheRedisRepo{redisClient}
+}
+
+// GetKeyTTLs is a method to get the remaining time to live of existing keys, keys without expiry have a negative
+// time to live and keys that don't exist are left out
+func (r *cacheRedisRepo) GetKeyTTLs(ctx context.Context, keys ...string) (map[string]time.Duration, error) {
+	pipe := r.redisClient.Pipeline()
+	ttlCmds := make([]*redis.DurationCmd, len(keys))
+	for i, key := range keys {
+		ttlCmds[i] = pipe.TTL(ctx, key)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Error().Err(err).Msg("Failed to get key ttl from redis")
+		return nil, errors.Wrap(err, "cacheRedisRepo.GetKeyTTLs.redisClient.TTL")
+	}
+
+	ttls := make(map[string]time.Duration, len(keys))
+	for i, key := range keys {
+		// TTL is -2 when the key doesn't exist and -1 when the key has no expiry
+		if ttlCmds[i].Val() == -2 {
+			continue
+		}
+		ttls[key] = ttlCmds[i].Val()
+	}
+
+	return ttls, nil
+}
+
+// DeleteKeys is a method to delete keys from redis, it returns the number of deleted keys
+func (r *cacheRedisRepo) DeleteKeys(ctx context.Context, keys ...string) (int64, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+
+	deleted, err := r.redisClient.Del(ctx, keys...).Result()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to delete keys from redis")
+		return 0, errors.Wrap(err, "cacheRedisRepo.DeleteKeys.redisClient.Del")
+	}
+
+	return deleted, nil
+}
+
+// SetCacheRefreshes is a method to save the last refresh time of cache keys to a redis hash keyed by cache key
+func (r *cacheRedisRepo) SetCacheRefreshes(ctx context.Context, key string, refreshes map[string]string) error {
+	if len(refreshes) == 0 {
+		return nil
+	}
+
+	if err := r.redisClient.HSet(ctx, key, refreshes).Err(); err != nil {
+		log.Error().Err(err).Msg("Failed to set cache refreshes to redis")
+		return errors.Wrap(err, "cacheRedisRepo.SetCacheRefreshes.redisClient.HSet")
+	}
+
+	return nil
+}
+
+// GetCacheRefreshes is a method to get the last refresh time of cache keys from a redis hash
+func (r *cacheRedisRepo) GetCacheRefreshes(ctx context.Context, key string) (map[string]string, error) {
+	refreshes, err := r.redisClient.HGetAll(ctx, key).Result()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get cache refreshes from redis")
+		return nil, errors.Wrap(err, "cacheRedisRepo.GetCacheRefreshes.redisClient.HGetAll")
+	}
+
+	return refreshes, nil
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/pkg/oltcli"
+	"github.com/pkg/errors"
+)
+
+// OltCliRepositoryInterface is an interface that represent the OLT CLI repository contract
+type OltCliRepositoryInterface interface {
+	GetOnuDetailInfo(ctx context.Context, boardID, ponID, onuID int) (oltcli.OnuDetailInfo, error)
+	GetOnuStateList(ctx context.Context, boardID, ponID int) ([]oltcli.OnuState, error)
+	GetOnuRunningConfig(ctx context.Context, boardID, ponID, onuID int) (oltcli.OnuRunningConfig, error)
+}
+
+type oltCliRepository struct {
+	driver oltcli.Driver
+}
+
+// NewOltCliRepository will create an object that represent the OLT CLI repository
+func NewOltCliRepository(driver oltcli.Driver) OltCliRepositoryInterface {
+	return &oltCliRepository{driver: driver}
+}
+
+// GetOnuDetailInfo is a method to get onu detail info from "show gpon onu detail-info"
+func (r *oltCliRepository) GetOnuDetailInfo(ctx context.Context, boardID, ponID, onuID int) (
+	oltcli.OnuDetailInfo, error,
+) {
+	output, err := r.driver.Run(ctx, "show gpon onu detail-info "+oltcli.GponOnuInterface(boardID, ponID, onuID))
+	if err != nil {
+		return oltcli.OnuDetailInfo{}, errors.Wrap(err, "oltCliRepository.GetOnuDetailInfo.driver.Run")
+	}
+
+	info, err := oltcli.ParseOnuDetailInfo(output)
+	if err != nil {
+		return oltcli.OnuDetailInfo{}, errors.Wrap(err, "oltCliRepository.GetOnuDetailInfo.ParseOnuDetailInfo")
+	}
+
+	return info, nil
+}
+
+// GetOnuStateList is a method to get onu state list of a pon from "show gpon onu state"
+func (r *oltCliRepository) GetOnuStateList(ctx context.Context, boardID, ponID int) ([]oltcli.OnuState, error) {
+	output, err := r.driver.Run(ctx, "show gpon onu state "+oltcli.GponOltInterface(boardID, ponID))
+	if err != nil {
+		return nil, errors.Wrap(err, "oltCliRepository.GetOnuStateList.driver.Run")
+	}
+
+	return oltcli.ParseOnuStateList(output), nil
+}
+
+// GetOnuRunningConfig is a method to get onu interface config from "show running-config interface"
+func (r *oltCliRepository) GetOnuRunningConfig(ctx context.Context, boardID, ponID, onuID int) (
+	oltcli.OnuRunningConfig, error,
+) {
+	output, err := r.driver.Run(ctx, "show running-config interface "+oltcli.GponOnuInterface(boardID, ponID, onuID))
+	if err != nil {
+		return oltcli.OnuRunningConfig{}, errors.Wrap(err, "oltCliRepository.GetOnuRunningConfig.driver.Run")
+	}
+
+	runningConfig, err := oltcli.ParseOnuRunningConfig(output)
+	if err != nil {
+		return oltcli.OnuRunningConfig{}, errors.Wrap(err, "oltCliRepository.GetOnuRunningConfig.ParseOnuRunningConfig")
+	}
+
+	return runningConfig, nil
+}
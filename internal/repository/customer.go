@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/model"
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+)
+
+// CustomerRepositoryInterface is an interface that represent the onu customer repository contract
+type CustomerRepositoryInterface interface {
+	SetOnuCustomers(ctx context.Context, key string, customers []model.OnuCustomer) error
+	GetOnuCustomers(ctx context.Context, key string) ([]model.OnuCustomer, error)
+	GetOnuCustomersBySerial(
+		ctx context.Context, key string, serialNumbers ...string,
+	) (map[string]model.OnuCustomer, error)
+	DeleteOnuCustomer(ctx context.Context, key, serialNumber string) (bool, error)
+}
+
+// customerRedisRepo stores onu customers in redis
+type customerRedisRepo struct {
+	redisClient *redis.Client
+}
+
+// NewCustomerRedisRepo will create an object that represent the onu customer repository
+func NewCustomerRedisRepo(redisClient *redis.Client) CustomerRepositoryInterface {
+	return &customerRedisRepo{redisClient}
+}
+
+// SetOnuCustomers is a method to save onu customers to a redis hash keyed by serial number
+func (r *customerRedisRepo) SetOnuCustomers(ctx context.Context, key string, customers []model.OnuCustomer) error {
+	if len(customers) == 0 {
+		return nil
+	}
+
+	values := make(map[string]interface{}, len(customers))
+	for _, customer := range customers {
+		customerBytes, err := json.Marshal(customer)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to marshal onu customer")
+			return errors.Wrap(err, "customerRedisRepo.SetOnuCustomers.json.Marshal")
+		}
+		values[customer.SerialNumber] = customerBytes
+	}
+
+	if err := r.redisClient.HSet(ctx, key, values).Err(); err != nil {
+		log.Error().Err(err).Msg("Failed to set onu customers to redis")
+		return errors.Wrap(err, "customerRedisRepo.SetOnuCustomers.redisClient.HSet")
+	}
+
+	return nil
+}
+
+// GetOnuCustomers is a method to get all onu customers from a redis hash
+func (r *customerRedisRepo) GetOnuCustomers(ctx context.Context, key string) ([]model.OnuCustomer, error) {
+	customerMap, err := r.redisClient.HGetAll(ctx, key).Result()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get onu customers from redis")
+		return nil, errors.Wrap(err, "customerRedisRepo.GetOnuCustomers.redisClient.HGetAll")
+	}
+
+	customers := make([]model.OnuCustomer, 0, len(customerMap))
+	for _, value := range customerMap {
+		var customer model.OnuCustomer
+		if err := json.Unmarshal([]byte(value), &customer); err != nil {
+			log.Error().Err(err).Msg("Failed to unmarshal onu customer")
+			return nil, errors.Wrap(err, "customerRedisRepo.GetOnuCustomers.json.Unmarshal")
+		}
+		customers = append(customers, customer)
+	}
+
+	return customers, nil
+}
+
+// GetOnuCustomersBySerial is a method to get the onu customers of serial numbers from a redis hash,
+// serial numbers without customer are not in the result
+func (r *customerRedisRepo) GetOnuCustomersBySerial(
+	ctx context.Context, key string, serialNumbers ...string,
+) (map[string]model.OnuCustomer, error) {
+	customers := make(map[string]model.OnuCustomer, len(serialNumbers))
+	if len(serialNumbers) == 0 {
+		return customers, nil
+	}
+
+	values, err := r.redisClient.HMGet(ctx, key, serialNumbers...).Result()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get onu customers from redis")
+		return nil, errors.Wrap(err, "customerRedisRepo.GetOnuCustomersBySerial.redisClient.HMGet")
+	}
+
+	for i, value := range values {
+		customerString, ok := value.(string)
+		if !ok {
+			continue // Field doesn't exist
+		}
+
+		var customer model.OnuCustomer
+		if err := json.Unmarshal([]byte(customerString), &customer); err != nil {
+			log.Error().Err(err).Msg("Failed to unmarshal onu customer")
+			return nil, errors.Wrap(err, "customerRedisRepo.GetOnuCustomersBySerial.json.Unmarshal")
+		}
+		customers[serialNumbers[i]] = customer
+	}
+
+	return customers, nil
+}
+
+// DeleteOnuCustomer is a method to delete an onu customer from a redis hash, it reports whether it existed
+func (r *customerRedisRepo) DeleteOnuCustomer(ctx context.Context, key, serialNumber string) (bool, error) {
+	deleted, err := r.redisClient.HDel(ctx, key, serialNumber).Result()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to delete onu customer from redis")
+		return false, errors.Wrap(err, "customerRedisRepo.DeleteOnuCustomer.redisClient.HDel")
+	}
+
+	return deleted > 0, nil
+}
@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/model"
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+	"strconv"
+	"time"
+)
+
+// OnuChangeRepositoryInterface is an interface that represent the onu inventory and change repository contract
+type OnuChangeRepositoryInterface interface {
+	SaveOnuInventory(ctx context.Context, key string, inventory []model.OnuInventory) error
+	GetOnuInventory(ctx context.Context, key string) ([]model.OnuInventory, error)
+	SetOnuLocations(ctx context.Context, key string, locations map[string]model.OnuInventory) error
+	GetOnuLocations(ctx context.Context, key string) (map[string]model.OnuInventory, error)
+	AddOnuChanges(ctx context.Context, key string, retention time.Duration, changes []model.OnuChange) error
+	GetOnuChanges(ctx context.Context, key string, since time.Time, limit int) ([]model.OnuChange, error)
+}
+
+// onuChangeRedisRepo stores onu inventory and changes in redis
+type onuChangeRedisRepo struct {
+	redisClient *redis.Client
+}
+
+// NewOnuChangeRedisRepo will create an object that represent the onu inventory and change repository
+func NewOnuChangeRedisRepo(redisClient *redis.Client) OnuChangeRepositoryInterface {
+	return &onuChangeRedisRepo{redisClient}
+}
+
+// SaveOnuInventory is a method to save the onu inventory snapshot of a pon to redis without expiration
+func (r *onuChangeRedisRepo) SaveOnuInventory(ctx context.Context, key string, inventory []model.OnuInventory) error {
+	inventoryBytes, err := json.Marshal(inventory)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to marshal onu inventory")
+		return errors.Wrap(err, "onuChangeRedisRepo.SaveOnuInventory.json.Marshal")
+	}
+
+	if err := r.redisClient.Set(ctx, key, inventoryBytes, 0).Err(); err != nil {
+		log.Error().Err(err).Msg("Failed to set onu inventory to redis")
+		return errors.Wrap(err, "onuChangeRedisRepo.SaveOnuInventory.redisClient.Set")
+	}
+
+	return nil
+}
+
+// GetOnuInventory is a method to get the onu inventory snapshot of a pon from redis
+func (r *onuChangeRedisRepo) GetOnuInventory(ctx context.Context, key string) ([]model.OnuInventory, error) {
+	inventoryBytes, err := r.redisClient.Get(ctx, key).Bytes()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get onu inventory from redis")
+		return nil, errors.Wrap(err, "onuChangeRedisRepo.GetOnuInventory.redisClient.Get")
+	}
+
+	var inventory []model.OnuInventory
+	if err := json.Unmarshal(inventoryBytes, &inventory); err != nil {
+		log.Error().Err(err).Msg("Failed to unmarshal onu inventory")
+		return nil, errors.Wrap(err, "onuChangeRedisRepo.GetOnuInventory.json.Unmarshal")
+	}
+
+	return inventory, nil
+}
+
+// SetOnuLocations is a method to save the last seen onu of serial numbers to a redis hash
+func (r *onuChangeRedisRepo) SetOnuLocations(
+	ctx context.Context, key string, locations map[string]model.OnuInventory,
+) error {
+	if len(locations) == 0 {
+		return nil
+	}
+
+	values := make(map[string]interface{}, len(locations))
+	for serialNumber, location := range locations {
+		locationBytes, err := json.Marshal(location)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to marshal onu location")
+			return errors.Wrap(err, "onuChangeRedisRepo.SetOnuLocations.json.Marshal")
+		}
+		values[serialNumber] = locationBytes
+	}
+
+	if err := r.redisClient.HSet(ctx, key, values).Err(); err != nil {
+		log.Error().Err(err).Msg("Failed to set onu locations to redis")
+		return errors.Wrap(err, "onuChangeRedisRepo.SetOnuLocations.redisClient.HSet")
+	}
+
+	return nil
+}
+
+// GetOnuLocations is a method to get the last seen onu of all serial numbers from a redis hash
+func (r *onuChangeRedisRepo) GetOnuLocations(ctx context.Context, key string) (map[string]model.OnuInventory, error) {
+	locationMap, err := r.redisClient.HGetAll(ctx, key).Result()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get onu locations from redis")
+		return nil, errors.Wrap(err, "onuChangeRedisRepo.GetOnuLocations.redisClient.HGetAll")
+	}
+
+	locations := make(map[string]model.OnuInventory, len(locationMap))
+	for serialNumber, value := range locationMap {
+		var location model.OnuInventory
+		if err := json.Unmarshal([]byte(value), &location); err != nil {
+			log.Error().Err(err).Msg("Failed to unmarshal onu location")
+			return nil, errors.Wrap(err, "onuChangeRedisRepo.GetOnuLocations.json.Unmarshal")
+		}
+		locations[serialNumber] = location
+	}
+
+	return locations, nil
+}
+
+// AddOnuChanges is a method to add onu changes to a redis sorted set scored by time in milliseconds,
+// changes older than retention are removed
+func (r *onuChangeRedisRepo) AddOnuChanges(
+	ctx context.Context, key string, retention time.Duration, changes []model.OnuChange,
+) error {
+	now := time.Now()
+	members := make([]redis.Z, 0, len(changes))
+	for _, change := range changes {
+		changeBytes, err := json.Marshal(change)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to marshal onu change")
+			return errors.Wrap(err, "onuChangeRedisRepo.AddOnuChanges.json.Marshal")
+		}
+		members = append(members, redis.Z{Score: float64(now.UnixMilli()), Member: changeBytes})
+	}
+
+	pipe := r.redisClient.TxPipeline()
+	pipe.ZAdd(ctx, key, members...)
+	pipe.ZRemRangeByScore(ctx, key, "-inf", "("+strconv.FormatInt(now.Add(-retention).UnixMilli(), 10))
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Error().Err(err).Msg("Failed to add onu changes to redis")
+		return errors.Wrap(err, "onuChangeRedisRepo.AddOnuChanges.redisClient.ZAdd")
+	}
+
+	return nil
+}
+
+// GetOnuChanges is a method to get up to limit onu changes at or after since from a redis sorted set, oldest first
+func (r *onuChangeRedisRepo) GetOnuChanges(
+	ctx context.Context, key string, since time.Time, limit int,
+) ([]model.OnuChange, error) {
+	changeList, err := r.redisClient.ZRangeByScore(ctx, key, &redis.ZRangeBy{
+		Min:   strconv.FormatInt(since.UnixMilli(), 10),
+		Max:   "+inf",
+		Count: int64(limit),
+	}).Result()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get onu changes from redis")
+		return nil, errors.Wrap(err, "onuChangeRedisRepo.GetOnuChanges.redisClient.ZRangeByScore")
+	}
+
+	changes := make([]model.OnuChange, 0, len(changeList))
+	for _, changeItem := range changeList {
+		var change model.OnuChange
+		if err := json.Unmarshal([]byte(changeItem), &change); err != nil {
+			log.Error().Err(err).Msg("Failed to unmarshal onu change")
+			return nil, errors.Wrap(err, "onuChangeRedisRepo.GetOnuChanges.json.Unmarshal")
+		}
+		changes = append(changes, change)
+	}
+
+	return changes, nil
+}
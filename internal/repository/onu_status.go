@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/model"
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+	"strconv"
+	"time"
+)
+
+// OnuStatusRepositoryInterface is an interface that represent the onu status transition repository contract
+type OnuStatusRepositoryInterface interface {
+	AddOnuStatusTransition(
+		ctx context.Context, key string, at time.Time, retention time.Duration, transition model.OnuStatusTransition,
+	) error
+	GetOnuStatusTransitions(ctx context.Context, key string, since time.Time) ([]model.OnuStatusTransition, error)
+}
+
+// onuStatusRedisRepo stores onu status transitions in redis
+type onuStatusRedisRepo struct {
+	redisClient *redis.Client
+}
+
+// NewOnuStatusRedisRepo will create an object that represent the onu status transition repository
+func NewOnuStatusRedisRepo(redisClient *redis.Client) OnuStatusRepositoryInterface {
+	return &onuStatusRedisRepo{redisClient}
+}
+
+// AddOnuStatusTransition is a method to add an onu status transition to a redis sorted set scored by time in
+// milliseconds, transitions older than retention are removed
+func (r *onuStatusRedisRepo) AddOnuStatusTransition(
+	ctx context.Context, key string, at time.Time, retention time.Duration, transition model.OnuStatusTransition,
+) error {
+	transitionBytes, err := json.Marshal(transition)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to marshal onu status transition")
+		return errors.Wrap(err, "onuStatusRedisRepo.AddOnuStatusTransition.json.Marshal")
+	}
+
+	pipe := r.redisClient.TxPipeline()
+	pipe.ZAdd(ctx, key, redis.Z{Score: float64(at.UnixMilli()), Member: transitionBytes})
+	pipe.ZRemRangeByScore(ctx, key, "-inf", "("+strconv.FormatInt(at.Add(-retention).UnixMilli(), 10))
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Error().Err(err).Msg("Failed to add onu status transition to redis")
+		return errors.Wrap(err, "onuStatusRedisRepo.AddOnuStatusTransition.redisClient.ZAdd")
+	}
+
+	return nil
+}
+
+// GetOnuStatusTransitions is a method to get the onu status transitions at or after since from a redis sorted set,
+// oldest first, preceded by the last transition before since which gives the status at since
+func (r *onuStatusRedisRepo) GetOnuStatusTransitions(
+	ctx context.Context, key string, since time.Time,
+) ([]model.OnuStatusTransition, error) {
+	sinceMilli := strconv.FormatInt(since.UnixMilli(), 10)
+
+	pipe := r.redisClient.Pipeline()
+	before := pipe.ZRevRangeByScore(ctx, key, &redis.ZRangeBy{Min: "-inf", Max: "(" + sinceMilli, Count: 1})
+	after := pipe.ZRangeByScore(ctx, key, &redis.ZRangeBy{Min: sinceMilli, Max: "+inf"})
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Error().Err(err).Msg("Failed to get onu status transitions from redis")
+		return nil, errors.Wrap(err, "onuStatusRedisRepo.GetOnuStatusTransitions.redisClient.ZRangeByScore")
+	}
+
+	transitionList := append(before.Val(), after.Val()...)
+	transitions := make([]model.OnuStatusTransition, 0, len(transitionList))
+	for _, transitionItem := range transitionList {
+		var transition model.OnuStatusTransition
+		if err := json.Unmarshal([]byte(transitionItem), &transition); err != nil {
+			log.Error().Err(err).Msg("Failed to unmarshal onu status transition")
+			return nil, errors.Wrap(err, "onuStatusRedisRepo.GetOnuStatusTransitions.json.Unmarshal")
+		}
+		transitions = append(transitions, transition)
+	}
+
+	return transitions, nil
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+	"time"
+)
+
+// RateLimitRepositoryInterface is an interface that represent the token bucket repository contract
+type RateLimitRepositoryInterface interface {
+	TakeTokens(ctx context.Context, key string, rate float64, burst, cost int) (bool, time.Duration, error)
+}
+
+// takeTokensScript takes cost tokens from the token bucket of KEYS[1] refilled with rate tokens per second up to burst,
+// it returns 1 and 0 when taken or 0 and the milliseconds until enough tokens are available
+var takeTokensScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local cost = tonumber(ARGV[3])
+local now = tonumber(ARGV[4])
+
+local bucket = redis.call("HMGET", KEYS[1], "tokens", "updated_at")
+local tokens = tonumber(bucket[1]) or burst
+local updatedAt = tonumber(bucket[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - updatedAt) * rate / 1000)
+
+local taken = 0
+local wait = 0
+if tokens >= cost then
+	tokens = tokens - cost
+	taken = 1
+else
+	wait = math.ceil((cost - tokens) * 1000 / rate)
+end
+
+redis.call("HMSET", KEYS[1], "tokens", tostring(tokens), "updated_at", now)
+redis.call("PEXPIRE", KEYS[1], math.ceil(burst * 1000 / rate) + 1000)
+return {taken, wait}
+`)
+
+// rateLimitRedisRepo stores token buckets in redis
+type rateLimitRedisRepo struct {
+	redisClient *redis.Client
+}
+
+// NewRateLimitRedisRepo will create an object that represent the token bucket repository
+func NewRateLimitRedisRepo(redisClient *redis.Client) RateLimitRepositoryInterface {
+	return &rateLimitRedisRepo{redisClient}
+}
+
+// TakeTokens is a method to take cost tokens from a redis token bucket shared by every replica,
+// it returns false and the time until enough tokens are available when the bucket is short
+func (r *rateLimitRedisRepo) TakeTokens(ctx context.Context, key string, rate float64, burst, cost int) (
+	bool, time.Duration, error,
+) {
+	result, err := takeTokensScript.Run(ctx, r.redisClient, []string{key},
+		rate, burst, cost, time.Now().UnixMilli()).Int64Slice()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to take tokens from redis")
+		return false, 0, errors.Wrap(err, "rateLimitRedisRepo.TakeTokens.takeTokensScript.Run")
+	}
+	if len(result) != 2 {
+		return false, 0, errors.New("rateLimitRedisRepo.TakeTokens: unexpected script result")
+	}
+
+	return result[0] == 1, time.Duration(result[1]) * time.Millisecond, nil
+}
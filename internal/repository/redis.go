@@ -19,6 +19,8 @@ type OnuRedisRepositoryInterface interface {
 	GetONUInfoList(ctx context.Context, key string) ([]model.ONUInfoPerBoard, error)
 	GetOnlyOnuIDCtx(ctx context.Context, key string) ([]model.OnuOnlyID, error)
 	SaveOnlyOnuIDCtx(ctx context.Context, key string, seconds int, onuId []model.OnuOnlyID) error
+	SaveOnuFirmwareList(ctx context.Context, key string, seconds int, onuFirmwareList []model.OnuFirmwareInfo) error
+	GetOnuFirmwareList(ctx context.Context, key string) ([]model.OnuFirmwareInfo, error)
 }
 
 // Auth redis repository
@@ -141,3 +143,38 @@ func (r *onuRedisRepo) SaveOnlyOnuIDCtx(ctx context.Context, key string, seconds
 
 	return nil
 }
+
+// SaveOnuFirmwareList is a method to save onu firmware list to redis
+func (r *onuRedisRepo) SaveOnuFirmwareList(
+	ctx context.Context, key string, seconds int, onuFirmwareList []model.OnuFirmwareInfo,
+) error {
+	onuBytes, err := json.Marshal(onuFirmwareList)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to marshal onu firmware list")
+		return errors.Wrap(err, "onuRedisRepo.SaveOnuFirmwareList.json.Marshal")
+	}
+
+	if err := r.redisClient.Set(ctx, key, onuBytes, time.Second*time.Duration(seconds)).Err(); err != nil {
+		log.Error().Err(err).Msg("Failed to set onu firmware list to redis")
+		return errors.Wrap(err, "onuRedisRepo.SaveOnuFirmwareList.redisClient.Set")
+	}
+
+	return nil
+}
+
+// GetOnuFirmwareList is a method to get onu firmware list from redis
+func (r *onuRedisRepo) GetOnuFirmwareList(ctx context.Context, key string) ([]model.OnuFirmwareInfo, error) {
+	onuBytes, err := r.redisClient.Get(ctx, key).Bytes()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get onu firmware list from redis")
+		return nil, errors.Wrap(err, "onuRedisRepo.GetOnuFirmwareList.redisClient.Get")
+	}
+
+	var onuFirmwareList []model.OnuFirmwareInfo
+	if err := json.Unmarshal(onuBytes, &onuFirmwareList); err != nil {
+		log.Error().Err(err).Msg("Failed to unmarshal onu firmware list")
+		return nil, errors.Wrap(err, "onuRedisRepo.GetOnuFirmwareList.json.Unmarshal")
+	}
+
+	return onuFirmwareList, nil
+}
@@ -7,6 +7,7 @@ import (
 type SnmpRepositoryInterface interface {
 	Get(oids []string) (result *gosnmp.SnmpPacket, err error)
 	Walk(oid string, walkFunc func(pdu gosnmp.SnmpPDU) error) error
+	Set(pdus []gosnmp.SnmpPDU) (result *gosnmp.SnmpPacket, err error)
 }
 
 type snmpRepository struct {
@@ -26,3 +27,7 @@ func (r *snmpRepository) Get(oids []string) (result *gosnmp.SnmpPacket, err erro
 func (r *snmpRepository) Walk(oid string, walkFunc func(pdu gosnmp.SnmpPDU) error) error {
 	return r.snmp.Walk(oid, walkFunc)
 }
+
+func (r *snmpRepository) Set(pdus []gosnmp.SnmpPDU) (result *gosnmp.SnmpPacket, err error) {
+	return r.snmp.Set(pdus)
+}
@@ -0,0 +1,317 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gosnmp/gosnmp"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/config"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/model"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/repository"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/utils"
+	"github.com/rs/zerolog/log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// alarmAckKeyPrefix is the prefix of the Redis key of an alarm acknowledgement, see alarmAckKey
+	alarmAckKeyPrefix = "olt_alarm_ack_"
+
+	// alarmAckRetention is how long an acknowledgement is kept, acknowledgements of cleared alarms expire with it
+	alarmAckRetention = 30 * 24 * time.Hour
+)
+
+var (
+	ErrInvalidAlarmRequest = errors.New("invalid alarm request")
+	ErrAlarmNotFound       = errors.New("alarm not found")
+)
+
+// alarmSeverityRank orders alarms from the most to the least severe
+var alarmSeverityRank = map[string]int{"Critical": 0, "Major": 1, "Minor": 2, "Warning": 3, "Info": 4}
+
+// AlarmFilter selects active alarms, zero values match any
+type AlarmFilter struct {
+	Severities   []string
+	Categories   []string
+	Board        int
+	PON          int
+	OnuID        int
+	Acknowledged *bool
+}
+
+// Match reports whether alarm is selected by the filter, severity and category are compared case-insensitively
+func (f AlarmFilter) Match(alarm model.OltAlarm) bool {
+	if (f.Board != 0 && f.Board != alarm.Board) || (f.PON != 0 && f.PON != alarm.PON) ||
+		(f.OnuID != 0 && f.OnuID != alarm.OnuID) || (f.Acknowledged != nil && *f.Acknowledged != alarm.Acknowledged) {
+		return false
+	}
+
+	return matchAny(f.Severities, alarm.Severity) && matchAny(f.Categories, alarm.Category)
+}
+
+// matchAny is a function to check if value is one of values, an empty values matches any
+func matchAny(values []string, value string) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+type AlarmUseCaseInterface interface {
+	GetAlarms(ctx context.Context, filter AlarmFilter) ([]model.OltAlarm, error)
+	AcknowledgeAlarm(ctx context.Context, alarmID int, request model.OltAlarmAckRequest, actor string) (
+		model.OltAlarm, error)
+	UnacknowledgeAlarm(ctx context.Context, alarmID int) (model.OltAlarm, error)
+}
+
+type alarmUsecase struct {
+	snmpRepository     repository.SnmpRepositoryInterface
+	alarmAckRepository repository.AlarmAckRepositoryInterface
+	cfg                *config.Config
+}
+
+// NewAlarmUsecase returns the usecase for the OLT active alarm table
+func NewAlarmUsecase(
+	snmpRepository repository.SnmpRepositoryInterface, alarmAckRepository repository.AlarmAckRepositoryInterface,
+	cfg *config.Config,
+) AlarmUseCaseInterface {
+	return &alarmUsecase{
+		snmpRepository:     snmpRepository,
+		alarmAckRepository: alarmAckRepository,
+		cfg:                cfg,
+	}
+}
+
+// GetAlarms walks the active alarm table and returns the alarms matching filter, most severe first
+func (u *alarmUsecase) GetAlarms(ctx context.Context, filter AlarmFilter) ([]model.OltAlarm, error) {
+
+	log.Info().Msg("Get OLT active alarms with SNMP Walk") // Log info message to logger
+
+	alarms, err := u.getActiveAlarms(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := make([]model.OltAlarm, 0, len(alarms))
+	for _, alarm := range alarms {
+		if filter.Match(alarm) {
+			filtered = append(filtered, alarm)
+		}
+	}
+
+	return filtered, nil
+}
+
+// AcknowledgeAlarm marks an active alarm as acknowledged by actor
+func (u *alarmUsecase) AcknowledgeAlarm(
+	ctx context.Context, alarmID int, request model.OltAlarmAckRequest, actor string,
+) (model.OltAlarm, error) {
+
+	actor = strings.TrimSpace(actor)
+	if actor == "" {
+		return model.OltAlarm{}, fmt.Errorf("%w: actor is required", ErrInvalidAlarmRequest)
+	}
+
+	comment := strings.TrimSpace(request.Comment)
+	if len(comment) > 256 {
+		return model.OltAlarm{}, fmt.Errorf("%w: 'comment' must be at most 256 characters", ErrInvalidAlarmRequest)
+	}
+
+	alarm, err := u.getActiveAlarm(ctx, alarmID)
+	if err != nil {
+		return model.OltAlarm{}, err
+	}
+
+	log.Info().Msg("Acknowledge alarm ID: " + strconv.Itoa(alarmID) + " by " + actor) // Log info message to logger
+
+	ack := model.OltAlarmAck{Actor: actor, Comment: comment, Timestamp: time.Now().Format(time.RFC3339)}
+	if err := u.alarmAckRepository.SetAlarmAck(ctx, alarmAckKey(alarm), alarmAckRetention, ack); err != nil {
+		return model.OltAlarm{}, err
+	}
+
+	alarm.Acknowledged = true
+	alarm.Acknowledgement = &ack
+
+	return alarm, nil
+}
+
+// UnacknowledgeAlarm removes the acknowledgement of an active alarm
+func (u *alarmUsecase) UnacknowledgeAlarm(ctx context.Context, alarmID int) (model.OltAlarm, error) {
+
+	alarm, err := u.getActiveAlarm(ctx, alarmID)
+	if err != nil {
+		return model.OltAlarm{}, err
+	}
+
+	log.Info().Msg("Unacknowledge alarm ID: " + strconv.Itoa(alarmID)) // Log info message to logger
+
+	if err := u.alarmAckRepository.DeleteAlarmAck(ctx, alarmAckKey(alarm)); err != nil {
+		return model.OltAlarm{}, err
+	}
+
+	alarm.Acknowledged = false
+	alarm.Acknowledgement = nil
+
+	return alarm, nil
+}
+
+// getActiveAlarm is a method to get one active alarm by ID
+func (u *alarmUsecase) getActiveAlarm(ctx context.Context, alarmID int) (model.OltAlarm, error) {
+
+	alarms, err := u.getActiveAlarms(ctx)
+	if err != nil {
+		return model.OltAlarm{}, err
+	}
+
+	for _, alarm := range alarms {
+		if alarm.ID == alarmID {
+			return alarm, nil
+		}
+	}
+
+	return model.OltAlarm{}, ErrAlarmNotFound
+}
+
+// getActiveAlarms is a method to walk the active alarm table and merge the acknowledgements from Redis
+func (u *alarmUsecase) getActiveAlarms(ctx context.Context) ([]model.OltAlarm, error) {
+
+	baseOID := u.cfg.OltCfg.BaseOID2
+	oltCfg := u.cfg.OltCfg
+
+	alarmMap := make(map[int]*model.OltAlarm)
+	sources := make(map[int][3]int) // Source ifIndex, ONU ID and slot of each alarm ID
+
+	// getAlarm returns the alarm of an ID, adding it on first use
+	getAlarm := func(alarmID int) *model.OltAlarm {
+		if alarm, ok := alarmMap[alarmID]; ok {
+			return alarm
+		}
+		alarm := &model.OltAlarm{ID: alarmID}
+		alarmMap[alarmID] = alarm
+		return alarm
+	}
+
+	// Walk the whole table entry once, the varbinds of each column are dispatched by column OID
+	err := u.snmpRepository.Walk(baseOID+oltCfg.OltAlarmTableOID, func(pdu gosnmp.SnmpPDU) error {
+		column, alarmID, ok := getAlarmColumn(pdu.Name, baseOID, []string{
+			oltCfg.OltAlarmCodeOID, oltCfg.OltAlarmSeverityOID, oltCfg.OltAlarmSourceIfIndexOID,
+			oltCfg.OltAlarmSourceOnuOID, oltCfg.OltAlarmSourceSlotOID, oltCfg.OltAlarmRaiseTimeOID,
+			oltCfg.OltAlarmDescriptionOID,
+		})
+		if !ok {
+			return nil
+		}
+
+		alarm := getAlarm(alarmID)
+		source := sources[alarmID]
+		switch column {
+		case oltCfg.OltAlarmCodeOID:
+			alarm.Code, _ = pdu.Value.(int)
+			alarm.Type, alarm.Category = utils.ExtractOltAlarmType(pdu.Value)
+		case oltCfg.OltAlarmSeverityOID:
+			alarm.Severity = utils.ExtractAlarmSeverity(pdu.Value)
+		case oltCfg.OltAlarmSourceIfIndexOID:
+			source[0], _ = pdu.Value.(int)
+		case oltCfg.OltAlarmSourceOnuOID:
+			source[1], _ = pdu.Value.(int)
+		case oltCfg.OltAlarmSourceSlotOID:
+			source[2], _ = pdu.Value.(int)
+		case oltCfg.OltAlarmRaiseTimeOID:
+			if value, ok := pdu.Value.([]byte); ok {
+				alarm.RaisedAt, _ = utils.ConvertByteArrayToDateTime(value)
+			}
+		case oltCfg.OltAlarmDescriptionOID:
+			alarm.Description = strings.TrimSpace(utils.ExtractName(pdu.Value))
+		}
+		sources[alarmID] = source
+
+		return nil
+	})
+	if err != nil {
+		log.Error().Msg("Failed to walk OLT alarm table: " + err.Error()) // Log error message to logger
+		return nil, fmt.Errorf("failed to walk OLT alarm table: %w", err)
+	}
+
+	alarms := make([]model.OltAlarm, 0, len(alarmMap))
+	for alarmID, alarm := range alarmMap {
+		source := sources[alarmID]
+		alarm.Source, alarm.Board, alarm.PON, alarm.OnuID = getAlarmSource(source[0], source[1], source[2])
+		alarms = append(alarms, *alarm)
+	}
+
+	// Acknowledgements are optional, alarms are still returned when Redis is unavailable
+	ackKeys := make([]string, len(alarms))
+	for i, alarm := range alarms {
+		ackKeys[i] = alarmAckKey(alarm)
+	}
+	acks, err := u.alarmAckRepository.GetAlarmAcks(ctx, ackKeys...)
+	if err != nil {
+		log.Error().Msg("Failed to get alarm acknowledgements: " + err.Error()) // Log error message to logger
+		acks = nil
+	}
+	for i := range alarms {
+		if ack, ok := acks[ackKeys[i]]; ok {
+			alarms[i].Acknowledged = true
+			alarms[i].Acknowledgement = &ack
+		}
+	}
+
+	// Sort alarms by severity then by alarm ID
+	sort.Slice(alarms, func(i, j int) bool {
+		rankI, okI := alarmSeverityRank[alarms[i].Severity]
+		rankJ, okJ := alarmSeverityRank[alarms[j].Severity]
+		if !okI {
+			rankI = len(alarmSeverityRank)
+		}
+		if !okJ {
+			rankJ = len(alarmSeverityRank)
+		}
+		if rankI != rankJ {
+			return rankI < rankJ
+		}
+		return alarms[i].ID < alarms[j].ID
+	})
+
+	return alarms, nil
+}
+
+// getAlarmColumn is a function to get the column and alarm ID of an alarm table varbind
+func getAlarmColumn(name, baseOID string, columns []string) (string, int, bool) {
+	for _, column := range columns {
+		if index := utils.ExtractOIDIndex(name, baseOID+column); len(index) == 1 {
+			return column, index[0], true
+		}
+	}
+	return "", 0, false
+}
+
+// getAlarmSource is a function to describe the alarm source from its ifIndex, ONU ID and slot
+func getAlarmSource(ifIndex, onuID, slot int) (string, int, int, int) {
+	if boardID, ponID, ok := utils.DecodeIfIndex(ifIndex); ok {
+		if onuID > 0 {
+			return "gpon-onu_1/" + strconv.Itoa(boardID) + "/" + strconv.Itoa(ponID) + ":" + strconv.Itoa(onuID),
+				boardID, ponID, onuID
+		}
+		return "gpon-olt_1/" + strconv.Itoa(boardID) + "/" + strconv.Itoa(ponID), boardID, ponID, 0
+	}
+
+	if slot > 0 {
+		return "card_1/" + strconv.Itoa(slot), slot, 0, 0
+	}
+
+	return "system", 0, 0, 0 // Shelf level alarms such as fan and power
+}
+
+// alarmAckKey is a function to get the acknowledgement key of an alarm, alarm IDs are reused by the OLT
+// so the code and raise time are part of the key
+func alarmAckKey(alarm model.OltAlarm) string {
+	return alarmAckKeyPrefix + strconv.Itoa(alarm.ID) + "_" + strconv.Itoa(alarm.Code) + "_" + alarm.RaisedAt
+}
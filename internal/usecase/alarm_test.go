@@ -0,0 +1,186 @@
+package usecase
+
+import (
+	"context"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// fakeAlarmAckRepo is an in-memory implementation of AlarmAckRepositoryInterface
+type fakeAlarmAckRepo struct {
+	acks       map[string]model.OltAlarmAck
+	retentions map[string]time.Duration
+}
+
+func newFakeAlarmAckRepo() *fakeAlarmAckRepo {
+	return &fakeAlarmAckRepo{
+		acks:       make(map[string]model.OltAlarmAck),
+		retentions: make(map[string]time.Duration),
+	}
+}
+
+func (f *fakeAlarmAckRepo) SetAlarmAck(
+	_ context.Context, key string, retention time.Duration, ack model.OltAlarmAck,
+) error {
+	f.acks[key] = ack
+	f.retentions[key] = retention
+	return nil
+}
+
+func (f *fakeAlarmAckRepo) GetAlarmAcks(_ context.Context, keys ...string) (map[string]model.OltAlarmAck, error) {
+	acks := make(map[string]model.OltAlarmAck, len(keys))
+	for _, key := range keys {
+		if ack, ok := f.acks[key]; ok {
+			acks[key] = ack
+		}
+	}
+	return acks, nil
+}
+
+func (f *fakeAlarmAckRepo) DeleteAlarmAck(_ context.Context, key string) error {
+	delete(f.acks, key)
+	return nil
+}
+
+const testAlarmTable = testBaseOID2 + ".3.40.2.1"
+
+// newTestAlarmUsecase returns an alarm usecase with a Major ONU LOS (1), a Critical PON LOS (2),
+// a Major fan fault (3) and a Minor card fault (4) active
+func newTestAlarmUsecase() (AlarmUseCaseInterface, *fakeAlarmAckRepo) {
+	cfg := newTestConfig()
+	cfg.OltCfg.OltAlarmTableOID = ".3.40.2.1"
+	cfg.OltCfg.OltAlarmCodeOID = ".3.40.2.1.2"
+	cfg.OltCfg.OltAlarmSeverityOID = ".3.40.2.1.3"
+	cfg.OltCfg.OltAlarmSourceIfIndexOID = ".3.40.2.1.4"
+	cfg.OltCfg.OltAlarmSourceOnuOID = ".3.40.2.1.5"
+	cfg.OltCfg.OltAlarmSourceSlotOID = ".3.40.2.1.6"
+	cfg.OltCfg.OltAlarmRaiseTimeOID = ".3.40.2.1.7"
+	cfg.OltCfg.OltAlarmDescriptionOID = ".3.40.2.1.8"
+
+	agent := newFakeSnmpAgent()
+	for alarmID, columns := range map[string][]interface{}{
+		"1": {2, 2, 285278465, 5, 0, "ONU 5 LOS"},
+		"2": {1, 1, 268568576, 0, 0, "PON LOS"},
+		"3": {8, 2, 0, 0, 0, "Fan 2 stopped"},
+		"4": {7, 3, 0, 0, 2, "Card temperature warning"},
+	} {
+		agent.values[testAlarmTable+".2."+alarmID] = columns[0]
+		agent.values[testAlarmTable+".3."+alarmID] = columns[1]
+		agent.values[testAlarmTable+".4."+alarmID] = columns[2]
+		agent.values[testAlarmTable+".5."+alarmID] = columns[3]
+		agent.values[testAlarmTable+".6."+alarmID] = columns[4]
+		agent.values[testAlarmTable+".7."+alarmID] = []byte{0x07, 0xe8, 10, 1, 8, 0, 0, 0}
+		agent.values[testAlarmTable+".8."+alarmID] = columns[5]
+	}
+
+	ackRepo := newFakeAlarmAckRepo()
+	return NewAlarmUsecase(agent, ackRepo, cfg), ackRepo
+}
+
+func TestGetAlarms(t *testing.T) {
+	u, _ := newTestAlarmUsecase()
+
+	alarms, err := u.GetAlarms(context.Background(), AlarmFilter{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []model.OltAlarm{
+		{ID: 2, Code: 1, Type: "PON LOS", Category: "pon", Severity: "Critical", Source: "gpon-olt_1/2/8", Board: 2,
+			PON: 8, Description: "PON LOS", RaisedAt: "2024-10-01 08:00:00"},
+		{ID: 1, Code: 2, Type: "ONU LOS", Category: "onu", Severity: "Major", Source: "gpon-onu_1/1/1:5", Board: 1,
+			PON: 1, OnuID: 5, Description: "ONU 5 LOS", RaisedAt: "2024-10-01 08:00:00"},
+		{ID: 3, Code: 8, Type: "Fan Fault", Category: "fan", Severity: "Major", Source: "system",
+			Description: "Fan 2 stopped", RaisedAt: "2024-10-01 08:00:00"},
+		{ID: 4, Code: 7, Type: "Card Fault", Category: "card", Severity: "Minor", Source: "card_1/2", Board: 2,
+			Description: "Card temperature warning", RaisedAt: "2024-10-01 08:00:00"},
+	}, alarms)
+}
+
+func TestGetAlarmsFilter(t *testing.T) {
+	u, _ := newTestAlarmUsecase()
+	notAcknowledged := false
+
+	testCases := []struct {
+		name     string
+		filter   AlarmFilter
+		expected []int
+	}{
+		{"Severity", AlarmFilter{Severities: []string{"critical", "MINOR"}}, []int{2, 4}},
+		{"Category", AlarmFilter{Categories: []string{"onu", "fan"}}, []int{1, 3}},
+		{"Board", AlarmFilter{Board: 2}, []int{2, 4}},
+		{"ONU", AlarmFilter{Board: 1, PON: 1, OnuID: 5}, []int{1}},
+		{"Not acknowledged", AlarmFilter{Acknowledged: &notAcknowledged}, []int{2, 1, 3, 4}},
+		{"No match", AlarmFilter{Categories: []string{"power"}}, []int{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			alarms, err := u.GetAlarms(context.Background(), tc.filter)
+			assert.NoError(t, err)
+
+			alarmIDs := make([]int, 0, len(alarms))
+			for _, alarm := range alarms {
+				alarmIDs = append(alarmIDs, alarm.ID)
+			}
+			assert.Equal(t, tc.expected, alarmIDs)
+		})
+	}
+}
+
+func TestAcknowledgeAlarm(t *testing.T) {
+	u, ackRepo := newTestAlarmUsecase()
+
+	alarm, err := u.AcknowledgeAlarm(context.Background(), 2, model.OltAlarmAckRequest{Comment: " Fiber cut "},
+		"noc-budi")
+	assert.NoError(t, err)
+	assert.True(t, alarm.Acknowledged)
+	assert.Equal(t, "noc-budi", alarm.Acknowledgement.Actor)
+	assert.Equal(t, "Fiber cut", alarm.Acknowledgement.Comment)
+
+	// Acknowledgement is merged into the alarm list
+	acknowledged := true
+	alarms, err := u.GetAlarms(context.Background(), AlarmFilter{Acknowledged: &acknowledged})
+	assert.NoError(t, err)
+	assert.Equal(t, []model.OltAlarm{alarm}, alarms)
+
+	// Acknowledgements expire instead of being removed by reading the alarms
+	assert.Equal(t, alarmAckRetention, ackRepo.retentions[alarmAckKey(alarm)])
+	ackRepo.acks[alarmAckKeyPrefix+"9_1_2024-09-30 08:00:00"] = model.OltAlarmAck{Actor: "noc-budi"}
+	_, err = u.GetAlarms(context.Background(), AlarmFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, ackRepo.acks, 2)
+
+	alarm, err = u.UnacknowledgeAlarm(context.Background(), 2)
+	assert.NoError(t, err)
+	assert.False(t, alarm.Acknowledged)
+	assert.Nil(t, alarm.Acknowledgement)
+	assert.Len(t, ackRepo.acks, 1)
+}
+
+func TestAcknowledgeAlarmErrors(t *testing.T) {
+	u, _ := newTestAlarmUsecase()
+
+	testCases := []struct {
+		name     string
+		alarmID  int
+		request  model.OltAlarmAckRequest
+		actor    string
+		expected error
+	}{
+		{"Missing actor", 2, model.OltAlarmAckRequest{}, " ", ErrInvalidAlarmRequest},
+		{"Comment too long", 2, model.OltAlarmAckRequest{Comment: string(make([]byte, 257))}, "noc-budi",
+			ErrInvalidAlarmRequest},
+		{"Alarm not active", 9, model.OltAlarmAckRequest{}, "noc-budi", ErrAlarmNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := u.AcknowledgeAlarm(context.Background(), tc.alarmID, tc.request, tc.actor)
+			assert.ErrorIs(t, err, tc.expected)
+		})
+	}
+
+	_, err := u.UnacknowledgeAlarm(context.Background(), 9)
+	assert.ErrorIs(t, err, ErrAlarmNotFound)
+}
@@ -0,0 +1,379 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/config"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/model"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/pkg/pubsub"
+	"github.com/rs/zerolog/log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// anomalySourceBuffer is the number of ONU events waiting to be recorded, e.g. during a serial number check
+const anomalySourceBuffer = 1024
+
+var (
+	// onuUpStatuses are the phase states of a ranged ONU
+	onuUpStatuses = map[string]bool{"Synchronization": true, "Online": true}
+
+	// onuDownStatuses are the phase states an ONU drops to, a change from an up status counts as a drop
+	onuDownStatuses = map[string]bool{"LOS": true, "Dying Gasp": true, "Offline": true}
+)
+
+type AnomalyUseCaseInterface interface {
+	Check(ctx context.Context) error
+	GetReport(ctx context.Context, refresh bool) (model.AnomalyReport, error)
+	Run(ctx context.Context, interval time.Duration)
+}
+
+// onuDrop is an ONU of a PON which dropped from an up status
+type onuDrop struct {
+	id int
+	at time.Time
+}
+
+// massDrop is a group of ONU of a PON which dropped within the drop window of each other
+type massDrop struct {
+	board     int
+	pon       int
+	onuIDs    []int
+	startedAt time.Time
+	endedAt   time.Time
+}
+
+type anomalyUsecase struct {
+	onuUsecase   OnuUseCaseInterface
+	source       <-chan model.OnuEvent
+	cancelSource func()
+	cfg          *config.Config
+	now          func() time.Time
+
+	mu          sync.Mutex
+	checkedAt   time.Time
+	duplicates  []model.DuplicateSerialAnomaly
+	lastStatus  map[[3]int]string      // Keyed by board, PON and ONU ID
+	transitions map[[3]int][]time.Time // LOS transitions within the flap window, keyed by board, PON and ONU ID
+	drops       map[[2]int][]onuDrop   // Drops within the drop window, keyed by board and PON
+	massDrops   []*massDrop
+}
+
+// NewAnomalyUsecase returns the usecase detecting duplicate serial numbers, flapping ONU and mass drops,
+// status changes are taken from the ONU events of broker
+func NewAnomalyUsecase(
+	onuUsecase OnuUseCaseInterface, broker *pubsub.Broker[model.OnuEvent], cfg *config.Config,
+) AnomalyUseCaseInterface {
+
+	// Subscribe now so no event published before Run is started is lost
+	source, cancelSource := broker.Subscribe(anomalySourceBuffer)
+
+	return &anomalyUsecase{
+		onuUsecase:   onuUsecase,
+		source:       source,
+		cancelSource: cancelSource,
+		cfg:          cfg,
+		now:          time.Now,
+		lastStatus:   make(map[[3]int]string),
+		transitions:  make(map[[3]int][]time.Time),
+		drops:        make(map[[2]int][]onuDrop),
+	}
+}
+
+// Run records ONU status changes until ctx is done and checks serial numbers every interval,
+// interval 0 disables the periodic check
+func (u *anomalyUsecase) Run(ctx context.Context, interval time.Duration) {
+
+	defer u.cancelSource()
+
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+
+		if err := u.Check(ctx); err != nil {
+			log.Error().Msg("Failed to check ONU serial numbers: " + err.Error()) // Log error message to logger
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-u.source:
+			if !ok {
+				return
+			}
+			u.record(event)
+		case <-tick:
+			if err := u.Check(ctx); err != nil {
+				log.Error().Msg("Failed to check ONU serial numbers: " + err.Error()) // Log error message to logger
+			}
+		}
+	}
+}
+
+// Check reads the serial numbers of all PON and keeps the serial numbers registered on more than one ONU
+func (u *anomalyUsecase) Check(ctx context.Context) error {
+
+	onuBySerial := make(map[string][]model.OnuID)
+	var serials []string
+	failed := 0
+
+	for boardID := 1; boardID <= maxBoardID; boardID++ {
+		for ponID := 1; ponID <= maxPonID; ponID++ {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			onuSerialNumberList, err := u.onuUsecase.GetOnuIDAndSerialNumber(boardID, ponID)
+			if err != nil {
+				// Check the other PON, a single unreachable PON shouldn't hide duplicates elsewhere
+				log.Error().Msgf("Failed to get ONU serial numbers of Board ID: %d and PON ID: %d: %s",
+					boardID, ponID, err.Error()) // Log error message to logger
+				failed++
+				continue
+			}
+
+			for _, onu := range onuSerialNumberList {
+				serial := normalizeSerialNumber(onu.SerialNumber)
+				if serial == "" {
+					continue
+				}
+				if _, ok := onuBySerial[serial]; !ok {
+					serials = append(serials, serial)
+				}
+				onuBySerial[serial] = append(onuBySerial[serial], model.OnuID{
+					Board: onu.Board, PON: onu.PON, ID: onu.ID,
+				})
+			}
+		}
+	}
+
+	if failed == maxBoardID*maxPonID {
+		return fmt.Errorf("failed to get ONU serial numbers of all PON")
+	}
+
+	sort.Strings(serials)
+	duplicates := make([]model.DuplicateSerialAnomaly, 0)
+	for _, serial := range serials {
+		if len(onuBySerial[serial]) > 1 {
+			duplicates = append(duplicates, model.DuplicateSerialAnomaly{
+				SerialNumber: serial, Onus: onuBySerial[serial],
+			})
+		}
+	}
+
+	if len(duplicates) > 0 {
+		log.Warn().Interface("duplicate_serials", duplicates).Msg("Duplicate ONU serial numbers detected")
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.duplicates = duplicates
+	u.checkedAt = u.now()
+
+	return nil
+}
+
+// GetReport returns the anomalies found by the last serial number check and in the recent status changes,
+// serial numbers are checked first when refresh is set or no check has run yet
+func (u *anomalyUsecase) GetReport(ctx context.Context, refresh bool) (model.AnomalyReport, error) {
+
+	u.mu.Lock()
+	checked := !u.checkedAt.IsZero()
+	u.mu.Unlock()
+
+	if refresh || !checked {
+		if err := u.Check(ctx); err != nil {
+			return model.AnomalyReport{}, err
+		}
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	now := u.now()
+	report := model.AnomalyReport{
+		Olt:              u.cfg.StreamCfg.OltName,
+		GeneratedAt:      now.Format(time.RFC3339),
+		CheckedAt:        u.checkedAt.Format(time.RFC3339),
+		DuplicateSerials: u.duplicates,
+		FlappingOnus:     u.getFlappingOnus(now),
+		MassDrops:        u.getMassDrops(now),
+	}
+
+	return report, nil
+}
+
+// record is a method to keep the LOS transitions and drops of an ONU status event
+func (u *anomalyUsecase) record(event model.OnuEvent) {
+
+	if event.Type != OnuEventStatus || event.Status == "" {
+		return
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	key := [3]int{event.Board, event.PON, event.ID}
+	previous, known := u.lastStatus[key]
+	u.lastStatus[key] = event.Status
+	if !known || previous == event.Status {
+		return
+	}
+
+	now := u.now()
+
+	// Polling may miss the short Synchronization phase, so LOS to Online and back counts as well
+	if (previous == "LOS" && onuUpStatuses[event.Status]) || (onuUpStatuses[previous] && event.Status == "LOS") {
+		u.transitions[key] = append(u.pruneTransitions(u.transitions[key], now), now)
+	}
+
+	if onuUpStatuses[previous] && onuDownStatuses[event.Status] {
+		u.recordDrop(event.Board, event.PON, event.ID, now)
+	}
+}
+
+// recordDrop is a method to keep a drop and open or extend a mass drop once enough ONU of the PON dropped
+func (u *anomalyUsecase) recordDrop(boardID, ponID, onuID int, now time.Time) {
+
+	window := time.Duration(u.cfg.AnomalyCfg.DropWindow) * time.Second
+	key := [2]int{boardID, ponID}
+
+	drops := u.drops[key][:0]
+	for _, drop := range u.drops[key] {
+		if now.Sub(drop.at) <= window {
+			drops = append(drops, drop)
+		}
+	}
+	drops = append(drops, onuDrop{id: onuID, at: now})
+	u.drops[key] = drops
+
+	// An ONU dropping twice within the window is counted once
+	onuIDs := make([]int, 0, len(drops))
+	seen := make(map[int]bool)
+	for _, drop := range drops {
+		if !seen[drop.id] {
+			seen[drop.id] = true
+			onuIDs = append(onuIDs, drop.id)
+		}
+	}
+	if len(onuIDs) < u.cfg.AnomalyCfg.DropThreshold {
+		return
+	}
+
+	// Extend the mass drop of the PON still in progress
+	for i := len(u.massDrops) - 1; i >= 0; i-- {
+		incident := u.massDrops[i]
+		if incident.board != boardID || incident.pon != ponID || now.Sub(incident.endedAt) > window {
+			continue
+		}
+		for _, id := range onuIDs {
+			if !containsInt(incident.onuIDs, id) {
+				incident.onuIDs = append(incident.onuIDs, id)
+			}
+		}
+		incident.endedAt = now
+		return
+	}
+
+	u.massDrops = append(u.massDrops, &massDrop{
+		board: boardID, pon: ponID, onuIDs: onuIDs, startedAt: drops[0].at, endedAt: now,
+	})
+
+	log.Warn().Msgf("Mass drop detected on Board ID: %d and PON ID: %d, %d ONU dropped",
+		boardID, ponID, len(onuIDs)) // Log warning message to logger
+}
+
+// pruneTransitions is a method to remove transitions older than the flap window
+func (u *anomalyUsecase) pruneTransitions(transitions []time.Time, now time.Time) []time.Time {
+	window := time.Duration(u.cfg.AnomalyCfg.FlapWindow) * time.Second
+	for len(transitions) > 0 && now.Sub(transitions[0]) > window {
+		transitions = transitions[1:]
+	}
+	return transitions
+}
+
+// getFlappingOnus is a method to list the ONU with at least the flap threshold of LOS transitions in the window
+func (u *anomalyUsecase) getFlappingOnus(now time.Time) []model.FlappingOnuAnomaly {
+
+	flapping := make([]model.FlappingOnuAnomaly, 0)
+	for key, transitions := range u.transitions {
+		transitions = u.pruneTransitions(transitions, now)
+		if len(transitions) == 0 {
+			delete(u.transitions, key)
+			continue
+		}
+		u.transitions[key] = transitions
+
+		if len(transitions) < u.cfg.AnomalyCfg.FlapThreshold {
+			continue
+		}
+		flapping = append(flapping, model.FlappingOnuAnomaly{
+			Board:           key[0],
+			PON:             key[1],
+			ID:              key[2],
+			Status:          u.lastStatus[key],
+			Transitions:     len(transitions),
+			FirstTransition: transitions[0].Format(time.RFC3339),
+			LastTransition:  transitions[len(transitions)-1].Format(time.RFC3339),
+		})
+	}
+
+	// Sort by board, PON and ONU ID
+	sort.Slice(flapping, func(i, j int) bool {
+		if flapping[i].Board != flapping[j].Board {
+			return flapping[i].Board < flapping[j].Board
+		}
+		if flapping[i].PON != flapping[j].PON {
+			return flapping[i].PON < flapping[j].PON
+		}
+		return flapping[i].ID < flapping[j].ID
+	})
+
+	return flapping
+}
+
+// getMassDrops is a method to list the mass drops ended within the retention, newest first
+func (u *anomalyUsecase) getMassDrops(now time.Time) []model.MassDropAnomaly {
+
+	retention := time.Duration(u.cfg.AnomalyCfg.Retention) * time.Second
+
+	kept := u.massDrops[:0]
+	for _, incident := range u.massDrops {
+		if now.Sub(incident.endedAt) <= retention {
+			kept = append(kept, incident)
+		}
+	}
+	u.massDrops = kept
+
+	massDrops := make([]model.MassDropAnomaly, 0, len(kept))
+	for i := len(kept) - 1; i >= 0; i-- {
+		onuIDs := append([]int(nil), kept[i].onuIDs...)
+		sort.Ints(onuIDs)
+		massDrops = append(massDrops, model.MassDropAnomaly{
+			Board:     kept[i].board,
+			PON:       kept[i].pon,
+			OnuCount:  len(onuIDs),
+			OnuIDs:    onuIDs,
+			StartedAt: kept[i].startedAt.Format(time.RFC3339),
+			EndedAt:   kept[i].endedAt.Format(time.RFC3339),
+		})
+	}
+
+	return massDrops
+}
+
+// containsInt is a function to check if values contains value
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
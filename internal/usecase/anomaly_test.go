@@ -0,0 +1,185 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/model"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/pkg/pubsub"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// fakeSerialSource serves GetOnuIDAndSerialNumber from serials keyed by board and PON,
+// other methods of the interface are not used by the anomaly usecase
+type fakeSerialSource struct {
+	OnuUseCaseInterface
+	serials map[[2]int][]model.OnuSerialNumber
+	err     error
+}
+
+func (f *fakeSerialSource) GetOnuIDAndSerialNumber(boardID, ponID int) ([]model.OnuSerialNumber, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return f.serials[[2]int{boardID, ponID}], nil
+}
+
+// newTestAnomalyUsecase returns an anomaly usecase with a clock moved by the returned function
+func newTestAnomalyUsecase(source *fakeSerialSource) (*anomalyUsecase, func(time.Duration)) {
+	cfg := newTestConfig()
+	cfg.StreamCfg.OltName = "olt-1"
+	cfg.AnomalyCfg.FlapWindow = 600
+	cfg.AnomalyCfg.FlapThreshold = 4
+	cfg.AnomalyCfg.DropWindow = 60
+	cfg.AnomalyCfg.DropThreshold = 3
+	cfg.AnomalyCfg.Retention = 3600
+
+	u := NewAnomalyUsecase(source, pubsub.NewBroker[model.OnuEvent](), cfg).(*anomalyUsecase)
+	now := time.Date(2024, 10, 1, 8, 0, 0, 0, time.UTC)
+	u.now = func() time.Time { return now }
+
+	return u, func(d time.Duration) { now = now.Add(d) }
+}
+
+// statusEvent returns a status event of an ONU of board 1
+func statusEvent(ponID, onuID int, status string) model.OnuEvent {
+	return model.OnuEvent{Type: OnuEventStatus, Board: 1, PON: ponID, ID: onuID, Status: status}
+}
+
+func TestAnomalyDuplicateSerials(t *testing.T) {
+	source := &fakeSerialSource{serials: map[[2]int][]model.OnuSerialNumber{
+		{1, 1}: {
+			{Board: 1, PON: 1, ID: 1, SerialNumber: "ZTEGC0000001"},
+			{Board: 1, PON: 1, ID: 2, SerialNumber: "ZTEGC0000002"},
+		},
+		{1, 3}: {{Board: 1, PON: 3, ID: 7, SerialNumber: "ztegc0000001 "}},
+		{2, 8}: {
+			{Board: 2, PON: 8, ID: 4, SerialNumber: "ZTEGC0000001"},
+			{Board: 2, PON: 8, ID: 5, SerialNumber: ""},
+			{Board: 2, PON: 8, ID: 6, SerialNumber: ""},
+		},
+	}}
+	u, _ := newTestAnomalyUsecase(source)
+
+	// The first report checks serial numbers
+	report, err := u.GetReport(context.Background(), false)
+	assert.NoError(t, err)
+	assert.Equal(t, "olt-1", report.Olt)
+	assert.Equal(t, "2024-10-01T08:00:00Z", report.CheckedAt)
+	assert.Equal(t, []model.DuplicateSerialAnomaly{{
+		SerialNumber: "ZTEGC0000001",
+		Onus:         []model.OnuID{{Board: 1, PON: 1, ID: 1}, {Board: 1, PON: 3, ID: 7}, {Board: 2, PON: 8, ID: 4}},
+	}}, report.DuplicateSerials)
+	assert.Empty(t, report.FlappingOnus)
+	assert.Empty(t, report.MassDrops)
+
+	// Later reports keep the last check unless refreshed
+	source.serials = nil
+	report, err = u.GetReport(context.Background(), false)
+	assert.NoError(t, err)
+	assert.Len(t, report.DuplicateSerials, 1)
+
+	report, err = u.GetReport(context.Background(), true)
+	assert.NoError(t, err)
+	assert.Empty(t, report.DuplicateSerials)
+
+	// A check failing on all PON keeps the last result
+	source.err = errors.New("request timeout")
+	assert.Error(t, u.Check(context.Background()))
+	_, err = u.GetReport(context.Background(), true)
+	assert.Error(t, err)
+}
+
+func TestAnomalyFlappingOnu(t *testing.T) {
+	u, advance := newTestAnomalyUsecase(&fakeSerialSource{})
+
+	u.record(statusEvent(1, 5, "Online"))
+	for _, status := range []string{"LOS", "Synchronization", "Online", "LOS", "Synchronization"} {
+		advance(time.Minute)
+		u.record(statusEvent(1, 5, status))
+	}
+
+	// Other status changes and a single LOS are not flapping
+	u.record(statusEvent(1, 6, "Online"))
+	u.record(statusEvent(1, 6, "Offline"))
+	u.record(statusEvent(1, 7, "Online"))
+	u.record(statusEvent(1, 7, "LOS"))
+	u.record(model.OnuEvent{Type: OnuEventOptical, Board: 1, PON: 1, ID: 8, RxPower: "-30.00"})
+
+	report, err := u.GetReport(context.Background(), false)
+	assert.NoError(t, err)
+	assert.Equal(t, []model.FlappingOnuAnomaly{{
+		Board: 1, PON: 1, ID: 5, Status: "Synchronization", Transitions: 4,
+		FirstTransition: "2024-10-01T08:01:00Z", LastTransition: "2024-10-01T08:05:00Z",
+	}}, report.FlappingOnus)
+
+	// Transitions leave the flap window
+	advance(7 * time.Minute)
+	report, err = u.GetReport(context.Background(), false)
+	assert.NoError(t, err)
+	assert.Empty(t, report.FlappingOnus)
+}
+
+func TestAnomalyMassDrop(t *testing.T) {
+	u, advance := newTestAnomalyUsecase(&fakeSerialSource{})
+
+	for onuID := 1; onuID <= 5; onuID++ {
+		u.record(statusEvent(2, onuID, "Online"))
+	}
+
+	// Two drops are below the threshold, a drop of an ONU already down is ignored
+	u.record(statusEvent(2, 1, "LOS"))
+	u.record(statusEvent(2, 1, "Offline"))
+	advance(10 * time.Second)
+	u.record(statusEvent(2, 2, "Dying Gasp"))
+	report, err := u.GetReport(context.Background(), false)
+	assert.NoError(t, err)
+	assert.Empty(t, report.MassDrops)
+
+	// The third drop opens a mass drop, following drops within the window extend it
+	advance(10 * time.Second)
+	u.record(statusEvent(2, 3, "LOS"))
+	advance(50 * time.Second)
+	u.record(statusEvent(2, 4, "LOS"))
+
+	report, err = u.GetReport(context.Background(), false)
+	assert.NoError(t, err)
+	assert.Equal(t, []model.MassDropAnomaly{{
+		Board: 1, PON: 2, OnuCount: 4, OnuIDs: []int{1, 2, 3, 4},
+		StartedAt: "2024-10-01T08:00:00Z", EndedAt: "2024-10-01T08:01:10Z",
+	}}, report.MassDrops)
+
+	// Mass drops are removed after the retention
+	advance(time.Hour + time.Second)
+	report, err = u.GetReport(context.Background(), false)
+	assert.NoError(t, err)
+	assert.Empty(t, report.MassDrops)
+}
+
+func TestAnomalyRun(t *testing.T) {
+	broker := pubsub.NewBroker[model.OnuEvent]()
+	cfg := newTestConfig()
+	cfg.AnomalyCfg.FlapWindow = 600
+	cfg.AnomalyCfg.FlapThreshold = 1
+	u := NewAnomalyUsecase(&fakeSerialSource{}, broker, cfg)
+
+	// Events published before Run are recorded
+	broker.Publish(statusEvent(1, 1, "Online"))
+	broker.Publish(statusEvent(1, 1, "LOS"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		u.Run(ctx, 0)
+		close(done)
+	}()
+
+	assert.Eventually(t, func() bool {
+		report, err := u.GetReport(context.Background(), false)
+		return err == nil && len(report.FlappingOnus) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+	<-done
+}
@@ -0,0 +1,273 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/config"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/model"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/repository"
+	"github.com/rs/zerolog/log"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	apiKeyStoreKey = "api_key" // Redis hash of API keys created through the API keyed by the hash of the key
+	apiKeyPrefix   = "olt_"    // Prefix of generated keys, makes them recognizable in secret scanners
+
+	ApiKeyScopeRead      = "read"      // GET requests
+	ApiKeyScopeProvision = "provision" // Changes to ONU, customers and alarms, includes read
+	ApiKeyScopeAdmin     = "admin"     // Cache, API key management and the audit log, includes provision
+
+	apiKeySourceConfig = "config"
+	apiKeySourceApi    = "api"
+)
+
+// apiKeyScopeRank orders scopes, a scope includes the scopes of a lower rank
+var apiKeyScopeRank = map[string]int{ApiKeyScopeRead: 1, ApiKeyScopeProvision: 2, ApiKeyScopeAdmin: 3}
+
+// apiKeyPonRegex matches a PON restriction as board/pon
+var apiKeyPonRegex = regexp.MustCompile(`^[1-2]/[1-8]$`)
+
+var (
+	ErrApiKeyInvalid        = errors.New("invalid or revoked API key")
+	ErrInvalidApiKeyRequest = errors.New("invalid API key request")
+	ErrApiKeyNotFound       = errors.New("API key not found")
+	ErrApiKeyInConfig       = errors.New("API key is defined in config")
+)
+
+type ApiKeyUseCaseInterface interface {
+	Authenticate(ctx context.Context, key string) (model.ApiKey, error)
+	GetKeys(ctx context.Context) ([]model.ApiKey, error)
+	CreateKey(ctx context.Context, actor string, request model.ApiKeyRequest) (model.ApiKeyCreated, error)
+	RevokeKey(ctx context.Context, keyID string) error
+}
+
+type apiKeyUsecase struct {
+	apiKeyRepository repository.ApiKeyRepositoryInterface
+	configKeys       map[string]model.ApiKey // API keys of config keyed by the hash of the key
+	now              func() time.Time
+}
+
+// NewApiKeyUsecase returns the usecase authenticating API keys of config and API keys created through the API,
+// keys are only kept as SHA-256 hashes
+func NewApiKeyUsecase(
+	apiKeyRepository repository.ApiKeyRepositoryInterface, cfg *config.Config,
+) ApiKeyUseCaseInterface {
+
+	configKeys := make(map[string]model.ApiKey, len(cfg.AuthCfg.Keys))
+	for _, keyCfg := range cfg.AuthCfg.Keys {
+		hash := strings.ToLower(strings.TrimSpace(keyCfg.Hash))
+		apiKey, err := validateApiKeyRequest(model.ApiKeyRequest{
+			Name: keyCfg.Name, Scopes: keyCfg.Scopes, Olts: keyCfg.Olts, Boards: keyCfg.Boards, Pons: keyCfg.Pons,
+		})
+		if err == nil && (keyCfg.ID == "" || len(hash) != sha256.Size*2) {
+			err = errors.New("'id' and a hex encoded SHA-256 'hash' are required")
+		}
+		if err != nil {
+			log.Error().Msg("Skipping API key " + keyCfg.ID + " of config: " + err.Error()) // Log error message to logger
+			continue
+		}
+
+		apiKey.ID = keyCfg.ID
+		apiKey.Source = apiKeySourceConfig
+		configKeys[hash] = apiKey
+	}
+
+	return &apiKeyUsecase{
+		apiKeyRepository: apiKeyRepository,
+		configKeys:       configKeys,
+		now:              time.Now,
+	}
+}
+
+// Authenticate returns the API key of a key, keys of config take precedence
+func (u *apiKeyUsecase) Authenticate(ctx context.Context, key string) (model.ApiKey, error) {
+
+	if key == "" {
+		return model.ApiKey{}, ErrApiKeyInvalid
+	}
+
+	hash := hashApiKey(key)
+	if apiKey, ok := u.configKeys[hash]; ok {
+		return apiKey, nil
+	}
+
+	apiKey, ok, err := u.apiKeyRepository.GetApiKey(ctx, apiKeyStoreKey, hash)
+	if err != nil {
+		return model.ApiKey{}, err
+	}
+	if !ok {
+		return model.ApiKey{}, ErrApiKeyInvalid
+	}
+
+	return apiKey, nil
+}
+
+// GetKeys returns the API keys of config followed by the API keys created through the API, sorted by ID
+func (u *apiKeyUsecase) GetKeys(ctx context.Context) ([]model.ApiKey, error) {
+
+	storedKeys, err := u.apiKeyRepository.GetApiKeys(ctx, apiKeyStoreKey)
+	if err != nil {
+		return nil, err
+	}
+
+	apiKeys := make([]model.ApiKey, 0, len(u.configKeys)+len(storedKeys))
+	for _, apiKey := range u.configKeys {
+		apiKeys = append(apiKeys, apiKey)
+	}
+	for _, apiKey := range storedKeys {
+		apiKeys = append(apiKeys, apiKey)
+	}
+
+	sort.Slice(apiKeys, func(i, j int) bool {
+		if apiKeys[i].Source != apiKeys[j].Source {
+			return apiKeys[i].Source == apiKeySourceConfig
+		}
+		return apiKeys[i].ID < apiKeys[j].ID
+	})
+
+	return apiKeys, nil
+}
+
+// CreateKey creates an API key and returns the key, which can't be retrieved again
+func (u *apiKeyUsecase) CreateKey(
+	ctx context.Context, actor string, request model.ApiKeyRequest,
+) (model.ApiKeyCreated, error) {
+
+	apiKey, err := validateApiKeyRequest(request)
+	if err != nil {
+		return model.ApiKeyCreated{}, err
+	}
+
+	idBytes := make([]byte, 8)
+	secretBytes := make([]byte, 32)
+	if _, err := rand.Read(idBytes); err != nil {
+		return model.ApiKeyCreated{}, err
+	}
+	if _, err := rand.Read(secretBytes); err != nil {
+		return model.ApiKeyCreated{}, err
+	}
+
+	key := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(secretBytes)
+	apiKey.ID = hex.EncodeToString(idBytes)
+	apiKey.Source = apiKeySourceApi
+	apiKey.CreatedBy = actor
+	apiKey.CreatedAt = u.now().Format(time.RFC3339)
+
+	if err := u.apiKeyRepository.SetApiKey(ctx, apiKeyStoreKey, hashApiKey(key), apiKey); err != nil {
+		return model.ApiKeyCreated{}, err
+	}
+
+	log.Info().Msg("Created API key " + apiKey.ID + " by " + actor) // Log info message to logger
+
+	return model.ApiKeyCreated{ApiKey: apiKey, Key: key}, nil
+}
+
+// RevokeKey deletes an API key created through the API, keys of config are removed from config
+func (u *apiKeyUsecase) RevokeKey(ctx context.Context, keyID string) error {
+
+	for _, apiKey := range u.configKeys {
+		if apiKey.ID == keyID {
+			return fmt.Errorf("%w: remove it from config", ErrApiKeyInConfig)
+		}
+	}
+
+	storedKeys, err := u.apiKeyRepository.GetApiKeys(ctx, apiKeyStoreKey)
+	if err != nil {
+		return err
+	}
+
+	for hash, apiKey := range storedKeys {
+		if apiKey.ID != keyID {
+			continue
+		}
+		if _, err := u.apiKeyRepository.DeleteApiKey(ctx, apiKeyStoreKey, hash); err != nil {
+			return err
+		}
+		log.Info().Msg("Revoked API key " + keyID) // Log info message to logger
+		return nil
+	}
+
+	return ErrApiKeyNotFound
+}
+
+// ApiKeyHasScope is a function to check if an API key has a scope, directly or through a higher scope
+func ApiKeyHasScope(apiKey model.ApiKey, scope string) bool {
+	for _, keyScope := range apiKey.Scopes {
+		if apiKeyScopeRank[keyScope] >= apiKeyScopeRank[scope] {
+			return true
+		}
+	}
+	return false
+}
+
+// ApiKeyAllows is a function to check if the restrictions of an API key allow a request to a board and PON of an OLT,
+// restrictions combine and a restricted key only allows requests selecting an allowed board and PON, 0 selects none
+func ApiKeyAllows(apiKey model.ApiKey, oltName, olt string, boardID, ponID int) bool {
+	if len(apiKey.Olts) > 0 && (!matchAny(apiKey.Olts, oltName) || (olt != "" && !matchAny(apiKey.Olts, olt))) {
+		return false
+	}
+	if len(apiKey.Boards) > 0 && !containsInt(apiKey.Boards, boardID) {
+		return false
+	}
+	if len(apiKey.Pons) > 0 && !matchAny(apiKey.Pons, fmt.Sprintf("%d/%d", boardID, ponID)) {
+		return false
+	}
+	return true
+}
+
+// validateApiKeyRequest is a function to validate and normalize an API key request
+func validateApiKeyRequest(request model.ApiKeyRequest) (model.ApiKey, error) {
+
+	apiKey := model.ApiKey{Name: strings.TrimSpace(request.Name), Boards: request.Boards}
+	if apiKey.Name == "" || len(apiKey.Name) > 64 {
+		return apiKey, fmt.Errorf("%w: 'name' must be 1 to 64 characters", ErrInvalidApiKeyRequest)
+	}
+
+	if len(request.Scopes) == 0 {
+		return apiKey, fmt.Errorf("%w: 'scopes' must not be empty", ErrInvalidApiKeyRequest)
+	}
+	for _, scope := range request.Scopes {
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		if _, ok := apiKeyScopeRank[scope]; !ok {
+			return apiKey, fmt.Errorf("%w: scope '%s' must be read, provision or admin", ErrInvalidApiKeyRequest, scope)
+		}
+		apiKey.Scopes = append(apiKey.Scopes, scope)
+	}
+
+	for _, olt := range request.Olts {
+		if olt = strings.TrimSpace(olt); olt == "" {
+			return apiKey, fmt.Errorf("%w: 'olts' must not contain empty names", ErrInvalidApiKeyRequest)
+		}
+		apiKey.Olts = append(apiKey.Olts, olt)
+	}
+
+	for _, boardID := range request.Boards {
+		if boardID < 1 || boardID > maxBoardID {
+			return apiKey, fmt.Errorf("%w: 'boards' must be between 1 and %d", ErrInvalidApiKeyRequest, maxBoardID)
+		}
+	}
+
+	for _, pon := range request.Pons {
+		if pon = strings.TrimSpace(pon); !apiKeyPonRegex.MatchString(pon) {
+			return apiKey, fmt.Errorf("%w: pon '%s' must be board/pon, e.g. 2/7", ErrInvalidApiKeyRequest, pon)
+		}
+		apiKey.Pons = append(apiKey.Pons, pon)
+	}
+
+	return apiKey, nil
+}
+
+// hashApiKey is a function to get the hex encoded SHA-256 of a key
+func hashApiKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
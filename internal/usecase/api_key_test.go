@@ -0,0 +1,154 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/config"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/model"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeApiKeyRepo is an in-memory implementation of ApiKeyRepositoryInterface
+type fakeApiKeyRepo struct {
+	apiKeys map[string]map[string]model.ApiKey
+}
+
+func newFakeApiKeyRepo() *fakeApiKeyRepo {
+	return &fakeApiKeyRepo{apiKeys: make(map[string]map[string]model.ApiKey)}
+}
+
+func (f *fakeApiKeyRepo) SetApiKey(_ context.Context, key, hash string, apiKey model.ApiKey) error {
+	if f.apiKeys[key] == nil {
+		f.apiKeys[key] = make(map[string]model.ApiKey)
+	}
+	f.apiKeys[key][hash] = apiKey
+	return nil
+}
+
+func (f *fakeApiKeyRepo) GetApiKey(_ context.Context, key, hash string) (model.ApiKey, bool, error) {
+	apiKey, ok := f.apiKeys[key][hash]
+	return apiKey, ok, nil
+}
+
+func (f *fakeApiKeyRepo) GetApiKeys(_ context.Context, key string) (map[string]model.ApiKey, error) {
+	apiKeys := make(map[string]model.ApiKey, len(f.apiKeys[key]))
+	for hash, apiKey := range f.apiKeys[key] {
+		apiKeys[hash] = apiKey
+	}
+	return apiKeys, nil
+}
+
+func (f *fakeApiKeyRepo) DeleteApiKey(_ context.Context, key, hash string) (bool, error) {
+	_, ok := f.apiKeys[key][hash]
+	delete(f.apiKeys[key], hash)
+	return ok, nil
+}
+
+func newTestApiKeyUsecase() (*apiKeyUsecase, *fakeApiKeyRepo) {
+	apiKeyRepo := newFakeApiKeyRepo()
+
+	cfg := newTestConfig()
+	cfg.AuthCfg.Keys = []config.ApiKeyConfig{
+		{ID: "noc", Name: "NOC dashboard", Hash: strings.ToUpper(hashApiKey("noc-secret")), Scopes: []string{"read"}},
+		{ID: "broken", Name: "Broken", Hash: "abc", Scopes: []string{"read"}},                  // Skipped, not a SHA-256
+		{ID: "typo", Name: "Typo", Hash: hashApiKey("typo-secret"), Scopes: []string{"write"}}, // Skipped
+	}
+
+	u := NewApiKeyUsecase(apiKeyRepo, cfg).(*apiKeyUsecase)
+	u.now = func() time.Time { return time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC) }
+	return u, apiKeyRepo
+}
+
+func TestApiKeyLifecycle(t *testing.T) {
+	u, _ := newTestApiKeyUsecase()
+	ctx := context.Background()
+
+	apiKey, err := u.Authenticate(ctx, "noc-secret")
+	assert.NoError(t, err)
+	assert.Equal(t, "noc", apiKey.ID)
+	assert.Equal(t, "config", apiKey.Source)
+
+	for _, key := range []string{"", "typo-secret", "unknown"} {
+		_, err = u.Authenticate(ctx, key)
+		assert.True(t, errors.Is(err, ErrApiKeyInvalid), key)
+	}
+
+	created, err := u.CreateKey(ctx, "noc", model.ApiKeyRequest{
+		Name: " billing ", Scopes: []string{"Provision"}, Pons: []string{"2/7"},
+	})
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(created.Key, "olt_"))
+	assert.Equal(t, model.ApiKey{
+		ID: created.ID, Name: "billing", Scopes: []string{"provision"}, Pons: []string{"2/7"}, Source: "api",
+		CreatedBy: "noc", CreatedAt: "2024-05-01T10:00:00Z",
+	}, created.ApiKey)
+
+	apiKey, err = u.Authenticate(ctx, created.Key)
+	assert.NoError(t, err)
+	assert.Equal(t, created.ApiKey, apiKey)
+
+	apiKeys, err := u.GetKeys(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []model.ApiKey{{ID: "noc", Name: "NOC dashboard", Scopes: []string{"read"}, Source: "config"},
+		created.ApiKey}, apiKeys)
+
+	// Keys of config can only be removed from config
+	assert.True(t, errors.Is(u.RevokeKey(ctx, "noc"), ErrApiKeyInConfig))
+	assert.True(t, errors.Is(u.RevokeKey(ctx, "unknown"), ErrApiKeyNotFound))
+
+	assert.NoError(t, u.RevokeKey(ctx, created.ID))
+	_, err = u.Authenticate(ctx, created.Key)
+	assert.True(t, errors.Is(err, ErrApiKeyInvalid))
+}
+
+func TestApiKeyRequestValidation(t *testing.T) {
+	u, _ := newTestApiKeyUsecase()
+
+	requests := []model.ApiKeyRequest{
+		{Scopes: []string{"read"}},
+		{Name: "billing"},
+		{Name: "billing", Scopes: []string{"write"}},
+		{Name: "billing", Scopes: []string{"read"}, Olts: []string{" "}},
+		{Name: "billing", Scopes: []string{"read"}, Boards: []int{3}},
+		{Name: "billing", Scopes: []string{"read"}, Pons: []string{"2/9"}},
+	}
+	for _, request := range requests {
+		_, err := u.CreateKey(context.Background(), "noc", request)
+		assert.True(t, errors.Is(err, ErrInvalidApiKeyRequest), "%+v: %v", request, err)
+	}
+}
+
+func TestApiKeyScopesAndRestrictions(t *testing.T) {
+	admin := model.ApiKey{Scopes: []string{"admin"}}
+	assert.True(t, ApiKeyHasScope(admin, ApiKeyScopeRead))
+	assert.True(t, ApiKeyHasScope(admin, ApiKeyScopeProvision))
+
+	reader := model.ApiKey{Scopes: []string{"read"}}
+	assert.True(t, ApiKeyHasScope(reader, ApiKeyScopeRead))
+	assert.False(t, ApiKeyHasScope(reader, ApiKeyScopeProvision))
+
+	tests := []struct {
+		apiKey  model.ApiKey
+		olt     string
+		boardID int
+		ponID   int
+		allowed bool
+	}{
+		{reader, "", 0, 0, true},
+		{model.ApiKey{Olts: []string{"OLT-1"}}, "", 0, 0, true},
+		{model.ApiKey{Olts: []string{"olt-1"}}, "olt-2", 0, 0, false},
+		{model.ApiKey{Olts: []string{"olt-2"}}, "", 1, 1, false},
+		{model.ApiKey{Boards: []int{2}}, "", 2, 0, true},
+		{model.ApiKey{Boards: []int{2}}, "", 1, 3, false},
+		{model.ApiKey{Boards: []int{2}}, "", 0, 0, false}, // A restricted key must select a board
+		{model.ApiKey{Pons: []string{"2/7"}}, "", 2, 7, true},
+		{model.ApiKey{Pons: []string{"2/7"}}, "", 2, 0, false},
+		{model.ApiKey{Boards: []int{1}, Pons: []string{"2/7"}}, "", 2, 7, false},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.allowed, ApiKeyAllows(test.apiKey, "olt-1", test.olt, test.boardID, test.ponID), "%+v", test)
+	}
+}
@@ -0,0 +1,198 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/model"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/repository"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	AuditResultSuccess = "success"
+	AuditResultFailure = "failure"
+
+	auditDefaultLimit = 100
+	auditMaxLimit     = 1000
+)
+
+var (
+	ErrAuditNotEnabled    = errors.New("audit log is not enabled")
+	ErrInvalidAuditFilter = errors.New("invalid audit filter")
+)
+
+// AuditFilter selects audit records, zero values select any record
+type AuditFilter struct {
+	Actor  string // Actor, X-Actor or key ID
+	Method string
+	Path   string // Prefix of the request path
+	Board  int
+	PON    int
+	OnuID  int
+	Result string // success or failure
+	From   time.Time
+	To     time.Time
+	Limit  int // Newest records returned by Query, export returns every record
+}
+
+type AuditUseCaseInterface interface {
+	Record(record model.AuditRecord) error
+	Query(ctx context.Context, filter AuditFilter) ([]model.AuditRecord, error)
+	Export(ctx context.Context, filter AuditFilter, w io.Writer) error
+}
+
+type auditUsecase struct {
+	auditRepository repository.AuditRepositoryInterface
+}
+
+// NewAuditUsecase returns the usecase recording and querying the audit log, auditRepository is nil when disabled
+func NewAuditUsecase(auditRepository repository.AuditRepositoryInterface) AuditUseCaseInterface {
+	return &auditUsecase{auditRepository: auditRepository}
+}
+
+// Record appends a record to the audit log, the result is taken from the status when empty
+func (u *auditUsecase) Record(record model.AuditRecord) error {
+
+	if u.auditRepository == nil {
+		return ErrAuditNotEnabled
+	}
+
+	if record.Result == "" {
+		record.Result = AuditResultSuccess
+		if record.Status >= http.StatusBadRequest {
+			record.Result = AuditResultFailure
+		}
+	}
+
+	_, err := u.auditRepository.Append(record)
+	return err
+}
+
+// Query returns the newest records matching the filter, newest first
+func (u *auditUsecase) Query(ctx context.Context, filter AuditFilter) ([]model.AuditRecord, error) {
+
+	filter, err := u.validateFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep the last matches in a ring, the log is read from the oldest record
+	ring := make([]model.AuditRecord, filter.Limit)
+	matched := 0
+	err = u.auditRepository.Scan(func(record model.AuditRecord) bool {
+		if matchAuditRecord(record, filter) {
+			ring[matched%filter.Limit] = record
+			matched++
+		}
+		return ctx.Err() == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	count := matched
+	if count > filter.Limit {
+		count = filter.Limit
+	}
+	records := make([]model.AuditRecord, 0, count)
+	for i := 1; i <= count; i++ {
+		records = append(records, ring[(matched-i)%filter.Limit])
+	}
+
+	return records, nil
+}
+
+// Export writes every record matching the filter as JSON lines, oldest first
+func (u *auditUsecase) Export(ctx context.Context, filter AuditFilter, w io.Writer) error {
+
+	filter, err := u.validateFilter(filter)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(w)
+	var writeErr error
+	err = u.auditRepository.Scan(func(record model.AuditRecord) bool {
+		if matchAuditRecord(record, filter) {
+			writeErr = encoder.Encode(record)
+		}
+		return writeErr == nil && ctx.Err() == nil
+	})
+	if err != nil {
+		return err
+	}
+	if writeErr != nil {
+		return writeErr
+	}
+
+	return ctx.Err()
+}
+
+// validateFilter is a method to validate and normalize an audit filter
+func (u *auditUsecase) validateFilter(filter AuditFilter) (AuditFilter, error) {
+
+	if u.auditRepository == nil {
+		return filter, ErrAuditNotEnabled
+	}
+
+	filter.Method = strings.ToUpper(filter.Method)
+	filter.Result = strings.ToLower(filter.Result)
+	if filter.Result != "" && filter.Result != AuditResultSuccess && filter.Result != AuditResultFailure {
+		return filter, fmt.Errorf("%w: 'result' must be success or failure", ErrInvalidAuditFilter)
+	}
+
+	if !filter.From.IsZero() && !filter.To.IsZero() && filter.To.Before(filter.From) {
+		return filter, fmt.Errorf("%w: 'to' must not be before 'from'", ErrInvalidAuditFilter)
+	}
+
+	if filter.Limit == 0 {
+		filter.Limit = auditDefaultLimit
+	}
+	if filter.Limit < 1 || filter.Limit > auditMaxLimit {
+		return filter, fmt.Errorf("%w: 'limit' must be between 1 and %d", ErrInvalidAuditFilter, auditMaxLimit)
+	}
+
+	return filter, nil
+}
+
+// matchAuditRecord is a function to check if a record matches every field of a filter
+func matchAuditRecord(record model.AuditRecord, filter AuditFilter) bool {
+
+	if filter.Actor != "" && record.Actor != filter.Actor && record.ClaimedActor != filter.Actor &&
+		record.KeyID != filter.Actor {
+		return false
+	}
+	if filter.Method != "" && record.Method != filter.Method {
+		return false
+	}
+	if filter.Path != "" && !strings.HasPrefix(record.Path, filter.Path) {
+		return false
+	}
+	if (filter.Board != 0 && record.Board != filter.Board) || (filter.PON != 0 && record.PON != filter.PON) ||
+		(filter.OnuID != 0 && record.OnuID != filter.OnuID) {
+		return false
+	}
+	if filter.Result != "" && record.Result != filter.Result {
+		return false
+	}
+
+	if !filter.From.IsZero() || !filter.To.IsZero() {
+		recordTime, err := time.Parse(time.RFC3339Nano, record.Time)
+		if err != nil {
+			return false
+		}
+		if (!filter.From.IsZero() && recordTime.Before(filter.From)) || (!filter.To.IsZero() && recordTime.After(filter.To)) {
+			return false
+		}
+	}
+
+	return true
+}
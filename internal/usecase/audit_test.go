@@ -0,0 +1,143 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeAuditRepo keeps audit records in memory
+type fakeAuditRepo struct {
+	records []model.AuditRecord
+}
+
+func (f *fakeAuditRepo) Append(record model.AuditRecord) (model.AuditRecord, error) {
+	record.ID = int64(len(f.records) + 1)
+	f.records = append(f.records, record)
+	return record, nil
+}
+
+func (f *fakeAuditRepo) Scan(fn func(record model.AuditRecord) bool) error {
+	for _, record := range f.records {
+		if !fn(record) {
+			return nil
+		}
+	}
+	return nil
+}
+
+func (f *fakeAuditRepo) Close() error {
+	return nil
+}
+
+func newTestAuditUsecase(t *testing.T) AuditUseCaseInterface {
+	u := NewAuditUsecase(&fakeAuditRepo{})
+
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	records := []model.AuditRecord{
+		{Actor: "noc (alice)", ClaimedActor: "alice", KeyID: "noc", Method: http.MethodPost, Path: "/api/v1/board/1/pon/1/onu", Board: 1, PON: 1,
+			OnuID: 5, Status: http.StatusCreated},
+		{KeyID: "jwt:bob", Method: http.MethodDelete, Path: "/api/v1/board/1/pon/2/onu/7", Board: 1, PON: 2, OnuID: 7,
+			Status: http.StatusForbidden},
+		{Actor: "noc (alice)", ClaimedActor: "alice", KeyID: "noc", Method: http.MethodPost, Path: "/api/v1/cache/refresh",
+			Status: http.StatusConflict},
+		{KeyID: "admin", Method: http.MethodDelete, Path: "/api/v1/cache", Status: http.StatusOK},
+	}
+	for i, record := range records {
+		record.Time = start.Add(time.Duration(i) * time.Hour).Format(time.RFC3339Nano)
+		require.NoError(t, u.Record(record))
+	}
+
+	return u
+}
+
+func TestAuditRecord(t *testing.T) {
+	u := newTestAuditUsecase(t)
+
+	records, err := u.Query(context.Background(), AuditFilter{})
+	require.NoError(t, err)
+	require.Len(t, records, 4)
+
+	// Newest first, the result is taken from the status
+	assert.Equal(t, []int64{4, 3, 2, 1}, []int64{records[0].ID, records[1].ID, records[2].ID, records[3].ID})
+	assert.Equal(t, AuditResultSuccess, records[0].Result)
+	assert.Equal(t, AuditResultFailure, records[1].Result)
+	assert.Equal(t, AuditResultFailure, records[2].Result)
+	assert.Equal(t, AuditResultSuccess, records[3].Result)
+
+	disabled := NewAuditUsecase(nil)
+	assert.True(t, errors.Is(disabled.Record(model.AuditRecord{}), ErrAuditNotEnabled))
+	_, err = disabled.Query(context.Background(), AuditFilter{})
+	assert.True(t, errors.Is(err, ErrAuditNotEnabled))
+}
+
+func TestAuditQuery(t *testing.T) {
+	u := newTestAuditUsecase(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		filter AuditFilter
+		ids    []int64
+	}{
+		{"actor", AuditFilter{Actor: "alice"}, []int64{3, 1}},
+		{"key ID", AuditFilter{Actor: "jwt:bob"}, []int64{2}},
+		{"authenticated actor", AuditFilter{Actor: "noc (alice)"}, []int64{3, 1}},
+		{"method", AuditFilter{Method: "delete"}, []int64{4, 2}},
+		{"path prefix", AuditFilter{Path: "/api/v1/cache"}, []int64{4, 3}},
+		{"target", AuditFilter{Board: 1, PON: 2}, []int64{2}},
+		{"onu", AuditFilter{OnuID: 5}, []int64{1}},
+		{"result", AuditFilter{Result: "failure"}, []int64{3, 2}},
+		{"time", AuditFilter{
+			From: time.Date(2024, 5, 1, 11, 0, 0, 0, time.UTC),
+			To:   time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		}, []int64{3, 2}},
+		{"limit", AuditFilter{Limit: 3}, []int64{4, 3, 2}},
+	}
+	for _, test := range tests {
+		records, err := u.Query(ctx, test.filter)
+		require.NoError(t, err, test.name)
+		ids := make([]int64, 0, len(records))
+		for _, record := range records {
+			ids = append(ids, record.ID)
+		}
+		assert.Equal(t, test.ids, ids, test.name)
+	}
+
+	invalid := []AuditFilter{
+		{Result: "done"},
+		{Limit: 1001},
+		{From: time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC), To: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, filter := range invalid {
+		_, err := u.Query(ctx, filter)
+		assert.True(t, errors.Is(err, ErrInvalidAuditFilter), "%+v", filter)
+	}
+}
+
+func TestAuditExport(t *testing.T) {
+	u := newTestAuditUsecase(t)
+
+	// Oldest first, the limit doesn't apply to the export
+	var buffer bytes.Buffer
+	require.NoError(t, u.Export(context.Background(), AuditFilter{Actor: "alice", Limit: 1}, &buffer))
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	require.Len(t, lines, 2)
+
+	var record model.AuditRecord
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &record))
+	assert.Equal(t, int64(1), record.ID)
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &record))
+	assert.Equal(t, int64(3), record.ID)
+
+	assert.True(t, errors.Is(NewAuditUsecase(nil).Export(context.Background(), AuditFilter{}, &buffer),
+		ErrAuditNotEnabled))
+}
@@ -0,0 +1,273 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/config"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/model"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/repository"
+	"github.com/rs/zerolog/log"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	cacheRefreshKey = "cache_refresh" // Redis hash of the last refresh time of each cache key
+
+	CacheScopeAll   = "all"   // Every PON of every board
+	CacheScopeOlt   = "olt"   // Every PON of the OLT, this app serves a single OLT
+	CacheScopeBoard = "board" // Every PON of a board
+	CacheScopePon   = "pon"   // A single PON
+)
+
+// onuCacheTypes are the cached data of a PON by Redis key suffix, refresh reads them again from SNMP and overwrites
+// the key, the key isn't written when it fails
+var onuCacheTypes = []struct {
+	suffix  string
+	name    string
+	refresh func(onuUsecase OnuUseCaseInterface, ctx context.Context, boardID, ponID int) error
+}{
+	{"", "onu_list", OnuUseCaseInterface.RefreshByBoardIDAndPonID},
+	{"_empty_onu_id", "empty_onu_id", OnuUseCaseInterface.UpdateEmptyOnuID},
+	{"_firmware", "firmware", OnuUseCaseInterface.RefreshFirmwareByBoardIDAndPonID},
+}
+
+var (
+	ErrInvalidCacheRequest    = errors.New("invalid cache request")
+	ErrCacheOltNotFound       = errors.New("olt not found")
+	ErrCacheRefreshInProgress = errors.New("cache refresh in progress")
+)
+
+// CacheScope selects the PON of a cache request, the scope is taken from the most specific ID when empty
+type CacheScope struct {
+	Scope string
+	Olt   string
+	Board int
+	PON   int
+}
+
+type CacheUseCaseInterface interface {
+	Refresh(ctx context.Context, scope CacheScope) (model.CacheRefreshResult, error)
+	Purge(ctx context.Context, scope CacheScope) (model.CachePurgeResult, error)
+	GetStatus(ctx context.Context, scope CacheScope) (model.CacheStatus, error)
+}
+
+type cacheUsecase struct {
+	onuUsecase      OnuUseCaseInterface
+	cacheRepository repository.CacheRepositoryInterface
+	cfg             *config.Config
+	now             func() time.Time
+	refreshMu       sync.Mutex // Allows a single refresh at a time, a refresh of all PON walks the OLT many times
+}
+
+// NewCacheUsecase returns the usecase refreshing, purging and reporting the cached ONU data of each PON
+func NewCacheUsecase(
+	onuUsecase OnuUseCaseInterface, cacheRepository repository.CacheRepositoryInterface, cfg *config.Config,
+) CacheUseCaseInterface {
+	return &cacheUsecase{
+		onuUsecase:      onuUsecase,
+		cacheRepository: cacheRepository,
+		cfg:             cfg,
+		now:             time.Now,
+	}
+}
+
+// Refresh walks the PON of the scope again and overwrites their ONU list, empty ONU ID and firmware keys,
+// a PON that fails keeps its cached data, it is reported in the result and doesn't stop the others
+func (u *cacheUsecase) Refresh(ctx context.Context, scope CacheScope) (model.CacheRefreshResult, error) {
+
+	scope, pons, err := u.resolveScope(scope)
+	if err != nil {
+		return model.CacheRefreshResult{}, err
+	}
+
+	if !u.refreshMu.TryLock() {
+		return model.CacheRefreshResult{}, ErrCacheRefreshInProgress
+	}
+	defer u.refreshMu.Unlock()
+
+	result := model.CacheRefreshResult{
+		Olt:     u.cfg.StreamCfg.OltName,
+		Scope:   scope.Scope,
+		Entries: make([]model.CacheEntry, 0),
+		Errors:  make([]model.CacheRefreshError, 0),
+	}
+
+	refreshes := make(map[string]string)
+	for _, pon := range pons {
+		boardID, ponID := pon[0], pon[1]
+
+		// Only the keys that were written have a new refresh time
+		refreshedKeys, err := u.refreshPon(ctx, boardID, ponID)
+		refreshedAt := u.now().Format(time.RFC3339)
+		for _, key := range refreshedKeys {
+			refreshes[key] = refreshedAt
+		}
+
+		if err != nil {
+			log.Error().Msg("Failed to refresh cache of Board ID: " + strconv.Itoa(boardID) + " and PON ID: " +
+				strconv.Itoa(ponID) + ": " + err.Error()) // Log error message to logger
+			result.Failed++
+			result.Errors = append(result.Errors, model.CacheRefreshError{Board: boardID, PON: ponID, Error: err.Error()})
+			continue
+		}
+
+		result.Refreshed++
+	}
+
+	if err := u.cacheRepository.SetCacheRefreshes(ctx, cacheRefreshKey, refreshes); err != nil {
+		return model.CacheRefreshResult{}, err
+	}
+
+	status, err := u.getStatus(ctx, pons)
+	if err != nil {
+		return model.CacheRefreshResult{}, err
+	}
+	result.Entries = status.Entries
+
+	return result, nil
+}
+
+// refreshPon is a method to read the cached data of a PON again from SNMP and overwrite its keys, the cached data
+// is kept until the new data is read, the keys written before a failure are returned with its error
+func (u *cacheUsecase) refreshPon(ctx context.Context, boardID, ponID int) ([]string, error) {
+
+	refreshedKeys := make([]string, 0, len(onuCacheTypes))
+	for _, cacheType := range onuCacheTypes {
+		if err := cacheType.refresh(u.onuUsecase, ctx, boardID, ponID); err != nil {
+			return refreshedKeys, fmt.Errorf("%s: %w", cacheType.name, err)
+		}
+		refreshedKeys = append(refreshedKeys, getCacheKey(boardID, ponID)+cacheType.suffix)
+	}
+
+	return refreshedKeys, nil
+}
+
+// Purge deletes the cached data of the PON of the scope, it is read again from SNMP on the next request
+func (u *cacheUsecase) Purge(ctx context.Context, scope CacheScope) (model.CachePurgeResult, error) {
+
+	scope, pons, err := u.resolveScope(scope)
+	if err != nil {
+		return model.CachePurgeResult{}, err
+	}
+
+	keys := make([]string, 0, len(pons)*len(onuCacheTypes))
+	for _, pon := range pons {
+		for _, cacheType := range onuCacheTypes {
+			keys = append(keys, getCacheKey(pon[0], pon[1])+cacheType.suffix)
+		}
+	}
+
+	deleted, err := u.cacheRepository.DeleteKeys(ctx, keys...)
+	if err != nil {
+		return model.CachePurgeResult{}, err
+	}
+
+	log.Info().Msg("Purged " + strconv.FormatInt(deleted, 10) + " cache keys of scope " + scope.Scope)
+
+	return model.CachePurgeResult{Olt: u.cfg.StreamCfg.OltName, Scope: scope.Scope, Deleted: deleted}, nil
+}
+
+// GetStatus returns the cache keys of the PON of the scope with their time to live and last refresh time
+func (u *cacheUsecase) GetStatus(ctx context.Context, scope CacheScope) (model.CacheStatus, error) {
+
+	_, pons, err := u.resolveScope(scope)
+	if err != nil {
+		return model.CacheStatus{}, err
+	}
+
+	return u.getStatus(ctx, pons)
+}
+
+// getStatus is a method to get the cache entries of PON, ordered by board, PON and cache type
+func (u *cacheUsecase) getStatus(ctx context.Context, pons [][2]int) (model.CacheStatus, error) {
+
+	entries := make([]model.CacheEntry, 0, len(pons)*len(onuCacheTypes))
+	keys := make([]string, 0, len(pons)*len(onuCacheTypes))
+	for _, pon := range pons {
+		for _, cacheType := range onuCacheTypes {
+			key := getCacheKey(pon[0], pon[1]) + cacheType.suffix
+			keys = append(keys, key)
+			entries = append(entries, model.CacheEntry{Key: key, Type: cacheType.name, Board: pon[0], PON: pon[1]})
+		}
+	}
+
+	ttls, err := u.cacheRepository.GetKeyTTLs(ctx, keys...)
+	if err != nil {
+		return model.CacheStatus{}, err
+	}
+
+	refreshes, err := u.cacheRepository.GetCacheRefreshes(ctx, cacheRefreshKey)
+	if err != nil {
+		return model.CacheStatus{}, err
+	}
+
+	for i := range entries {
+		if ttl, ok := ttls[entries[i].Key]; ok {
+			entries[i].Cached = true
+			if ttl >= 0 {
+				ttlSeconds := int64(ttl / time.Second)
+				entries[i].TTLSeconds = &ttlSeconds
+			}
+		}
+		entries[i].LastRefresh = refreshes[entries[i].Key]
+	}
+
+	return model.CacheStatus{Olt: u.cfg.StreamCfg.OltName, Entries: entries}, nil
+}
+
+// resolveScope is a method to validate a scope and get its board and PON IDs
+func (u *cacheUsecase) resolveScope(scope CacheScope) (CacheScope, [][2]int, error) {
+
+	if scope.Scope == "" {
+		switch {
+		case scope.PON != 0:
+			scope.Scope = CacheScopePon
+		case scope.Board != 0:
+			scope.Scope = CacheScopeBoard
+		case scope.Olt != "":
+			scope.Scope = CacheScopeOlt
+		default:
+			scope.Scope = CacheScopeAll
+		}
+	}
+
+	switch scope.Scope {
+	case CacheScopeAll, CacheScopeOlt:
+		if scope.Board != 0 || scope.PON != 0 {
+			return scope, nil, fmt.Errorf("%w: 'board' and 'pon' are not allowed with scope %s",
+				ErrInvalidCacheRequest, scope.Scope)
+		}
+		if scope.Scope == CacheScopeOlt && scope.Olt != "" && scope.Olt != u.cfg.StreamCfg.OltName {
+			return scope, nil, fmt.Errorf("%w: %s", ErrCacheOltNotFound, scope.Olt)
+		}
+	case CacheScopeBoard:
+		if scope.Board < 1 || scope.Board > maxBoardID || scope.PON != 0 {
+			return scope, nil, fmt.Errorf("%w: scope board needs 'board' between 1 and %d and no 'pon'",
+				ErrInvalidCacheRequest, maxBoardID)
+		}
+	case CacheScopePon:
+		if scope.Board < 1 || scope.Board > maxBoardID || scope.PON < 1 || scope.PON > maxPonID {
+			return scope, nil, fmt.Errorf("%w: scope pon needs 'board' between 1 and %d and 'pon' between 1 and %d",
+				ErrInvalidCacheRequest, maxBoardID, maxPonID)
+		}
+	default:
+		return scope, nil, fmt.Errorf("%w: 'scope' must be all, olt, board or pon", ErrInvalidCacheRequest)
+	}
+
+	pons := make([][2]int, 0, maxBoardID*maxPonID)
+	for _, boardID := range selectedIDs(scope.Board, maxBoardID) {
+		for _, ponID := range selectedIDs(scope.PON, maxPonID) {
+			pons = append(pons, [2]int{boardID, ponID})
+		}
+	}
+
+	return scope, pons, nil
+}
+
+// getCacheKey is a function to get the Redis key of the cached ONU list of a PON, other cached data add a suffix
+func getCacheKey(boardID, ponID int) string {
+	return "board_" + strconv.Itoa(boardID) + "_pon_" + strconv.Itoa(ponID)
+}
@@ -0,0 +1,209 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// fakeCacheRepo is an in-memory implementation of CacheRepositoryInterface over the keys of a fakeOnuRedisRepo
+type fakeCacheRepo struct {
+	onu       *fakeOnuRedisRepo
+	refreshes map[string]map[string]string
+}
+
+func newFakeCacheRepo(onu *fakeOnuRedisRepo) *fakeCacheRepo {
+	return &fakeCacheRepo{onu: onu, refreshes: make(map[string]map[string]string)}
+}
+
+// GetKeyTTLs returns 5 minutes for every cached ONU ID, ONU list and firmware list key
+func (f *fakeCacheRepo) GetKeyTTLs(_ context.Context, keys ...string) (map[string]time.Duration, error) {
+	ttls := make(map[string]time.Duration)
+	for _, key := range keys {
+		_, okOnuID := f.onu.onuID[key]
+		_, okOnuInfo := f.onu.onuInfo[key]
+		_, okFirmware := f.onu.firmware[key]
+		if okOnuID || okOnuInfo || okFirmware {
+			ttls[key] = 300 * time.Second
+		}
+	}
+	return ttls, nil
+}
+
+func (f *fakeCacheRepo) DeleteKeys(_ context.Context, keys ...string) (int64, error) {
+	ttls, _ := f.GetKeyTTLs(context.Background(), keys...)
+	for _, key := range keys {
+		delete(f.onu.onuID, key)
+		delete(f.onu.onuInfo, key)
+		delete(f.onu.firmware, key)
+		f.onu.deleted = append(f.onu.deleted, key)
+	}
+	return int64(len(ttls)), nil
+}
+
+func (f *fakeCacheRepo) SetCacheRefreshes(_ context.Context, key string, refreshes map[string]string) error {
+	if f.refreshes[key] == nil {
+		f.refreshes[key] = make(map[string]string)
+	}
+	for field, refresh := range refreshes {
+		f.refreshes[key][field] = refresh
+	}
+	return nil
+}
+
+func (f *fakeCacheRepo) GetCacheRefreshes(_ context.Context, key string) (map[string]string, error) {
+	refreshes := make(map[string]string, len(f.refreshes[key]))
+	for field, refresh := range f.refreshes[key] {
+		refreshes[field] = refresh
+	}
+	return refreshes, nil
+}
+
+// fakeCacheSource caches ONU data of PON with a list like the ONU usecase, PON without a list fail and keep
+// their cached data, firmwareErrs fail the firmware refresh of a PON
+type fakeCacheSource struct {
+	fakeOnuListSource
+	redisRepo    *fakeOnuRedisRepo
+	firmwareErrs map[[2]int]error
+}
+
+func (f *fakeCacheSource) RefreshByBoardIDAndPonID(ctx context.Context, boardID, ponID int) error {
+	list, err := f.fakeOnuListSource.GetByBoardIDAndPonID(ctx, boardID, ponID)
+	if err != nil {
+		return err
+	}
+	return f.redisRepo.SaveONUInfoList(ctx, getCacheKey(boardID, ponID), 300, list)
+}
+
+func (f *fakeCacheSource) UpdateEmptyOnuID(ctx context.Context, boardID, ponID int) error {
+	return f.redisRepo.SetOnuIDCtx(ctx, getCacheKey(boardID, ponID)+"_empty_onu_id", 300, []model.OnuID{})
+}
+
+func (f *fakeCacheSource) RefreshFirmwareByBoardIDAndPonID(ctx context.Context, boardID, ponID int) error {
+	if err := f.firmwareErrs[[2]int{boardID, ponID}]; err != nil {
+		return err
+	}
+	return f.redisRepo.SaveOnuFirmwareList(ctx, getCacheKey(boardID, ponID)+"_firmware", 300, nil)
+}
+
+func newTestCacheUsecase() (*cacheUsecase, *fakeOnuRedisRepo) {
+	redisRepo := newFakeOnuRedisRepo()
+	source := &fakeCacheSource{redisRepo: redisRepo, firmwareErrs: make(map[[2]int]error), fakeOnuListSource: fakeOnuListSource{
+		lists: map[[2]int][]model.ONUInfoPerBoard{
+			{1, 1}: {{Board: 1, PON: 1, ID: 1, Status: "Online"}},
+			{1, 2}: {{Board: 1, PON: 2, ID: 1, Status: "LOS"}},
+		},
+	}}
+
+	cfg := newTestConfig()
+	cfg.StreamCfg.OltName = "olt-1"
+
+	u := NewCacheUsecase(source, newFakeCacheRepo(redisRepo), cfg).(*cacheUsecase)
+	u.now = func() time.Time { return time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC) }
+	return u, redisRepo
+}
+
+func TestCacheRefresh(t *testing.T) {
+	u, redisRepo := newTestCacheUsecase()
+	redisRepo.onuInfo["board_1_pon_1"] = []model.ONUInfoPerBoard{{Board: 1, PON: 1, ID: 1, Status: "Offline"}}
+
+	// The scope is taken from board and PON
+	result, err := u.Refresh(context.Background(), CacheScope{Board: 1, PON: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, CacheScopePon, result.Scope)
+	assert.Equal(t, 1, result.Refreshed)
+	assert.Equal(t, "Online", redisRepo.onuInfo["board_1_pon_1"][0].Status) // Rewritten, not served from cache
+	assert.Len(t, result.Entries, 3)
+	for _, entry := range result.Entries {
+		assert.True(t, entry.Cached, entry.Key)
+		assert.Equal(t, int64(300), *entry.TTLSeconds)
+		assert.Equal(t, "2024-05-01T10:00:00Z", entry.LastRefresh)
+	}
+
+	// PON that fail don't stop the others
+	result, err = u.Refresh(context.Background(), CacheScope{Scope: CacheScopeBoard, Board: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, result.Refreshed)
+	assert.Equal(t, 6, result.Failed)
+	assert.Equal(t, model.CacheRefreshError{Board: 1, PON: 3, Error: "onu_list: request timeout"}, result.Errors[0])
+	assert.Len(t, result.Entries, 24)
+
+	// A failed PON keeps its cached data and only the keys written before the failure have a new refresh time
+	u.now = func() time.Time { return time.Date(2024, 5, 1, 11, 0, 0, 0, time.UTC) }
+	source := u.onuUsecase.(*fakeCacheSource)
+	delete(source.lists, [2]int{1, 1})
+	source.firmwareErrs[[2]int{1, 2}] = errors.New("request timeout")
+	result, err = u.Refresh(context.Background(), CacheScope{Scope: CacheScopeBoard, Board: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, result.Refreshed)
+	assert.Equal(t, 8, result.Failed)
+	assert.Equal(t, model.CacheRefreshError{Board: 1, PON: 1, Error: "onu_list: request timeout"}, result.Errors[0])
+	assert.Equal(t, model.CacheRefreshError{Board: 1, PON: 2, Error: "firmware: request timeout"}, result.Errors[1])
+	assert.Equal(t, "Online", redisRepo.onuInfo["board_1_pon_1"][0].Status)
+	lastRefreshes := make(map[string]string)
+	for _, entry := range result.Entries {
+		lastRefreshes[entry.Key] = entry.LastRefresh
+	}
+	assert.Equal(t, "2024-05-01T10:00:00Z", lastRefreshes["board_1_pon_1"])
+	assert.Equal(t, "2024-05-01T10:00:00Z", lastRefreshes["board_1_pon_1_firmware"])
+	assert.Equal(t, "2024-05-01T11:00:00Z", lastRefreshes["board_1_pon_2"])
+	assert.Equal(t, "2024-05-01T11:00:00Z", lastRefreshes["board_1_pon_2_empty_onu_id"])
+	assert.Equal(t, "2024-05-01T10:00:00Z", lastRefreshes["board_1_pon_2_firmware"])
+
+	// A refresh is rejected while another is running
+	u.refreshMu.Lock()
+	_, err = u.Refresh(context.Background(), CacheScope{})
+	u.refreshMu.Unlock()
+	assert.True(t, errors.Is(err, ErrCacheRefreshInProgress))
+}
+
+func TestCacheScope(t *testing.T) {
+	u, _ := newTestCacheUsecase()
+
+	tests := []struct {
+		scope CacheScope
+		pons  int
+		err   error
+	}{
+		{CacheScope{}, 16, nil},
+		{CacheScope{Scope: CacheScopeOlt, Olt: "olt-1"}, 16, nil},
+		{CacheScope{Olt: "olt-2"}, 0, ErrCacheOltNotFound},
+		{CacheScope{Board: 2}, 8, nil},
+		{CacheScope{Scope: CacheScopeBoard, Board: 2, PON: 1}, 0, ErrInvalidCacheRequest},
+		{CacheScope{Scope: CacheScopePon, Board: 2}, 0, ErrInvalidCacheRequest},
+		{CacheScope{Scope: CacheScopeAll, Board: 1}, 0, ErrInvalidCacheRequest},
+		{CacheScope{Board: 3, PON: 1}, 0, ErrInvalidCacheRequest},
+		{CacheScope{Scope: "onu"}, 0, ErrInvalidCacheRequest},
+	}
+	for _, test := range tests {
+		_, pons, err := u.resolveScope(test.scope)
+		assert.True(t, errors.Is(err, test.err), "%+v: %v", test.scope, err)
+		assert.Len(t, pons, test.pons, "%+v", test.scope)
+	}
+}
+
+func TestCachePurgeAndStatus(t *testing.T) {
+	u, redisRepo := newTestCacheUsecase()
+	redisRepo.onuInfo["board_1_pon_1"] = []model.ONUInfoPerBoard{{Board: 1, PON: 1, ID: 1}}
+	redisRepo.onuID["board_1_pon_1_empty_onu_id"] = []model.OnuID{}
+	redisRepo.onuInfo["board_2_pon_8"] = []model.ONUInfoPerBoard{{Board: 2, PON: 8, ID: 1}}
+
+	status, err := u.GetStatus(context.Background(), CacheScope{Board: 1, PON: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, "olt-1", status.Olt)
+	assert.Equal(t, []model.CacheEntry{
+		{Key: "board_1_pon_1", Type: "onu_list", Board: 1, PON: 1, Cached: true, TTLSeconds: status.Entries[0].TTLSeconds},
+		{Key: "board_1_pon_1_empty_onu_id", Type: "empty_onu_id", Board: 1, PON: 1, Cached: true,
+			TTLSeconds: status.Entries[1].TTLSeconds},
+		{Key: "board_1_pon_1_firmware", Type: "firmware", Board: 1, PON: 1},
+	}, status.Entries)
+
+	result, err := u.Purge(context.Background(), CacheScope{Board: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, model.CachePurgeResult{Olt: "olt-1", Scope: CacheScopeBoard, Deleted: 2}, result)
+	assert.NotContains(t, redisRepo.onuInfo, "board_1_pon_1")
+	assert.Contains(t, redisRepo.onuInfo, "board_2_pon_8") // Out of scope
+}
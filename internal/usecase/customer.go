@@ -0,0 +1,405 @@
+package usecase
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/model"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/repository"
+	"github.com/rs/zerolog/log"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	onuCustomerKey            = "onu_customer" // Redis hash of customer metadata keyed by ONU serial number
+	onuCustomerImportMaxRows  = 20000          // Maximum number of rows of a CSV import
+	onuCustomerImportMaxError = 100            // Number of row errors reported by a CSV import
+)
+
+var (
+	ErrInvalidCustomerRequest = errors.New("invalid customer request")
+	ErrCustomerNotFound       = errors.New("customer not found")
+)
+
+// onuCustomerColumns maps accepted CSV header names to customer fields
+var onuCustomerColumns = map[string]string{
+	"serial_number": "serial_number",
+	"serial":        "serial_number",
+	"sn":            "serial_number",
+	"customer_id":   "customer_id",
+	"name":          "name",
+	"customer_name": "name",
+	"address":       "address",
+	"package":       "package",
+	"latitude":      "latitude",
+	"lat":           "latitude",
+	"longitude":     "longitude",
+	"lon":           "longitude",
+	"lng":           "longitude",
+}
+
+type CustomerUseCaseInterface interface {
+	GetCustomers(ctx context.Context) ([]model.OnuCustomer, error)
+	GetCustomer(ctx context.Context, serialNumber string) (model.OnuCustomer, error)
+	SaveCustomer(ctx context.Context, serialNumber string, request model.OnuCustomerRequest) (model.OnuCustomer, error)
+	DeleteCustomer(ctx context.Context, serialNumber string) error
+	ImportCustomers(ctx context.Context, reader io.Reader) (model.OnuCustomerImportResult, error)
+}
+
+type customerUsecase struct {
+	customerRepository repository.CustomerRepositoryInterface
+}
+
+// NewCustomerUsecase returns the usecase managing customer metadata linked to ONU serial numbers
+func NewCustomerUsecase(customerRepository repository.CustomerRepositoryInterface) CustomerUseCaseInterface {
+	return &customerUsecase{customerRepository: customerRepository}
+}
+
+// GetCustomers returns all customers sorted by serial number
+func (u *customerUsecase) GetCustomers(ctx context.Context) ([]model.OnuCustomer, error) {
+
+	customers, err := u.customerRepository.GetOnuCustomers(ctx, onuCustomerKey)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(customers, func(i, j int) bool { return customers[i].SerialNumber < customers[j].SerialNumber })
+
+	return customers, nil
+}
+
+// GetCustomer returns the customer of a serial number
+func (u *customerUsecase) GetCustomer(ctx context.Context, serialNumber string) (model.OnuCustomer, error) {
+
+	serialNumber, err := validateCustomerSerialNumber(serialNumber)
+	if err != nil {
+		return model.OnuCustomer{}, err
+	}
+
+	customers, err := u.customerRepository.GetOnuCustomersBySerial(ctx, onuCustomerKey, serialNumber)
+	if err != nil {
+		return model.OnuCustomer{}, err
+	}
+
+	customer, ok := customers[serialNumber]
+	if !ok {
+		return model.OnuCustomer{}, fmt.Errorf("%w: serial number %s", ErrCustomerNotFound, serialNumber)
+	}
+
+	return customer, nil
+}
+
+// SaveCustomer creates or replaces the customer of a serial number
+func (u *customerUsecase) SaveCustomer(
+	ctx context.Context, serialNumber string, request model.OnuCustomerRequest,
+) (model.OnuCustomer, error) {
+
+	customer, err := newOnuCustomer(serialNumber, request)
+	if err != nil {
+		return model.OnuCustomer{}, err
+	}
+
+	if err := u.customerRepository.SetOnuCustomers(ctx, onuCustomerKey, []model.OnuCustomer{customer}); err != nil {
+		return model.OnuCustomer{}, err
+	}
+
+	log.Info().Msg("Save customer of ONU serial number: " + customer.SerialNumber) // Log info message to logger
+
+	return customer, nil
+}
+
+// DeleteCustomer removes the customer of a serial number
+func (u *customerUsecase) DeleteCustomer(ctx context.Context, serialNumber string) error {
+
+	serialNumber, err := validateCustomerSerialNumber(serialNumber)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := u.customerRepository.DeleteOnuCustomer(ctx, onuCustomerKey, serialNumber)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return fmt.Errorf("%w: serial number %s", ErrCustomerNotFound, serialNumber)
+	}
+
+	log.Info().Msg("Delete customer of ONU serial number: " + serialNumber) // Log info message to logger
+
+	return nil
+}
+
+// ImportCustomers creates or replaces the customers of a CSV file with a header row, valid rows are imported
+// and invalid rows are reported
+func (u *customerUsecase) ImportCustomers(ctx context.Context, reader io.Reader) (model.OnuCustomerImportResult, error) {
+
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = -1 // Spreadsheets often drop trailing empty cells
+	csvReader.TrimLeadingSpace = true
+
+	header, err := csvReader.Read()
+	if err != nil {
+		return model.OnuCustomerImportResult{}, fmt.Errorf("%w: cannot read CSV header: %s",
+			ErrInvalidCustomerRequest, err.Error())
+	}
+
+	// Map column index to customer field, unknown columns are ignored
+	columns := make(map[int]string)
+	hasSerialNumber := false
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))) // Excel adds a BOM
+		if field, ok := onuCustomerColumns[name]; ok {
+			columns[i] = field
+			hasSerialNumber = hasSerialNumber || field == "serial_number"
+		}
+	}
+	if !hasSerialNumber {
+		return model.OnuCustomerImportResult{}, fmt.Errorf("%w: CSV header must contain a 'serial_number' column",
+			ErrInvalidCustomerRequest)
+	}
+
+	result := model.OnuCustomerImportResult{Errors: make([]model.OnuCustomerImportError, 0)}
+	customers := make(map[string]model.OnuCustomer) // The last row of a serial number wins
+	var serialNumbers []string
+
+	for row := 2; ; row++ {
+		record, err := csvReader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		// A malformed row is reported, a failing reader stops the import
+		var parseError *csv.ParseError
+		if err != nil && !errors.As(err, &parseError) {
+			return model.OnuCustomerImportResult{}, err
+		}
+		if row-1 > onuCustomerImportMaxRows {
+			return model.OnuCustomerImportResult{}, fmt.Errorf("%w: CSV must have at most %d rows",
+				ErrInvalidCustomerRequest, onuCustomerImportMaxRows)
+		}
+
+		var customer model.OnuCustomer
+		if err == nil {
+			customer, err = parseCustomerRecord(columns, record)
+		}
+		if err != nil {
+			result.Failed++
+			if len(result.Errors) < onuCustomerImportMaxError {
+				importError := model.OnuCustomerImportError{Row: row, Error: err.Error()}
+				if customer.SerialNumber != "" {
+					importError.SerialNumber = customer.SerialNumber
+				}
+				result.Errors = append(result.Errors, importError)
+			}
+			continue
+		}
+
+		if _, ok := customers[customer.SerialNumber]; !ok {
+			serialNumbers = append(serialNumbers, customer.SerialNumber)
+		}
+		customers[customer.SerialNumber] = customer
+	}
+
+	customerList := make([]model.OnuCustomer, 0, len(serialNumbers))
+	for _, serialNumber := range serialNumbers {
+		customerList = append(customerList, customers[serialNumber])
+	}
+
+	if err := u.customerRepository.SetOnuCustomers(ctx, onuCustomerKey, customerList); err != nil {
+		return model.OnuCustomerImportResult{}, err
+	}
+	result.Imported = len(customerList)
+
+	log.Info().Msgf("Import %d customers, %d rows failed", result.Imported, result.Failed) // Log info message to logger
+
+	return result, nil
+}
+
+// parseCustomerRecord is a function to convert a CSV record to a customer, the serial number is set
+// on validation errors when it could be read
+func parseCustomerRecord(columns map[int]string, record []string) (model.OnuCustomer, error) {
+
+	var serialNumber string
+	var request model.OnuCustomerRequest
+
+	for i, value := range record {
+		value = strings.TrimSpace(value)
+		switch columns[i] {
+		case "serial_number":
+			serialNumber = value
+		case "customer_id":
+			request.CustomerID = value
+		case "name":
+			request.Name = value
+		case "address":
+			request.Address = value
+		case "package":
+			request.Package = value
+		case "latitude", "longitude":
+			if value == "" {
+				continue
+			}
+			coordinate, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return model.OnuCustomer{SerialNumber: strings.ToUpper(serialNumber)},
+					fmt.Errorf("%w: '%s' must be a number", ErrInvalidCustomerRequest, columns[i])
+			}
+			if columns[i] == "latitude" {
+				request.Latitude = &coordinate
+			} else {
+				request.Longitude = &coordinate
+			}
+		}
+	}
+
+	customer, err := newOnuCustomer(serialNumber, request)
+	if err != nil {
+		return model.OnuCustomer{SerialNumber: strings.ToUpper(serialNumber)}, err
+	}
+
+	return customer, nil
+}
+
+// newOnuCustomer is a function to validate a customer request and create the customer of a serial number
+func newOnuCustomer(serialNumber string, request model.OnuCustomerRequest) (model.OnuCustomer, error) {
+
+	serialNumber, err := validateCustomerSerialNumber(serialNumber)
+	if err != nil {
+		return model.OnuCustomer{}, err
+	}
+
+	customer := model.OnuCustomer{
+		SerialNumber: serialNumber,
+		CustomerID:   strings.TrimSpace(request.CustomerID),
+		Name:         strings.TrimSpace(request.Name),
+		Address:      strings.TrimSpace(request.Address),
+		Package:      strings.TrimSpace(request.Package),
+		Latitude:     request.Latitude,
+		Longitude:    request.Longitude,
+		UpdatedAt:    time.Now().Format(time.RFC3339),
+	}
+
+	fields := []struct {
+		name      string
+		value     string
+		maxLength int
+	}{
+		{"customer_id", customer.CustomerID, 64},
+		{"name", customer.Name, 128},
+		{"address", customer.Address, 512},
+		{"package", customer.Package, 128},
+	}
+	for _, field := range fields {
+		if err := validateCustomerText(field.name, field.value, field.maxLength); err != nil {
+			return model.OnuCustomer{}, err
+		}
+	}
+
+	// Coordinates are WGS 84 decimal degrees and are set together
+	if (customer.Latitude == nil) != (customer.Longitude == nil) {
+		return model.OnuCustomer{}, fmt.Errorf("%w: 'latitude' and 'longitude' must be set together",
+			ErrInvalidCustomerRequest)
+	}
+	if customer.Latitude != nil && (*customer.Latitude < -90 || *customer.Latitude > 90) {
+		return model.OnuCustomer{}, fmt.Errorf("%w: 'latitude' must be between -90 and 90", ErrInvalidCustomerRequest)
+	}
+	if customer.Longitude != nil && (*customer.Longitude < -180 || *customer.Longitude > 180) {
+		return model.OnuCustomer{}, fmt.Errorf("%w: 'longitude' must be between -180 and 180",
+			ErrInvalidCustomerRequest)
+	}
+
+	return customer, nil
+}
+
+// validateCustomerSerialNumber is a function to validate and normalize an ONU serial number
+func validateCustomerSerialNumber(serialNumber string) (string, error) {
+
+	serialNumber = normalizeSerialNumber(serialNumber)
+	if serialNumber == "" {
+		return "", fmt.Errorf("%w: 'serial_number' is required", ErrInvalidCustomerRequest)
+	}
+	if len(serialNumber) > 32 {
+		return "", fmt.Errorf("%w: 'serial_number' must be at most 32 characters", ErrInvalidCustomerRequest)
+	}
+	for _, r := range serialNumber {
+		if r > unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-') {
+			return "", fmt.Errorf("%w: 'serial_number' must only contain letters, digits and '-'",
+				ErrInvalidCustomerRequest)
+		}
+	}
+
+	return serialNumber, nil
+}
+
+// validateCustomerText is a function to validate the length and characters of a customer text field,
+// unlike ONU names customer data may contain non-ASCII characters
+func validateCustomerText(field, value string, maxLength int) error {
+
+	if utf8.RuneCountInString(value) > maxLength {
+		return fmt.Errorf("%w: '%s' must be at most %d characters", ErrInvalidCustomerRequest, field, maxLength)
+	}
+
+	if !utf8.ValidString(value) {
+		return fmt.Errorf("%w: '%s' must be valid UTF-8", ErrInvalidCustomerRequest, field)
+	}
+	for _, r := range value {
+		if unicode.IsControl(r) {
+			return fmt.Errorf("%w: '%s' must not contain control characters", ErrInvalidCustomerRequest, field)
+		}
+	}
+
+	return nil
+}
+
+// setOnuInfoCustomers is a method to add the customer of each ONU of a list, the list is returned without
+// customers when they can't be read
+func (u *onuUsecase) setOnuInfoCustomers(ctx context.Context, onuInfoList []model.ONUInfoPerBoard) {
+
+	serialNumbers := make([]string, 0, len(onuInfoList))
+	for _, onuInfo := range onuInfoList {
+		if serialNumber := normalizeSerialNumber(onuInfo.SerialNumber); serialNumber != "" {
+			serialNumbers = append(serialNumbers, serialNumber)
+		}
+	}
+
+	customers, err := u.customerRepository.GetOnuCustomersBySerial(ctx, onuCustomerKey, serialNumbers...)
+	if err != nil {
+		log.Error().Msg("Failed to get ONU customers: " + err.Error()) // Log error message to logger
+		return
+	}
+
+	for i := range onuInfoList {
+		if customer, ok := customers[normalizeSerialNumber(onuInfoList[i].SerialNumber)]; ok {
+			onuInfoList[i].Customer = &customer
+		}
+	}
+}
+
+// getOnuCustomer is a method to get the customer of an ONU serial number, nil when there is none
+func (u *onuUsecase) getOnuCustomer(ctx context.Context, serialNumber string) *model.OnuCustomer {
+
+	serialNumber = normalizeSerialNumber(serialNumber)
+	if serialNumber == "" {
+		return nil
+	}
+
+	customers, err := u.customerRepository.GetOnuCustomersBySerial(ctx, onuCustomerKey, serialNumber)
+	if err != nil {
+		log.Error().Msg("Failed to get ONU customer: " + err.Error()) // Log error message to logger
+		return nil
+	}
+
+	customer, ok := customers[serialNumber]
+	if !ok {
+		return nil
+	}
+
+	return &customer
+}
@@ -0,0 +1,156 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/model"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+// fakeCustomerRepo is an in-memory implementation of CustomerRepositoryInterface
+type fakeCustomerRepo struct {
+	customers map[string]map[string]model.OnuCustomer
+}
+
+func newFakeCustomerRepo() *fakeCustomerRepo {
+	return &fakeCustomerRepo{customers: make(map[string]map[string]model.OnuCustomer)}
+}
+
+func (f *fakeCustomerRepo) SetOnuCustomers(_ context.Context, key string, customers []model.OnuCustomer) error {
+	if f.customers[key] == nil {
+		f.customers[key] = make(map[string]model.OnuCustomer)
+	}
+	for _, customer := range customers {
+		f.customers[key][customer.SerialNumber] = customer
+	}
+	return nil
+}
+
+func (f *fakeCustomerRepo) GetOnuCustomers(_ context.Context, key string) ([]model.OnuCustomer, error) {
+	customers := make([]model.OnuCustomer, 0, len(f.customers[key]))
+	for _, customer := range f.customers[key] {
+		customers = append(customers, customer)
+	}
+	return customers, nil
+}
+
+func (f *fakeCustomerRepo) GetOnuCustomersBySerial(
+	_ context.Context, key string, serialNumbers ...string,
+) (map[string]model.OnuCustomer, error) {
+	customers := make(map[string]model.OnuCustomer)
+	for _, serialNumber := range serialNumbers {
+		if customer, ok := f.customers[key][serialNumber]; ok {
+			customers[serialNumber] = customer
+		}
+	}
+	return customers, nil
+}
+
+func (f *fakeCustomerRepo) DeleteOnuCustomer(_ context.Context, key, serialNumber string) (bool, error) {
+	_, ok := f.customers[key][serialNumber]
+	delete(f.customers[key], serialNumber)
+	return ok, nil
+}
+
+func TestCustomerCRUD(t *testing.T) {
+	customerRepo := newFakeCustomerRepo()
+	u := NewCustomerUsecase(customerRepo)
+	ctx := context.Background()
+
+	latitude, longitude := -6.2088, 106.8456
+	customer, err := u.SaveCustomer(ctx, " ztegc0000001", model.OnuCustomerRequest{
+		CustomerID: "CUST-001", Name: "Budi Santoso", Address: "Jl. Merdeka 1, Bandung", Package: "50 Mbps",
+		Latitude: &latitude, Longitude: &longitude,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "ZTEGC0000001", customer.SerialNumber)
+	assert.NotEmpty(t, customer.UpdatedAt)
+
+	found, err := u.GetCustomer(ctx, "ZTEGC0000001")
+	assert.NoError(t, err)
+	assert.Equal(t, customer, found)
+
+	customers, err := u.GetCustomers(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []model.OnuCustomer{customer}, customers)
+
+	assert.NoError(t, u.DeleteCustomer(ctx, "ztegc0000001"))
+	_, err = u.GetCustomer(ctx, "ZTEGC0000001")
+	assert.True(t, errors.Is(err, ErrCustomerNotFound))
+	assert.True(t, errors.Is(u.DeleteCustomer(ctx, "ZTEGC0000001"), ErrCustomerNotFound))
+}
+
+func TestCustomerValidation(t *testing.T) {
+	u := NewCustomerUsecase(newFakeCustomerRepo())
+	ctx := context.Background()
+	latitude, longitude := -91.0, 106.8
+
+	tests := []struct {
+		name         string
+		serialNumber string
+		request      model.OnuCustomerRequest
+	}{
+		{"missing serial number", " ", model.OnuCustomerRequest{}},
+		{"invalid serial number", "ZTEG/0001", model.OnuCustomerRequest{}},
+		{"latitude without longitude", "ZTEGC0000001", model.OnuCustomerRequest{Latitude: &longitude}},
+		{"latitude out of range", "ZTEGC0000001", model.OnuCustomerRequest{Latitude: &latitude, Longitude: &longitude}},
+		{"control character", "ZTEGC0000001", model.OnuCustomerRequest{Address: "Jl. Merdeka\n1"}},
+		{"too long", "ZTEGC0000001", model.OnuCustomerRequest{CustomerID: strings.Repeat("1", 65)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := u.SaveCustomer(ctx, tt.serialNumber, tt.request)
+			assert.True(t, errors.Is(err, ErrInvalidCustomerRequest), err)
+		})
+	}
+}
+
+func TestImportCustomers(t *testing.T) {
+	customerRepo := newFakeCustomerRepo()
+	u := NewCustomerUsecase(customerRepo)
+	ctx := context.Background()
+
+	csv := "\ufeffSN,Customer_ID,Name,Address,Package,Lat,Lng,Notes\n" +
+		"ZTEGC0000001,CUST-001,Budi,\"Jl. Merdeka 1, Bandung\",50 Mbps,-6.2088,106.8456,ignored\n" +
+		"ZTEGC0000002,CUST-002,Siti\n" +
+		"ZTEGC0000003,CUST-003,Andi,,,north,106.8\n" +
+		",CUST-004,Dewi\n" +
+		"ztegc0000002,CUST-002,Siti Aminah\n"
+
+	result, err := u.ImportCustomers(ctx, strings.NewReader(csv))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, result.Imported)
+	assert.Equal(t, 2, result.Failed)
+	assert.Equal(t, 4, result.Errors[0].Row)
+	assert.Equal(t, "ZTEGC0000003", result.Errors[0].SerialNumber)
+	assert.Equal(t, 5, result.Errors[1].Row)
+
+	stored := customerRepo.customers[onuCustomerKey]
+	assert.Len(t, stored, 2)
+	assert.Equal(t, "Jl. Merdeka 1, Bandung", stored["ZTEGC0000001"].Address)
+	assert.Equal(t, 106.8456, *stored["ZTEGC0000001"].Longitude)
+	assert.Equal(t, "Siti Aminah", stored["ZTEGC0000002"].Name) // The last row of a serial number wins
+
+	_, err = u.ImportCustomers(ctx, strings.NewReader("customer_id,name\nCUST-001,Budi\n"))
+	assert.True(t, errors.Is(err, ErrInvalidCustomerRequest))
+}
+
+func TestSetOnuInfoCustomers(t *testing.T) {
+	customerRepo := newFakeCustomerRepo()
+	customer := model.OnuCustomer{SerialNumber: "ZTEGC0000001", CustomerID: "CUST-001"}
+	customerRepo.customers[onuCustomerKey] = map[string]model.OnuCustomer{"ZTEGC0000001": customer}
+	u := &onuUsecase{customerRepository: customerRepo, cfg: newTestConfig()}
+
+	onuInfoList := []model.ONUInfoPerBoard{
+		{Board: 1, PON: 1, ID: 1, SerialNumber: "ztegc0000001"},
+		{Board: 1, PON: 1, ID: 2, SerialNumber: "ZTEGC0000002"},
+	}
+	u.setOnuInfoCustomers(context.Background(), onuInfoList)
+
+	assert.Equal(t, &customer, onuInfoList[0].Customer)
+	assert.Nil(t, onuInfoList[1].Customer)
+	assert.Equal(t, &customer, u.getOnuCustomer(context.Background(), "ZTEGC0000001"))
+	assert.Nil(t, u.getOnuCustomer(context.Background(), ""))
+}
@@ -0,0 +1,101 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/config"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/model"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/pkg/jwt"
+	"github.com/rs/zerolog/log"
+	"strings"
+)
+
+const (
+	JwtRoleViewer   = "viewer"
+	JwtRoleOperator = "operator"
+	JwtRoleAdmin    = "admin"
+
+	jwtSource            = "jwt"
+	jwtDefaultRolesClaim = "roles"
+)
+
+// jwtRoleScopes are the API key scopes granted by each role
+var jwtRoleScopes = map[string]string{
+	JwtRoleViewer:   ApiKeyScopeRead,
+	JwtRoleOperator: ApiKeyScopeProvision,
+	JwtRoleAdmin:    ApiKeyScopeAdmin,
+}
+
+var ErrJwtInvalid = errors.New("invalid bearer token")
+
+type JwtAuthUseCaseInterface interface {
+	Authenticate(ctx context.Context, token string) (model.ApiKey, error)
+}
+
+type jwtAuthUsecase struct {
+	verifier   *jwt.Verifier
+	rolesClaim string
+	roles      map[string]string // Roles by value of the roles claim
+}
+
+// NewJwtAuthUsecase returns the usecase authenticating bearer tokens, the roles of a token grant the scopes of API keys
+func NewJwtAuthUsecase(verifier *jwt.Verifier, cfg *config.Config) JwtAuthUseCaseInterface {
+
+	rolesClaim := cfg.AuthCfg.Jwt.RolesClaim
+	if rolesClaim == "" {
+		rolesClaim = jwtDefaultRolesClaim
+	}
+
+	roles := make(map[string]string, len(jwtRoleScopes)+len(cfg.AuthCfg.Jwt.Roles))
+	for role := range jwtRoleScopes {
+		roles[role] = role
+	}
+	for _, roleCfg := range cfg.AuthCfg.Jwt.Roles {
+		role := strings.ToLower(roleCfg.Role)
+		if _, ok := jwtRoleScopes[role]; !ok || roleCfg.Value == "" {
+			log.Error().Msg("Skipping JWT role " + roleCfg.Value + " of config: role must be viewer, operator or admin")
+			continue
+		}
+		roles[roleCfg.Value] = role
+	}
+
+	return &jwtAuthUsecase{
+		verifier:   verifier,
+		rolesClaim: rolesClaim,
+		roles:      roles,
+	}
+}
+
+// Authenticate verifies a bearer token and returns its subject with the scopes of its roles,
+// a token without a known role has no scope
+func (u *jwtAuthUsecase) Authenticate(ctx context.Context, token string) (model.ApiKey, error) {
+
+	claims, err := u.verifier.Verify(ctx, token)
+	if errors.Is(err, jwt.ErrKeySetUnavailable) {
+		return model.ApiKey{}, err
+	}
+	if err != nil {
+		return model.ApiKey{}, fmt.Errorf("%w: %v", ErrJwtInvalid, err)
+	}
+
+	subject := claims.String("sub")
+	if subject == "" {
+		return model.ApiKey{}, fmt.Errorf("%w: 'sub' claim is required", ErrJwtInvalid)
+	}
+
+	identity := model.ApiKey{ID: jwtSource + ":" + subject, Name: claims.String("name"), Source: jwtSource}
+	if identity.Name == "" {
+		identity.Name = claims.String("preferred_username")
+	}
+
+	for _, value := range claims.Strings(u.rolesClaim) {
+		role, ok := u.roles[value]
+		if !ok || ApiKeyHasScope(identity, jwtRoleScopes[role]) {
+			continue
+		}
+		identity.Scopes = append(identity.Scopes, jwtRoleScopes[role])
+	}
+
+	return identity, nil
+}
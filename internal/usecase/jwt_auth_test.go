@@ -0,0 +1,70 @@
+package usecase
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/config"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/pkg/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+var testJwtSecret = []byte("0123456789abcdef0123456789abcdef")
+
+// signTestJwt returns an HS256 token of claims signed with testJwtSecret, it expires in an hour
+func signTestJwt(t *testing.T, claims map[string]interface{}) string {
+	claims["exp"] = time.Now().Add(time.Hour).Unix()
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+	signed := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`)) + "." +
+		base64.RawURLEncoding.EncodeToString(payload)
+
+	mac := hmac.New(sha256.New, testJwtSecret)
+	mac.Write([]byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestJwtAuthenticate(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.AuthCfg.Jwt.RolesClaim = "realm_access.roles"
+	cfg.AuthCfg.Jwt.Roles = []config.JwtRoleConfig{
+		{Value: "noc-team", Role: "Operator"},
+		{Value: "interns", Role: "guest"}, // Skipped, not a role
+	}
+	verifier := jwt.NewVerifier(jwt.NewStaticKeySet(map[string]interface{}{"": testJwtSecret}), "", "olt-api", 0)
+	u := NewJwtAuthUsecase(verifier, cfg)
+	ctx := context.Background()
+
+	tests := []struct {
+		roles  []string
+		scopes []string
+	}{
+		{[]string{"viewer"}, []string{"read"}},
+		{[]string{"noc-team", "viewer"}, []string{"provision"}}, // read is included in provision
+		{[]string{"viewer", "admin"}, []string{"read", "admin"}},
+		{[]string{"interns", "Admin"}, nil}, // Values of the claim are case-sensitive
+	}
+	for _, test := range tests {
+		identity, err := u.Authenticate(ctx, signTestJwt(t, map[string]interface{}{
+			"sub": "alice", "aud": "olt-api", "preferred_username": "Alice",
+			"realm_access": map[string]interface{}{"roles": test.roles},
+		}))
+		assert.NoError(t, err)
+		assert.Equal(t, "jwt:alice", identity.ID)
+		assert.Equal(t, "Alice", identity.Name)
+		assert.Equal(t, "jwt", identity.Source)
+		assert.Equal(t, test.scopes, identity.Scopes, "%v", test.roles)
+	}
+
+	_, err := u.Authenticate(ctx, signTestJwt(t, map[string]interface{}{"aud": "olt-api"}))
+	assert.True(t, errors.Is(err, ErrJwtInvalid), err)
+
+	_, err = u.Authenticate(ctx, signTestJwt(t, map[string]interface{}{"sub": "alice", "aud": "portal"}))
+	assert.True(t, errors.Is(err, ErrJwtInvalid), err)
+}
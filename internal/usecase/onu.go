@@ -12,11 +12,13 @@ import (
 	"github.com/rs/zerolog/log"
 	"sort"
 	"strconv"
+	"sync"
 	"time"
 )
 
 type OnuUseCaseInterface interface {
 	GetByBoardIDAndPonID(ctx context.Context, boardID, ponID int) ([]model.ONUInfoPerBoard, error)
+	RefreshByBoardIDAndPonID(ctx context.Context, boardID, ponID int) error
 	GetByBoardIDPonIDAndOnuID(boardID, ponID, onuID int) (model.ONUCustomerInfo, error)
 	GetEmptyOnuID(ctx context.Context, boardID, ponID int) ([]model.OnuID, error)
 	GetOnuIDAndSerialNumber(boardID, ponID int) ([]model.OnuSerialNumber, error)
@@ -24,22 +26,54 @@ type OnuUseCaseInterface interface {
 	GetByBoardIDAndPonIDWithPagination(boardID, ponID, page, pageSize int) (
 		[]model.ONUInfoPerBoard, int,
 	)
+	GetOnuUniInfo(boardID, ponID, onuID int) (model.OnuUniInfo, error)
+	GetFirmwareByBoardIDAndPonID(ctx context.Context, boardID, ponID int) ([]model.OnuFirmwareInfo, error)
+	RefreshFirmwareByBoardIDAndPonID(ctx context.Context, boardID, ponID int) error
+	GetFirmwareReport(ctx context.Context) (model.FirmwareReport, error)
+	GetUnconfiguredByBoardIDAndPonID(ctx context.Context, boardID, ponID int) (model.UnconfiguredOnuPon, error)
+	GetUnconfigured(ctx context.Context) ([]model.UnconfiguredOnuPon, error)
+
+	// Reads of the provisioning usecase of this package
+	getOltConfig(boardID, ponID int) (*model.OltConfig, error)
+	getSerialNumber(OnuSerialNumberOID, onuID string) (string, error)
 }
 
+const (
+	maxBoardID = 2   // Number of GPON boards on the OLT
+	maxPonID   = 8   // Number of PON ports on each board
+	maxOnuID   = 128 // Number of ONU IDs on each PON port
+)
+
 type onuUsecase struct {
-	snmpRepository  repository.SnmpRepositoryInterface
-	redisRepository repository.OnuRedisRepositoryInterface
-	cfg             *config.Config
+	snmpRepository     repository.SnmpRepositoryInterface
+	redisRepository    repository.OnuRedisRepositoryInterface
+	changeRepository   repository.OnuChangeRepositoryInterface
+	customerRepository repository.CustomerRepositoryInterface
+	cfg                *config.Config
+	location           *time.Location // OLT timezone of DateAndTime values without an offset, UTC when nil
+	inventoryMu        sync.Mutex     // Serializes ONU inventory snapshots
 }
 
 func NewOnuUsecase(
 	snmpRepository repository.SnmpRepositoryInterface, redisRepository repository.OnuRedisRepositoryInterface,
-	cfg *config.Config,
+	changeRepository repository.OnuChangeRepositoryInterface,
+	customerRepository repository.CustomerRepositoryInterface, cfg *config.Config,
 ) OnuUseCaseInterface {
+
+	// ONU last online and offline times are OLT local time
+	location, err := utils.LoadTimezone(cfg.OltCfg.Timezone)
+	if err != nil {
+		log.Error().Msg("Invalid OLT timezone, using UTC: " + err.Error()) // Log error message to logger
+		location = time.UTC
+	}
+
 	return &onuUsecase{
-		snmpRepository:  snmpRepository,
-		redisRepository: redisRepository,
-		cfg:             cfg,
+		snmpRepository:     snmpRepository,
+		redisRepository:    redisRepository,
+		changeRepository:   changeRepository,
+		customerRepository: customerRepository,
+		cfg:                cfg,
+		location:           location,
 	}
 }
 
@@ -361,10 +395,56 @@ func (u *onuUsecase) GetByBoardIDAndPonID(ctx context.Context, boardID, ponID in
 	cachedOnuData, err := u.redisRepository.GetONUInfoList(ctx, redisKey)
 	if err == nil && cachedOnuData != nil {
 		log.Info().Msg("Get ONU Information from Redis with Key: " + redisKey) // Log info message to logger
+		u.setOnuInfoCustomers(ctx, cachedOnuData)                              // Add customer metadata, it isn't cached
 		return cachedOnuData, nil                                              // Return cached data if error is nil and cached data is not nil
 	}
 
+	onuInformationList, _, err := u.readPon(ctx, boardID, ponID, oltConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	// Add customer metadata after saving to Redis, so metadata changes are returned immediately
+	u.setOnuInfoCustomers(ctx, onuInformationList)
+
+	return onuInformationList, nil // Return ONU information list and nil error
+}
+
+// RefreshByBoardIDAndPonID reads the ONU of a PON from SNMP without the cache and overwrites its cached list,
+// a failed read fails the refresh and the cached list is kept
+func (u *onuUsecase) RefreshByBoardIDAndPonID(ctx context.Context, boardID, ponID int) error {
+
+	// Get OLT config based on Board ID and PON ID
+	oltConfig, err := u.getOltConfig(boardID, ponID)
+	if err != nil {
+		log.Error().Msg("Failed to get OLT Config: " + err.Error()) // Log error message to logger
+		return err                                                  // Return error if error is not nil
+	}
+
+	_, readErr, err := u.readPon(ctx, boardID, ponID, oltConfig)
+	if err != nil {
+		return err
+	}
+	if readErr != nil {
+		return fmt.Errorf("not cached, a read failed: %w", readErr)
+	}
+
+	return nil
+}
+
+// readPon is a method to read the ONU of a PON from SNMP and cache them, the first failed read is returned as
+// readErr with the list, such a list isn't cached
+func (u *onuUsecase) readPon(ctx context.Context, boardID, ponID int, oltConfig *model.OltConfig) (
+	[]model.ONUInfoPerBoard, error, error,
+) {
+
+	// Redis Key
+	redisKey := "board_" + strconv.Itoa(boardID) + "_pon_" + strconv.Itoa(ponID)
+
 	var onuInformationList []model.ONUInfoPerBoard // Create slice to store ONU informationList
+	var onuInventory []model.OnuInventory          // Create slice to store ONU inventory snapshot
+	var readErr error                              // First failed read, a list with empty fields isn't cached
+	// nor diffed against the inventory snapshot
 
 	snmpDataMap := make(map[string]gosnmp.SnmpPDU) // Create map to store SNMP data
 
@@ -377,14 +457,20 @@ func (u *onuUsecase) GetByBoardIDAndPonID(ctx context.Context, boardID, ponID in
 	log.Info().Msg("Get All ONU Information from SNMP Walk Board ID: " + strconv.Itoa(
 		boardID) + " and PON ID: " + strconv.Itoa(ponID)) // Log info message to logger
 
-	err = u.snmpRepository.Walk(oltConfig.BaseOID+oltConfig.OnuIDNameOID, func(pdu gosnmp.SnmpPDU) error {
+	err := u.snmpRepository.Walk(oltConfig.BaseOID+oltConfig.OnuIDNameOID, func(pdu gosnmp.SnmpPDU) error {
 		// Store SNMP data to map with ONU ID as key and PDU as value to be used later
 		snmpDataMap[utils.ExtractONUID(pdu.Name)] = pdu // Extract ONU ID from SNMP PDU Name and use it as key in map
 		return nil                                      // Return nil error
 	})
 
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	// Walk the description column of the PON once for the inventory snapshot
+	onuDescriptions, err := u.getDescriptions(oltConfig.OnuDescriptionOID)
+	if err != nil && keepReadError(&readErr, err) {
+		return nil, nil, err
 	}
 
 	/*
@@ -403,27 +489,51 @@ func (u *onuUsecase) GetByBoardIDAndPonID(ctx context.Context, boardID, ponID in
 		onuType, err := u.getONUType(oltConfig.OnuTypeOID, strconv.Itoa(onuInfo.ID))
 		if err == nil {
 			onuInfo.OnuType = onuType // Set ONU Type to ONU onuInfo struct OnuType field
+		} else if keepReadError(&readErr, err) {
+			return nil, nil, err // Every other read would wait for the SNMP budget too
 		}
 
 		// Get ONU Serial Number based on ONU ID and ONU Serial Number OID and store it to ONU onuInfo struct
 		onuSerialNumber, err := u.getSerialNumber(oltConfig.OnuSerialNumberOID, strconv.Itoa(onuInfo.ID))
 		if err == nil {
 			onuInfo.SerialNumber = onuSerialNumber // Set ONU Serial Number to ONU onuInfo struct SerialNumber field
+		} else if keepReadError(&readErr, err) {
+			return nil, nil, err // Every other read would wait for the SNMP budget too
 		}
 
 		// Get ONU RX Power based on ONU ID and ONU RX Power OID and store it to ONU onuInfo struct
 		onuRXPower, err := u.getRxPower(oltConfig.OnuRxPowerOID, strconv.Itoa(onuInfo.ID))
 		if err == nil {
 			onuInfo.RXPower = onuRXPower // Set ONU RX Power to ONU onuInfo struct RXPower field
+		} else if keepReadError(&readErr, err) {
+			return nil, nil, err // Every other read would wait for the SNMP budget too
 		}
 
 		// Get ONU Status based on ONU ID and ONU Status OID and store it to ONU onuInfo struct
 		onuStatus, err := u.getStatus(oltConfig.OnuStatusOID, strconv.Itoa(onuInfo.ID))
 		if err == nil {
 			onuInfo.Status = onuStatus // Set ONU Status to ONU onuInfo struct Status field
+		} else if keepReadError(&readErr, err) {
+			return nil, nil, err // Every other read would wait for the SNMP budget too
 		}
 
 		onuInformationList = append(onuInformationList, onuInfo) // Append ONU onuInfo struct to ONU information list
+
+		onuInventory = append(onuInventory, model.OnuInventory{
+			Board:        onuInfo.Board,
+			PON:          onuInfo.PON,
+			ID:           onuInfo.ID,
+			Name:         onuInfo.Name,
+			OnuType:      onuInfo.OnuType,
+			SerialNumber: onuInfo.SerialNumber,
+			Description:  onuDescriptions[strconv.Itoa(onuInfo.ID)],
+		})
+	}
+
+	// Record ONU added, removed, renamed or moved since the previous refresh, an empty field of a failed read
+	// would be reported as a change
+	if readErr == nil {
+		u.recordInventory(ctx, boardID, ponID, onuInventory)
 	}
 
 	// Sort ONU information list based on ONU ID ascending
@@ -431,17 +541,21 @@ func (u *onuUsecase) GetByBoardIDAndPonID(ctx context.Context, boardID, ponID in
 		return onuInformationList[i].ID < onuInformationList[j].ID
 	})
 
-	// Save ONU information list to Redis 12 hours
-	err = u.redisRepository.SaveONUInfoList(ctx, redisKey, 300, onuInformationList)
+	// Save ONU information list to Redis 12 hours, unless a read failed so the next request reads the PON again
+	if readErr != nil {
+		log.Warn().Msg("Not caching ONU Information with Key: " + redisKey + ", a read failed: " + readErr.Error())
+	} else {
+		err = u.redisRepository.SaveONUInfoList(ctx, redisKey, 300, onuInformationList)
 
-	log.Info().Msg("Save ONU Information to Redis with Key: " + redisKey) // Log info message to logger
+		log.Info().Msg("Save ONU Information to Redis with Key: " + redisKey) // Log info message to logger
 
-	if err != nil {
-		log.Error().Msg("Failed to save ONU Information to Redis: " + err.Error()) // Log error message to logger
-		return nil, err                                                            // Return error if error is not nil
+		if err != nil {
+			log.Error().Msg("Failed to save ONU Information to Redis: " + err.Error()) // Log error message to logger
+			return nil, nil, err                                                       // Return error if error is not nil
+		}
 	}
 
-	return onuInformationList, nil // Return ONU information list and nil error
+	return onuInformationList, readErr, nil
 }
 
 func (u *onuUsecase) GetByBoardIDPonIDAndOnuID(boardID, ponID, onuID int) (
@@ -461,6 +575,8 @@ func (u *onuUsecase) GetByBoardIDPonIDAndOnuID(boardID, ponID, onuID int) (
 	// Create a map to store SNMP Walk results
 	snmpDataMap := make(map[string]gosnmp.SnmpPDU)
 
+	var readErr error // First failed read, the SNMP budget error is returned
+
 	log.Info().Msg("Get Detail ONU Information with SNMP Walk from Board ID: " + strconv.Itoa(
 		boardID) + " PON ID: " + strconv.Itoa(
 		ponID) + " ONU ID: " + strconv.Itoa(onuID))
@@ -474,8 +590,8 @@ func (u *onuUsecase) GetByBoardIDPonIDAndOnuID(boardID, ponID, onuID int) (
 		})
 
 	if err != nil {
-		log.Error().Msg("Failed to walk OID: " + err.Error())            // Log error message to logger
-		return model.ONUCustomerInfo{}, errors.New("failed to walk OID") // Return error
+		log.Error().Msg("Failed to walk OID: " + err.Error())                     // Log error message to logger
+		return model.ONUCustomerInfo{}, fmt.Errorf("failed to walk OID: %w", err) // Return error
 	}
 
 	/*
@@ -494,80 +610,111 @@ func (u *onuUsecase) GetByBoardIDPonIDAndOnuID(boardID, ponID, onuID int) (
 		onuType, err := u.getONUType(oltConfig.OnuTypeOID, strconv.Itoa(onuInfo.ID))
 		if err == nil {
 			onuInfo.OnuType = onuType // Set ONU Type from SNMP Walk result if no error to onuInfo variable (ONU Type)
+		} else if keepReadError(&readErr, err) {
+			return model.ONUCustomerInfo{}, err
 		}
 
 		// Get Data ONU Serial Number from SNMP Walk using getSerialNumber method
 		onuSerialNumber, err := u.getSerialNumber(oltConfig.OnuSerialNumberOID, strconv.Itoa(onuInfo.ID))
 		if err == nil {
 			onuInfo.SerialNumber = onuSerialNumber // Set ONU Serial Number from SNMP Walk result to onuInfo variable (ONU Serial Number)
+		} else if keepReadError(&readErr, err) {
+			return model.ONUCustomerInfo{}, err
 		}
 
 		// Get Data ONU RX Power from SNMP Walk using getRxPower method
 		onuRXPower, err := u.getRxPower(oltConfig.OnuRxPowerOID, strconv.Itoa(onuInfo.ID))
 		if err == nil {
 			onuInfo.RXPower = onuRXPower // Set ONU RX Power from SNMP Walk result to onuInfo variable (ONU RX Power)
+		} else if keepReadError(&readErr, err) {
+			return model.ONUCustomerInfo{}, err
 		}
 
 		// Get Data ONU TX Power from SNMP Walk using getTxPower method
 		onuTXPower, err := u.getTxPower(oltConfig.OnuTxPowerOID, strconv.Itoa(onuInfo.ID))
 		if err == nil {
 			onuInfo.TXPower = onuTXPower // Set ONU TX Power from SNMP Walk result to onuInfo variable (ONU TX Power)
+		} else if keepReadError(&readErr, err) {
+			return model.ONUCustomerInfo{}, err
 		}
 
 		// Get Data ONU Status from SNMP Walk using getStatus method
 		onuStatus, err := u.getStatus(oltConfig.OnuStatusOID, strconv.Itoa(onuInfo.ID))
 		if err == nil {
 			onuInfo.Status = onuStatus // Set ONU Status from SNMP Walk result to onuInfo variable (ONU Status)
+		} else if keepReadError(&readErr, err) {
+			return model.ONUCustomerInfo{}, err
 		}
 
 		// Get Data ONU IP Address from SNMP Walk using getIPAddress method
 		onuIPAddress, err := u.getIPAddress(oltConfig.OnuIPAddressOID, strconv.Itoa(onuInfo.ID))
 		if err == nil {
 			onuInfo.IPAddress = onuIPAddress // Set ONU IP Address from SNMP Walk result to onuInfo variable (ONU IP Address)
+		} else if keepReadError(&readErr, err) {
+			return model.ONUCustomerInfo{}, err
 		}
 
 		// Get Data ONU Description from SNMP Walk using getDescription method
 		onuDescription, err := u.getDescription(oltConfig.OnuDescriptionOID, strconv.Itoa(onuInfo.ID))
 		if err == nil {
 			onuInfo.Description = onuDescription // Set ONU Description from SNMP Walk result to onuInfo variable (ONU Description)
+		} else if keepReadError(&readErr, err) {
+			return model.ONUCustomerInfo{}, err
 		}
 
 		// Get Data ONU Last Online from SNMP Walk using getLastOnline method
 		onuLastOnline, err := u.getLastOnline(oltConfig.OnuLastOnlineOID, strconv.Itoa(onuInfo.ID))
 		if err == nil {
-			onuInfo.LastOnline = onuLastOnline // Set ONU Last Online from SNMP Walk result to onuInfo variable (ONU Last Online)
+			onuInfo.LastOnline = onuLastOnline.Format(time.RFC3339) // Set ONU Last Online as RFC 3339 to onuInfo variable (ONU Last Online)
+		} else if keepReadError(&readErr, err) {
+			return model.ONUCustomerInfo{}, err
 		}
 
 		// Get Data ONU Last Offline from SNMP Walk using getLastOffline method
 		onuLastOffline, err := u.getLastOffline(oltConfig.OnuLastOfflineOID, strconv.Itoa(onuInfo.ID))
 		if err == nil {
-			onuInfo.LastOffline = onuLastOffline // Set ONU Last Offline from SNMP Walk result to onuInfo variable (ONU Last Offline)
+			onuInfo.LastOffline = onuLastOffline.Format(time.RFC3339) // Set ONU Last Offline as RFC 3339 to onuInfo variable (ONU Last Offline)
+		} else if keepReadError(&readErr, err) {
+			return model.ONUCustomerInfo{}, err
 		}
 
+		now := time.Now()
+
 		// Get Data Uptime Duration from getUptimeDuration method
-		onuUptimeDuration, err := u.getUptimeDuration(onuLastOnline)
+		onuUptimeDuration, err := getUptimeDuration(onuLastOnline, onuLastOffline, now)
 		if err == nil {
-			onuInfo.Uptime = onuUptimeDuration // Set ONU Uptime Duration from SNMP Walk result to onuInfo variable (ONU Uptime Duration)
+			uptimeSeconds := int64(onuUptimeDuration / time.Second)
+			onuInfo.UptimeSeconds = &uptimeSeconds                            // Set ONU Uptime in seconds to onuInfo variable
+			onuInfo.Uptime = utils.ConvertDurationToString(onuUptimeDuration) // Set ONU Uptime as human-readable string to onuInfo variable
 		}
 
 		// Get Data Last Down Duration from getLastDownDuration method
-		onuLastDownDuration, err := u.getLastDownDuration(onuLastOffline, onuLastOnline)
+		onuLastDownDuration, err := getLastDownDuration(onuLastOffline, onuLastOnline, now)
 		if err == nil {
-			onuInfo.LastDownTimeDuration = onuLastDownDuration // Set ONU Last Down Duration from SNMP Walk result to onuInfo variable (ONU Last Down Duration)
+			lastDownSeconds := int64(onuLastDownDuration / time.Second)
+			onuInfo.LastDownTimeSeconds = &lastDownSeconds                                    // Set ONU Last Down Duration in seconds to onuInfo variable
+			onuInfo.LastDownTimeDuration = utils.ConvertDurationToString(onuLastDownDuration) // Set ONU Last Down Duration as human-readable string to onuInfo variable
 		}
 
 		// Get Data ONU Last Offline Reason from SNMP Walk using getLastOfflineReason method
 		onuLastOfflineReason, err := u.getLastOfflineReason(oltConfig.OnuLastOfflineReasonOID, strconv.Itoa(onuInfo.ID))
 		if err == nil {
 			onuInfo.LastOfflineReason = onuLastOfflineReason // Set ONU Last Offline Reason from SNMP Walk result to onuInfo variable (ONU Last Offline Reason)
+		} else if keepReadError(&readErr, err) {
+			return model.ONUCustomerInfo{}, err
 		}
 
 		// Get Data ONU GPON Optical Distance from SNMP Walk using getGponOpticalDistance method
 		onuGponOpticalDistance, err := u.getOnuGponOpticalDistance(oltConfig.OnuGponOpticalDistanceOID, strconv.Itoa(onuInfo.ID))
 		if err == nil {
 			onuInfo.GponOpticalDistance = onuGponOpticalDistance // Set ONU GPON Optical Distance from SNMP Walk result to onuInfo variable (ONU GPON Optical Distance)
+		} else if keepReadError(&readErr, err) {
+			return model.ONUCustomerInfo{}, err
 		}
 
+		// Get customer metadata linked to the ONU serial number
+		onuInfo.Customer = u.getOnuCustomer(context.Background(), onuInfo.SerialNumber)
+
 		onuInformationList = onuInfo // Append onuInfo variable to onuInformationList slice
 	}
 
@@ -670,46 +817,27 @@ func (u *onuUsecase) GetOnuIDAndSerialNumber(boardID, ponID int) (
 		return nil, err                                             // Return error if error is not nil
 	}
 
-	// Perform SNMP Walk to get ONU ID
-	snmpOID := oltConfig.BaseOID + oltConfig.OnuIDNameOID // SNMP OID variable
-	onuIDList := make([]model.OnuID, 0)                   // Create a slice of ONU ID
+	// Perform one SNMP Walk of the serial number column, every registered ONU has a row in this column
+	snmpOID := oltConfig.BaseOID + oltConfig.OnuSerialNumberOID // SNMP OID variable
+	onuSerialNumberList := make([]model.OnuSerialNumber, 0)     // Create a slice of ONU Serial Number
 
-	log.Info().Msg("Get ONU ID with SNMP Walk from Board ID: " + strconv.Itoa(
+	log.Info().Msg("Get ONU Serial Number with SNMP Walk from Board ID: " + strconv.Itoa(
 		boardID) + " and PON ID: " + strconv.Itoa(ponID)) // Log info message to logger
 
-	// Perform SNMP BulkWalk to get ONU ID and Name using snmpRepository BulkWalk method with timeout context parameter
+	// A failed walk fails the whole list, a list missing an ONU would hide its serial number from the callers
 	err = u.snmpRepository.Walk(snmpOID, func(pdu gosnmp.SnmpPDU) error {
-		idOnuID := utils.ExtractIDOnuID(pdu.Name) // Extract ONU ID from SNMP PDU Name
-		// Append ONU information to the onuIDList
-		onuIDList = append(onuIDList, model.OnuID{
-			Board: boardID, // Set Board ID to ONU onuInfo struct Board field
-			PON:   ponID,   // Set PON ID to ONU onuInfo  struct PON field
-			ID:    idOnuID, // Set ONU ID (extracted from SNMP PDU) to onuInfo variable (ONU ID)
+		onuSerialNumberList = append(onuSerialNumberList, model.OnuSerialNumber{
+			Board:        boardID,                              // Set Board ID to ONU onuInfo struct Board field
+			PON:          ponID,                                // Set PON ID to ONU onuInfo  struct PON field
+			ID:           utils.ExtractIDOnuID(pdu.Name),       // Extract ONU ID from SNMP PDU Name
+			SerialNumber: utils.ExtractSerialNumber(pdu.Value), // Extract ONU Serial Number from SNMP PDU Value
 		})
-
 		return nil
 	})
 
 	if err != nil {
-		log.Error().Msg("Failed to perform SNMP Walk get ONU ID: " + err.Error()) // Log error message to logger
-		return nil, err
-	}
-
-	// Create a slice of ONU Serial Number
-	var onuSerialNumberList []model.OnuSerialNumber
-
-	// Loop through onuIDList to get ONU Serial Number
-	for _, onuInfo := range onuIDList {
-		// Get Data ONU Serial Number from SNMP Walk using getSerialNumber method
-		onuSerialNumber, err := u.getSerialNumber(oltConfig.OnuSerialNumberOID, strconv.Itoa(onuInfo.ID))
-		if err == nil {
-			onuSerialNumberList = append(onuSerialNumberList, model.OnuSerialNumber{
-				Board:        boardID, // Set Board ID to ONU onuInfo struct Board field
-				PON:          ponID,   // Set PON ID to ONU onuInfo  struct PON field
-				ID:           onuInfo.ID,
-				SerialNumber: onuSerialNumber, // Set ONU Serial Number from SNMP Walk result to onuInfo variable (ONU Serial Number)
-			})
-		}
+		log.Error().Msg("Failed to perform SNMP Walk get ONU Serial Number: " + err.Error()) // Log error message to logger
+		return nil, fmt.Errorf("failed to walk OID: %w", err)
 	}
 
 	// Sort ONU Serial Number list based on ONU ID ascending
@@ -753,7 +881,7 @@ func (u *onuUsecase) UpdateEmptyOnuID(ctx context.Context, boardID, ponID int) e
 	})
 
 	if err != nil {
-		return errors.New("failed to perform SNMP Walk")
+		return fmt.Errorf("failed to perform SNMP Walk: %w", err)
 	}
 
 	// Create a map to store numbers to be deleted
@@ -901,10 +1029,150 @@ func (u *onuUsecase) GetByBoardIDAndPonIDWithPagination(
 		return onuInformationList[i].ID < onuInformationList[j].ID
 	})
 
+	// Add customer metadata linked to the ONU serial numbers
+	u.setOnuInfoCustomers(context.Background(), onuInformationList)
+
 	// Return the page data along with the total number of available data
 	return onuInformationList, count
 }
 
+func (u *onuUsecase) GetOnuUniInfo(boardID, ponID, onuID int) (model.OnuUniInfo, error) {
+
+	// Validate Board ID and PON ID against OLT config
+	if _, err := u.getOltConfig(boardID, ponID); err != nil {
+		log.Error().Msg("Failed to get OLT Config: " + err.Error()) // Log error message to logger
+		return model.OnuUniInfo{}, err                              // Return error if error is not nil
+	}
+
+	log.Info().Msg("Get ONU UNI Port Information with SNMP Walk from Board ID: " + strconv.Itoa(
+		boardID) + " PON ID: " + strconv.Itoa(ponID) + " ONU ID: " + strconv.Itoa(onuID))
+
+	// Remote ONU management tables are indexed by PON port index and ONU ID, followed by UNI port ID
+	onuIndex := "." + strconv.Itoa(utils.GetPonPortIndex(boardID, ponID)) + "." + strconv.Itoa(onuID)
+
+	// Create a map to store UNI ports with port ID as key
+	portMap := make(map[int]*model.OnuUniPort)
+	getPort := func(portID int) *model.OnuUniPort {
+		if _, ok := portMap[portID]; !ok {
+			portMap[portID] = &model.OnuUniPort{
+				PortID:     portID,
+				AdminState: "Unknown",
+				OperState:  "Unknown",
+				Speed:      "Unknown",
+				Duplex:     "Unknown",
+			}
+		}
+		return portMap[portID]
+	}
+
+	// Get UNI port admin state
+	err := u.walkColumn(u.cfg.OltCfg.OnuUniEthAdminStateOID, onuIndex, func(index []int, value interface{}) {
+		getPort(index[0]).AdminState = utils.ExtractUniPortAdminState(value)
+	})
+	if err != nil {
+		return model.OnuUniInfo{}, err
+	}
+
+	// Get UNI port link state
+	err = u.walkColumn(u.cfg.OltCfg.OnuUniEthOperStateOID, onuIndex, func(index []int, value interface{}) {
+		getPort(index[0]).OperState = utils.ExtractUniPortOperState(value)
+	})
+	if err != nil {
+		return model.OnuUniInfo{}, err
+	}
+
+	// Get UNI port speed and duplex
+	err = u.walkColumn(u.cfg.OltCfg.OnuUniEthSpeedDuplexOID, onuIndex, func(index []int, value interface{}) {
+		port := getPort(index[0])
+		port.Speed, port.Duplex = utils.ExtractUniPortSpeedDuplex(value)
+	})
+	if err != nil {
+		return model.OnuUniInfo{}, err
+	}
+
+	// Get MAC addresses learned behind the ONU, indexed by UNI port ID followed by a sequence number
+	macAddressList := make([]model.OnuMacAddress, 0)
+	err = u.walkColumn(u.cfg.OltCfg.OnuUniMacAddressOID, onuIndex, func(index []int, value interface{}) {
+		macAddress := utils.ExtractMacAddress(value)
+		if macAddress != "" {
+			macAddressList = append(macAddressList, model.OnuMacAddress{
+				PortID:     index[0],
+				MacAddress: macAddress,
+			})
+		}
+	})
+	if err != nil {
+		return model.OnuUniInfo{}, err
+	}
+
+	onuUniInfo := model.OnuUniInfo{
+		Board:        boardID,
+		PON:          ponID,
+		ID:           onuID,
+		Ports:        make([]model.OnuUniPort, 0, len(portMap)),
+		MacAddresses: macAddressList,
+	}
+
+	for _, port := range portMap {
+		onuUniInfo.Ports = append(onuUniInfo.Ports, *port)
+	}
+
+	// Sort UNI ports based on port ID ascending
+	sort.Slice(onuUniInfo.Ports, func(i, j int) bool {
+		return onuUniInfo.Ports[i].PortID < onuUniInfo.Ports[j].PortID
+	})
+
+	return onuUniInfo, nil
+}
+
+// getDescriptions is a method to walk the description column of a PON, descriptions are keyed by ONU ID
+func (u *onuUsecase) getDescriptions(OnuDescriptionOID string) (map[string]string, error) {
+
+	onuDescriptions := make(map[string]string) // Map to store ONU Description by ONU ID
+
+	err := u.snmpRepository.Walk(u.cfg.OltCfg.BaseOID1+OnuDescriptionOID, func(pdu gosnmp.SnmpPDU) error {
+		onuDescriptions[utils.ExtractONUID(pdu.Name)] = utils.ExtractName(pdu.Value)
+		return nil
+	})
+	if err != nil {
+		log.Error().Msg("Failed to walk ONU Description: " + err.Error()) // Log error message to logger
+		return nil, fmt.Errorf("failed to walk OID: %w", err)
+	}
+
+	return onuDescriptions, nil
+}
+
+// keepReadError is a function to keep the first failed read of an ONU, it returns true when the SNMP budget of the OLT
+// is exceeded so the caller stops reading
+func keepReadError(readErr *error, err error) bool {
+	if *readErr == nil {
+		*readErr = err
+	}
+	return errors.Is(err, ErrSnmpBudgetExceeded)
+}
+
+// walkColumn is a function to walk a base_oid_2 column below the given index (PON port index, optionally ONU ID)
+func (u *onuUsecase) walkColumn(columnOID, index string, fn func(index []int, value interface{})) error {
+
+	snmpOID := u.cfg.OltCfg.BaseOID2 + columnOID + index // SNMP OID variable
+
+	err := u.snmpRepository.Walk(snmpOID, func(pdu gosnmp.SnmpPDU) error {
+		// Extract the remaining index components after the given index
+		index := utils.ExtractOIDIndex(pdu.Name, snmpOID)
+		if len(index) > 0 {
+			fn(index, pdu.Value)
+		}
+		return nil
+	})
+
+	if err != nil {
+		log.Error().Msg("Failed to walk OID " + snmpOID + ": " + err.Error()) // Log error message to logger
+		return fmt.Errorf("failed to walk OID: %w", err)
+	}
+
+	return nil
+}
+
 func (u *onuUsecase) getName(OnuIDNameOID, onuID string) (string, error) {
 
 	var onuName string // Variable to store ONU Name
@@ -917,7 +1185,7 @@ func (u *onuUsecase) getName(OnuIDNameOID, onuID string) (string, error) {
 	//result, err := u.snmpRepository.Get(oids)
 	if err != nil {
 		log.Error().Msg("Failed to perform SNMP Get for Name: " + err.Error()) // Log error message to logger
-		return "", errors.New("failed to perform SNMP Get")                    // Return error
+		return "", fmt.Errorf("failed to perform SNMP Get: %w", err)           // Return error
 	}
 
 	// Check if the result contains the expected OID
@@ -942,7 +1210,7 @@ func (u *onuUsecase) getONUType(OnuTypeOID, onuID string) (string, error) {
 	result, err := u.snmpRepository.Get(oids)
 	if err != nil {
 		log.Error().Msg("Failed to perform SNMP Get to get ONU Type: " + err.Error()) // Log error message to logger
-		return "", errors.New("failed to perform SNMP Get")                           // Return error
+		return "", fmt.Errorf("failed to perform SNMP Get: %w", err)                  // Return error
 	}
 
 	// Check if the result contains the expected OID
@@ -967,7 +1235,7 @@ func (u *onuUsecase) getSerialNumber(OnuSerialNumberOID, onuID string) (string,
 	result, err := u.snmpRepository.Get(oids)
 	if err != nil {
 		log.Error().Msg("Failed to perform SNMP Get for serial number: " + err.Error()) // Log error message to logger
-		return "", errors.New("failed to perform SNMP Get")                             // Return error
+		return "", fmt.Errorf("failed to perform SNMP Get: %w", err)                    // Return error
 	}
 
 	// Check if the result contains the expected OID
@@ -992,7 +1260,7 @@ func (u *onuUsecase) getRxPower(OnuRxPowerOID, onuID string) (string, error) {
 	result, err := u.snmpRepository.Get(oids)
 	if err != nil {
 		log.Error().Msg("Failed to perform SNMP Get for RX Power: " + err.Error()) // Log error message to logger
-		return "", errors.New("failed to perform SNMP Get")                        // Return error
+		return "", fmt.Errorf("failed to perform SNMP Get: %w", err)               // Return error
 	}
 
 	// Check if the result contains the expected OID
@@ -1017,7 +1285,7 @@ func (u *onuUsecase) getTxPower(OnuTxPowerOID, onuID string) (string, error) {
 	result, err := u.snmpRepository.Get(oids)
 	if err != nil {
 		log.Error().Msg("Failed to perform SNMP Get for TX Power: " + err.Error()) // Log error message to logger
-		return "", errors.New("failed to perform SNMP Get")                        // Return error
+		return "", fmt.Errorf("failed to perform SNMP Get: %w", err)               // Return error
 	}
 
 	// Check if the result contains the expected OID
@@ -1042,7 +1310,7 @@ func (u *onuUsecase) getStatus(OnuStatusOID, onuID string) (string, error) {
 	result, err := u.snmpRepository.Get(oids)
 	if err != nil {
 		log.Error().Msg("Failed to perform SNMP Get for status: " + err.Error()) // Log error message to logger
-		return "", errors.New("failed to perform SNMP Get")                      // Return error
+		return "", fmt.Errorf("failed to perform SNMP Get: %w", err)             // Return error
 	}
 
 	// Check if the result contains the expected OID
@@ -1067,7 +1335,7 @@ func (u *onuUsecase) getIPAddress(OnuIPAddressOID, onuID string) (string, error)
 	result, err := u.snmpRepository.Get(oids)
 	if err != nil {
 		log.Error().Msg("Failed to perform SNMP Get for IP Address: " + err.Error()) // Log error message to logger
-		return "", errors.New("failed to perform SNMP Get")                          // Return error
+		return "", fmt.Errorf("failed to perform SNMP Get: %w", err)                 // Return error
 	}
 
 	// Check if the result contains the expected OID
@@ -1092,7 +1360,7 @@ func (u *onuUsecase) getDescription(OnuDescriptionOID, onuID string) (string, er
 	result, err := u.snmpRepository.Get(oids)
 	if err != nil {
 		log.Error().Msg("Failed to perform SNMP Get for description: " + err.Error()) // Log error message to logger
-		return "", errors.New("failed to perform SNMP Get")                           // Return error
+		return "", fmt.Errorf("failed to perform SNMP Get: %w", err)                  // Return error
 	}
 
 	// Check if the result contains the expected OID
@@ -1106,9 +1374,9 @@ func (u *onuUsecase) getDescription(OnuDescriptionOID, onuID string) (string, er
 	return onuDescription, nil // Return ONU Description
 }
 
-func (u *onuUsecase) getLastOnline(OnuLastOnlineOID, onuID string) (string, error) {
+func (u *onuUsecase) getLastOnline(OnuLastOnlineOID, onuID string) (time.Time, error) {
 
-	var onuLastOnline string // Variable to store ONU Last Online
+	var onuLastOnline time.Time // Variable to store ONU Last Online
 
 	baseOID := u.cfg.OltCfg.BaseOID1 // Base OID variable get from config
 
@@ -1117,32 +1385,36 @@ func (u *onuUsecase) getLastOnline(OnuLastOnlineOID, onuID string) (string, erro
 	result, err := u.snmpRepository.Get(oids)
 	if err != nil {
 		log.Error().Msg("Failed to perform SNMP Get for last online: " + err.Error()) // Log error message to logger
-		return "", errors.New("failed to perform SNMP Get")                           // Return error
+		return time.Time{}, fmt.Errorf("failed to perform SNMP Get: %w", err)         // Return error
 	}
 
 	// Check if the result contains the expected OID
 	if len(result.Variables) > 0 {
-		value := result.Variables[0].Value.([]byte) // Assuming the value is returned as a byte array (Octet String)
+		value, ok := result.Variables[0].Value.([]byte) // The value is returned as a byte array (Octet String)
+		if !ok {
+			log.Error().Msg("Failed to get ONU Last Online: Value is not a DateAndTime")
+			return time.Time{}, errors.New("value is not a DateAndTime")
+		}
 
-		// Convert the Octet String to a DateTime
-		onuLastOnline, err = utils.ConvertByteArrayToDateTime(value)
+		// Convert the Octet String to a DateTime, in the OLT timezone unless it carries an offset from UTC
+		onuLastOnline, err = utils.ParseDateAndTime(value, u.location)
 
 		if err != nil {
 			log.Error().Msg("Failed to convert byte array to DateTime: " + err.Error())
-			return "", err
+			return time.Time{}, err
 		}
 
 	} else {
 		log.Error().Msg("Failed to get ONU Last Online: No variables in the response")
-		return "", errors.New("no variables in the response")
+		return time.Time{}, errors.New("no variables in the response")
 	}
 
-	return onuLastOnline, nil // Return ONU Last Online as a string
+	return onuLastOnline, nil // Return ONU Last Online
 }
 
-func (u *onuUsecase) getLastOffline(OnuLastOfflineOID, onuID string) (string, error) {
+func (u *onuUsecase) getLastOffline(OnuLastOfflineOID, onuID string) (time.Time, error) {
 
-	var onuLastOffline string // Variable to store ONU Last Offline
+	var onuLastOffline time.Time // Variable to store ONU Last Offline
 
 	baseOID := u.cfg.OltCfg.BaseOID1 // Base OID variable get from config
 
@@ -1151,27 +1423,31 @@ func (u *onuUsecase) getLastOffline(OnuLastOfflineOID, onuID string) (string, er
 	result, err := u.snmpRepository.Get(oids)
 	if err != nil {
 		log.Error().Msg("Failed to perform SNMP Get for last offline: " + err.Error()) // Log error message to logger
-		return "", errors.New("failed to perform SNMP Get")                            // Return error
+		return time.Time{}, fmt.Errorf("failed to perform SNMP Get: %w", err)          // Return error
 	}
 
 	// Check if the result contains the expected OID
 	if len(result.Variables) > 0 {
-		value := result.Variables[0].Value.([]byte) // Assuming the value is returned as a byte array (Octet String)
+		value, ok := result.Variables[0].Value.([]byte) // The value is returned as a byte array (Octet String)
+		if !ok {
+			log.Error().Msg("Failed to get ONU Last Offline: Value is not a DateAndTime")
+			return time.Time{}, errors.New("value is not a DateAndTime")
+		}
 
-		// Convert the Octet String to a DateTime
-		onuLastOffline, err = utils.ConvertByteArrayToDateTime(value)
+		// Convert the Octet String to a DateTime, in the OLT timezone unless it carries an offset from UTC
+		onuLastOffline, err = utils.ParseDateAndTime(value, u.location)
 
 		if err != nil {
 			log.Error().Msg("Failed to convert byte array to DateTime: " + err.Error())
-			return "", err
+			return time.Time{}, err
 		}
 
 	} else {
 		log.Error().Msg("Failed to get ONU Last Offline: No variables in the response")
-		return "", errors.New("no variables in the response")
+		return time.Time{}, errors.New("no variables in the response")
 	}
 
-	return onuLastOffline, nil // Return ONU Last Offline as a string
+	return onuLastOffline, nil // Return ONU Last Offline
 }
 
 func (u *onuUsecase) getLastOfflineReason(OnuLastOfflineReasonOID, onuID string) (string, error) {
@@ -1185,7 +1461,7 @@ func (u *onuUsecase) getLastOfflineReason(OnuLastOfflineReasonOID, onuID string)
 	result, err := u.snmpRepository.Get(oids)
 	if err != nil {
 		log.Error().Msg("Failed to perform SNMP Get for last offline reason: " + err.Error()) // Log error message to logger
-		return "", errors.New("failed to perform SNMP Get")                                   // Return error
+		return "", fmt.Errorf("failed to perform SNMP Get: %w", err)                          // Return error
 	}
 
 	// Check if the result contains the expected OID
@@ -1211,7 +1487,7 @@ func (u *onuUsecase) getOnuGponOpticalDistance(OnuGponOpticalDistanceOID, onuID
 	result, err := u.snmpRepository.Get(oids)
 	if err != nil {
 		log.Error().Msg("Failed to perform SNMP Get for GPON Optical Distance: " + err.Error()) // Log error message to logger
-		return "", errors.New("failed to perform SNMP Get")                                     // Return error
+		return "", fmt.Errorf("failed to perform SNMP Get: %w", err)                            // Return error
 	}
 
 	// Check if the result contains the expected OID
@@ -1225,54 +1501,44 @@ func (u *onuUsecase) getOnuGponOpticalDistance(OnuGponOpticalDistanceOID, onuID
 	return onuGponOpticalDistance, nil // Return ONU GPON Optical Distance
 }
 
-func (u *onuUsecase) getUptimeDuration(lastOnline string) (string, error) {
-
-	// Get current time in UTC
-	currentTime := time.Now()
+// getUptimeDuration is a function to get the time an ONU has been online, an ONU which went offline after its
+// last online time has no uptime
+func getUptimeDuration(lastOnline, lastOffline, now time.Time) (time.Duration, error) {
 
-	// Convert last online time to UTC
-	lastOnlineTime, err := time.Parse("2006-01-02 15:04:05", lastOnline)
-	if err != nil {
-		log.Error().Msg("Failed to parse last online time: " + err.Error())
-		return "", err
+	if lastOnline.IsZero() {
+		return 0, errors.New("last online time is unknown")
+	}
+	if lastOffline.After(lastOnline) {
+		return 0, errors.New("ONU is offline")
 	}
-
-	fmt.Println("This is time now: ", currentTime)
-	fmt.Println("This is last online time: ", lastOnlineTime)
-	fmt.Println("This is duration: ", currentTime.Sub(lastOnlineTime))
 
 	// Calculate the duration between the last online time and the current time
-	duration := currentTime.Sub(lastOnlineTime) + time.Hour*7
-
-	// Convert the duration to a string
-	uptimeDuration := utils.ConvertDurationToString(duration)
-
-	return uptimeDuration, nil
+	duration := now.Sub(lastOnline)
+	if duration < 0 {
+		duration = 0 // OLT clock ahead of ours
+	}
 
+	return duration, nil
 }
 
-// Last Down Duration
-func (u *onuUsecase) getLastDownDuration(lastOffline, lastOnline string) (string, error) {
+// getLastDownDuration is a function to get the duration of the last outage of an ONU, an ONU which went offline after
+// its last online time is still down
+func getLastDownDuration(lastOffline, lastOnline, now time.Time) (time.Duration, error) {
 
-	// Convert last offline time to time
-	lastOfflineTime, err := time.Parse("2006-01-02 15:04:05", lastOffline)
-	if err != nil {
-		log.Error().Msg("Failed to parse last offline time: " + err.Error())
-		return "", err
+	if lastOffline.IsZero() {
+		return 0, errors.New("last offline time is unknown")
 	}
 
-	// Convert last online time to time
-	lastOnlineTime, err := time.Parse("2006-01-02 15:04:05", lastOnline)
-	if err != nil {
-		log.Error().Msg("Failed to parse last online time: " + err.Error())
-		return "", err
+	// Calculate the duration between the last offline time and the last online time, or now while still down
+	end := lastOnline
+	if lastOffline.After(lastOnline) {
+		end = now
 	}
 
-	// Calculate the duration between the last offline time and the last online time
-	duration := lastOnlineTime.Sub(lastOfflineTime)
-
-	// Convert the duration to a string
-	lastDownDuration := utils.ConvertDurationToString(duration)
+	duration := end.Sub(lastOffline)
+	if duration < 0 {
+		duration = 0
+	}
 
-	return lastDownDuration, nil
+	return duration, nil
 }
@@ -0,0 +1,204 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"github.com/gosnmp/gosnmp"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/model"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/utils"
+	"github.com/rs/zerolog/log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	onuRebootAction      = 1   // Value written to the ONU reboot OID to trigger a reboot
+	onuActionLogMaxItems = 100 // Number of action log entries kept per ONU
+)
+
+func (u *onuProvisionUsecase) UpdateOnu(
+	ctx context.Context, boardID, ponID, onuID int, request model.OnuUpdateRequest, actor string,
+) (model.OnuActionLog, error) {
+
+	// Get OLT config based on Board ID and PON ID
+	oltConfig, err := u.getOltConfig(boardID, ponID)
+	if err != nil {
+		return model.OnuActionLog{}, err
+	}
+
+	if request.Name == nil && request.Description == nil {
+		return model.OnuActionLog{}, fmt.Errorf("%w: 'name' or 'description' is required", ErrInvalidProvisionRequest)
+	}
+
+	changes := make(map[string]string)
+	pdus := make([]gosnmp.SnmpPDU, 0, 2)
+
+	// Validate new ONU name and build the SNMP Set PDU
+	if request.Name != nil {
+		name := strings.TrimSpace(*request.Name)
+		if err := validateOnuText("name", name, 64); err != nil {
+			return model.OnuActionLog{}, err
+		}
+		changes["name"] = name
+		pdus = append(pdus, gosnmp.SnmpPDU{
+			Name:  u.cfg.OltCfg.BaseOID1 + oltConfig.OnuIDNameOID + "." + strconv.Itoa(onuID),
+			Type:  gosnmp.OctetString,
+			Value: name,
+		})
+	}
+
+	// Validate new ONU description and build the SNMP Set PDU
+	if request.Description != nil {
+		description := strings.TrimSpace(*request.Description)
+		if err := validateOnuText("description", description, 128); err != nil {
+			return model.OnuActionLog{}, err
+		}
+		changes["description"] = description
+		pdus = append(pdus, gosnmp.SnmpPDU{
+			Name:  u.cfg.OltCfg.BaseOID1 + oltConfig.OnuDescriptionOID + "." + strconv.Itoa(onuID),
+			Type:  gosnmp.OctetString,
+			Value: description,
+		})
+	}
+
+	// Make sure the caller targets the ONU they think they are changing
+	serialNumber, err := u.confirmOnu(oltConfig, onuID, request.Confirm, actor)
+	if err != nil {
+		return model.OnuActionLog{}, err
+	}
+
+	log.Info().Msg("Update ONU ID: " + strconv.Itoa(onuID) + " on Board ID: " + strconv.Itoa(
+		boardID) + " and PON ID: " + strconv.Itoa(ponID) + " by " + actor) // Log info message to logger
+
+	if err := u.set(pdus); err != nil {
+		return model.OnuActionLog{}, err
+	}
+
+	// Invalidate cached data of the PON
+	u.invalidateCache(ctx, boardID, ponID)
+
+	return u.recordAction(ctx, model.OnuActionLog{
+		Action:       "update",
+		Board:        boardID,
+		PON:          ponID,
+		ID:           onuID,
+		SerialNumber: serialNumber,
+		Actor:        actor,
+		Changes:      changes,
+	}), nil
+}
+
+func (u *onuProvisionUsecase) RebootOnu(
+	ctx context.Context, boardID, ponID, onuID int, request model.OnuRebootRequest, actor string,
+) (model.OnuActionLog, error) {
+
+	// Get OLT config based on Board ID and PON ID
+	oltConfig, err := u.getOltConfig(boardID, ponID)
+	if err != nil {
+		return model.OnuActionLog{}, err
+	}
+
+	// Make sure the caller targets the ONU they think they are rebooting
+	serialNumber, err := u.confirmOnu(oltConfig, onuID, request.Confirm, actor)
+	if err != nil {
+		return model.OnuActionLog{}, err
+	}
+
+	log.Info().Msg("Reboot ONU ID: " + strconv.Itoa(onuID) + " on Board ID: " + strconv.Itoa(
+		boardID) + " and PON ID: " + strconv.Itoa(ponID) + " by " + actor) // Log info message to logger
+
+	onuIndex := "." + strconv.Itoa(utils.GetPonPortIndex(boardID, ponID)) + "." + strconv.Itoa(onuID)
+
+	err = u.set([]gosnmp.SnmpPDU{
+		{
+			Name:  u.cfg.OltCfg.BaseOID2 + u.cfg.OltCfg.OnuRebootOID + onuIndex,
+			Type:  gosnmp.Integer,
+			Value: onuRebootAction,
+		},
+	})
+	if err != nil {
+		return model.OnuActionLog{}, err
+	}
+
+	// Invalidate cached data of the PON, the ONU status is about to change
+	u.invalidateCache(ctx, boardID, ponID)
+
+	return u.recordAction(ctx, model.OnuActionLog{
+		Action:       "reboot",
+		Board:        boardID,
+		PON:          ponID,
+		ID:           onuID,
+		SerialNumber: serialNumber,
+		Actor:        actor,
+	}), nil
+}
+
+func (u *onuProvisionUsecase) GetOnuActionLog(ctx context.Context, boardID, ponID, onuID int) (
+	[]model.OnuActionLog, error,
+) {
+
+	// Validate Board ID and PON ID against OLT config
+	if _, err := u.getOltConfig(boardID, ponID); err != nil {
+		return nil, err
+	}
+
+	return u.actionLogRepository.GetOnuActionLog(ctx, onuActionLogKey(boardID, ponID, onuID))
+}
+
+// confirmOnu is a function to validate the actor and check the confirmation against the ONU serial number
+func (u *onuProvisionUsecase) confirmOnu(
+	oltConfig *model.OltConfig, onuID int, confirm, actor string,
+) (string, error) {
+
+	// Validate ONU ID
+	if onuID < 1 || onuID > maxOnuID {
+		return "", fmt.Errorf("%w: onu id must be between 1 and %d", ErrInvalidProvisionRequest, maxOnuID)
+	}
+
+	// Every change must be attributable to someone
+	if strings.TrimSpace(actor) == "" {
+		return "", fmt.Errorf("%w: actor is required", ErrInvalidProvisionRequest)
+	}
+
+	if err := validateOnuText("actor", actor, 64); err != nil {
+		return "", err
+	}
+
+	// Get the current ONU serial number from the OLT
+	serialNumber, err := u.onuUsecase.getSerialNumber(oltConfig.OnuSerialNumberOID, strconv.Itoa(onuID))
+	if err != nil {
+		return "", err
+	}
+
+	if serialNumber == "" {
+		return "", ErrOnuNotFound
+	}
+
+	if !strings.EqualFold(strings.TrimSpace(confirm), serialNumber) {
+		return "", ErrConfirmationMismatch
+	}
+
+	return serialNumber, nil
+}
+
+// recordAction is a function to save who did what to an ONU, a failure is logged and does not fail the action
+func (u *onuProvisionUsecase) recordAction(ctx context.Context, actionLog model.OnuActionLog) model.OnuActionLog {
+
+	actionLog.Timestamp = time.Now().Format(time.RFC3339)
+
+	log.Info().Interface("onu_action", actionLog).Msg("ONU action performed") // Log info message to logger
+
+	key := onuActionLogKey(actionLog.Board, actionLog.PON, actionLog.ID)
+	if err := u.actionLogRepository.PushOnuActionLog(ctx, key, onuActionLogMaxItems, actionLog); err != nil {
+		log.Error().Msg("Failed to save ONU action log: " + err.Error()) // Log error message to logger
+	}
+
+	return actionLog
+}
+
+// onuActionLogKey is a function to get Redis key of the ONU action log
+func onuActionLogKey(boardID, ponID, onuID int) string {
+	return "board_" + strconv.Itoa(boardID) + "_pon_" + strconv.Itoa(ponID) + "_onu_" + strconv.Itoa(
+		onuID) + "_action_log"
+}
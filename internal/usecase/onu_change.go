@@ -0,0 +1,210 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/model"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/repository"
+	"github.com/rs/zerolog/log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	OnuChangeAdded   = "added"   // ONU registered
+	OnuChangeRemoved = "removed" // ONU deleted
+	OnuChangeRenamed = "renamed" // ONU name changed
+	OnuChangeMoved   = "moved"   // ONU serial number registered on another PON or ONU ID
+	OnuChangeUpdated = "updated" // ONU type or description changed
+
+	onuChangeLogKey       = "onu_change_log"       // Redis sorted set of ONU changes
+	onuLocationKey        = "onu_inventory_serial" // Redis hash of the last seen ONU of each serial number
+	onuChangeLogRetention = 400 * 24 * time.Hour   // Changes are kept a little over a year
+	onuChangeMaxLimit     = 10000                  // Maximum number of changes returned at once
+	onuChangeDefaultLimit = 1000                   // Number of changes returned without a limit
+)
+
+// ErrInvalidChangeRequest is returned when the changes query is invalid
+var ErrInvalidChangeRequest = fmt.Errorf("invalid change request")
+
+type OnuChangeUseCaseInterface interface {
+	GetChanges(ctx context.Context, since time.Time, limit int) ([]model.OnuChange, error)
+}
+
+type onuChangeUsecase struct {
+	changeRepository repository.OnuChangeRepositoryInterface
+}
+
+// NewOnuChangeUsecase returns the usecase reading the ONU change log recorded on each PON refresh
+func NewOnuChangeUsecase(changeRepository repository.OnuChangeRepositoryInterface) OnuChangeUseCaseInterface {
+	return &onuChangeUsecase{changeRepository: changeRepository}
+}
+
+// GetChanges returns up to limit changes recorded at or after since, oldest first
+func (u *onuChangeUsecase) GetChanges(ctx context.Context, since time.Time, limit int) ([]model.OnuChange, error) {
+
+	if limit == 0 {
+		limit = onuChangeDefaultLimit
+	}
+	if limit < 0 || limit > onuChangeMaxLimit {
+		return nil, fmt.Errorf("%w: 'limit' must be between 1 and %d", ErrInvalidChangeRequest, onuChangeMaxLimit)
+	}
+
+	return u.changeRepository.GetOnuChanges(ctx, onuChangeLogKey, since, limit)
+}
+
+// onuInventoryKey is a function to get the Redis key of the ONU inventory snapshot of a PON
+func onuInventoryKey(boardID, ponID int) string {
+	return "board_" + strconv.Itoa(boardID) + "_pon_" + strconv.Itoa(ponID) + "_inventory"
+}
+
+// recordInventory is a method to diff the ONU inventory of a PON against the previous snapshot,
+// persist the changes and save the inventory as the new snapshot
+func (u *onuUsecase) recordInventory(ctx context.Context, boardID, ponID int, inventory []model.OnuInventory) {
+
+	// Refreshes of the same PON may run concurrently, each snapshot must be diffed once
+	u.inventoryMu.Lock()
+	defer u.inventoryMu.Unlock()
+
+	redisKey := onuInventoryKey(boardID, ponID)
+
+	previous, err := u.changeRepository.GetOnuInventory(ctx, redisKey)
+	baseline := err != nil // No snapshot yet, the first inventory is the baseline
+
+	locations, err := u.changeRepository.GetOnuLocations(ctx, onuLocationKey)
+	if err != nil {
+		log.Error().Msg("Failed to get ONU locations: " + err.Error()) // Log error message to logger
+		return
+	}
+
+	changes := diffOnuInventory(previous, inventory, locations)
+
+	// Serial numbers of this PON were last seen here
+	seen := make(map[string]model.OnuInventory, len(inventory))
+	for _, onu := range inventory {
+		if serialNumber := normalizeSerialNumber(onu.SerialNumber); serialNumber != "" {
+			seen[serialNumber] = onu
+		}
+	}
+	if err := u.changeRepository.SetOnuLocations(ctx, onuLocationKey, seen); err != nil {
+		log.Error().Msg("Failed to save ONU locations: " + err.Error()) // Log error message to logger
+		return
+	}
+
+	if !baseline && len(changes) > 0 {
+		timestamp := time.Now().Format(time.RFC3339)
+		for i := range changes {
+			changes[i].Olt = u.cfg.StreamCfg.OltName
+			changes[i].Timestamp = timestamp
+		}
+
+		log.Info().Interface("onu_changes", changes).Msg("ONU inventory changed") // Log info message to logger
+
+		if err := u.changeRepository.AddOnuChanges(ctx, onuChangeLogKey, onuChangeLogRetention, changes); err != nil {
+			// Keep the previous snapshot, so the changes are recorded on the next refresh
+			log.Error().Msg("Failed to save ONU changes: " + err.Error()) // Log error message to logger
+			return
+		}
+	}
+
+	if err := u.changeRepository.SaveOnuInventory(ctx, redisKey, inventory); err != nil {
+		log.Error().Msg("Failed to save ONU inventory: " + err.Error()) // Log error message to logger
+	}
+}
+
+// diffOnuInventory is a function to list the changes from the previous to the current inventory of a PON,
+// locations holds the last seen ONU of each serial number and is updated with the current inventory
+func diffOnuInventory(
+	previous, current []model.OnuInventory, locations map[string]model.OnuInventory,
+) []model.OnuChange {
+
+	previousByID := make(map[int]model.OnuInventory, len(previous))
+	for _, onu := range previous {
+		previousByID[onu.ID] = onu
+	}
+
+	sortedCurrent := append([]model.OnuInventory(nil), current...)
+	sort.Slice(sortedCurrent, func(i, j int) bool { return sortedCurrent[i].ID < sortedCurrent[j].ID })
+
+	var changes []model.OnuChange
+	matched := make(map[int]bool)
+
+	for i := range sortedCurrent {
+		onu := sortedCurrent[i]
+		serialNumber := normalizeSerialNumber(onu.SerialNumber)
+
+		// Same ONU when the serial number didn't change, an unread serial number is assumed unchanged
+		if old, ok := previousByID[onu.ID]; ok {
+			oldSerialNumber := normalizeSerialNumber(old.SerialNumber)
+			if oldSerialNumber == serialNumber || oldSerialNumber == "" || serialNumber == "" {
+				matched[onu.ID] = true
+				switch {
+				case old.Name != onu.Name:
+					changes = append(changes, newOnuChange(OnuChangeRenamed, &old, &onu))
+				case old.OnuType != onu.OnuType || old.Description != onu.Description:
+					changes = append(changes, newOnuChange(OnuChangeUpdated, &old, &onu))
+				}
+				continue
+			}
+		}
+
+		// A serial number last seen on another PON or ONU ID was moved
+		if last, ok := locations[serialNumber]; ok && serialNumber != "" && !sameOnu(last, onu) {
+			changes = append(changes, newOnuChange(OnuChangeMoved, &last, &onu))
+		} else {
+			changes = append(changes, newOnuChange(OnuChangeAdded, nil, &onu))
+		}
+		if serialNumber != "" {
+			locations[serialNumber] = onu
+		}
+	}
+
+	sortedPrevious := append([]model.OnuInventory(nil), previous...)
+	sort.Slice(sortedPrevious, func(i, j int) bool { return sortedPrevious[i].ID < sortedPrevious[j].ID })
+
+	for i := range sortedPrevious {
+		old := sortedPrevious[i]
+		if matched[old.ID] {
+			continue
+		}
+
+		// The serial number is already registered elsewhere, the move was recorded there
+		serialNumber := normalizeSerialNumber(old.SerialNumber)
+		if last, ok := locations[serialNumber]; ok && serialNumber != "" && !sameOnu(last, old) {
+			continue
+		}
+		changes = append(changes, newOnuChange(OnuChangeRemoved, &old, nil))
+	}
+
+	return changes
+}
+
+// newOnuChange is a function to create a change of the ONU before and after
+func newOnuChange(changeType string, previous, current *model.OnuInventory) model.OnuChange {
+	onu := current
+	if onu == nil {
+		onu = previous
+	}
+
+	return model.OnuChange{
+		Type:         changeType,
+		Board:        onu.Board,
+		PON:          onu.PON,
+		ID:           onu.ID,
+		SerialNumber: onu.SerialNumber,
+		Previous:     previous,
+		Current:      current,
+	}
+}
+
+// sameOnu is a function to check if both inventory entries are on the same board, PON and ONU ID
+func sameOnu(a, b model.OnuInventory) bool {
+	return a.Board == b.Board && a.PON == b.PON && a.ID == b.ID
+}
+
+// normalizeSerialNumber is a function to compare serial numbers regardless of case and padding
+func normalizeSerialNumber(serialNumber string) string {
+	return strings.ToUpper(strings.TrimSpace(serialNumber))
+}
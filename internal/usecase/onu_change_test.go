@@ -0,0 +1,170 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// fakeOnuChangeRepo is an in-memory implementation of OnuChangeRepositoryInterface
+type fakeOnuChangeRepo struct {
+	inventory map[string][]model.OnuInventory
+	locations map[string]map[string]model.OnuInventory
+	changes   map[string][]model.OnuChange
+}
+
+func newFakeOnuChangeRepo() *fakeOnuChangeRepo {
+	return &fakeOnuChangeRepo{
+		inventory: make(map[string][]model.OnuInventory),
+		locations: make(map[string]map[string]model.OnuInventory),
+		changes:   make(map[string][]model.OnuChange),
+	}
+}
+
+func (f *fakeOnuChangeRepo) SaveOnuInventory(_ context.Context, key string, inventory []model.OnuInventory) error {
+	f.inventory[key] = inventory
+	return nil
+}
+
+func (f *fakeOnuChangeRepo) GetOnuInventory(_ context.Context, key string) ([]model.OnuInventory, error) {
+	if value, ok := f.inventory[key]; ok {
+		return value, nil
+	}
+	return nil, errFakeRedisNil
+}
+
+func (f *fakeOnuChangeRepo) SetOnuLocations(_ context.Context, key string, locations map[string]model.OnuInventory) error {
+	if f.locations[key] == nil {
+		f.locations[key] = make(map[string]model.OnuInventory)
+	}
+	for serialNumber, location := range locations {
+		f.locations[key][serialNumber] = location
+	}
+	return nil
+}
+
+func (f *fakeOnuChangeRepo) GetOnuLocations(_ context.Context, key string) (map[string]model.OnuInventory, error) {
+	locations := make(map[string]model.OnuInventory, len(f.locations[key]))
+	for serialNumber, location := range f.locations[key] {
+		locations[serialNumber] = location
+	}
+	return locations, nil
+}
+
+// AddOnuChanges keeps all changes, timestamps are compared by GetOnuChanges instead of scores
+func (f *fakeOnuChangeRepo) AddOnuChanges(_ context.Context, key string, _ time.Duration, changes []model.OnuChange) error {
+	f.changes[key] = append(f.changes[key], changes...)
+	return nil
+}
+
+func (f *fakeOnuChangeRepo) GetOnuChanges(
+	_ context.Context, key string, since time.Time, limit int,
+) ([]model.OnuChange, error) {
+	changes := make([]model.OnuChange, 0)
+	for _, change := range f.changes[key] {
+		timestamp, _ := time.Parse(time.RFC3339, change.Timestamp)
+		if !timestamp.Before(since.Truncate(time.Second)) && len(changes) < limit {
+			changes = append(changes, change)
+		}
+	}
+	return changes, nil
+}
+
+func TestDiffOnuInventory(t *testing.T) {
+	onu1 := model.OnuInventory{Board: 1, PON: 1, ID: 1, Name: "customer-001", SerialNumber: "ZTEGC0000001"}
+	onu2 := model.OnuInventory{Board: 1, PON: 1, ID: 2, Name: "customer-002", SerialNumber: "ZTEGC0000002"}
+	onu3 := model.OnuInventory{Board: 1, PON: 1, ID: 3, Name: "customer-003", SerialNumber: "ZTEGC0000003"}
+
+	renamed := onu1
+	renamed.Name = "customer-001-new"
+	updated := onu2
+	updated.Description = "Jl. Merdeka 1"
+	added := model.OnuInventory{Board: 1, PON: 1, ID: 4, Name: "customer-004", SerialNumber: "ZTEGC0000004"}
+	movedFromPon := model.OnuInventory{Board: 1, PON: 1, ID: 5, Name: "customer-009", SerialNumber: "ztegc0000009"}
+	lastSeen := model.OnuInventory{Board: 2, PON: 3, ID: 7, Name: "customer-009", SerialNumber: "ZTEGC0000009"}
+
+	locations := map[string]model.OnuInventory{
+		"ZTEGC0000001": onu1, "ZTEGC0000002": onu2, "ZTEGC0000003": onu3, "ZTEGC0000009": lastSeen,
+	}
+
+	changes := diffOnuInventory(
+		[]model.OnuInventory{onu1, onu2, onu3},
+		[]model.OnuInventory{movedFromPon, added, updated, renamed},
+		locations,
+	)
+
+	assert.Equal(t, []model.OnuChange{
+		{Type: OnuChangeRenamed, Board: 1, PON: 1, ID: 1, SerialNumber: "ZTEGC0000001", Previous: &onu1, Current: &renamed},
+		{Type: OnuChangeUpdated, Board: 1, PON: 1, ID: 2, SerialNumber: "ZTEGC0000002", Previous: &onu2, Current: &updated},
+		{Type: OnuChangeAdded, Board: 1, PON: 1, ID: 4, SerialNumber: "ZTEGC0000004", Current: &added},
+		{Type: OnuChangeMoved, Board: 1, PON: 1, ID: 5, SerialNumber: "ztegc0000009", Previous: &lastSeen, Current: &movedFromPon},
+		{Type: OnuChangeRemoved, Board: 1, PON: 1, ID: 3, SerialNumber: "ZTEGC0000003", Previous: &onu3},
+	}, changes)
+	assert.Equal(t, movedFromPon, locations["ZTEGC0000009"])
+}
+
+func TestDiffOnuInventoryMoveWithinPon(t *testing.T) {
+	before := model.OnuInventory{Board: 1, PON: 1, ID: 2, Name: "customer-002", SerialNumber: "ZTEGC0000002"}
+	after := model.OnuInventory{Board: 1, PON: 1, ID: 9, Name: "customer-002", SerialNumber: "ZTEGC0000002"}
+
+	// Re-registering on another ONU ID is a single move, not a removal and an addition
+	changes := diffOnuInventory(
+		[]model.OnuInventory{before}, []model.OnuInventory{after},
+		map[string]model.OnuInventory{"ZTEGC0000002": before},
+	)
+	assert.Equal(t, []model.OnuChange{
+		{Type: OnuChangeMoved, Board: 1, PON: 1, ID: 9, SerialNumber: "ZTEGC0000002", Previous: &before, Current: &after},
+	}, changes)
+
+	// An unread serial number keeps the ONU
+	unread := before
+	unread.SerialNumber = ""
+	changes = diffOnuInventory([]model.OnuInventory{before}, []model.OnuInventory{unread}, map[string]model.OnuInventory{})
+	assert.Empty(t, changes)
+}
+
+func TestRecordInventory(t *testing.T) {
+	changeRepo := newFakeOnuChangeRepo()
+	cfg := newTestConfig()
+	cfg.StreamCfg.OltName = "olt-1"
+	u := &onuUsecase{changeRepository: changeRepo, cfg: cfg}
+	ctx := context.Background()
+
+	onu1 := model.OnuInventory{Board: 1, PON: 1, ID: 1, Name: "customer-001", SerialNumber: "ZTEGC0000001"}
+	onu2 := model.OnuInventory{Board: 1, PON: 2, ID: 3, Name: "customer-002", SerialNumber: "ZTEGC0000002"}
+
+	// The first snapshot of each PON is the baseline
+	u.recordInventory(ctx, 1, 1, []model.OnuInventory{onu1})
+	u.recordInventory(ctx, 1, 2, []model.OnuInventory{onu2})
+	assert.Empty(t, changeRepo.changes[onuChangeLogKey])
+	assert.Equal(t, []model.OnuInventory{onu1}, changeRepo.inventory["board_1_pon_1_inventory"])
+
+	// ONU 2 moves to PON 1, the later refresh of PON 2 doesn't report it removed
+	moved := model.OnuInventory{Board: 1, PON: 1, ID: 2, Name: "customer-002", SerialNumber: "ZTEGC0000002"}
+	u.recordInventory(ctx, 1, 1, []model.OnuInventory{onu1, moved})
+	u.recordInventory(ctx, 1, 2, []model.OnuInventory{})
+
+	changes := changeRepo.changes[onuChangeLogKey]
+	assert.Len(t, changes, 1)
+	assert.Equal(t, OnuChangeMoved, changes[0].Type)
+	assert.Equal(t, "olt-1", changes[0].Olt)
+	assert.Equal(t, &onu2, changes[0].Previous)
+	assert.NotEmpty(t, changes[0].Timestamp)
+	assert.Empty(t, changeRepo.inventory["board_1_pon_2_inventory"])
+
+	// Changes are read back from the change log
+	changeUsecase := NewOnuChangeUsecase(changeRepo)
+	result, err := changeUsecase.GetChanges(ctx, time.Now().Add(-time.Minute), 0)
+	assert.NoError(t, err)
+	assert.Equal(t, changes, result)
+
+	result, err = changeUsecase.GetChanges(ctx, time.Now().Add(time.Minute), 0)
+	assert.NoError(t, err)
+	assert.Empty(t, result)
+
+	_, err = changeUsecase.GetChanges(ctx, time.Time{}, onuChangeMaxLimit+1)
+	assert.True(t, errors.Is(err, ErrInvalidChangeRequest))
+}
@@ -0,0 +1,79 @@
+package usecase
+
+import (
+	"context"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/model"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/repository"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/pkg/pubsub"
+	"github.com/rs/zerolog/log"
+	"strconv"
+	"time"
+)
+
+const (
+	OnuEventStatus  = "status"  // ONU phase state changed
+	OnuEventOptical = "optical" // ONU RX power changed by more than the configured threshold
+	OnuEventAlarm   = "alarm"   // ONU alarm raised or cleared
+
+	onuEventSourceTrap = "trap"
+	onuEventSourcePoll = "poll"
+)
+
+// onuEventPublisher updates the Redis cache and publishes ONU events detected by traps or polling
+type onuEventPublisher struct {
+	redisRepository repository.OnuRedisRepositoryInterface
+	broker          *pubsub.Broker[model.OnuEvent]
+	oltName         string
+}
+
+// publish is a method to stamp, cache and publish events
+func (p *onuEventPublisher) publish(ctx context.Context, events []model.OnuEvent, source string) []model.OnuEvent {
+
+	timestamp := time.Now().Format(time.RFC3339)
+	for i := range events {
+		events[i].Olt = p.oltName
+		events[i].Source = source
+		events[i].Timestamp = timestamp
+
+		log.Info().Interface("onu_event", events[i]).Msg("ONU event detected") // Log info message to logger
+
+		p.updateCache(ctx, events[i])
+		p.broker.Publish(events[i])
+	}
+
+	return events
+}
+
+// updateCache is a method to patch or invalidate the cached ONU list of the event PON
+func (p *onuEventPublisher) updateCache(ctx context.Context, event model.OnuEvent) {
+
+	redisKey := "board_" + strconv.Itoa(event.Board) + "_pon_" + strconv.Itoa(event.PON)
+
+	// Alarms don't map to a cached field, so the ONU list is reloaded from SNMP on the next request
+	if event.Type == OnuEventStatus || event.Type == OnuEventOptical {
+		onuInfoList, err := p.redisRepository.GetONUInfoList(ctx, redisKey)
+		if err != nil {
+			return // Nothing cached for this PON
+		}
+
+		for i := range onuInfoList {
+			if onuInfoList[i].ID != event.ID {
+				continue
+			}
+			if event.Type == OnuEventStatus {
+				onuInfoList[i].Status = event.Status
+			} else {
+				onuInfoList[i].RXPower = event.RxPower
+			}
+			if err := p.redisRepository.SaveONUInfoList(ctx, redisKey, 300, onuInfoList); err != nil {
+				log.Error().Msg("Failed to patch Redis Key: " + redisKey + ": " + err.Error()) // Log error message to logger
+			}
+			return
+		}
+	}
+
+	// Invalidate the cache, the ONU is not cached yet or the event changes data that is not cached
+	if err := p.redisRepository.DeleteOnuIDCtx(ctx, redisKey); err != nil {
+		log.Error().Msg("Failed to invalidate Redis Key: " + redisKey + ": " + err.Error()) // Log error message to logger
+	}
+}
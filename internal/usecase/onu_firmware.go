@@ -0,0 +1,224 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/model"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/utils"
+	"github.com/rs/zerolog/log"
+	"sort"
+	"strconv"
+)
+
+func (u *onuUsecase) GetFirmwareByBoardIDAndPonID(ctx context.Context, boardID, ponID int) (
+	[]model.OnuFirmwareInfo, error,
+) {
+
+	// Validate Board ID and PON ID against OLT config
+	if _, err := u.getOltConfig(boardID, ponID); err != nil {
+		log.Error().Msg("Failed to get OLT Config: " + err.Error()) // Log error message to logger
+		return nil, err                                             // Return error if error is not nil
+	}
+
+	// Redis Key
+	redisKey := "board_" + strconv.Itoa(boardID) + "_pon_" + strconv.Itoa(ponID) + "_firmware"
+
+	// Try to get data from Redis using GetOnuFirmwareList method with context and Redis key as parameter
+	cachedFirmwareData, err := u.redisRepository.GetOnuFirmwareList(ctx, redisKey)
+	if err == nil && cachedFirmwareData != nil {
+		log.Info().Msg("Get ONU Firmware from Redis with Key: " + redisKey) // Log info message to logger
+		return cachedFirmwareData, nil
+	}
+
+	return u.readFirmware(ctx, boardID, ponID)
+}
+
+// RefreshFirmwareByBoardIDAndPonID reads the ONU firmware of a PON from SNMP without the cache and overwrites its
+// cached list, the cached list is kept when a walk fails
+func (u *onuUsecase) RefreshFirmwareByBoardIDAndPonID(ctx context.Context, boardID, ponID int) error {
+
+	// Validate Board ID and PON ID against OLT config
+	if _, err := u.getOltConfig(boardID, ponID); err != nil {
+		log.Error().Msg("Failed to get OLT Config: " + err.Error()) // Log error message to logger
+		return err                                                  // Return error if error is not nil
+	}
+
+	_, err := u.readFirmware(ctx, boardID, ponID)
+	return err
+}
+
+// readFirmware is a method to walk the ONU version tables of a PON and cache the ONU firmware list
+func (u *onuUsecase) readFirmware(ctx context.Context, boardID, ponID int) ([]model.OnuFirmwareInfo, error) {
+
+	// Redis Key
+	redisKey := "board_" + strconv.Itoa(boardID) + "_pon_" + strconv.Itoa(ponID) + "_firmware"
+
+	log.Info().Msg("Get ONU Firmware with SNMP Walk from Board ID: " + strconv.Itoa(
+		boardID) + " and PON ID: " + strconv.Itoa(ponID)) // Log info message to logger
+
+	// ONU version tables are indexed by PON port index and ONU ID
+	ponIndex := "." + strconv.Itoa(utils.GetPonPortIndex(boardID, ponID))
+
+	// Create a map to store ONU firmware information with ONU ID as key
+	firmwareMap := make(map[int]*model.OnuFirmwareInfo)
+	getFirmware := func(onuID int) *model.OnuFirmwareInfo {
+		if _, ok := firmwareMap[onuID]; !ok {
+			firmwareMap[onuID] = &model.OnuFirmwareInfo{
+				Board: boardID,
+				PON:   ponID,
+				ID:    onuID,
+			}
+		}
+		return firmwareMap[onuID]
+	}
+
+	// Walk ONU Type column, every registered ONU has a row in this column
+	err := u.walkColumn(u.cfg.OltCfg.OnuTypeAllPon, ponIndex, func(index []int, value interface{}) {
+		getFirmware(index[0]).OnuType = utils.ExtractName(value)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Walk ONU Vendor ID column
+	err = u.walkColumn(u.cfg.OltCfg.OnuVendorIDOID, ponIndex, func(index []int, value interface{}) {
+		getFirmware(index[0]).VendorID = utils.ExtractName(value)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Walk ONU Equipment ID column
+	err = u.walkColumn(u.cfg.OltCfg.OnuEquipmentIDOID, ponIndex, func(index []int, value interface{}) {
+		getFirmware(index[0]).EquipmentID = utils.ExtractName(value)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Walk ONU Hardware Version column
+	err = u.walkColumn(u.cfg.OltCfg.OnuHardwareVersionOID, ponIndex, func(index []int, value interface{}) {
+		getFirmware(index[0]).HardwareVersion = utils.ExtractName(value)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Walk ONU software image active flag, indexed by ONU ID and image ID (0 or 1)
+	activeImage := make(map[int]int) // ONU ID as key and active image ID as value
+	err = u.walkColumn(u.cfg.OltCfg.OnuSoftwareImageActiveOID, ponIndex, func(index []int, value interface{}) {
+		if len(index) > 1 && utils.ExtractTruthValue(value) {
+			activeImage[index[0]] = index[1]
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Walk ONU software image version, the image not flagged as active is the standby image
+	err = u.walkColumn(u.cfg.OltCfg.OnuSoftwareImageVersionOID, ponIndex, func(index []int, value interface{}) {
+		if len(index) < 2 {
+			return
+		}
+
+		firmware := getFirmware(index[0])
+		if imageID, ok := activeImage[index[0]]; ok && imageID == index[1] {
+			firmware.SoftwareVersionActive = utils.ExtractName(value)
+		} else {
+			firmware.SoftwareVersionStandby = utils.ExtractName(value)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	onuFirmwareList := make([]model.OnuFirmwareInfo, 0, len(firmwareMap))
+	for _, firmware := range firmwareMap {
+		onuFirmwareList = append(onuFirmwareList, *firmware)
+	}
+
+	// Sort ONU firmware list based on ONU ID ascending
+	sort.Slice(onuFirmwareList, func(i, j int) bool {
+		return onuFirmwareList[i].ID < onuFirmwareList[j].ID
+	})
+
+	// Save ONU firmware list to Redis 5 minutes
+	err = u.redisRepository.SaveOnuFirmwareList(ctx, redisKey, 300, onuFirmwareList)
+	if err != nil {
+		log.Error().Msg("Failed to save ONU Firmware to Redis: " + err.Error()) // Log error message to logger
+		return nil, err
+	}
+
+	log.Info().Msg("Save ONU Firmware to Redis with Key: " + redisKey) // Log info message to logger
+
+	return onuFirmwareList, nil
+}
+
+func (u *onuUsecase) GetFirmwareReport(ctx context.Context) (model.FirmwareReport, error) {
+
+	log.Info().Msg("Get ONU Firmware Report from all Board and PON") // Log info message to logger
+
+	report := model.FirmwareReport{
+		Groups: make([]model.FirmwareGroup, 0),
+	}
+
+	// Create a map to group ONU by ONU Type and active software version
+	groupMap := make(map[string]*model.FirmwareGroup)
+
+	var failedPon int // Number of PON that failed to be walked
+
+	// Loop through all Board and PON
+	for boardID := 1; boardID <= maxBoardID; boardID++ {
+		for ponID := 1; ponID <= maxPonID; ponID++ {
+			onuFirmwareList, err := u.GetFirmwareByBoardIDAndPonID(ctx, boardID, ponID)
+			if err != nil {
+				log.Error().Msg("Failed to get ONU Firmware from Board ID: " + strconv.Itoa(
+					boardID) + " and PON ID: " + strconv.Itoa(ponID) + ": " + err.Error())
+				if errors.Is(err, ErrSnmpBudgetExceeded) {
+					return model.FirmwareReport{}, err // The other PON would wait for the SNMP budget too
+				}
+				failedPon++
+				continue
+			}
+
+			for _, firmware := range onuFirmwareList {
+				groupKey := firmware.OnuType + "|" + firmware.SoftwareVersionActive
+				if _, ok := groupMap[groupKey]; !ok {
+					groupMap[groupKey] = &model.FirmwareGroup{
+						OnuType:         firmware.OnuType,
+						SoftwareVersion: firmware.SoftwareVersionActive,
+						Onus:            make([]model.OnuID, 0),
+					}
+				}
+
+				group := groupMap[groupKey]
+				group.Count++
+				group.Onus = append(group.Onus, model.OnuID{
+					Board: firmware.Board,
+					PON:   firmware.PON,
+					ID:    firmware.ID,
+				})
+				report.TotalOnu++
+			}
+		}
+	}
+
+	// Return error if no PON could be walked at all
+	if failedPon == maxBoardID*maxPonID {
+		return model.FirmwareReport{}, errors.New("failed to get ONU firmware from all PON")
+	}
+
+	for _, group := range groupMap {
+		report.Groups = append(report.Groups, *group)
+	}
+
+	// Sort groups based on ONU Type and software version ascending
+	sort.Slice(report.Groups, func(i, j int) bool {
+		if report.Groups[i].OnuType != report.Groups[j].OnuType {
+			return report.Groups[i].OnuType < report.Groups[j].OnuType
+		}
+		return report.Groups[i].SoftwareVersion < report.Groups[j].SoftwareVersion
+	})
+
+	return report, nil
+}
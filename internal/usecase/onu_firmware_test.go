@@ -0,0 +1,155 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/model"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strconv"
+	"testing"
+	"time"
+)
+
+const (
+	testOnuTypeAllPonOID           = ".3.50.11.2.1.17"
+	testOnuVendorIDOID             = ".3.50.11.2.1.1"
+	testOnuEquipmentIDOID          = ".3.50.11.2.1.2"
+	testOnuHardwareVersionOID      = ".3.50.11.2.1.3"
+	testOnuSoftwareImageVersionOID = ".3.50.11.5.1.2"
+	testOnuSoftwareImageActiveOID  = ".3.50.11.5.1.4"
+	testSoftwareImageActive        = 1 // TruthValue true
+	testSoftwareImageInactive      = 2 // TruthValue false
+)
+
+// setTestFirmware sets the version tables of an ONU, the image flagged as active is activeImage (0 or 1)
+func setTestFirmware(agent *fakeSnmpAgent, ponIndex string, onuID int, onuType string, images [2]string, activeImage int) {
+	index := ponIndex + "." + strconv.Itoa(onuID)
+	agent.values[testBaseOID2+testOnuTypeAllPonOID+index] = onuType
+	agent.values[testBaseOID2+testOnuVendorIDOID+index] = "ZTEG"
+	agent.values[testBaseOID2+testOnuEquipmentIDOID+index] = onuType
+	agent.values[testBaseOID2+testOnuHardwareVersionOID+index] = "V1.0"
+	for imageID, version := range images {
+		imageIndex := index + "." + strconv.Itoa(imageID)
+		agent.values[testBaseOID2+testOnuSoftwareImageVersionOID+imageIndex] = version
+		agent.values[testBaseOID2+testOnuSoftwareImageActiveOID+imageIndex] = testSoftwareImageInactive
+		if imageID == activeImage {
+			agent.values[testBaseOID2+testOnuSoftwareImageActiveOID+imageIndex] = testSoftwareImageActive
+		}
+	}
+}
+
+// newTestFirmwareUsecase returns an ONU usecase backed by a fake agent with three ONU on board 1 PON 1 and one ONU
+// on board 2 PON 8
+func newTestFirmwareUsecase() (*onuUsecase, *fakeSnmpAgent, *fakeOnuRedisRepo) {
+	agent := newFakeSnmpAgent()
+
+	// ONU 10 is walked before ONU 2
+	setTestFirmware(agent, testPonPortIndex, 1, "F670L", [2]string{"V1.0.10", "V1.1.0"}, 1)
+	setTestFirmware(agent, testPonPortIndex, 2, "F670L", [2]string{"V1.1.0", "V1.0.10"}, 0)
+	setTestFirmware(agent, testPonPortIndex, 10, "F609", [2]string{"V2.0", ""}, 0)
+	board2Pon8 := "." + strconv.Itoa(utils.GetPonPortIndex(2, 8))
+	setTestFirmware(agent, board2Pon8, 1, "F670L", [2]string{"V1.0.10", "V1.1.0"}, 1)
+
+	cfg := newTestConfig()
+	cfg.OltCfg.OnuTypeAllPon = testOnuTypeAllPonOID
+	cfg.OltCfg.OnuVendorIDOID = testOnuVendorIDOID
+	cfg.OltCfg.OnuEquipmentIDOID = testOnuEquipmentIDOID
+	cfg.OltCfg.OnuHardwareVersionOID = testOnuHardwareVersionOID
+	cfg.OltCfg.OnuSoftwareImageVersionOID = testOnuSoftwareImageVersionOID
+	cfg.OltCfg.OnuSoftwareImageActiveOID = testOnuSoftwareImageActiveOID
+
+	redisRepo := newFakeOnuRedisRepo()
+	u := NewOnuUsecase(agent, redisRepo, newFakeOnuChangeRepo(), newFakeCustomerRepo(), cfg).(*onuUsecase)
+	return u, agent, redisRepo
+}
+
+func TestGetFirmwareByBoardIDAndPonID(t *testing.T) {
+	u, agent, redisRepo := newTestFirmwareUsecase()
+	ctx := context.Background()
+
+	// The image flagged as active is the active version whatever its image ID, ONU are sorted by ONU ID
+	onuFirmwareList, err := u.GetFirmwareByBoardIDAndPonID(ctx, 1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []model.OnuFirmwareInfo{
+		{
+			Board: 1, PON: 1, ID: 1, OnuType: "F670L", SoftwareVersionActive: "V1.1.0",
+			SoftwareVersionStandby: "V1.0.10", HardwareVersion: "V1.0", VendorID: "ZTEG", EquipmentID: "F670L",
+		},
+		{
+			Board: 1, PON: 1, ID: 2, OnuType: "F670L", SoftwareVersionActive: "V1.1.0",
+			SoftwareVersionStandby: "V1.0.10", HardwareVersion: "V1.0", VendorID: "ZTEG", EquipmentID: "F670L",
+		},
+		{
+			Board: 1, PON: 1, ID: 10, OnuType: "F609", SoftwareVersionActive: "V2.0",
+			HardwareVersion: "V1.0", VendorID: "ZTEG", EquipmentID: "F609",
+		},
+	}, onuFirmwareList)
+	assert.Equal(t, onuFirmwareList, redisRepo.firmware["board_1_pon_1_firmware"])
+
+	// The cached list of the PON is returned until it expires
+	setTestFirmware(agent, testPonPortIndex, 1, "F670L", [2]string{"V1.0.10", "V1.2.0"}, 1)
+	onuFirmwareList, err = u.GetFirmwareByBoardIDAndPonID(ctx, 1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "V1.1.0", onuFirmwareList[0].SoftwareVersionActive)
+
+	delete(redisRepo.firmware, "board_1_pon_1_firmware")
+	onuFirmwareList, err = u.GetFirmwareByBoardIDAndPonID(ctx, 1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "V1.2.0", onuFirmwareList[0].SoftwareVersionActive)
+
+	// A failed walk isn't cached
+	delete(redisRepo.firmware, "board_1_pon_1_firmware")
+	agent.walkErrs[testBaseOID2+testOnuSoftwareImageActiveOID+testPonPortIndex] = errors.New("request timeout")
+	_, err = u.GetFirmwareByBoardIDAndPonID(ctx, 1, 1)
+	assert.EqualError(t, err, "failed to walk OID: request timeout")
+	assert.NotContains(t, redisRepo.firmware, "board_1_pon_1_firmware")
+
+	_, err = u.GetFirmwareByBoardIDAndPonID(ctx, 3, 1)
+	assert.Error(t, err)
+}
+
+func TestGetFirmwareReport(t *testing.T) {
+	u, agent, redisRepo := newTestFirmwareUsecase()
+	ctx := context.Background()
+
+	// ONU of every PON are grouped by ONU type and active version
+	report, err := u.GetFirmwareReport(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, model.FirmwareReport{
+		TotalOnu: 4,
+		Groups: []model.FirmwareGroup{
+			{OnuType: "F609", SoftwareVersion: "V2.0", Count: 1, Onus: []model.OnuID{{Board: 1, PON: 1, ID: 10}}},
+			{OnuType: "F670L", SoftwareVersion: "V1.1.0", Count: 3, Onus: []model.OnuID{
+				{Board: 1, PON: 1, ID: 1}, {Board: 1, PON: 1, ID: 2}, {Board: 2, PON: 8, ID: 1},
+			}},
+		},
+	}, report)
+
+	// A PON that can't be walked is left out of the report
+	delete(redisRepo.firmware, "board_1_pon_1_firmware")
+	agent.walkErrs[testBaseOID2+testOnuTypeAllPonOID+testPonPortIndex] = errors.New("request timeout")
+	report, err = u.GetFirmwareReport(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.TotalOnu)
+	require.Len(t, report.Groups, 1)
+	assert.Equal(t, []model.OnuID{{Board: 2, PON: 8, ID: 1}}, report.Groups[0].Onus)
+
+	// The other PON would wait for the SNMP budget too
+	agent.walkErrs[testBaseOID2+testOnuTypeAllPonOID+testPonPortIndex] = &SnmpBudgetError{RetryAfter: time.Second}
+	_, err = u.GetFirmwareReport(ctx)
+	assert.ErrorIs(t, err, ErrSnmpBudgetExceeded)
+
+	// The report fails only when no PON could be walked
+	for boardID := 1; boardID <= maxBoardID; boardID++ {
+		for ponID := 1; ponID <= maxPonID; ponID++ {
+			redisKey := "board_" + strconv.Itoa(boardID) + "_pon_" + strconv.Itoa(ponID) + "_firmware"
+			delete(redisRepo.firmware, redisKey)
+			ponIndex := "." + strconv.Itoa(utils.GetPonPortIndex(boardID, ponID))
+			agent.walkErrs[testBaseOID2+testOnuTypeAllPonOID+ponIndex] = errors.New("request timeout")
+		}
+	}
+	_, err = u.GetFirmwareReport(ctx)
+	assert.EqualError(t, err, "failed to get ONU firmware from all PON")
+}
@@ -0,0 +1,199 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/config"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/model"
+	"github.com/rs/zerolog/log"
+	"strconv"
+	"strings"
+)
+
+const (
+	onuRxPowerHigh     = -8.0  // RX power above this overloads the ONU receiver, in dBm
+	onuRxPowerLow      = -25.0 // RX power below this leaves little margin, in dBm
+	onuRxPowerCritical = -28.0 // RX power below this is near the ONU receiver sensitivity, in dBm
+)
+
+// onuStatusClasses maps ONU phase states to map status classes
+var onuStatusClasses = map[string]string{
+	"Online":          "online",
+	"Logging":         "connecting",
+	"Synchronization": "connecting",
+	"LOS":             "los",
+	"Dying Gasp":      "dying_gasp",
+	"Offline":         "offline",
+	"Auth Failed":     "offline",
+}
+
+// onuMarkerColors maps status classes, and signal classes of online ONU, to marker colors
+var onuMarkerColors = map[string]string{
+	"good":       "#2ecc71",
+	"low":        "#f1c40f",
+	"critical":   "#e67e22",
+	"high":       "#e67e22",
+	"connecting": "#3498db",
+	"los":        "#e74c3c",
+	"dying_gasp": "#8e44ad",
+	"offline":    "#7f8c8d",
+}
+
+// OnuMapFilter selects map ONU, zero values match any
+type OnuMapFilter struct {
+	Olt      string
+	Board    int
+	PON      int
+	Statuses []string // ONU phase states or status classes
+}
+
+// match is a method to check if an ONU status is selected by the filter
+func (f OnuMapFilter) match(status, statusClass string) bool {
+	if len(f.Statuses) == 0 {
+		return true
+	}
+	for _, value := range f.Statuses {
+		value = strings.TrimSpace(value)
+		if strings.EqualFold(value, status) || strings.EqualFold(value, statusClass) {
+			return true
+		}
+	}
+	return false
+}
+
+type OnuMapUseCaseInterface interface {
+	GetOnuMap(ctx context.Context, filter OnuMapFilter) (model.OnuFeatureCollection, error)
+}
+
+type onuMapUsecase struct {
+	onuUsecase OnuUseCaseInterface
+	cfg        *config.Config
+}
+
+// NewOnuMapUsecase returns the usecase placing ONU with customer coordinates on a map
+func NewOnuMapUsecase(onuUsecase OnuUseCaseInterface, cfg *config.Config) OnuMapUseCaseInterface {
+	return &onuMapUsecase{onuUsecase: onuUsecase, cfg: cfg}
+}
+
+// GetOnuMap returns the ONU with customer coordinates as a GeoJSON FeatureCollection of points
+func (u *onuMapUsecase) GetOnuMap(ctx context.Context, filter OnuMapFilter) (model.OnuFeatureCollection, error) {
+
+	collection := model.OnuFeatureCollection{Type: "FeatureCollection", Features: make([]model.OnuFeature, 0)}
+
+	oltName := u.cfg.StreamCfg.OltName
+	if filter.Olt != "" && filter.Olt != oltName {
+		return collection, nil // This service reads a single OLT
+	}
+
+	boardIDs, ponIDs := selectedIDs(filter.Board, maxBoardID), selectedIDs(filter.PON, maxPonID)
+	failed := 0
+
+	for _, boardID := range boardIDs {
+		for _, ponID := range ponIDs {
+			// ONU lists are cached per PON and include the customer of each serial number
+			onuInfoList, err := u.onuUsecase.GetByBoardIDAndPonID(ctx, boardID, ponID)
+			if err != nil {
+				log.Error().Msgf("Failed to get ONU of Board ID: %d and PON ID: %d: %s",
+					boardID, ponID, err.Error()) // Log error message to logger
+				if errors.Is(err, ErrSnmpBudgetExceeded) {
+					return model.OnuFeatureCollection{}, err // The other PON would wait for the SNMP budget too
+				}
+				failed++
+				continue
+			}
+
+			for _, onuInfo := range onuInfoList {
+				customer := onuInfo.Customer
+				if customer == nil || customer.Latitude == nil || customer.Longitude == nil {
+					continue
+				}
+
+				statusClass, signalClass := classifyOnuStatus(onuInfo.Status), classifyRxPower(onuInfo.RXPower)
+				if !filter.match(onuInfo.Status, statusClass) {
+					continue
+				}
+
+				markerColor := onuMarkerColors[statusClass]
+				if statusClass == "online" {
+					markerColor = onuMarkerColors[signalClass]
+					if markerColor == "" {
+						markerColor = onuMarkerColors["good"] // Online without a reading
+					}
+				}
+
+				collection.Features = append(collection.Features, model.OnuFeature{
+					Type: "Feature",
+					ID:   fmt.Sprintf("%s/%d/%d/%d", oltName, onuInfo.Board, onuInfo.PON, onuInfo.ID),
+					Geometry: model.PointGeometry{
+						Type:        "Point",
+						Coordinates: [2]float64{*customer.Longitude, *customer.Latitude},
+					},
+					Properties: model.OnuMapProperties{
+						Olt:          oltName,
+						Board:        onuInfo.Board,
+						PON:          onuInfo.PON,
+						ID:           onuInfo.ID,
+						Name:         onuInfo.Name,
+						SerialNumber: onuInfo.SerialNumber,
+						Status:       onuInfo.Status,
+						RXPower:      onuInfo.RXPower,
+						StatusClass:  statusClass,
+						SignalClass:  signalClass,
+						MarkerColor:  markerColor,
+						CustomerID:   customer.CustomerID,
+						CustomerName: customer.Name,
+						Address:      customer.Address,
+						Package:      customer.Package,
+					},
+				})
+			}
+		}
+	}
+
+	if failed == len(boardIDs)*len(ponIDs) {
+		return model.OnuFeatureCollection{}, fmt.Errorf("failed to get ONU of all selected PON")
+	}
+
+	return collection, nil
+}
+
+// selectedIDs is a function to list the selected ID, or all ID from 1 to maxID when none is selected
+func selectedIDs(selected, maxID int) []int {
+	if selected != 0 {
+		return []int{selected}
+	}
+
+	ids := make([]int, 0, maxID)
+	for id := 1; id <= maxID; id++ {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// classifyOnuStatus is a function to get the map status class of an ONU phase state
+func classifyOnuStatus(status string) string {
+	if statusClass, ok := onuStatusClasses[status]; ok {
+		return statusClass
+	}
+	return "offline"
+}
+
+// classifyRxPower is a function to get the signal class of an ONU RX power in dBm
+func classifyRxPower(rxPower string) string {
+	value, err := strconv.ParseFloat(rxPower, 64)
+	if err != nil {
+		return "unknown"
+	}
+
+	switch {
+	case value > onuRxPowerHigh:
+		return "high"
+	case value < onuRxPowerCritical:
+		return "critical"
+	case value < onuRxPowerLow:
+		return "low"
+	default:
+		return "good"
+	}
+}
@@ -0,0 +1,98 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// fakeOnuListSource serves GetByBoardIDAndPonID from lists keyed by board and PON, PON without a list fail
+type fakeOnuListSource struct {
+	OnuUseCaseInterface
+	lists map[[2]int][]model.ONUInfoPerBoard
+}
+
+func (f *fakeOnuListSource) GetByBoardIDAndPonID(_ context.Context, boardID, ponID int) (
+	[]model.ONUInfoPerBoard, error,
+) {
+	list, ok := f.lists[[2]int{boardID, ponID}]
+	if !ok {
+		return nil, errors.New("request timeout")
+	}
+	return list, nil
+}
+
+func TestGetOnuMap(t *testing.T) {
+	latitude, longitude := -6.2088, 106.8456
+	customer := &model.OnuCustomer{
+		SerialNumber: "ZTEGC0000001", CustomerID: "CUST-001", Name: "Budi", Latitude: &latitude, Longitude: &longitude,
+	}
+
+	source := &fakeOnuListSource{lists: map[[2]int][]model.ONUInfoPerBoard{
+		{1, 1}: {
+			{Board: 1, PON: 1, ID: 1, Name: "budi", SerialNumber: "ZTEGC0000001", Status: "Online", RXPower: "-26.50",
+				Customer: customer},
+			{Board: 1, PON: 1, ID: 2, SerialNumber: "ZTEGC0000002", Status: "Online"},
+			{Board: 1, PON: 1, ID: 3, SerialNumber: "ZTEGC0000003", Status: "LOS", Customer: customer},
+		},
+		{2, 4}: {
+			{Board: 2, PON: 4, ID: 9, SerialNumber: "ZTEGC0000009", Status: "Online", RXPower: "-20.00",
+				Customer: &model.OnuCustomer{SerialNumber: "ZTEGC0000009"}},
+		},
+	}}
+	cfg := newTestConfig()
+	cfg.StreamCfg.OltName = "olt-1"
+	u := NewOnuMapUsecase(source, cfg)
+	ctx := context.Background()
+
+	// ONU without customer coordinates are left out, other PON fail
+	collection, err := u.GetOnuMap(ctx, OnuMapFilter{})
+	assert.NoError(t, err)
+	assert.Equal(t, "FeatureCollection", collection.Type)
+	assert.Len(t, collection.Features, 2)
+
+	feature := collection.Features[0]
+	assert.Equal(t, "Feature", feature.Type)
+	assert.Equal(t, "olt-1/1/1/1", feature.ID)
+	assert.Equal(t, model.PointGeometry{Type: "Point", Coordinates: [2]float64{106.8456, -6.2088}}, feature.Geometry)
+	assert.Equal(t, "online", feature.Properties.StatusClass)
+	assert.Equal(t, "low", feature.Properties.SignalClass)
+	assert.Equal(t, "#f1c40f", feature.Properties.MarkerColor)
+	assert.Equal(t, "CUST-001", feature.Properties.CustomerID)
+	assert.Equal(t, "#e74c3c", collection.Features[1].Properties.MarkerColor)
+
+	// Filter by status class or phase state
+	collection, err = u.GetOnuMap(ctx, OnuMapFilter{Board: 1, PON: 1, Statuses: []string{"los"}})
+	assert.NoError(t, err)
+	assert.Len(t, collection.Features, 1)
+	assert.Equal(t, 3, collection.Features[0].Properties.ID)
+
+	collection, err = u.GetOnuMap(ctx, OnuMapFilter{Statuses: []string{"Online"}})
+	assert.NoError(t, err)
+	assert.Len(t, collection.Features, 1)
+
+	// Other OLT are empty, failing PON fail the map
+	collection, err = u.GetOnuMap(ctx, OnuMapFilter{Olt: "olt-2"})
+	assert.NoError(t, err)
+	assert.Empty(t, collection.Features)
+
+	_, err = u.GetOnuMap(ctx, OnuMapFilter{Board: 1, PON: 2})
+	assert.Error(t, err)
+}
+
+func TestClassifyRxPower(t *testing.T) {
+	tests := map[string]string{
+		"-5.00":  "high",
+		"-8.00":  "good",
+		"-24.99": "good",
+		"-25.10": "low",
+		"-28.10": "critical",
+		"":       "unknown",
+		"N/A":    "unknown",
+	}
+	for rxPower, expected := range tests {
+		assert.Equal(t, expected, classifyRxPower(rxPower), rxPower)
+	}
+}
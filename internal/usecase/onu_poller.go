@@ -0,0 +1,170 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"github.com/gosnmp/gosnmp"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/config"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/model"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/repository"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/utils"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/pkg/pubsub"
+	"github.com/rs/zerolog/log"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+)
+
+type OnuPollerUseCaseInterface interface {
+	Poll(ctx context.Context) ([]model.OnuEvent, error)
+	Run(ctx context.Context, interval time.Duration)
+}
+
+// onuSnapshot is the last reported status and RX power of an ONU
+type onuSnapshot struct {
+	status  string
+	rxPower string
+}
+
+type onuPollerUsecase struct {
+	snmpRepository repository.SnmpRepositoryInterface
+	publisher      *onuEventPublisher
+	cfg            *config.Config
+	snapshot       map[[3]int]onuSnapshot // Keyed by board, PON and ONU ID, nil until the first poll
+}
+
+// NewOnuPollerUsecase returns the usecase detecting ONU status and RX power changes by walking all PON
+func NewOnuPollerUsecase(
+	snmpRepository repository.SnmpRepositoryInterface, redisRepository repository.OnuRedisRepositoryInterface,
+	broker *pubsub.Broker[model.OnuEvent], cfg *config.Config,
+) OnuPollerUseCaseInterface {
+	return &onuPollerUsecase{
+		snmpRepository: snmpRepository,
+		publisher: &onuEventPublisher{
+			redisRepository: redisRepository,
+			broker:          broker,
+			oltName:         cfg.StreamCfg.OltName,
+		},
+		cfg: cfg,
+	}
+}
+
+// Run polls every interval until ctx is done
+func (u *onuPollerUsecase) Run(ctx context.Context, interval time.Duration) {
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := u.Poll(ctx); err != nil {
+			log.Error().Msg("Failed to poll ONU changes: " + err.Error()) // Log error message to logger
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll walks ONU status and RX power of all PON and publishes the changes since the previous poll,
+// the first poll only records the current values
+func (u *onuPollerUsecase) Poll(ctx context.Context) ([]model.OnuEvent, error) {
+
+	current := make(map[[3]int]onuSnapshot)
+
+	// Walk ONU phase state of all PON, indexed by gpon-olt ifIndex and ONU ID
+	statusColumn := u.cfg.OltCfg.BaseOID1 + u.cfg.OltCfg.OnuStatusAllPon
+	err := u.snmpRepository.Walk(statusColumn, func(pdu gosnmp.SnmpPDU) error {
+		boardID, ponID, onuID, ok := decodeOnuVarbind(pdu.Name, statusColumn)
+		if ok {
+			current[[3]int{boardID, ponID, onuID}] = onuSnapshot{status: utils.ExtractAndGetStatus(pdu.Value)}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk ONU status: %w", err)
+	}
+
+	// Walk ONU RX power of all PON, indexed by gpon-olt ifIndex, ONU ID and 1
+	rxPowerColumn := u.cfg.OltCfg.BaseOID1 + u.cfg.OltCfg.OnuRxPowerAllPon
+	err = u.snmpRepository.Walk(rxPowerColumn, func(pdu gosnmp.SnmpPDU) error {
+		index := utils.ExtractOIDIndex(pdu.Name, rxPowerColumn)
+		if len(index) != 3 || index[2] != 1 {
+			return nil
+		}
+		boardID, ponID, ok := utils.DecodeIfIndex(index[0])
+		if !ok {
+			return nil
+		}
+		key := [3]int{boardID, ponID, index[1]}
+		if snapshot, ok := current[key]; ok {
+			snapshot.rxPower, _ = utils.ConvertAndMultiply(pdu.Value)
+			current[key] = snapshot
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk ONU RX power: %w", err)
+	}
+
+	if u.snapshot == nil {
+		u.snapshot = current
+		return nil, nil
+	}
+
+	// Sort keys so events are published in board, PON and ONU ID order
+	keys := make([][3]int, 0, len(current))
+	for key := range current {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		for k := range keys[i] {
+			if keys[i][k] != keys[j][k] {
+				return keys[i][k] < keys[j][k]
+			}
+		}
+		return false
+	})
+
+	var events []model.OnuEvent
+	for _, key := range keys {
+		snapshot := current[key]
+		previous, known := u.snapshot[key]
+
+		if !known || previous.status != snapshot.status {
+			events = append(events, model.OnuEvent{
+				Type: OnuEventStatus, Board: key[0], PON: key[1], ID: key[2], Status: snapshot.status,
+			})
+		}
+
+		// Keep the last reported RX power, so slow drift is reported once it reaches the threshold
+		if known {
+			if u.rxPowerChanged(previous.rxPower, snapshot.rxPower) {
+				events = append(events, model.OnuEvent{
+					Type: OnuEventOptical, Board: key[0], PON: key[1], ID: key[2], RxPower: snapshot.rxPower,
+				})
+			} else {
+				snapshot.rxPower = previous.rxPower
+			}
+		}
+
+		current[key] = snapshot
+	}
+	u.snapshot = current
+
+	return u.publisher.publish(ctx, events, onuEventSourcePoll), nil
+}
+
+// rxPowerChanged is a method to check if RX power changed by at least the configured threshold in dBm
+func (u *onuPollerUsecase) rxPowerChanged(previous, current string) bool {
+	previousValue, previousErr := strconv.ParseFloat(previous, 64)
+	currentValue, currentErr := strconv.ParseFloat(current, 64)
+	if previousErr != nil || currentErr != nil {
+		return previous != current
+	}
+
+	return math.Abs(currentValue-previousValue) >= u.cfg.StreamCfg.RxPowerThreshold
+}
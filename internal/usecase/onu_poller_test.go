@@ -0,0 +1,82 @@
+package usecase
+
+import (
+	"context"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/model"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/pkg/pubsub"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+const (
+	testStatusColumn  = testBaseOID1 + ".500.10.2.3.8.1.4"
+	testRxPowerColumn = testBaseOID1 + ".500.20.2.2.2.1.10"
+)
+
+// rxPowerValue returns the raw SNMP value of an RX power in dBm
+func rxPowerValue(dBm float64) int {
+	return int((dBm + 30) / 0.002)
+}
+
+func TestPollOnuChanges(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.OltCfg.OnuStatusAllPon = ".500.10.2.3.8.1.4"
+	cfg.OltCfg.OnuRxPowerAllPon = ".500.20.2.2.2.1.10"
+	cfg.StreamCfg.OltName = "olt-1"
+	cfg.StreamCfg.RxPowerThreshold = 1
+
+	// ONU 1 and 2 of board 1 PON 1 are Online
+	agent := newFakeSnmpAgent()
+	agent.values[testStatusColumn+".285278465.1"] = 4
+	agent.values[testStatusColumn+".285278465.2"] = 4
+	agent.values[testRxPowerColumn+".285278465.1.1"] = rxPowerValue(-20)
+	agent.values[testRxPowerColumn+".285278465.2.1"] = rxPowerValue(-20)
+
+	redisRepo := newFakeOnuRedisRepo()
+	redisRepo.onuInfo["board_1_pon_1"] = []model.ONUInfoPerBoard{
+		{Board: 1, PON: 1, ID: 1, Status: "Online", RXPower: "-20.00"},
+		{Board: 1, PON: 1, ID: 2, Status: "Online", RXPower: "-20.00"},
+	}
+
+	broker := pubsub.NewBroker[model.OnuEvent]()
+	published, _ := broker.Subscribe(10)
+	u := NewOnuPollerUsecase(agent, redisRepo, broker, cfg)
+
+	// First poll only records the current values
+	events, err := u.Poll(context.Background())
+	assert.NoError(t, err)
+	assert.Empty(t, events)
+
+	// ONU 1 goes LOS, ONU 2 RX power drifts below the threshold and ONU 3 of board 2 PON 8 appears
+	agent.values[testStatusColumn+".285278465.1"] = 2
+	agent.values[testRxPowerColumn+".285278465.2.1"] = rxPowerValue(-20.6)
+	agent.values[testStatusColumn+".285278728.3"] = 4
+	agent.values[testRxPowerColumn+".285278728.3.1"] = rxPowerValue(-18)
+
+	events, err = u.Poll(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []model.OnuEvent{
+		{Type: OnuEventStatus, Olt: "olt-1", Board: 1, PON: 1, ID: 1, Status: "LOS", Source: "poll",
+			Timestamp: events[0].Timestamp},
+		{Type: OnuEventStatus, Olt: "olt-1", Board: 2, PON: 8, ID: 3, Status: "Online", Source: "poll",
+			Timestamp: events[1].Timestamp},
+	}, events)
+	assert.Len(t, published, 2)
+	assert.Equal(t, "LOS", redisRepo.onuInfo["board_1_pon_1"][0].Status)
+
+	// ONU 2 RX power drift reaches the threshold compared to the last reported value
+	agent.values[testRxPowerColumn+".285278465.2.1"] = rxPowerValue(-21.2)
+
+	events, err = u.Poll(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []model.OnuEvent{
+		{Type: OnuEventOptical, Olt: "olt-1", Board: 1, PON: 1, ID: 2, RxPower: "-21.20", Source: "poll",
+			Timestamp: events[0].Timestamp},
+	}, events)
+	assert.Equal(t, "-21.20", redisRepo.onuInfo["board_1_pon_1"][1].RXPower)
+
+	// Nothing changed
+	events, err = u.Poll(context.Background())
+	assert.NoError(t, err)
+	assert.Empty(t, events)
+}
@@ -0,0 +1,112 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/model"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/repository"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/pkg/oltcli"
+	"github.com/rs/zerolog/log"
+	"strconv"
+)
+
+var ErrCliNotConfigured = errors.New("olt cli is not configured")
+
+type OnuServiceUseCaseInterface interface {
+	GetOnuServices(ctx context.Context, boardID, ponID, onuID int) (model.OnuServiceInfo, error)
+}
+
+type onuServiceUsecase struct {
+	cliRepository repository.OltCliRepositoryInterface
+}
+
+// NewOnuServiceUsecase returns the ONU service usecase, cliRepository is nil when the OLT CLI is not configured
+func NewOnuServiceUsecase(cliRepository repository.OltCliRepositoryInterface) OnuServiceUseCaseInterface {
+	return &onuServiceUsecase{cliRepository: cliRepository}
+}
+
+func (u *onuServiceUsecase) GetOnuServices(ctx context.Context, boardID, ponID, onuID int) (
+	model.OnuServiceInfo, error,
+) {
+
+	// Service-port, TCONT and GEM port config is only available from the OLT CLI
+	if u.cliRepository == nil {
+		return model.OnuServiceInfo{}, ErrCliNotConfigured
+	}
+
+	log.Info().Msg("Get ONU Services from OLT CLI for Board ID: " + strconv.Itoa(boardID) + " PON ID: " +
+		strconv.Itoa(ponID) + " ONU ID: " + strconv.Itoa(onuID)) // Log info message to logger
+
+	runningConfig, err := u.cliRepository.GetOnuRunningConfig(ctx, boardID, ponID, onuID)
+	if err != nil {
+		// The OLT rejects the command or shows no interface when the ONU is not configured
+		var commandErr *oltcli.CommandError
+		if errors.Is(err, oltcli.ErrNoOnuInterface) || errors.As(err, &commandErr) {
+			return model.OnuServiceInfo{}, ErrOnuNotFound
+		}
+		log.Error().Msg("Failed to get ONU running config: " + err.Error()) // Log error message to logger
+		return model.OnuServiceInfo{}, err
+	}
+
+	onuServiceInfo := model.OnuServiceInfo{
+		Board:        boardID,
+		PON:          ponID,
+		ID:           onuID,
+		Name:         runningConfig.Name,
+		Description:  runningConfig.Description,
+		Tconts:       make([]model.OnuTcont, 0, len(runningConfig.Tconts)),
+		Gemports:     make([]model.OnuGemport, 0, len(runningConfig.Gemports)),
+		ServicePorts: make([]model.OnuServicePort, 0, len(runningConfig.ServicePorts)),
+	}
+
+	for _, tcont := range runningConfig.Tconts {
+		onuServiceInfo.Tconts = append(onuServiceInfo.Tconts, model.OnuTcont{
+			ID:      tcont.ID,
+			Name:    tcont.Name,
+			Profile: tcont.Profile,
+		})
+	}
+
+	for _, gemport := range runningConfig.Gemports {
+		onuServiceInfo.Gemports = append(onuServiceInfo.Gemports, model.OnuGemport{
+			ID:                gemport.ID,
+			Name:              gemport.Name,
+			Tcont:             gemport.Tcont,
+			UpstreamProfile:   gemport.UpstreamProfile,
+			DownstreamProfile: gemport.DownstreamProfile,
+		})
+	}
+
+	for _, servicePort := range runningConfig.ServicePorts {
+		onuServicePort := model.OnuServicePort{
+			ID:          servicePort.ID,
+			Vport:       servicePort.Vport,
+			UserVlan:    servicePort.UserVlan,
+			UserEtype:   servicePort.UserEtype,
+			Vlan:        servicePort.Vlan,
+			Svlan:       servicePort.Svlan,
+			Translation: getVlanTranslation(servicePort),
+		}
+		if servicePort.NewCos >= 0 {
+			newCos := servicePort.NewCos
+			onuServicePort.NewCos = &newCos
+		}
+		onuServiceInfo.ServicePorts = append(onuServiceInfo.ServicePorts, onuServicePort)
+	}
+
+	return onuServiceInfo, nil
+}
+
+// getVlanTranslation is a function to describe how the user VLAN is mapped to the network VLAN
+func getVlanTranslation(servicePort oltcli.ServicePort) string {
+	switch {
+	case servicePort.Svlan > 0:
+		return "qinq" // Network VLAN is stacked under an outer SVLAN
+	case servicePort.UserVlan == 0:
+		return "untagged" // Untagged user traffic is tagged with the network VLAN
+	case servicePort.UserVlan == servicePort.Vlan:
+		return "transparent"
+	default:
+		return "translate"
+	}
+}
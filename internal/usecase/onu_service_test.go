@@ -0,0 +1,85 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/model"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/pkg/oltcli"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// fakeCliRepo is an OLT CLI repository returning a fixed running-config or error
+type fakeCliRepo struct {
+	runningConfig oltcli.OnuRunningConfig
+	err           error
+}
+
+func (f *fakeCliRepo) GetOnuDetailInfo(_ context.Context, _, _, _ int) (oltcli.OnuDetailInfo, error) {
+	return oltcli.OnuDetailInfo{}, f.err
+}
+
+func (f *fakeCliRepo) GetOnuStateList(_ context.Context, _, _ int) ([]oltcli.OnuState, error) {
+	return nil, f.err
+}
+
+func (f *fakeCliRepo) GetOnuRunningConfig(_ context.Context, _, _, _ int) (oltcli.OnuRunningConfig, error) {
+	return f.runningConfig, f.err
+}
+
+func TestGetOnuServices(t *testing.T) {
+	u := NewOnuServiceUsecase(&fakeCliRepo{runningConfig: oltcli.OnuRunningConfig{
+		Interface: "gpon-onu_1/1/1:1",
+		Name:      "customer-001",
+		Tconts:    []oltcli.Tcont{{ID: 1, Name: "HSI", Profile: "UP-100M"}},
+		Gemports:  []oltcli.Gemport{{ID: 1, Name: "HSI", Tcont: 1, UpstreamProfile: "UP-100M"}},
+		ServicePorts: []oltcli.ServicePort{
+			{ID: 1, Vport: 1, UserVlan: 100, Vlan: 100, NewCos: -1},
+			{ID: 2, Vport: 1, UserVlan: 200, Vlan: 300, NewCos: 5},
+			{ID: 3, Vport: 2, UserVlan: 0, Vlan: 400, NewCos: -1},
+			{ID: 4, Vport: 2, UserVlan: 500, Vlan: 500, Svlan: 1000, NewCos: -1},
+		},
+	}})
+
+	onuServiceInfo, err := u.GetOnuServices(context.Background(), 1, 1, 1)
+
+	newCos := 5
+	assert.NoError(t, err)
+	assert.Equal(t, model.OnuServiceInfo{
+		Board:    1,
+		PON:      1,
+		ID:       1,
+		Name:     "customer-001",
+		Tconts:   []model.OnuTcont{{ID: 1, Name: "HSI", Profile: "UP-100M"}},
+		Gemports: []model.OnuGemport{{ID: 1, Name: "HSI", Tcont: 1, UpstreamProfile: "UP-100M"}},
+		ServicePorts: []model.OnuServicePort{
+			{ID: 1, Vport: 1, UserVlan: 100, Vlan: 100, Translation: "transparent"},
+			{ID: 2, Vport: 1, UserVlan: 200, Vlan: 300, Translation: "translate", NewCos: &newCos},
+			{ID: 3, Vport: 2, UserVlan: 0, Vlan: 400, Translation: "untagged"},
+			{ID: 4, Vport: 2, UserVlan: 500, Vlan: 500, Svlan: 1000, Translation: "qinq"},
+		},
+	}, onuServiceInfo)
+}
+
+func TestGetOnuServicesErrors(t *testing.T) {
+	transportErr := errors.New("connection reset")
+
+	testCases := []struct {
+		name     string
+		usecase  OnuServiceUseCaseInterface
+		expected error
+	}{
+		{"CLI not configured", NewOnuServiceUsecase(nil), ErrCliNotConfigured},
+		{"ONU not configured", NewOnuServiceUsecase(&fakeCliRepo{err: oltcli.ErrNoOnuInterface}), ErrOnuNotFound},
+		{"Command rejected", NewOnuServiceUsecase(&fakeCliRepo{err: &oltcli.CommandError{Command: "show",
+			Message: "%Code 32310-GPONSRV : Invalid ONU ID."}}), ErrOnuNotFound},
+		{"Transport error", NewOnuServiceUsecase(&fakeCliRepo{err: transportErr}), transportErr},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := tc.usecase.GetOnuServices(context.Background(), 1, 1, 1)
+			assert.ErrorIs(t, err, tc.expected)
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package usecase
+
+import (
+	"context"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/model"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/pkg/pubsub"
+	"sync"
+	"time"
+)
+
+// onuStreamSourceBuffer is the number of source events waiting to be numbered, e.g. a burst of traps
+const onuStreamSourceBuffer = 1024
+
+// OnuEventFilter selects stream events, zero values match any
+type OnuEventFilter struct {
+	Olt   string
+	Board int
+	PON   int
+	ID    int
+	Types []string
+}
+
+// Match reports whether event is selected by the filter
+func (f OnuEventFilter) Match(event model.OnuEvent) bool {
+	if (f.Olt != "" && f.Olt != event.Olt) || (f.Board != 0 && f.Board != event.Board) ||
+		(f.PON != 0 && f.PON != event.PON) || (f.ID != 0 && f.ID != event.ID) {
+		return false
+	}
+
+	if len(f.Types) == 0 {
+		return true
+	}
+	for _, eventType := range f.Types {
+		if eventType == event.Type {
+			return true
+		}
+	}
+
+	return false
+}
+
+type OnuStreamUseCaseInterface interface {
+	Subscribe(lastEventID uint64, buffer int) ([]model.OnuEvent, <-chan model.OnuEvent, func())
+	Run(ctx context.Context)
+}
+
+type onuStreamUsecase struct {
+	source       <-chan model.OnuEvent
+	cancelSource func()
+	stream       *pubsub.Broker[model.OnuEvent]
+
+	mu          sync.Mutex
+	history     []model.OnuEvent // Ring buffer of the latest events, oldest at historyNext once full
+	historyNext int
+	historySize int
+	lastEventID uint64
+}
+
+// NewOnuStreamUsecase returns the usecase numbering events from source and keeping the latest historySize events
+// so clients can resume with Last-Event-ID
+func NewOnuStreamUsecase(source *pubsub.Broker[model.OnuEvent], historySize int) OnuStreamUseCaseInterface {
+	if historySize <= 0 {
+		historySize = 1000
+	}
+
+	// Subscribe now so no event published before Run is started is lost
+	sourceCh, cancelSource := source.Subscribe(onuStreamSourceBuffer)
+
+	return &onuStreamUsecase{
+		source:       sourceCh,
+		cancelSource: cancelSource,
+		stream:       pubsub.NewBroker[model.OnuEvent](),
+		history:      make([]model.OnuEvent, 0, historySize),
+		historySize:  historySize,
+		// Event IDs start from the current time, so IDs from before a restart are older than all new events
+		lastEventID: uint64(time.Now().UnixMilli()) * 1000,
+	}
+}
+
+// Run numbers and forwards source events until ctx is done or the source is closed, then closes all subscriptions
+func (u *onuStreamUsecase) Run(ctx context.Context) {
+
+	defer u.stream.Close()
+	defer u.cancelSource()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-u.source:
+			if !ok {
+				return
+			}
+			u.add(event)
+		}
+	}
+}
+
+// add is a method to number an event, keep it in history and forward it to subscribers
+func (u *onuStreamUsecase) add(event model.OnuEvent) {
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.lastEventID++
+	event.EventID = u.lastEventID
+
+	if len(u.history) < u.historySize {
+		u.history = append(u.history, event)
+	} else {
+		u.history[u.historyNext] = event
+		u.historyNext = (u.historyNext + 1) % u.historySize
+	}
+
+	// Publish under the lock so a concurrent Subscribe gets the event either in replay or live, never both
+	u.stream.Publish(event)
+}
+
+// Subscribe returns the kept events newer than lastEventID (none when it is 0), a channel of new events and
+// a function to cancel the subscription
+func (u *onuStreamUsecase) Subscribe(lastEventID uint64, buffer int) ([]model.OnuEvent, <-chan model.OnuEvent, func()) {
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	events, cancel := u.stream.Subscribe(buffer)
+
+	var replay []model.OnuEvent
+	if lastEventID > 0 {
+		for i := range u.history {
+			event := u.history[(u.historyNext+i)%len(u.history)]
+			if event.EventID > lastEventID {
+				replay = append(replay, event)
+			}
+		}
+	}
+
+	return replay, events, cancel
+}
@@ -0,0 +1,92 @@
+package usecase
+
+import (
+	"context"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/model"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/pkg/pubsub"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// receiveEvent returns the next event of ch or fails after a timeout
+func receiveEvent(t *testing.T, ch <-chan model.OnuEvent) model.OnuEvent {
+	select {
+	case event := <-ch:
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("event not received")
+		return model.OnuEvent{}
+	}
+}
+
+func TestOnuStream(t *testing.T) {
+	source := pubsub.NewBroker[model.OnuEvent]()
+	u := NewOnuStreamUsecase(source, 2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		u.Run(ctx)
+		close(done)
+	}()
+
+	_, live, cancelLive := u.Subscribe(0, 10)
+	defer cancelLive()
+
+	// Events published before anybody listens are numbered in order
+	for onuID := 1; onuID <= 3; onuID++ {
+		source.Publish(model.OnuEvent{Type: OnuEventStatus, Board: 1, PON: 1, ID: onuID})
+	}
+	first := receiveEvent(t, live)
+	second := receiveEvent(t, live)
+	third := receiveEvent(t, live)
+	assert.Equal(t, 1, first.ID)
+	assert.Equal(t, first.EventID+1, second.EventID)
+	assert.Equal(t, second.EventID+1, third.EventID)
+
+	// Resume after the first event, only the last 2 events are kept
+	replay, _, cancelReplay := u.Subscribe(first.EventID, 10)
+	cancelReplay()
+	assert.Equal(t, []model.OnuEvent{second, third}, replay)
+
+	replay, _, cancelReplay = u.Subscribe(third.EventID, 10)
+	cancelReplay()
+	assert.Empty(t, replay)
+
+	// Last-Event-ID from before a restart replays all kept events
+	replay, _, cancelReplay = u.Subscribe(1, 10)
+	cancelReplay()
+	assert.Equal(t, []model.OnuEvent{second, third}, replay)
+
+	// Subscriptions are closed when Run ends
+	cancel()
+	<-done
+	_, ok := <-live
+	assert.False(t, ok)
+}
+
+func TestOnuEventFilter(t *testing.T) {
+	event := model.OnuEvent{Type: OnuEventOptical, Olt: "olt-1", Board: 1, PON: 8, ID: 11}
+
+	testCases := []struct {
+		name     string
+		filter   OnuEventFilter
+		expected bool
+	}{
+		{"No filter", OnuEventFilter{}, true},
+		{"Same ONU", OnuEventFilter{Olt: "olt-1", Board: 1, PON: 8, ID: 11}, true},
+		{"Other OLT", OnuEventFilter{Olt: "olt-2"}, false},
+		{"Other board", OnuEventFilter{Board: 2}, false},
+		{"Other PON", OnuEventFilter{PON: 1}, false},
+		{"Other ONU", OnuEventFilter{ID: 12}, false},
+		{"Matching type", OnuEventFilter{Types: []string{OnuEventStatus, OnuEventOptical}}, true},
+		{"Other type", OnuEventFilter{Types: []string{OnuEventAlarm}}, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.filter.Match(event))
+		})
+	}
+}
@@ -0,0 +1,290 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"github.com/gosnmp/gosnmp"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/config"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/model"
+	"github.com/stretchr/testify/assert"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeSnmpAgent is an in-memory SNMP agent that serves Get and Walk from a map and records every Set
+type fakeSnmpAgent struct {
+	values    map[string]interface{}
+	getErrs   map[string]error // Errors of a Get of an OID
+	walkErrs  map[string]error // Errors of a Walk of an OID
+	sets      [][]gosnmp.SnmpPDU
+	setErr    gosnmp.SNMPError
+	setErrOID string // Only a Set of this OID is rejected with setErr when not empty
+}
+
+func newFakeSnmpAgent() *fakeSnmpAgent {
+	return &fakeSnmpAgent{
+		values: make(map[string]interface{}), getErrs: make(map[string]error), walkErrs: make(map[string]error),
+	}
+}
+
+func (f *fakeSnmpAgent) Get(oids []string) (*gosnmp.SnmpPacket, error) {
+	result := &gosnmp.SnmpPacket{}
+	for _, oid := range oids {
+		if err := f.getErrs[oid]; err != nil {
+			return nil, err
+		}
+		value, ok := f.values[oid]
+		if !ok {
+			result.Variables = append(result.Variables, gosnmp.SnmpPDU{Name: oid, Type: gosnmp.NoSuchInstance})
+			continue
+		}
+		result.Variables = append(result.Variables, gosnmp.SnmpPDU{Name: oid, Type: gosnmp.OctetString, Value: value})
+	}
+	return result, nil
+}
+
+func (f *fakeSnmpAgent) Walk(oid string, walkFunc func(pdu gosnmp.SnmpPDU) error) error {
+	if err := f.walkErrs[oid]; err != nil {
+		return err
+	}
+
+	names := make([]string, 0)
+	for name := range f.values {
+		if strings.HasPrefix(name, oid+".") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := walkFunc(gosnmp.SnmpPDU{Name: name, Type: gosnmp.OctetString, Value: f.values[name]}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *fakeSnmpAgent) Set(pdus []gosnmp.SnmpPDU) (*gosnmp.SnmpPacket, error) {
+	f.sets = append(f.sets, pdus)
+	if f.setErrOID != "" && pdus[0].Name != f.setErrOID {
+		return &gosnmp.SnmpPacket{Variables: pdus}, nil
+	}
+	return &gosnmp.SnmpPacket{Error: f.setErr, Variables: pdus}, nil
+}
+
+// fakeOnuRedisRepo is an in-memory implementation of OnuRedisRepositoryInterface
+type fakeOnuRedisRepo struct {
+	onuID    map[string][]model.OnuID
+	onuInfo  map[string][]model.ONUInfoPerBoard
+	onlyID   map[string][]model.OnuOnlyID
+	firmware map[string][]model.OnuFirmwareInfo
+	deleted  []string
+}
+
+func newFakeOnuRedisRepo() *fakeOnuRedisRepo {
+	return &fakeOnuRedisRepo{
+		onuID:    make(map[string][]model.OnuID),
+		onuInfo:  make(map[string][]model.ONUInfoPerBoard),
+		onlyID:   make(map[string][]model.OnuOnlyID),
+		firmware: make(map[string][]model.OnuFirmwareInfo),
+	}
+}
+
+var errFakeRedisNil = errors.New("redis: nil")
+
+func (f *fakeOnuRedisRepo) GetOnuIDCtx(_ context.Context, key string) ([]model.OnuID, error) {
+	if value, ok := f.onuID[key]; ok {
+		return value, nil
+	}
+	return nil, errFakeRedisNil
+}
+
+func (f *fakeOnuRedisRepo) SetOnuIDCtx(_ context.Context, key string, _ int, onuId []model.OnuID) error {
+	f.onuID[key] = onuId
+	return nil
+}
+
+func (f *fakeOnuRedisRepo) DeleteOnuIDCtx(_ context.Context, key string) error {
+	delete(f.onuID, key)
+	delete(f.onuInfo, key)
+	delete(f.onlyID, key)
+	delete(f.firmware, key)
+	f.deleted = append(f.deleted, key)
+	return nil
+}
+
+func (f *fakeOnuRedisRepo) SaveONUInfoList(_ context.Context, key string, _ int, onuInfoList []model.ONUInfoPerBoard) error {
+	f.onuInfo[key] = onuInfoList
+	return nil
+}
+
+func (f *fakeOnuRedisRepo) GetONUInfoList(_ context.Context, key string) ([]model.ONUInfoPerBoard, error) {
+	if value, ok := f.onuInfo[key]; ok {
+		return value, nil
+	}
+	return nil, errFakeRedisNil
+}
+
+func (f *fakeOnuRedisRepo) GetOnlyOnuIDCtx(_ context.Context, key string) ([]model.OnuOnlyID, error) {
+	if value, ok := f.onlyID[key]; ok {
+		return value, nil
+	}
+	return nil, errFakeRedisNil
+}
+
+func (f *fakeOnuRedisRepo) SaveOnlyOnuIDCtx(_ context.Context, key string, _ int, onuId []model.OnuOnlyID) error {
+	f.onlyID[key] = onuId
+	return nil
+}
+
+func (f *fakeOnuRedisRepo) SaveOnuFirmwareList(
+	_ context.Context, key string, _ int, onuFirmwareList []model.OnuFirmwareInfo,
+) error {
+	f.firmware[key] = onuFirmwareList
+	return nil
+}
+
+func (f *fakeOnuRedisRepo) GetOnuFirmwareList(_ context.Context, key string) ([]model.OnuFirmwareInfo, error) {
+	if value, ok := f.firmware[key]; ok {
+		return value, nil
+	}
+	return nil, errFakeRedisNil
+}
+
+const (
+	testBaseOID1          = ".1.3.6.1.4.1.3902.1082"
+	testBaseOID2          = ".1.3.6.1.4.1.3902.1012"
+	testOnuIDNameOID      = ".500.10.2.3.3.1.2.285278465"
+	testOnuSerialOID      = ".500.10.2.3.3.1.18.285278465"
+	testOnuDescriptionOID = ".500.10.2.3.3.1.3.285278465"
+	testPonPortIndex      = ".268501248"
+)
+
+func newTestConfig() *config.Config {
+	cfg := &config.Config{}
+	cfg.OltCfg.BaseOID1 = testBaseOID1
+	cfg.OltCfg.BaseOID2 = testBaseOID2
+	cfg.OltCfg.OnuRegisterTypeOID = ".3.28.1.1.1"
+	cfg.OltCfg.OnuRegisterNameOID = ".3.28.1.1.2"
+	cfg.OltCfg.OnuRegisterSerialNumberOID = ".3.28.1.1.5"
+	cfg.OltCfg.OnuRegisterRowStatusOID = ".3.28.1.1.9"
+	cfg.OltCfg.OnuRebootOID = ".3.50.11.3.1.1"
+	cfg.Board1Pon1.OnuIDNameOID = testOnuIDNameOID
+	cfg.Board1Pon1.OnuSerialNumberOID = testOnuSerialOID
+	cfg.Board1Pon1.OnuDescriptionOID = testOnuDescriptionOID
+	return cfg
+}
+
+func TestGetLastOnlineTimezone(t *testing.T) {
+	agent := newFakeSnmpAgent()
+	agent.values[testBaseOID1+".500.10.2.3.8.1.5.285278465.1"] = []byte{0x07, 0xe8, 0x05, 0x01, 0x0a, 0x1e, 0x00, 0x00}
+	agent.values[testBaseOID1+".500.10.2.3.8.1.6.285278465.1"] = []byte{
+		0x07, 0xe8, 0x05, 0x01, 0x0a, 0x1e, 0x00, 0x00, '+', 0x00, 0x00,
+	}
+
+	u := &onuUsecase{snmpRepository: agent, cfg: newTestConfig(), location: time.FixedZone("WIB", 7*3600)}
+
+	// DateAndTime without an offset is OLT local time
+	lastOnline, err := u.getLastOnline(".500.10.2.3.8.1.5.285278465", "1")
+	assert.NoError(t, err)
+	assert.Equal(t, "2024-05-01T10:30:00+07:00", lastOnline.Format(time.RFC3339))
+
+	// DateAndTime with an offset keeps it
+	lastOffline, err := u.getLastOffline(".500.10.2.3.8.1.6.285278465", "1")
+	assert.NoError(t, err)
+	assert.Equal(t, "2024-05-01T10:30:00Z", lastOffline.Format(time.RFC3339))
+
+	_, err = u.getLastOnline(".500.10.2.3.8.1.5.285278465", "2")
+	assert.Error(t, err)
+}
+
+func TestGetUptimeAndLastDownDuration(t *testing.T) {
+	now := time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC)
+	lastOffline := now.Add(-3 * time.Hour)
+	lastOnline := now.Add(-2 * time.Hour)
+
+	// Online again after the last outage
+	uptime, err := getUptimeDuration(lastOnline, lastOffline, now)
+	assert.NoError(t, err)
+	assert.Equal(t, 2*time.Hour, uptime)
+
+	lastDown, err := getLastDownDuration(lastOffline, lastOnline, now)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Hour, lastDown)
+
+	// Offline since the last offline time, the outage is still going on
+	_, err = getUptimeDuration(lastOffline, lastOnline, now)
+	assert.Error(t, err)
+
+	lastDown, err = getLastDownDuration(lastOnline, lastOffline, now)
+	assert.NoError(t, err)
+	assert.Equal(t, 2*time.Hour, lastDown)
+
+	// Never offline
+	_, err = getLastDownDuration(time.Time{}, lastOnline, now)
+	assert.Error(t, err)
+	uptime, err = getUptimeDuration(lastOnline, time.Time{}, now)
+	assert.NoError(t, err)
+	assert.Equal(t, 2*time.Hour, uptime)
+}
+
+func TestGetByBoardIDAndPonIDReadErrors(t *testing.T) {
+	agent := newFakeSnmpAgent()
+	agent.values[testBaseOID1+testOnuIDNameOID+".1"] = "customer-001"
+	agent.values[testBaseOID1+testOnuIDNameOID+".2"] = "customer-002"
+	agent.values[testBaseOID1+testOnuSerialOID+".1"] = "1,ZTEGC0000001"
+	agent.values[testBaseOID1+testOnuSerialOID+".2"] = "1,ZTEGC0000002"
+	agent.values[testBaseOID1+testOnuDescriptionOID+".1"] = "Jl. Merdeka 1"
+	redisRepo := newFakeOnuRedisRepo()
+	changeRepo := newFakeOnuChangeRepo()
+	u := NewOnuUsecase(agent, redisRepo, changeRepo, newFakeCustomerRepo(), newTestConfig())
+	ctx := context.Background()
+
+	// A list with a failed read is returned but neither cached nor recorded in the inventory, so the next request
+	// reads the PON again and the empty serial number isn't reported as a change
+	agent.getErrs[testBaseOID1+testOnuSerialOID+".2"] = errors.New("request timeout")
+	onuList, err := u.GetByBoardIDAndPonID(ctx, 1, 1)
+	assert.NoError(t, err)
+	assert.Len(t, onuList, 2)
+	assert.Equal(t, "ZTEGC0000001", onuList[0].SerialNumber)
+	assert.Empty(t, onuList[1].SerialNumber)
+	assert.Empty(t, redisRepo.onuInfo)
+	assert.Empty(t, changeRepo.inventory)
+
+	delete(agent.getErrs, testBaseOID1+testOnuSerialOID+".2")
+	agent.walkErrs[testBaseOID1+testOnuDescriptionOID] = errors.New("request timeout")
+	onuList, err = u.GetByBoardIDAndPonID(ctx, 1, 1)
+	assert.NoError(t, err)
+	assert.Len(t, onuList, 2)
+	assert.Empty(t, redisRepo.onuInfo)
+	assert.Empty(t, changeRepo.inventory)
+
+	// A refresh that isn't cached fails
+	err = u.RefreshByBoardIDAndPonID(ctx, 1, 1)
+	assert.EqualError(t, err, "not cached, a read failed: failed to walk OID: request timeout")
+	assert.Empty(t, redisRepo.onuInfo)
+	delete(agent.walkErrs, testBaseOID1+testOnuDescriptionOID)
+
+	// Requests waiting too long for the SNMP budget are rejected
+	agent.getErrs[testBaseOID1+testOnuSerialOID+".2"] = &SnmpBudgetError{RetryAfter: 2 * time.Second}
+	_, err = u.GetByBoardIDAndPonID(ctx, 1, 1)
+	var budgetErr *SnmpBudgetError
+	assert.True(t, errors.As(err, &budgetErr))
+	assert.Equal(t, 2*time.Second, budgetErr.RetryAfter)
+	assert.Empty(t, redisRepo.onuInfo)
+
+	delete(agent.getErrs, testBaseOID1+testOnuSerialOID+".2")
+	onuList, err = u.GetByBoardIDAndPonID(ctx, 1, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "ZTEGC0000002", onuList[1].SerialNumber)
+	assert.Equal(t, onuList, redisRepo.onuInfo["board_1_pon_1"])
+
+	// Descriptions of the inventory are read with one walk of the PON
+	descriptions := make(map[int]string)
+	for _, onuInventory := range changeRepo.inventory["board_1_pon_1_inventory"] {
+		descriptions[onuInventory.ID] = onuInventory.Description
+	}
+	assert.Equal(t, map[int]string{1: "Jl. Merdeka 1", 2: ""}, descriptions)
+}
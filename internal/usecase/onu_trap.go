@@ -0,0 +1,132 @@
+package usecase
+
+import (
+	"context"
+	"github.com/gosnmp/gosnmp"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/config"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/model"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/repository"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/utils"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/pkg/pubsub"
+	"github.com/rs/zerolog/log"
+)
+
+// snmpTrapOID is the snmpTrapOID.0 varbind carrying the notification OID
+const snmpTrapOID = ".1.3.6.1.6.3.1.1.4.1.0"
+
+type OnuTrapUseCaseInterface interface {
+	HandleTrap(ctx context.Context, packet *gosnmp.SnmpPacket) []model.OnuEvent
+}
+
+type onuTrapUsecase struct {
+	publisher *onuEventPublisher
+	cfg       *config.Config
+}
+
+// NewOnuTrapUsecase returns the usecase decoding OLT traps into ONU events published to broker
+func NewOnuTrapUsecase(
+	redisRepository repository.OnuRedisRepositoryInterface, broker *pubsub.Broker[model.OnuEvent], cfg *config.Config,
+) OnuTrapUseCaseInterface {
+	return &onuTrapUsecase{
+		publisher: &onuEventPublisher{
+			redisRepository: redisRepository,
+			broker:          broker,
+			oltName:         cfg.StreamCfg.OltName,
+		},
+		cfg: cfg,
+	}
+}
+
+// HandleTrap decodes an ONU state change or alarm trap, updates the Redis cache and publishes the events
+func (u *onuTrapUsecase) HandleTrap(ctx context.Context, packet *gosnmp.SnmpPacket) []model.OnuEvent {
+
+	var trapOID string
+	for _, pdu := range packet.Variables {
+		if pdu.Name == snmpTrapOID {
+			trapOID, _ = pdu.Value.(string)
+			break
+		}
+	}
+
+	var events []model.OnuEvent
+	switch trapOID {
+	case u.cfg.OltCfg.BaseOID1 + u.cfg.OltCfg.TrapOnuStateChangeOID:
+		events = u.decodeStateChangeTrap(packet.Variables)
+	case u.cfg.OltCfg.BaseOID2 + u.cfg.OltCfg.TrapOnuAlarmOID:
+		events = u.decodeAlarmTrap(packet.Variables)
+	default:
+		log.Debug().Msg("Ignored SNMP trap: " + trapOID) // Log debug message to logger
+		return nil
+	}
+
+	return u.publisher.publish(ctx, events, onuEventSourceTrap)
+}
+
+// decodeStateChangeTrap is a function to get one status event per ONU phase state varbind
+func (u *onuTrapUsecase) decodeStateChangeTrap(variables []gosnmp.SnmpPDU) []model.OnuEvent {
+
+	column := u.cfg.OltCfg.BaseOID1 + u.cfg.OltCfg.OnuStatusAllPon
+
+	var events []model.OnuEvent
+	for _, pdu := range variables {
+		boardID, ponID, onuID, ok := decodeOnuVarbind(pdu.Name, column)
+		if !ok {
+			continue
+		}
+		events = append(events, model.OnuEvent{
+			Type:   OnuEventStatus,
+			Board:  boardID,
+			PON:    ponID,
+			ID:     onuID,
+			Status: utils.ExtractAndGetStatus(pdu.Value),
+		})
+	}
+
+	return events
+}
+
+// decodeAlarmTrap is a function to get one alarm event per ONU from the alarm type and severity varbinds
+func (u *onuTrapUsecase) decodeAlarmTrap(variables []gosnmp.SnmpPDU) []model.OnuEvent {
+
+	typeColumn := u.cfg.OltCfg.BaseOID2 + u.cfg.OltCfg.OnuAlarmTypeOID
+	severityColumn := u.cfg.OltCfg.BaseOID2 + u.cfg.OltCfg.OnuAlarmSeverityOID
+
+	var events []model.OnuEvent
+	eventIndex := make(map[[3]int]int) // Position in events of each board, PON and ONU ID
+
+	// getEvent returns the event of an ONU, adding it on first use so varbinds of the same ONU are merged
+	getEvent := func(boardID, ponID, onuID int) *model.OnuEvent {
+		key := [3]int{boardID, ponID, onuID}
+		if i, ok := eventIndex[key]; ok {
+			return &events[i]
+		}
+		eventIndex[key] = len(events)
+		events = append(events, model.OnuEvent{Type: OnuEventAlarm, Board: boardID, PON: ponID, ID: onuID})
+		return &events[len(events)-1]
+	}
+
+	for _, pdu := range variables {
+		if boardID, ponID, onuID, ok := decodeOnuVarbind(pdu.Name, typeColumn); ok {
+			getEvent(boardID, ponID, onuID).Alarm = utils.ExtractOnuAlarmType(pdu.Value)
+		} else if boardID, ponID, onuID, ok := decodeOnuVarbind(pdu.Name, severityColumn); ok {
+			getEvent(boardID, ponID, onuID).Severity = utils.ExtractAlarmSeverity(pdu.Value)
+		}
+	}
+
+	return events
+}
+
+// decodeOnuVarbind is a function to get board, PON and ONU ID of a varbind indexed by ifIndex and ONU ID
+func decodeOnuVarbind(name, column string) (boardID, ponID, onuID int, ok bool) {
+	index := utils.ExtractOIDIndex(name, column)
+	if len(index) != 2 {
+		return 0, 0, 0, false
+	}
+
+	boardID, ponID, ok = utils.DecodeIfIndex(index[0])
+	if !ok {
+		return 0, 0, 0, false
+	}
+
+	return boardID, ponID, index[1], true
+}
@@ -0,0 +1,114 @@
+package usecase
+
+import (
+	"context"
+	"github.com/gosnmp/gosnmp"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/model"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/pkg/pubsub"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// newTestTrapUsecase returns a trap usecase with ONU 1 of board 1 PON 1 cached as Online
+func newTestTrapUsecase() (OnuTrapUseCaseInterface, *fakeOnuRedisRepo, <-chan model.OnuEvent) {
+	cfg := newTestConfig()
+	cfg.OltCfg.OnuStatusAllPon = ".500.10.2.3.8.1.4"
+	cfg.OltCfg.OnuAlarmTypeOID = ".3.40.1.1.2"
+	cfg.OltCfg.OnuAlarmSeverityOID = ".3.40.1.1.3"
+	cfg.OltCfg.TrapOnuStateChangeOID = ".500.10.2.3.0.1"
+	cfg.OltCfg.TrapOnuAlarmOID = ".3.40.0.1"
+
+	redisRepo := newFakeOnuRedisRepo()
+	redisRepo.onuInfo["board_1_pon_1"] = []model.ONUInfoPerBoard{
+		{Board: 1, PON: 1, ID: 1, Status: "Online"},
+		{Board: 1, PON: 1, ID: 2, Status: "Online"},
+	}
+	redisRepo.onuInfo["board_2_pon_8"] = []model.ONUInfoPerBoard{{Board: 2, PON: 8, ID: 1, Status: "Online"}}
+
+	broker := pubsub.NewBroker[model.OnuEvent]()
+	events, _ := broker.Subscribe(10)
+
+	return NewOnuTrapUsecase(redisRepo, broker, cfg), redisRepo, events
+}
+
+// newTestTrap returns a trap packet with the given notification OID and varbinds
+func newTestTrap(trapOID string, variables ...gosnmp.SnmpPDU) *gosnmp.SnmpPacket {
+	return &gosnmp.SnmpPacket{Variables: append([]gosnmp.SnmpPDU{
+		{Name: ".1.3.6.1.2.1.1.3.0", Type: gosnmp.TimeTicks, Value: uint32(100)},
+		{Name: snmpTrapOID, Type: gosnmp.ObjectIdentifier, Value: trapOID},
+	}, variables...)}
+}
+
+func TestHandleStateChangeTrap(t *testing.T) {
+	u, redisRepo, published := newTestTrapUsecase()
+
+	events := u.HandleTrap(context.Background(), newTestTrap(testBaseOID1+".500.10.2.3.0.1",
+		gosnmp.SnmpPDU{Name: testBaseOID1 + ".500.10.2.3.8.1.4.285278465.2", Type: gosnmp.Integer, Value: 2},
+		gosnmp.SnmpPDU{Name: testBaseOID1 + ".500.10.2.3.8.1.4.285278465.9", Type: gosnmp.Integer, Value: 4},
+	))
+
+	assert.Len(t, events, 2)
+	assert.Equal(t, model.OnuEvent{Type: OnuEventStatus, Board: 1, PON: 1, ID: 2, Status: "LOS", Source: "trap",
+		Timestamp: events[0].Timestamp}, events[0])
+	assert.Equal(t, 9, events[1].ID)
+	assert.Equal(t, "Online", events[1].Status)
+	assert.Equal(t, events[0], <-published)
+	assert.Equal(t, events[1], <-published)
+
+	// ONU 2 is patched in place, ONU 9 is not cached so the PON is invalidated
+	assert.Equal(t, []string{"board_1_pon_1"}, redisRepo.deleted)
+	_, cached := redisRepo.onuInfo["board_1_pon_1"]
+	assert.False(t, cached)
+}
+
+func TestHandleStateChangeTrapPatchesCache(t *testing.T) {
+	u, redisRepo, _ := newTestTrapUsecase()
+
+	u.HandleTrap(context.Background(), newTestTrap(testBaseOID1+".500.10.2.3.0.1",
+		gosnmp.SnmpPDU{Name: testBaseOID1 + ".500.10.2.3.8.1.4.285278465.2", Type: gosnmp.Integer, Value: 5},
+	))
+
+	assert.Empty(t, redisRepo.deleted)
+	assert.Equal(t, []model.ONUInfoPerBoard{
+		{Board: 1, PON: 1, ID: 1, Status: "Online"},
+		{Board: 1, PON: 1, ID: 2, Status: "Dying Gasp"},
+	}, redisRepo.onuInfo["board_1_pon_1"])
+}
+
+func TestHandleAlarmTrap(t *testing.T) {
+	u, redisRepo, published := newTestTrapUsecase()
+
+	// Alarm table is indexed by the PON port index of board 2 PON 8
+	events := u.HandleTrap(context.Background(), newTestTrap(testBaseOID2+".3.40.0.1",
+		gosnmp.SnmpPDU{Name: testBaseOID2 + ".3.40.1.1.2.268568576.1", Type: gosnmp.Integer, Value: 1},
+		gosnmp.SnmpPDU{Name: testBaseOID2 + ".3.40.1.1.3.268568576.1", Type: gosnmp.Integer, Value: 1},
+	))
+
+	assert.Equal(t, []model.OnuEvent{{Type: OnuEventAlarm, Board: 2, PON: 8, ID: 1, Alarm: "LOS", Severity: "Critical",
+		Source: "trap", Timestamp: events[0].Timestamp}}, events)
+	assert.Equal(t, events[0], <-published)
+	assert.Equal(t, []string{"board_2_pon_8"}, redisRepo.deleted)
+}
+
+func TestHandleIgnoredTrap(t *testing.T) {
+	testCases := []struct {
+		name   string
+		packet *gosnmp.SnmpPacket
+	}{
+		{"Unknown notification", newTestTrap(".1.3.6.1.6.3.1.1.5.3",
+			gosnmp.SnmpPDU{Name: testBaseOID1 + ".500.10.2.3.8.1.4.285278465.2", Type: gosnmp.Integer, Value: 2})},
+		{"Missing snmpTrapOID", &gosnmp.SnmpPacket{}},
+		{"Unknown ifIndex", newTestTrap(testBaseOID1+".500.10.2.3.0.1",
+			gosnmp.SnmpPDU{Name: testBaseOID1 + ".500.10.2.3.8.1.4.1.2", Type: gosnmp.Integer, Value: 2})},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			u, redisRepo, published := newTestTrapUsecase()
+
+			assert.Empty(t, u.HandleTrap(context.Background(), tc.packet))
+			assert.Empty(t, redisRepo.deleted)
+			assert.Len(t, published, 0)
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/model"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/utils"
+	"github.com/rs/zerolog/log"
+	"sort"
+	"strconv"
+)
+
+func (u *onuUsecase) GetUnconfiguredByBoardIDAndPonID(ctx context.Context, boardID, ponID int) (
+	model.UnconfiguredOnuPon, error,
+) {
+
+	// Get unconfigured ONU from SNMP Walk
+	unconfiguredOnuList, err := u.getUnconfiguredOnu(boardID, ponID)
+	if err != nil {
+		return model.UnconfiguredOnuPon{}, err
+	}
+
+	// Suggest the first free ONU ID of the PON to register the new ONU
+	suggestedOnuID, err := u.getSuggestedOnuID(ctx, boardID, ponID)
+	if err != nil {
+		return model.UnconfiguredOnuPon{}, err
+	}
+
+	return model.UnconfiguredOnuPon{
+		Board:            boardID,
+		PON:              ponID,
+		SuggestedOnuID:   suggestedOnuID,
+		UnconfiguredOnus: unconfiguredOnuList,
+	}, nil
+}
+
+func (u *onuUsecase) GetUnconfigured(ctx context.Context) ([]model.UnconfiguredOnuPon, error) {
+
+	log.Info().Msg("Get Unconfigured ONU from all Board and PON") // Log info message to logger
+
+	unconfiguredOnuPonList := make([]model.UnconfiguredOnuPon, 0)
+
+	var failedPon int // Number of PON that failed to be walked
+
+	// Loop through all Board and PON
+	for boardID := 1; boardID <= maxBoardID; boardID++ {
+		for ponID := 1; ponID <= maxPonID; ponID++ {
+			unconfiguredOnuList, err := u.getUnconfiguredOnu(boardID, ponID)
+			if err != nil {
+				if errors.Is(err, ErrSnmpBudgetExceeded) {
+					return nil, err // The other PON would wait for the SNMP budget too
+				}
+				failedPon++
+				continue
+			}
+
+			// Only return PON which has unconfigured ONU
+			if len(unconfiguredOnuList) == 0 {
+				continue
+			}
+
+			unconfiguredOnuPon := model.UnconfiguredOnuPon{
+				Board:            boardID,
+				PON:              ponID,
+				UnconfiguredOnus: unconfiguredOnuList,
+			}
+
+			// A failed lookup is flagged on its PON, the unconfigured ONU are listed anyway
+			unconfiguredOnuPon.SuggestedOnuID, err = u.getSuggestedOnuID(ctx, boardID, ponID)
+			if err != nil {
+				if errors.Is(err, ErrSnmpBudgetExceeded) {
+					return nil, err
+				}
+				unconfiguredOnuPon.SuggestedOnuIDError = err.Error()
+			}
+
+			unconfiguredOnuPonList = append(unconfiguredOnuPonList, unconfiguredOnuPon)
+		}
+	}
+
+	// Return error if no PON could be walked at all
+	if failedPon == maxBoardID*maxPonID {
+		return nil, errors.New("failed to get unconfigured ONU from all PON")
+	}
+
+	return unconfiguredOnuPonList, nil
+}
+
+// getUnconfiguredOnu is a function to walk the unconfigured ONU table of a PON
+func (u *onuUsecase) getUnconfiguredOnu(boardID, ponID int) ([]model.UnconfiguredOnu, error) {
+
+	// Validate Board ID and PON ID against OLT config
+	if _, err := u.getOltConfig(boardID, ponID); err != nil {
+		log.Error().Msg("Failed to get OLT Config: " + err.Error()) // Log error message to logger
+		return nil, err                                             // Return error if error is not nil
+	}
+
+	log.Info().Msg("Get Unconfigured ONU with SNMP Walk from Board ID: " + strconv.Itoa(
+		boardID) + " and PON ID: " + strconv.Itoa(ponID)) // Log info message to logger
+
+	// Unconfigured ONU table is indexed by PON port index and discovery sequence
+	ponIndex := "." + strconv.Itoa(utils.GetPonPortIndex(boardID, ponID))
+
+	// Create a map to store unconfigured ONU with discovery sequence as key
+	unconfiguredMap := make(map[int]*model.UnconfiguredOnu)
+	getUnconfigured := func(sequence int) *model.UnconfiguredOnu {
+		if _, ok := unconfiguredMap[sequence]; !ok {
+			unconfiguredMap[sequence] = &model.UnconfiguredOnu{
+				Board: boardID,
+				PON:   ponID,
+			}
+		}
+		return unconfiguredMap[sequence]
+	}
+
+	// Walk unconfigured ONU Serial Number column
+	err := u.walkColumn(u.cfg.OltCfg.OnuUncfgSerialNumberOID, ponIndex, func(index []int, value interface{}) {
+		getUnconfigured(index[0]).SerialNumber = utils.ExtractSerialNumber(value)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Walk unconfigured ONU Equipment ID column
+	err = u.walkColumn(u.cfg.OltCfg.OnuUncfgEquipmentIDOID, ponIndex, func(index []int, value interface{}) {
+		getUnconfigured(index[0]).EquipmentID = utils.ExtractName(value)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Walk unconfigured ONU discovery time column
+	err = u.walkColumn(u.cfg.OltCfg.OnuUncfgDiscoveryTimeOID, ponIndex, func(index []int, value interface{}) {
+		byteValue, ok := value.([]byte) // Discovery time is returned as a byte array (Octet String)
+		if !ok {
+			return
+		}
+
+		discoveryTime, err := utils.ConvertByteArrayToDateTime(byteValue)
+		if err == nil {
+			getUnconfigured(index[0]).DiscoveryTime = discoveryTime
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sequences := make([]int, 0, len(unconfiguredMap))
+	for sequence := range unconfiguredMap {
+		sequences = append(sequences, sequence)
+	}
+
+	// Sort unconfigured ONU based on discovery sequence ascending
+	sort.Ints(sequences)
+
+	unconfiguredOnuList := make([]model.UnconfiguredOnu, 0, len(sequences))
+	for _, sequence := range sequences {
+		unconfiguredOnuList = append(unconfiguredOnuList, *unconfiguredMap[sequence])
+	}
+
+	return unconfiguredOnuList, nil
+}
+
+// getSuggestedOnuID is a function to get the first free ONU ID of a PON, it returns 0 if the PON is full
+func (u *onuUsecase) getSuggestedOnuID(ctx context.Context, boardID, ponID int) (int, error) {
+
+	emptyOnuIDList, err := u.GetEmptyOnuID(ctx, boardID, ponID)
+	if err != nil {
+		log.Error().Msg("Failed to get suggested ONU ID: " + err.Error()) // Log error message to logger
+		return 0, fmt.Errorf("failed to get suggested ONU ID: %w", err)
+	}
+	if len(emptyOnuIDList) == 0 {
+		return 0, nil
+	}
+
+	return emptyOnuIDList[0].ID, nil // Empty ONU ID list is sorted ascending
+}
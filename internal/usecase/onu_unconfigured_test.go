@@ -0,0 +1,144 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/model"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strconv"
+	"testing"
+	"time"
+)
+
+const (
+	testOnuUncfgSerialOID        = ".3.13.3.1.2"
+	testOnuUncfgEquipmentIDOID   = ".3.13.3.1.10"
+	testOnuUncfgDiscoveryTimeOID = ".3.13.3.1.8"
+	testBoard2Pon8OnuIDNameOID   = ".500.10.2.3.3.1.2.285278728"
+)
+
+// newTestUnconfiguredUsecase returns an ONU usecase backed by a fake agent, board 1 PON 1 has ONU 1 and 2 registered
+// and two unconfigured ONU, board 2 PON 8 is full and has one unconfigured ONU
+func newTestUnconfiguredUsecase() (*onuUsecase, *fakeSnmpAgent, *fakeOnuRedisRepo) {
+	agent := newFakeSnmpAgent()
+	agent.values[testBaseOID1+testOnuIDNameOID+".1"] = "customer-001"
+	agent.values[testBaseOID1+testOnuIDNameOID+".2"] = "customer-002"
+	for onuID := 1; onuID <= 128; onuID++ {
+		agent.values[testBaseOID1+testBoard2Pon8OnuIDNameOID+"."+strconv.Itoa(onuID)] = "customer"
+	}
+
+	// Discovery sequence 10 is walked before 2
+	board1Pon1 := testPonPortIndex
+	agent.values[testBaseOID2+testOnuUncfgSerialOID+board1Pon1+".2"] = "1,ZTEGC0000003"
+	agent.values[testBaseOID2+testOnuUncfgEquipmentIDOID+board1Pon1+".2"] = "F670L"
+	agent.values[testBaseOID2+testOnuUncfgDiscoveryTimeOID+board1Pon1+".2"] = []byte{
+		0x07, 0xe8, 0x05, 0x01, 0x0a, 0x1e, 0x00, 0x00,
+	}
+	agent.values[testBaseOID2+testOnuUncfgSerialOID+board1Pon1+".10"] = "1,ZTEGC0000004"
+	agent.values[testBaseOID2+testOnuUncfgEquipmentIDOID+board1Pon1+".10"] = "F609"
+	agent.values[testBaseOID2+testOnuUncfgDiscoveryTimeOID+board1Pon1+".10"] = "invalid"
+
+	board2Pon8 := "." + strconv.Itoa(utils.GetPonPortIndex(2, 8))
+	agent.values[testBaseOID2+testOnuUncfgSerialOID+board2Pon8+".1"] = "1,ZTEGC0000005"
+
+	cfg := newTestConfig()
+	cfg.OltCfg.OnuUncfgSerialNumberOID = testOnuUncfgSerialOID
+	cfg.OltCfg.OnuUncfgEquipmentIDOID = testOnuUncfgEquipmentIDOID
+	cfg.OltCfg.OnuUncfgDiscoveryTimeOID = testOnuUncfgDiscoveryTimeOID
+	cfg.Board2Pon8.OnuIDNameOID = testBoard2Pon8OnuIDNameOID
+
+	redisRepo := newFakeOnuRedisRepo()
+	u := NewOnuUsecase(agent, redisRepo, newFakeOnuChangeRepo(), newFakeCustomerRepo(), cfg).(*onuUsecase)
+	return u, agent, redisRepo
+}
+
+func TestGetUnconfiguredByBoardIDAndPonID(t *testing.T) {
+	u, agent, redisRepo := newTestUnconfiguredUsecase()
+	ctx := context.Background()
+
+	// Unconfigured ONU are sorted by discovery sequence, the suggested ONU ID is the first free one
+	unconfiguredOnuPon, err := u.GetUnconfiguredByBoardIDAndPonID(ctx, 1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, model.UnconfiguredOnuPon{
+		Board:          1,
+		PON:            1,
+		SuggestedOnuID: 3,
+		UnconfiguredOnus: []model.UnconfiguredOnu{
+			{Board: 1, PON: 1, SerialNumber: "ZTEGC0000003", EquipmentID: "F670L", DiscoveryTime: "2024-05-01 10:30:00"},
+			{Board: 1, PON: 1, SerialNumber: "ZTEGC0000004", EquipmentID: "F609"},
+		},
+	}, unconfiguredOnuPon)
+
+	// A full PON has no suggested ONU ID
+	unconfiguredOnuPon, err = u.GetUnconfiguredByBoardIDAndPonID(ctx, 2, 8)
+	require.NoError(t, err)
+	assert.Equal(t, 0, unconfiguredOnuPon.SuggestedOnuID)
+	assert.Len(t, unconfiguredOnuPon.UnconfiguredOnus, 1)
+
+	// A failed lookup of the suggested ONU ID isn't reported as a full PON
+	delete(redisRepo.onuID, "board_1_pon_1_empty_onu_id")
+	agent.walkErrs[testBaseOID1+testOnuIDNameOID] = errors.New("request timeout")
+	_, err = u.GetUnconfiguredByBoardIDAndPonID(ctx, 1, 1)
+	assert.EqualError(t, err, "failed to get suggested ONU ID: request timeout")
+
+	agent.walkErrs[testBaseOID2+testOnuUncfgSerialOID+testPonPortIndex] = errors.New("request timeout")
+	_, err = u.GetUnconfiguredByBoardIDAndPonID(ctx, 1, 1)
+	assert.EqualError(t, err, "failed to walk OID: request timeout")
+
+	_, err = u.GetUnconfiguredByBoardIDAndPonID(ctx, 3, 1)
+	assert.Error(t, err)
+}
+
+func TestGetUnconfigured(t *testing.T) {
+	u, agent, redisRepo := newTestUnconfiguredUsecase()
+	ctx := context.Background()
+
+	// Only PON with unconfigured ONU are listed
+	unconfiguredOnuPonList, err := u.GetUnconfigured(ctx)
+	require.NoError(t, err)
+	require.Len(t, unconfiguredOnuPonList, 2)
+	assert.Equal(t, 1, unconfiguredOnuPonList[0].Board)
+	assert.Equal(t, 1, unconfiguredOnuPonList[0].PON)
+	assert.Equal(t, 3, unconfiguredOnuPonList[0].SuggestedOnuID)
+	assert.Len(t, unconfiguredOnuPonList[0].UnconfiguredOnus, 2)
+	assert.Equal(t, model.UnconfiguredOnuPon{
+		Board:            2,
+		PON:              8,
+		UnconfiguredOnus: []model.UnconfiguredOnu{{Board: 2, PON: 8, SerialNumber: "ZTEGC0000005"}},
+	}, unconfiguredOnuPonList[1])
+
+	// A failed lookup of the suggested ONU ID is flagged on its PON and the other PON are still listed
+	delete(redisRepo.onuID, "board_2_pon_8_empty_onu_id")
+	agent.walkErrs[testBaseOID1+testBoard2Pon8OnuIDNameOID] = errors.New("request timeout")
+	unconfiguredOnuPonList, err = u.GetUnconfigured(ctx)
+	require.NoError(t, err)
+	require.Len(t, unconfiguredOnuPonList, 2)
+	assert.Equal(t, 3, unconfiguredOnuPonList[0].SuggestedOnuID)
+	assert.Empty(t, unconfiguredOnuPonList[0].SuggestedOnuIDError)
+	assert.Equal(t, "failed to get suggested ONU ID: request timeout", unconfiguredOnuPonList[1].SuggestedOnuIDError)
+	assert.Len(t, unconfiguredOnuPonList[1].UnconfiguredOnus, 1)
+
+	// The other PON would wait for the SNMP budget too
+	agent.walkErrs[testBaseOID1+testBoard2Pon8OnuIDNameOID] = &SnmpBudgetError{RetryAfter: time.Second}
+	_, err = u.GetUnconfigured(ctx)
+	assert.ErrorIs(t, err, ErrSnmpBudgetExceeded)
+
+	// A PON that can't be walked is skipped, the list fails only when no PON could be walked
+	delete(agent.walkErrs, testBaseOID1+testBoard2Pon8OnuIDNameOID)
+	agent.walkErrs[testBaseOID2+testOnuUncfgSerialOID+testPonPortIndex] = errors.New("request timeout")
+	unconfiguredOnuPonList, err = u.GetUnconfigured(ctx)
+	require.NoError(t, err)
+	require.Len(t, unconfiguredOnuPonList, 1)
+	assert.Equal(t, 2, unconfiguredOnuPonList[0].Board)
+
+	for boardID := 1; boardID <= maxBoardID; boardID++ {
+		for ponID := 1; ponID <= maxPonID; ponID++ {
+			ponIndex := "." + strconv.Itoa(utils.GetPonPortIndex(boardID, ponID))
+			agent.walkErrs[testBaseOID2+testOnuUncfgSerialOID+ponIndex] = errors.New("request timeout")
+		}
+	}
+	_, err = u.GetUnconfigured(ctx)
+	assert.EqualError(t, err, "failed to get unconfigured ONU from all PON")
+}
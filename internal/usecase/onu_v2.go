@@ -0,0 +1,134 @@
+package usecase
+
+import (
+	"context"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/model"
+	"strconv"
+	"strings"
+)
+
+const (
+	onuOpticalPowerMin = -50.0 // Readings below this are not a received signal, in dBm
+	onuOpticalPowerMax = 10.0  // Readings above this are the OLT placeholder of an unavailable reading, in dBm
+)
+
+// onuStatusCodes maps ONU phase states to the phase state codes of the OLT, Unknown is 0
+var onuStatusCodes = map[string]int{
+	"Logging":         1,
+	"LOS":             2,
+	"Synchronization": 3,
+	"Online":          4,
+	"Dying Gasp":      5,
+	"Auth Failed":     6,
+	"Offline":         7,
+}
+
+type OnuV2UseCaseInterface interface {
+	GetByBoardIDAndPonID(ctx context.Context, boardID, ponID int) ([]model.OnuInfoV2, error)
+	GetByBoardIDPonIDAndOnuID(boardID, ponID, onuID int) (model.OnuDetailV2, error)
+}
+
+type onuV2Usecase struct {
+	onuUsecase OnuUseCaseInterface
+}
+
+// NewOnuV2Usecase returns the usecase serving ONU of the v1 usecase with typed values, unavailable readings are nil
+func NewOnuV2Usecase(onuUsecase OnuUseCaseInterface) OnuV2UseCaseInterface {
+	return &onuV2Usecase{onuUsecase: onuUsecase}
+}
+
+// GetByBoardIDAndPonID returns the ONU of a PON with typed values
+func (u *onuV2Usecase) GetByBoardIDAndPonID(ctx context.Context, boardID, ponID int) ([]model.OnuInfoV2, error) {
+
+	onuInfoList, err := u.onuUsecase.GetByBoardIDAndPonID(ctx, boardID, ponID)
+	if err != nil {
+		return nil, err
+	}
+
+	onuList := make([]model.OnuInfoV2, 0, len(onuInfoList))
+	for _, onuInfo := range onuInfoList {
+		onuList = append(onuList, model.OnuInfoV2{
+			Board:        onuInfo.Board,
+			PON:          onuInfo.PON,
+			ID:           onuInfo.ID,
+			Name:         onuInfo.Name,
+			OnuType:      onuInfo.OnuType,
+			SerialNumber: onuInfo.SerialNumber,
+			RXPowerDbm:   parseOpticalPower(onuInfo.RXPower),
+			Status:       getOnuStatus(onuInfo.Status),
+			Customer:     onuInfo.Customer,
+		})
+	}
+
+	return onuList, nil
+}
+
+// GetByBoardIDPonIDAndOnuID returns the detail of an ONU with typed values, an ONU not found has zero IDs
+func (u *onuV2Usecase) GetByBoardIDPonIDAndOnuID(boardID, ponID, onuID int) (model.OnuDetailV2, error) {
+
+	onuInfo, err := u.onuUsecase.GetByBoardIDPonIDAndOnuID(boardID, ponID, onuID)
+	if err != nil {
+		return model.OnuDetailV2{}, err
+	}
+	if onuInfo.Board == 0 && onuInfo.PON == 0 && onuInfo.ID == 0 {
+		return model.OnuDetailV2{}, nil
+	}
+
+	onuDetail := model.OnuDetailV2{
+		Board:               onuInfo.Board,
+		PON:                 onuInfo.PON,
+		ID:                  onuInfo.ID,
+		Name:                onuInfo.Name,
+		Description:         onuInfo.Description,
+		OnuType:             onuInfo.OnuType,
+		SerialNumber:        onuInfo.SerialNumber,
+		RXPowerDbm:          parseOpticalPower(onuInfo.RXPower),
+		TXPowerDbm:          parseOpticalPower(onuInfo.TXPower),
+		Status:              getOnuStatus(onuInfo.Status),
+		IPAddress:           optionalString(onuInfo.IPAddress, "0.0.0.0"),
+		LastOnline:          optionalString(onuInfo.LastOnline),
+		LastOffline:         optionalString(onuInfo.LastOffline),
+		UptimeSeconds:       onuInfo.UptimeSeconds,
+		LastDownTimeSeconds: onuInfo.LastDownTimeSeconds,
+		LastOfflineReason:   optionalString(onuInfo.LastOfflineReason),
+		Customer:            onuInfo.Customer,
+	}
+
+	// GPON optical distance is in metres
+	if distance, err := strconv.Atoi(onuInfo.GponOpticalDistance); err == nil && distance >= 0 {
+		onuDetail.GponOpticalDistanceM = &distance
+	}
+
+	return onuDetail, nil
+}
+
+// getOnuStatus is a function to get the code and label of an ONU phase state
+func getOnuStatus(status string) model.OnuStatus {
+	if code, ok := onuStatusCodes[status]; ok {
+		return model.OnuStatus{Code: code, Label: status}
+	}
+	return model.OnuStatus{Code: 0, Label: "Unknown"}
+}
+
+// parseOpticalPower is a function to parse an optical power in dBm, nil when unavailable or out of range
+func parseOpticalPower(power string) *float64 {
+	value, err := strconv.ParseFloat(strings.TrimSpace(power), 64)
+	if err != nil || value < onuOpticalPowerMin || value > onuOpticalPowerMax {
+		return nil
+	}
+	return &value
+}
+
+// optionalString is a function to get nil for an empty, Unknown or placeholder value
+func optionalString(value string, placeholders ...string) *string {
+	value = strings.TrimSpace(value)
+	if value == "" || value == "Unknown" {
+		return nil
+	}
+	for _, placeholder := range placeholders {
+		if value == placeholder {
+			return nil
+		}
+	}
+	return &value
+}
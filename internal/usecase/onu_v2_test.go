@@ -0,0 +1,80 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// fakeOnuDetailSource serves GetByBoardIDPonIDAndOnuID from details keyed by board, PON and ONU ID
+type fakeOnuDetailSource struct {
+	fakeOnuListSource
+	details map[[3]int]model.ONUCustomerInfo
+}
+
+func (f *fakeOnuDetailSource) GetByBoardIDPonIDAndOnuID(boardID, ponID, onuID int) (model.ONUCustomerInfo, error) {
+	return f.details[[3]int{boardID, ponID, onuID}], nil
+}
+
+func TestOnuV2GetByBoardIDAndPonID(t *testing.T) {
+	source := &fakeOnuDetailSource{fakeOnuListSource: fakeOnuListSource{lists: map[[2]int][]model.ONUInfoPerBoard{
+		{1, 1}: {
+			{Board: 1, PON: 1, ID: 1, SerialNumber: "ZTEGC0000001", RXPower: "-20.71", Status: "Online"},
+			{Board: 1, PON: 1, ID: 2, SerialNumber: "ZTEGC0000002", RXPower: "101.07", Status: "LOS"},
+			{Board: 1, PON: 1, ID: 3, SerialNumber: "ZTEGC0000003", Status: "Unknown"},
+		},
+	}}}
+	u := NewOnuV2Usecase(source)
+
+	onuList, err := u.GetByBoardIDAndPonID(context.Background(), 1, 1)
+	assert.NoError(t, err)
+	assert.Len(t, onuList, 3)
+	assert.Equal(t, -20.71, *onuList[0].RXPowerDbm)
+	assert.Equal(t, model.OnuStatus{Code: 4, Label: "Online"}, onuList[0].Status)
+	assert.Nil(t, onuList[1].RXPowerDbm) // Placeholder of an ONU without signal
+	assert.Equal(t, model.OnuStatus{Code: 2, Label: "LOS"}, onuList[1].Status)
+	assert.Nil(t, onuList[2].RXPowerDbm)
+	assert.Equal(t, model.OnuStatus{Code: 0, Label: "Unknown"}, onuList[2].Status)
+
+	_, err = u.GetByBoardIDAndPonID(context.Background(), 1, 2)
+	assert.Error(t, err)
+}
+
+func TestOnuV2GetByBoardIDPonIDAndOnuID(t *testing.T) {
+	uptime := int64(479450)
+	source := &fakeOnuDetailSource{details: map[[3]int]model.ONUCustomerInfo{
+		{2, 7, 4}: {
+			Board: 2, PON: 7, ID: 4, Name: "Isroh", SerialNumber: "ZTEGCEEA1119", RXPower: "-20.71", TXPower: "2.57",
+			Status: "Online", IPAddress: "0.0.0.0", LastOnline: "2024-08-11T10:09:37+07:00", UptimeSeconds: &uptime,
+			Uptime: "5 days 13 hours 10 minutes 50 seconds", LastOfflineReason: "PowerOff", GponOpticalDistance: "6701",
+		},
+		{2, 7, 5}: {Board: 2, PON: 7, ID: 5, Status: "Offline", GponOpticalDistance: "Unknown"},
+	}}
+	u := NewOnuV2Usecase(source)
+
+	onuDetail, err := u.GetByBoardIDPonIDAndOnuID(2, 7, 4)
+	assert.NoError(t, err)
+	assert.Equal(t, 2.57, *onuDetail.TXPowerDbm)
+	assert.Equal(t, 6701, *onuDetail.GponOpticalDistanceM)
+	assert.Equal(t, "PowerOff", *onuDetail.LastOfflineReason)
+	assert.Equal(t, int64(479450), *onuDetail.UptimeSeconds)
+	assert.Nil(t, onuDetail.IPAddress)
+	assert.Nil(t, onuDetail.LastOffline)
+
+	// Unavailable readings are null, not strings
+	onuDetail, err = u.GetByBoardIDPonIDAndOnuID(2, 7, 5)
+	assert.NoError(t, err)
+	body, err := json.Marshal(onuDetail)
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), `"rx_power_dbm":null`)
+	assert.Contains(t, string(body), `"gpon_optical_distance_m":null`)
+	assert.Contains(t, string(body), `"status":{"code":7,"label":"Offline"}`)
+	assert.NotContains(t, string(body), "Unknown")
+
+	// An ONU not found keeps zero IDs
+	onuDetail, err = u.GetByBoardIDPonIDAndOnuID(2, 7, 6)
+	assert.NoError(t, err)
+	assert.Equal(t, model.OnuDetailV2{}, onuDetail)
+}
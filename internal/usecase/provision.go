@@ -0,0 +1,358 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gosnmp/gosnmp"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/config"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/model"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/repository"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/utils"
+	"github.com/rs/zerolog/log"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var (
+	ErrInvalidProvisionRequest = errors.New("invalid provision request")
+	ErrOnuIDOccupied           = errors.New("onu id is already in use")
+	ErrNoEmptyOnuID            = errors.New("no empty onu id left on this pon")
+	ErrSerialNumberRegistered  = errors.New("serial number is already registered on this pon")
+	ErrOnuNotFound             = errors.New("onu not found")
+	ErrConfirmationMismatch    = errors.New("confirmation does not match the onu serial number")
+)
+
+const (
+	rowStatusCreateAndGo = 4 // RFC 2579 RowStatus createAndGo
+	rowStatusDestroy     = 6 // RFC 2579 RowStatus destroy
+)
+
+var (
+	serialNumberRegex = regexp.MustCompile(`^[A-Z]{4}[0-9A-F]{8}$`)  // Vendor ID followed by 8 hex digits
+	onuTypeRegex      = regexp.MustCompile(`^[A-Za-z0-9._-]{1,32}$`) // ONU type profile name on the OLT
+)
+
+type OnuProvisionUseCaseInterface interface {
+	RegisterOnu(ctx context.Context, boardID, ponID int, request model.OnuRegisterRequest) (
+		model.OnuProvisionResult, error,
+	)
+	DeregisterOnu(ctx context.Context, boardID, ponID, onuID int, request model.OnuDeregisterRequest, actor string) (
+		model.OnuActionLog, error,
+	)
+	UpdateOnu(ctx context.Context, boardID, ponID, onuID int, request model.OnuUpdateRequest, actor string) (
+		model.OnuActionLog, error,
+	)
+	RebootOnu(ctx context.Context, boardID, ponID, onuID int, request model.OnuRebootRequest, actor string) (
+		model.OnuActionLog, error,
+	)
+	GetOnuActionLog(ctx context.Context, boardID, ponID, onuID int) ([]model.OnuActionLog, error)
+}
+
+type onuProvisionUsecase struct {
+	onuUsecase          OnuUseCaseInterface
+	snmpRepository      repository.SnmpRepositoryInterface
+	redisRepository     repository.OnuRedisRepositoryInterface
+	actionLogRepository repository.OnuActionLogRepositoryInterface
+	cfg                 *config.Config
+}
+
+func NewOnuProvisionUsecase(
+	onuUsecase OnuUseCaseInterface, snmpRepository repository.SnmpRepositoryInterface,
+	redisRepository repository.OnuRedisRepositoryInterface,
+	actionLogRepository repository.OnuActionLogRepositoryInterface, cfg *config.Config,
+) OnuProvisionUseCaseInterface {
+	return &onuProvisionUsecase{
+		onuUsecase:          onuUsecase,
+		snmpRepository:      snmpRepository,
+		redisRepository:     redisRepository,
+		actionLogRepository: actionLogRepository,
+		cfg:                 cfg,
+	}
+}
+
+func (u *onuProvisionUsecase) RegisterOnu(ctx context.Context, boardID, ponID int, request model.OnuRegisterRequest) (
+	model.OnuProvisionResult, error,
+) {
+
+	// Get OLT config based on Board ID and PON ID
+	oltConfig, err := u.getOltConfig(boardID, ponID)
+	if err != nil {
+		return model.OnuProvisionResult{}, err
+	}
+
+	// Validate and normalize the request
+	request, err = validateRegisterRequest(request)
+	if err != nil {
+		return model.OnuProvisionResult{}, err
+	}
+
+	log.Info().Msg("Register ONU with Serial Number: " + request.SerialNumber + " on Board ID: " + strconv.Itoa(
+		boardID) + " and PON ID: " + strconv.Itoa(ponID)) // Log info message to logger
+
+	// Refuse to register a serial number which is already registered on this PON
+	onuSerialNumberList, err := u.onuUsecase.GetOnuIDAndSerialNumber(boardID, ponID)
+	if err != nil {
+		return model.OnuProvisionResult{}, err
+	}
+
+	for _, onuSerialNumber := range onuSerialNumberList {
+		if strings.EqualFold(onuSerialNumber.SerialNumber, request.SerialNumber) {
+			return model.OnuProvisionResult{}, fmt.Errorf("%w: onu id %d", ErrSerialNumberRegistered,
+				onuSerialNumber.ID)
+		}
+	}
+
+	// Get a fresh list of empty ONU ID, the cached list may be outdated
+	emptyOnuIDList, err := u.getFreshEmptyOnuID(ctx, boardID, ponID)
+	if err != nil {
+		return model.OnuProvisionResult{}, err
+	}
+
+	onuID, err := pickOnuID(emptyOnuIDList, request.OnuID)
+	if err != nil {
+		return model.OnuProvisionResult{}, err
+	}
+
+	onuIndex := "." + strconv.Itoa(utils.GetPonPortIndex(boardID, ponID)) + "." + strconv.Itoa(onuID)
+	baseOID := u.cfg.OltCfg.BaseOID2
+
+	// Create the ONU row with type, serial number and name in a single SNMP Set
+	pdus := []gosnmp.SnmpPDU{
+		{
+			Name:  baseOID + u.cfg.OltCfg.OnuRegisterTypeOID + onuIndex,
+			Type:  gosnmp.OctetString,
+			Value: request.OnuType,
+		},
+		{
+			Name:  baseOID + u.cfg.OltCfg.OnuRegisterSerialNumberOID + onuIndex,
+			Type:  gosnmp.OctetString,
+			Value: request.SerialNumber,
+		},
+		{
+			Name:  baseOID + u.cfg.OltCfg.OnuRegisterNameOID + onuIndex,
+			Type:  gosnmp.OctetString,
+			Value: request.Name,
+		},
+		{
+			Name:  baseOID + u.cfg.OltCfg.OnuRegisterRowStatusOID + onuIndex,
+			Type:  gosnmp.Integer,
+			Value: rowStatusCreateAndGo,
+		},
+	}
+
+	if err := u.set(pdus); err != nil {
+		return model.OnuProvisionResult{}, err
+	}
+
+	// Invalidate cached data of the PON as soon as the OLT has been changed
+	u.invalidateCache(ctx, boardID, ponID)
+
+	log.Info().Msg("Successfully registered ONU ID: " + strconv.Itoa(onuID) + " on Board ID: " + strconv.Itoa(
+		boardID) + " and PON ID: " + strconv.Itoa(ponID)) // Log info message to logger
+
+	result := model.OnuProvisionResult{
+		Board:        boardID,
+		PON:          ponID,
+		ID:           onuID,
+		SerialNumber: request.SerialNumber,
+		OnuType:      request.OnuType,
+		Name:         request.Name,
+	}
+
+	// Set ONU description once the row exists, the ONU stays registered when it fails, so a retry of the
+	// whole request doesn't find its ONU ID in use
+	if request.Description != "" {
+		err := u.set([]gosnmp.SnmpPDU{
+			{
+				Name:  u.cfg.OltCfg.BaseOID1 + oltConfig.OnuDescriptionOID + "." + strconv.Itoa(onuID),
+				Type:  gosnmp.OctetString,
+				Value: request.Description,
+			},
+		})
+		if err != nil {
+			log.Error().Msg("ONU registered but failed to set description: " + err.Error()) // Log error message to logger
+			result.Warnings = append(result.Warnings, "description was not set: "+err.Error())
+		} else {
+			result.Description = request.Description
+		}
+	}
+
+	return result, nil
+}
+
+func (u *onuProvisionUsecase) DeregisterOnu(
+	ctx context.Context, boardID, ponID, onuID int, request model.OnuDeregisterRequest, actor string,
+) (model.OnuActionLog, error) {
+
+	// Get OLT config based on Board ID and PON ID
+	oltConfig, err := u.getOltConfig(boardID, ponID)
+	if err != nil {
+		return model.OnuActionLog{}, err
+	}
+
+	// Make sure the caller targets the ONU they think they are deregistering
+	serialNumber, err := u.confirmOnu(oltConfig, onuID, request.Confirm, actor)
+	if err != nil {
+		return model.OnuActionLog{}, err
+	}
+
+	log.Info().Msg("Deregister ONU ID: " + strconv.Itoa(onuID) + " on Board ID: " + strconv.Itoa(
+		boardID) + " and PON ID: " + strconv.Itoa(ponID) + " by " + actor) // Log info message to logger
+
+	onuIndex := "." + strconv.Itoa(utils.GetPonPortIndex(boardID, ponID)) + "." + strconv.Itoa(onuID)
+
+	// Destroy the ONU row
+	err = u.set([]gosnmp.SnmpPDU{
+		{
+			Name:  u.cfg.OltCfg.BaseOID2 + u.cfg.OltCfg.OnuRegisterRowStatusOID + onuIndex,
+			Type:  gosnmp.Integer,
+			Value: rowStatusDestroy,
+		},
+	})
+	if err != nil {
+		return model.OnuActionLog{}, err
+	}
+
+	// Invalidate cached data of the PON
+	u.invalidateCache(ctx, boardID, ponID)
+
+	log.Info().Msg("Successfully deregistered ONU ID: " + strconv.Itoa(onuID) + " on Board ID: " + strconv.Itoa(
+		boardID) + " and PON ID: " + strconv.Itoa(ponID)) // Log info message to logger
+
+	return u.recordAction(ctx, model.OnuActionLog{
+		Action:       "deregister",
+		Board:        boardID,
+		PON:          ponID,
+		ID:           onuID,
+		SerialNumber: serialNumber,
+		Actor:        actor,
+	}), nil
+}
+
+// getOltConfig is a function to get OLT config and reject unknown Board ID or PON ID
+func (u *onuProvisionUsecase) getOltConfig(boardID, ponID int) (*model.OltConfig, error) {
+
+	oltConfig, err := u.onuUsecase.getOltConfig(boardID, ponID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidProvisionRequest, err.Error())
+	}
+
+	// Board config returns nil for an unknown PON ID
+	if oltConfig == nil {
+		return nil, fmt.Errorf("%w: invalid PON ID", ErrInvalidProvisionRequest)
+	}
+
+	return oltConfig, nil
+}
+
+// getFreshEmptyOnuID is a function to rewrite the empty ONU ID cache from SNMP and return it
+func (u *onuProvisionUsecase) getFreshEmptyOnuID(ctx context.Context, boardID, ponID int) ([]model.OnuID, error) {
+
+	if err := u.onuUsecase.UpdateEmptyOnuID(ctx, boardID, ponID); err != nil {
+		log.Error().Msg("Failed to update empty ONU ID: " + err.Error()) // Log error message to logger
+		return nil, err
+	}
+
+	return u.onuUsecase.GetEmptyOnuID(ctx, boardID, ponID)
+}
+
+// set is a function to perform SNMP Set and check the error status of the response
+func (u *onuProvisionUsecase) set(pdus []gosnmp.SnmpPDU) error {
+
+	result, err := u.snmpRepository.Set(pdus)
+	if err != nil {
+		log.Error().Msg("Failed to perform SNMP Set: " + err.Error()) // Log error message to logger
+		return fmt.Errorf("failed to perform SNMP Set: %w", err)
+	}
+
+	if result != nil && result.Error != gosnmp.NoError {
+		log.Error().Msgf("SNMP Set rejected by OLT: %v at index %d", result.Error, result.ErrorIndex)
+		return fmt.Errorf("snmp set rejected by olt: %v", result.Error)
+	}
+
+	return nil
+}
+
+// invalidateCache is a function to delete every Redis key related to a PON
+func (u *onuProvisionUsecase) invalidateCache(ctx context.Context, boardID, ponID int) {
+
+	redisKey := "board_" + strconv.Itoa(boardID) + "_pon_" + strconv.Itoa(ponID)
+
+	for _, key := range []string{redisKey, redisKey + "_empty_onu_id", redisKey + "_firmware"} {
+		if err := u.redisRepository.DeleteOnuIDCtx(ctx, key); err != nil {
+			log.Error().Msg("Failed to invalidate Redis Key: " + key + ": " + err.Error()) // Log error message to logger
+		}
+	}
+}
+
+// validateRegisterRequest is a function to validate and normalize ONU register request
+func validateRegisterRequest(request model.OnuRegisterRequest) (model.OnuRegisterRequest, error) {
+
+	request.SerialNumber = strings.ToUpper(strings.TrimSpace(request.SerialNumber))
+	request.OnuType = strings.TrimSpace(request.OnuType)
+	request.Name = strings.TrimSpace(request.Name)
+	request.Description = strings.TrimSpace(request.Description)
+
+	if !serialNumberRegex.MatchString(request.SerialNumber) {
+		return request, fmt.Errorf("%w: 'serial_number' must be 4 letters vendor ID followed by 8 hex digits",
+			ErrInvalidProvisionRequest)
+	}
+
+	if !onuTypeRegex.MatchString(request.OnuType) {
+		return request, fmt.Errorf("%w: 'onu_type' must be 1-32 letters, digits, '.', '_' or '-'",
+			ErrInvalidProvisionRequest)
+	}
+
+	if request.OnuID < 0 || request.OnuID > maxOnuID {
+		return request, fmt.Errorf("%w: 'onu_id' must be between 1 and %d", ErrInvalidProvisionRequest, maxOnuID)
+	}
+
+	if err := validateOnuText("name", request.Name, 64); err != nil {
+		return request, err
+	}
+
+	if err := validateOnuText("description", request.Description, 128); err != nil {
+		return request, err
+	}
+
+	return request, nil
+}
+
+// validateOnuText is a function to validate free-text ONU fields stored on the OLT
+func validateOnuText(field, value string, maxLength int) error {
+
+	if len(value) > maxLength {
+		return fmt.Errorf("%w: '%s' must be at most %d characters", ErrInvalidProvisionRequest, field, maxLength)
+	}
+
+	for _, r := range value {
+		if r > unicode.MaxASCII || !unicode.IsPrint(r) {
+			return fmt.Errorf("%w: '%s' must only contain printable ASCII characters", ErrInvalidProvisionRequest,
+				field)
+		}
+	}
+
+	return nil
+}
+
+// pickOnuID is a function to pick the requested ONU ID, or the first empty ONU ID if none is requested
+func pickOnuID(emptyOnuIDList []model.OnuID, requestedOnuID int) (int, error) {
+
+	if requestedOnuID == 0 {
+		if len(emptyOnuIDList) == 0 {
+			return 0, ErrNoEmptyOnuID
+		}
+		return emptyOnuIDList[0].ID, nil // Empty ONU ID list is sorted ascending
+	}
+
+	for _, emptyOnuID := range emptyOnuIDList {
+		if emptyOnuID.ID == requestedOnuID {
+			return requestedOnuID, nil
+		}
+	}
+
+	return 0, fmt.Errorf("%w: onu id %d", ErrOnuIDOccupied, requestedOnuID)
+}
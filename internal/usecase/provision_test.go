@@ -0,0 +1,367 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"github.com/gosnmp/gosnmp"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/model"
+	"github.com/stretchr/testify/assert"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// fakeActionLogRepo is an in-memory implementation of OnuActionLogRepositoryInterface
+type fakeActionLogRepo struct {
+	actions map[string][]model.OnuActionLog
+}
+
+func newFakeActionLogRepo() *fakeActionLogRepo {
+	return &fakeActionLogRepo{actions: make(map[string][]model.OnuActionLog)}
+}
+
+func (f *fakeActionLogRepo) PushOnuActionLog(
+	_ context.Context, key string, maxLength int, actionLog model.OnuActionLog,
+) error {
+	f.actions[key] = append([]model.OnuActionLog{actionLog}, f.actions[key]...)
+	if len(f.actions[key]) > maxLength {
+		f.actions[key] = f.actions[key][:maxLength]
+	}
+	return nil
+}
+
+func (f *fakeActionLogRepo) GetOnuActionLog(_ context.Context, key string) ([]model.OnuActionLog, error) {
+	return f.actions[key], nil
+}
+
+// newTestProvisionUsecase returns a provisioning usecase backed by a fake agent with ONU 1 and 2 registered
+func newTestProvisionUsecase() (*onuProvisionUsecase, *fakeSnmpAgent, *fakeOnuRedisRepo, *fakeActionLogRepo) {
+	agent := newFakeSnmpAgent()
+	agent.values[testBaseOID1+testOnuIDNameOID+".1"] = "ONU-1"
+	agent.values[testBaseOID1+testOnuIDNameOID+".2"] = "ONU-2"
+	agent.values[testBaseOID1+testOnuSerialOID+".1"] = "1,ZTEGC0000001"
+	agent.values[testBaseOID1+testOnuSerialOID+".2"] = "1,ZTEGC0000002"
+
+	redisRepo := newFakeOnuRedisRepo()
+	redisRepo.onuInfo["board_1_pon_1"] = []model.ONUInfoPerBoard{{Board: 1, PON: 1, ID: 1}}
+
+	actionLogRepo := newFakeActionLogRepo()
+	cfg := newTestConfig()
+	onuUsecase := NewOnuUsecase(agent, redisRepo, newFakeOnuChangeRepo(), newFakeCustomerRepo(), cfg)
+	u := NewOnuProvisionUsecase(onuUsecase, agent, redisRepo, actionLogRepo, cfg).(*onuProvisionUsecase)
+	return u, agent, redisRepo, actionLogRepo
+}
+
+func TestRegisterOnu(t *testing.T) {
+	u, agent, redisRepo, _ := newTestProvisionUsecase()
+
+	result, err := u.RegisterOnu(context.Background(), 1, 1, model.OnuRegisterRequest{
+		SerialNumber: "ztegc1234567",
+		OnuType:      "ZTE-F660",
+		Name:         "customer-001",
+		Description:  "Jl. Merdeka No. 1",
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, result.ID) // First empty ONU ID
+	assert.Equal(t, "ZTEGC1234567", result.SerialNumber)
+
+	// Row creation and description are two separate SNMP Set
+	assert.Len(t, agent.sets, 2)
+
+	onuIndex := testPonPortIndex + ".3"
+	assert.Equal(t, []gosnmp.SnmpPDU{
+		{Name: testBaseOID2 + ".3.28.1.1.1" + onuIndex, Type: gosnmp.OctetString, Value: "ZTE-F660"},
+		{Name: testBaseOID2 + ".3.28.1.1.5" + onuIndex, Type: gosnmp.OctetString, Value: "ZTEGC1234567"},
+		{Name: testBaseOID2 + ".3.28.1.1.2" + onuIndex, Type: gosnmp.OctetString, Value: "customer-001"},
+		{Name: testBaseOID2 + ".3.28.1.1.9" + onuIndex, Type: gosnmp.Integer, Value: rowStatusCreateAndGo},
+	}, agent.sets[0])
+	assert.Equal(t, []gosnmp.SnmpPDU{
+		{Name: testBaseOID1 + testOnuDescriptionOID + ".3", Type: gosnmp.OctetString, Value: "Jl. Merdeka No. 1"},
+	}, agent.sets[1])
+
+	// Cached data of the PON must be invalidated
+	assert.ElementsMatch(t, []string{"board_1_pon_1", "board_1_pon_1_empty_onu_id", "board_1_pon_1_firmware"},
+		redisRepo.deleted)
+	assert.NotContains(t, redisRepo.onuInfo, "board_1_pon_1")
+}
+
+func TestRegisterOnuWithRequestedOnuID(t *testing.T) {
+	u, agent, _, _ := newTestProvisionUsecase()
+
+	result, err := u.RegisterOnu(context.Background(), 1, 1, model.OnuRegisterRequest{
+		OnuID:        10,
+		SerialNumber: "ZTEGC1234567",
+		OnuType:      "ZTE-F660",
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 10, result.ID)
+	assert.Len(t, agent.sets, 1) // No description, no second SNMP Set
+	assert.Equal(t, testBaseOID2+".3.28.1.1.1"+testPonPortIndex+".10", agent.sets[0][0].Name)
+}
+
+func TestRegisterOnuErrors(t *testing.T) {
+	testCases := []struct {
+		name     string
+		boardID  int
+		ponID    int
+		request  model.OnuRegisterRequest
+		expected error
+	}{
+		{"Invalid board", 3, 1, model.OnuRegisterRequest{SerialNumber: "ZTEGC1234567", OnuType: "ZTE-F660"},
+			ErrInvalidProvisionRequest},
+		{"Invalid serial number", 1, 1, model.OnuRegisterRequest{SerialNumber: "ZTEG123", OnuType: "ZTE-F660"},
+			ErrInvalidProvisionRequest},
+		{"Invalid ONU type", 1, 1, model.OnuRegisterRequest{SerialNumber: "ZTEGC1234567", OnuType: "ZTE F660"},
+			ErrInvalidProvisionRequest},
+		{"Invalid ONU ID", 1, 1, model.OnuRegisterRequest{OnuID: 129, SerialNumber: "ZTEGC1234567",
+			OnuType: "ZTE-F660"}, ErrInvalidProvisionRequest},
+		{"Name too long", 1, 1, model.OnuRegisterRequest{SerialNumber: "ZTEGC1234567", OnuType: "ZTE-F660",
+			Name: strings.Repeat("a", 65)}, ErrInvalidProvisionRequest},
+		{"Control character in description", 1, 1, model.OnuRegisterRequest{SerialNumber: "ZTEGC1234567",
+			OnuType: "ZTE-F660", Description: "line\nbreak"}, ErrInvalidProvisionRequest},
+		{"Occupied ONU ID", 1, 1, model.OnuRegisterRequest{OnuID: 2, SerialNumber: "ZTEGC1234567",
+			OnuType: "ZTE-F660"}, ErrOnuIDOccupied},
+		{"Serial number already registered", 1, 1, model.OnuRegisterRequest{SerialNumber: "ZTEGC0000002",
+			OnuType: "ZTE-F660"}, ErrSerialNumberRegistered},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			u, agent, redisRepo, _ := newTestProvisionUsecase()
+
+			_, err := u.RegisterOnu(context.Background(), tc.boardID, tc.ponID, tc.request)
+
+			assert.ErrorIs(t, err, tc.expected)
+			assert.Empty(t, agent.sets)        // OLT must not be changed
+			assert.Empty(t, redisRepo.deleted) // Cache must not be invalidated
+		})
+	}
+}
+
+func TestRegisterOnuNoEmptyOnuID(t *testing.T) {
+	u, agent, _, _ := newTestProvisionUsecase()
+	for i := 3; i <= 128; i++ {
+		agent.values[testBaseOID1+testOnuIDNameOID+"."+strconv.Itoa(i)] = "ONU"
+	}
+
+	_, err := u.RegisterOnu(context.Background(), 1, 1, model.OnuRegisterRequest{
+		SerialNumber: "ZTEGC1234567",
+		OnuType:      "ZTE-F660",
+	})
+
+	assert.ErrorIs(t, err, ErrNoEmptyOnuID)
+	assert.Empty(t, agent.sets)
+}
+
+func TestRegisterOnuSerialNumberReadFailed(t *testing.T) {
+	u, agent, redisRepo, _ := newTestProvisionUsecase()
+	agent.walkErrs[testBaseOID1+testOnuSerialOID] = errors.New("request timeout")
+
+	// The serial number could already be registered on the PON
+	_, err := u.RegisterOnu(context.Background(), 1, 1, model.OnuRegisterRequest{
+		SerialNumber: "ZTEGC0000002",
+		OnuType:      "ZTE-F660",
+	})
+
+	assert.EqualError(t, err, "failed to walk OID: request timeout")
+	assert.Empty(t, agent.sets)
+	assert.Empty(t, redisRepo.deleted)
+}
+
+func TestRegisterOnuRejectedByOlt(t *testing.T) {
+	u, agent, redisRepo, _ := newTestProvisionUsecase()
+	agent.setErr = gosnmp.InconsistentValue
+
+	_, err := u.RegisterOnu(context.Background(), 1, 1, model.OnuRegisterRequest{
+		SerialNumber: "ZTEGC1234567",
+		OnuType:      "ZTE-F660",
+	})
+
+	assert.Error(t, err)
+	assert.Len(t, agent.sets, 1)
+	assert.Empty(t, redisRepo.deleted)
+}
+
+func TestRegisterOnuDescriptionRejectedByOlt(t *testing.T) {
+	u, agent, redisRepo, _ := newTestProvisionUsecase()
+	agent.setErr = gosnmp.WrongLength
+	agent.setErrOID = testBaseOID1 + testOnuDescriptionOID + ".3"
+
+	result, err := u.RegisterOnu(context.Background(), 1, 1, model.OnuRegisterRequest{
+		SerialNumber: "ZTEGC1234567",
+		OnuType:      "ZTE-F660",
+		Description:  "Jl. Merdeka No. 1",
+	})
+
+	// The ONU is registered, only the description is missing
+	assert.NoError(t, err)
+	assert.Equal(t, 3, result.ID)
+	assert.Empty(t, result.Description)
+	assert.Equal(t, []string{"description was not set: snmp set rejected by olt: WrongLength"}, result.Warnings)
+	assert.Len(t, agent.sets, 2)
+	assert.Contains(t, redisRepo.deleted, "board_1_pon_1")
+}
+
+func TestDeregisterOnu(t *testing.T) {
+	u, agent, redisRepo, actionLogRepo := newTestProvisionUsecase()
+
+	result, err := u.DeregisterOnu(context.Background(), 1, 1, 2, model.OnuDeregisterRequest{
+		Confirm: "ztegc0000002",
+	}, "support-andi")
+
+	assert.NoError(t, err)
+	assert.Equal(t, [][]gosnmp.SnmpPDU{{
+		{Name: testBaseOID2 + ".3.28.1.1.9" + testPonPortIndex + ".2", Type: gosnmp.Integer, Value: rowStatusDestroy},
+	}}, agent.sets)
+	assert.ElementsMatch(t, []string{"board_1_pon_1", "board_1_pon_1_empty_onu_id", "board_1_pon_1_firmware"},
+		redisRepo.deleted)
+
+	// The deregistration is recorded in the action log of the ONU
+	assert.Equal(t, "deregister", result.Action)
+	assert.Equal(t, "ZTEGC0000002", result.SerialNumber)
+	assert.Equal(t, "support-andi", result.Actor)
+	assert.Equal(t, []model.OnuActionLog{result}, actionLogRepo.actions["board_1_pon_1_onu_2_action_log"])
+}
+
+func TestDeregisterOnuErrors(t *testing.T) {
+	testCases := []struct {
+		name     string
+		boardID  int
+		ponID    int
+		onuID    int
+		confirm  string
+		actor    string
+		expected error
+	}{
+		{"Invalid PON", 1, 9, 1, "ZTEGC0000001", "noc", ErrInvalidProvisionRequest},
+		{"Invalid ONU ID", 1, 1, 0, "ZTEGC0000001", "noc", ErrInvalidProvisionRequest},
+		{"Missing actor", 1, 1, 2, "ZTEGC0000002", " ", ErrInvalidProvisionRequest},
+		{"ONU not registered", 1, 1, 5, "ZTEGC0000005", "noc", ErrOnuNotFound},
+		{"Confirmation of another ONU", 1, 1, 2, "ZTEGC0000001", "noc", ErrConfirmationMismatch},
+		{"Missing confirmation", 1, 1, 2, "", "noc", ErrConfirmationMismatch},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			u, agent, redisRepo, actionLogRepo := newTestProvisionUsecase()
+
+			_, err := u.DeregisterOnu(context.Background(), tc.boardID, tc.ponID, tc.onuID,
+				model.OnuDeregisterRequest{Confirm: tc.confirm}, tc.actor)
+
+			assert.ErrorIs(t, err, tc.expected)
+			assert.Empty(t, agent.sets)
+			assert.Empty(t, redisRepo.deleted)
+			assert.Empty(t, actionLogRepo.actions)
+		})
+	}
+}
+
+func TestUpdateOnu(t *testing.T) {
+	u, agent, redisRepo, _ := newTestProvisionUsecase()
+	name := " customer-002 "
+
+	result, err := u.UpdateOnu(context.Background(), 1, 1, 2, model.OnuUpdateRequest{
+		Name:    &name,
+		Confirm: "ztegc0000002",
+	}, "support-andi")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []gosnmp.SnmpPDU{
+		{Name: testBaseOID1 + testOnuIDNameOID + ".2", Type: gosnmp.OctetString, Value: "customer-002"},
+	}, agent.sets[0])
+	assert.Equal(t, "update", result.Action)
+	assert.Equal(t, "ZTEGC0000002", result.SerialNumber)
+	assert.Equal(t, map[string]string{"name": "customer-002"}, result.Changes)
+	assert.Contains(t, redisRepo.deleted, "board_1_pon_1")
+
+	// The action is recorded with the actor
+	actionLog, err := u.GetOnuActionLog(context.Background(), 1, 1, 2)
+	assert.NoError(t, err)
+	assert.Len(t, actionLog, 1)
+	assert.Equal(t, "support-andi", actionLog[0].Actor)
+}
+
+func TestRebootOnu(t *testing.T) {
+	u, agent, redisRepo, actionLogRepo := newTestProvisionUsecase()
+
+	result, err := u.RebootOnu(context.Background(), 1, 1, 1, model.OnuRebootRequest{Confirm: "ZTEGC0000001"},
+		"support-andi")
+
+	assert.NoError(t, err)
+	assert.Equal(t, [][]gosnmp.SnmpPDU{{
+		{Name: testBaseOID2 + ".3.50.11.3.1.1" + testPonPortIndex + ".1", Type: gosnmp.Integer, Value: onuRebootAction},
+	}}, agent.sets)
+	assert.Equal(t, "reboot", result.Action)
+	assert.Equal(t, "support-andi", result.Actor)
+	assert.NotEmpty(t, result.Timestamp)
+	assert.Contains(t, redisRepo.deleted, "board_1_pon_1")
+	assert.Len(t, actionLogRepo.actions["board_1_pon_1_onu_1_action_log"], 1)
+}
+
+func TestUpdateOnuErrors(t *testing.T) {
+	name := "customer-001"
+	badName := "bad\tname"
+
+	testCases := []struct {
+		name     string
+		onuID    int
+		request  model.OnuUpdateRequest
+		actor    string
+		expected error
+	}{
+		{"Nothing to update", 1, model.OnuUpdateRequest{Confirm: "ZTEGC0000001"}, "support-andi",
+			ErrInvalidProvisionRequest},
+		{"Invalid name", 1, model.OnuUpdateRequest{Name: &badName, Confirm: "ZTEGC0000001"}, "support-andi",
+			ErrInvalidProvisionRequest},
+		{"Missing actor", 1, model.OnuUpdateRequest{Name: &name, Confirm: "ZTEGC0000001"}, " ",
+			ErrInvalidProvisionRequest},
+		{"Confirmation mismatch", 1, model.OnuUpdateRequest{Name: &name, Confirm: "ZTEGC0000002"}, "support-andi",
+			ErrConfirmationMismatch},
+		{"ONU not registered", 5, model.OnuUpdateRequest{Name: &name, Confirm: "ZTEGC0000005"}, "support-andi",
+			ErrOnuNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			u, agent, redisRepo, actionLogRepo := newTestProvisionUsecase()
+
+			_, err := u.UpdateOnu(context.Background(), 1, 1, tc.onuID, tc.request, tc.actor)
+
+			assert.ErrorIs(t, err, tc.expected)
+			assert.Empty(t, agent.sets)
+			assert.Empty(t, redisRepo.deleted)
+			assert.Empty(t, actionLogRepo.actions)
+		})
+	}
+}
+
+func TestRebootOnuErrors(t *testing.T) {
+	testCases := []struct {
+		name     string
+		onuID    int
+		confirm  string
+		actor    string
+		expected error
+	}{
+		{"Missing actor", 1, "ZTEGC0000001", "", ErrInvalidProvisionRequest},
+		{"Missing confirmation", 1, "", "support-andi", ErrConfirmationMismatch},
+		{"Confirmation of another ONU", 1, "ZTEGC0000002", "support-andi", ErrConfirmationMismatch},
+		{"ONU not registered", 5, "ZTEGC0000005", "support-andi", ErrOnuNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			u, agent, redisRepo, actionLogRepo := newTestProvisionUsecase()
+
+			_, err := u.RebootOnu(context.Background(), 1, 1, tc.onuID, model.OnuRebootRequest{Confirm: tc.confirm},
+				tc.actor)
+
+			assert.ErrorIs(t, err, tc.expected)
+			assert.Empty(t, agent.sets)
+			assert.Empty(t, redisRepo.deleted)
+			assert.Empty(t, actionLogRepo.actions)
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gosnmp/gosnmp"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/config"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/repository"
+	"github.com/rs/zerolog/log"
+	"sync"
+	"time"
+)
+
+const (
+	rateLimitIPKey     = "rate_limit_ip_"     // Redis token bucket of a client IP, followed by the IP
+	rateLimitClientKey = "rate_limit_client_" // Redis token bucket of a client, followed by the client ID
+	rateLimitSnmpKey   = "rate_limit_snmp_"   // Redis token bucket of SNMP requests, followed by the OLT name
+)
+
+var (
+	ErrRateLimited        = errors.New("rate limit exceeded")
+	ErrSnmpBudgetExceeded = errors.New("snmp budget of the OLT exceeded")
+)
+
+// SnmpBudgetError is returned by SNMP requests that waited snmp_max_wait for the SNMP budget of the OLT,
+// it matches ErrSnmpBudgetExceeded
+type SnmpBudgetError struct {
+	RetryAfter time.Duration // Time until the next SNMP tokens
+}
+
+func (e *SnmpBudgetError) Error() string {
+	return ErrSnmpBudgetExceeded.Error() + ": retry after " + e.RetryAfter.String()
+}
+
+func (e *SnmpBudgetError) Unwrap() error {
+	return ErrSnmpBudgetExceeded
+}
+
+type RateLimitUseCaseInterface interface {
+	AllowIP(ctx context.Context, ip string) (time.Duration, error)
+	AllowClient(ctx context.Context, clientID string) (time.Duration, error)
+	SnmpSaturated() (time.Duration, bool)
+	LimitSnmp(snmpRepository repository.SnmpRepositoryInterface) repository.SnmpRepositoryInterface
+}
+
+type rateLimitUsecase struct {
+	rateLimitRepository repository.RateLimitRepositoryInterface
+	cfg                 config.RateLimitConfig
+	snmpKey             string
+	sleep               func(d time.Duration)
+	now                 func() time.Time
+
+	mu             sync.Mutex
+	snmpWaiting    int           // SNMP requests waiting for tokens
+	snmpRetryAfter time.Duration // Last wait for SNMP tokens
+}
+
+// NewRateLimitUsecase returns the usecase limiting requests of each client and SNMP requests to the OLT,
+// a limit with a rate of 0 is disabled
+func NewRateLimitUsecase(
+	rateLimitRepository repository.RateLimitRepositoryInterface, cfg *config.Config,
+) RateLimitUseCaseInterface {
+	return &rateLimitUsecase{
+		rateLimitRepository: rateLimitRepository,
+		cfg:                 cfg.RateLimitCfg,
+		snmpKey:             rateLimitSnmpKey + cfg.StreamCfg.OltName,
+		sleep:               time.Sleep,
+		now:                 time.Now,
+	}
+}
+
+// AllowIP takes a token of a client IP, it returns ErrRateLimited and the time until the next token when the IP
+// made too many requests
+func (u *rateLimitUsecase) AllowIP(ctx context.Context, ip string) (time.Duration, error) {
+
+	if !u.cfg.Enabled || u.cfg.IpRate <= 0 {
+		return 0, nil
+	}
+
+	return u.takeClientToken(ctx, rateLimitIPKey+ip, u.cfg.IpRate, u.cfg.IpBurst)
+}
+
+// AllowClient takes a token of a client, it returns ErrRateLimited and the time until the next token when the client
+// made too many requests
+func (u *rateLimitUsecase) AllowClient(ctx context.Context, clientID string) (time.Duration, error) {
+
+	if !u.cfg.Enabled || u.cfg.ClientRate <= 0 {
+		return 0, nil
+	}
+
+	return u.takeClientToken(ctx, rateLimitClientKey+clientID, u.cfg.ClientRate, u.cfg.ClientBurst)
+}
+
+// takeClientToken is a method to take a token of a token bucket of clients
+func (u *rateLimitUsecase) takeClientToken(
+	ctx context.Context, key string, rate float64, burst int,
+) (time.Duration, error) {
+
+	taken, retryAfter, err := u.rateLimitRepository.TakeTokens(ctx, key, rate, burst, 1)
+	if err != nil {
+		return 0, err
+	}
+	if !taken {
+		return retryAfter, fmt.Errorf("%w: %s", ErrRateLimited, key)
+	}
+
+	return 0, nil
+}
+
+// SnmpSaturated returns true and the last wait for SNMP tokens while snmp_queue_size SNMP requests are waiting
+func (u *rateLimitUsecase) SnmpSaturated() (time.Duration, bool) {
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if !u.cfg.Enabled || u.cfg.SnmpRate <= 0 || u.snmpWaiting < u.cfg.SnmpQueueSize {
+		return 0, false
+	}
+
+	return u.snmpRetryAfter, true
+}
+
+// LimitSnmp returns an SNMP repository taking tokens of the SNMP budget before each request
+func (u *rateLimitUsecase) LimitSnmp(
+	snmpRepository repository.SnmpRepositoryInterface,
+) repository.SnmpRepositoryInterface {
+
+	if !u.cfg.Enabled || u.cfg.SnmpRate <= 0 {
+		return snmpRepository
+	}
+
+	return &snmpBudgetRepository{snmpRepository: snmpRepository, rateLimit: u}
+}
+
+// waitSnmp is a method to take SNMP tokens, it waits up to snmp_max_wait for them and fails open when Redis fails,
+// so a Redis outage doesn't stop SNMP
+func (u *rateLimitUsecase) waitSnmp(cost int) error {
+
+	u.mu.Lock()
+	u.snmpWaiting++
+	u.mu.Unlock()
+	defer func() {
+		u.mu.Lock()
+		u.snmpWaiting--
+		u.mu.Unlock()
+	}()
+
+	// A request costing more than the burst could never be taken
+	if cost > u.cfg.SnmpBurst {
+		cost = u.cfg.SnmpBurst
+	}
+
+	deadline := u.now().Add(time.Duration(u.cfg.SnmpMaxWait) * time.Second)
+	for {
+		taken, wait, err := u.rateLimitRepository.TakeTokens(
+			context.Background(), u.snmpKey, u.cfg.SnmpRate, u.cfg.SnmpBurst, cost,
+		)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to take SNMP tokens, sending SNMP request anyway")
+			return nil
+		}
+		if taken {
+			return nil
+		}
+
+		u.mu.Lock()
+		u.snmpRetryAfter = wait
+		u.mu.Unlock()
+
+		if u.now().Add(wait).After(deadline) {
+			return &SnmpBudgetError{RetryAfter: wait}
+		}
+		u.sleep(wait)
+	}
+}
+
+// snmpBudgetRepository takes tokens of the SNMP budget of the OLT before each request
+type snmpBudgetRepository struct {
+	snmpRepository repository.SnmpRepositoryInterface
+	rateLimit      *rateLimitUsecase
+}
+
+func (r *snmpBudgetRepository) Get(oids []string) (*gosnmp.SnmpPacket, error) {
+	if err := r.rateLimit.waitSnmp(1); err != nil {
+		return nil, err
+	}
+	return r.snmpRepository.Get(oids)
+}
+
+func (r *snmpBudgetRepository) Walk(oid string, walkFunc func(pdu gosnmp.SnmpPDU) error) error {
+	if err := r.rateLimit.waitSnmp(r.rateLimit.cfg.SnmpWalkCost); err != nil {
+		return err
+	}
+	return r.snmpRepository.Walk(oid, walkFunc)
+}
+
+func (r *snmpBudgetRepository) Set(pdus []gosnmp.SnmpPDU) (*gosnmp.SnmpPacket, error) {
+	if err := r.rateLimit.waitSnmp(1); err != nil {
+		return nil, err
+	}
+	return r.snmpRepository.Set(pdus)
+}
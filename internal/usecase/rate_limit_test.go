@@ -0,0 +1,143 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"github.com/gosnmp/gosnmp"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// fakeTokenBucketRepo is an in-memory implementation of RateLimitRepositoryInterface,
+// token buckets are never refilled, tests refill them
+type fakeTokenBucketRepo struct {
+	tokens map[string]float64
+}
+
+func newFakeTokenBucketRepo() *fakeTokenBucketRepo {
+	return &fakeTokenBucketRepo{tokens: make(map[string]float64)}
+}
+
+func (f *fakeTokenBucketRepo) TakeTokens(_ context.Context, key string, rate float64, burst, cost int) (
+	bool, time.Duration, error,
+) {
+	tokens, ok := f.tokens[key]
+	if !ok {
+		tokens = float64(burst)
+	}
+	if tokens < float64(cost) {
+		f.tokens[key] = tokens
+		return false, time.Duration((float64(cost) - tokens) / rate * float64(time.Second)), nil
+	}
+	f.tokens[key] = tokens - float64(cost)
+	return true, 0, nil
+}
+
+func newTestRateLimitUsecase() (*rateLimitUsecase, *fakeTokenBucketRepo) {
+	tokenRepo := newFakeTokenBucketRepo()
+
+	cfg := newTestConfig()
+	cfg.StreamCfg.OltName = "olt-1"
+	cfg.RateLimitCfg.Enabled = true
+	cfg.RateLimitCfg.IpRate = 4
+	cfg.RateLimitCfg.IpBurst = 1
+	cfg.RateLimitCfg.ClientRate = 2
+	cfg.RateLimitCfg.ClientBurst = 3
+	cfg.RateLimitCfg.SnmpRate = 10
+	cfg.RateLimitCfg.SnmpBurst = 5
+	cfg.RateLimitCfg.SnmpWalkCost = 4
+	cfg.RateLimitCfg.SnmpMaxWait = 1
+	cfg.RateLimitCfg.SnmpQueueSize = 1
+
+	// Sleeping advances the clock
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	u := NewRateLimitUsecase(tokenRepo, cfg).(*rateLimitUsecase)
+	u.now = func() time.Time { return now }
+	u.sleep = func(d time.Duration) { now = now.Add(d) }
+	return u, tokenRepo
+}
+
+func TestRateLimitClient(t *testing.T) {
+	u, _ := newTestRateLimitUsecase()
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		_, err := u.AllowClient(ctx, "key:noc")
+		assert.NoError(t, err)
+	}
+	retryAfter, err := u.AllowClient(ctx, "key:noc")
+	assert.True(t, errors.Is(err, ErrRateLimited))
+	assert.Equal(t, 500*time.Millisecond, retryAfter)
+
+	// Clients have their own bucket
+	_, err = u.AllowClient(ctx, "key:ops")
+	assert.NoError(t, err)
+
+	// Client IPs have their own rate and burst
+	_, err = u.AllowIP(ctx, "192.0.2.10")
+	assert.NoError(t, err)
+	retryAfter, err = u.AllowIP(ctx, "192.0.2.10")
+	assert.True(t, errors.Is(err, ErrRateLimited))
+	assert.Equal(t, 250*time.Millisecond, retryAfter)
+
+	u.cfg.Enabled = false
+	_, err = u.AllowClient(ctx, "key:noc")
+	assert.NoError(t, err)
+}
+
+func TestRateLimitSnmpBudget(t *testing.T) {
+	u, tokenRepo := newTestRateLimitUsecase()
+	agent := newFakeSnmpAgent()
+	snmpRepo := u.LimitSnmp(agent)
+
+	// A walk takes 4 tokens and a get 1 of the 5 of the burst
+	assert.NoError(t, snmpRepo.Walk(".1.3", func(gosnmp.SnmpPDU) error { return nil }))
+	_, err := snmpRepo.Get([]string{".1.3.1"})
+	assert.NoError(t, err)
+	assert.Equal(t, 0.0, tokenRepo.tokens["rate_limit_snmp_olt-1"])
+
+	// A request waits for tokens, the fake bucket is refilled while it sleeps
+	sleep := u.sleep
+	var slept time.Duration
+	u.sleep = func(d time.Duration) {
+		sleep(d)
+		slept += d
+		tokenRepo.tokens["rate_limit_snmp_olt-1"] = 1
+	}
+	_, err = snmpRepo.Set(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 100*time.Millisecond, slept)
+	assert.Len(t, agent.sets, 1)
+
+	// A request failing to get tokens within snmp_max_wait isn't sent
+	u.sleep = sleep
+	_, err = snmpRepo.Set(nil)
+	assert.True(t, errors.Is(err, ErrSnmpBudgetExceeded))
+	assert.Len(t, agent.sets, 1)
+
+	// The walk cost is capped by the burst, 12 tokens would never be available
+	u.cfg.SnmpWalkCost = 12
+	tokenRepo.tokens["rate_limit_snmp_olt-1"] = 5
+	assert.NoError(t, snmpRepo.Walk(".1.3", func(gosnmp.SnmpPDU) error { return nil }))
+}
+
+func TestRateLimitSnmpSaturated(t *testing.T) {
+	u, _ := newTestRateLimitUsecase()
+
+	_, saturated := u.SnmpSaturated()
+	assert.False(t, saturated)
+
+	u.snmpWaiting = 1
+	u.snmpRetryAfter = 300 * time.Millisecond
+	retryAfter, saturated := u.SnmpSaturated()
+	assert.True(t, saturated)
+	assert.Equal(t, 300*time.Millisecond, retryAfter)
+
+	// Without a budget the SNMP repository isn't wrapped
+	u.cfg.SnmpRate = 0
+	_, saturated = u.SnmpSaturated()
+	assert.False(t, saturated)
+	agent := newFakeSnmpAgent()
+	assert.Equal(t, agent, u.LimitSnmp(agent))
+}
@@ -0,0 +1,400 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/config"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/model"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/repository"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/utils"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/pkg/pubsub"
+	"github.com/rs/zerolog/log"
+	"math"
+	"time"
+)
+
+const (
+	slaSourceBuffer        = 1024                // The number of ONU events waiting to be recorded
+	slaTransitionRetention = 35 * 24 * time.Hour // Longest report window with a margin
+	slaDefaultWindow       = "day"
+)
+
+// slaWindows are the report windows by name
+var slaWindows = map[string]time.Duration{
+	"day":   24 * time.Hour,
+	"week":  7 * 24 * time.Hour,
+	"month": 30 * 24 * time.Hour,
+}
+
+// ErrInvalidSlaRequest is returned for an unknown report window or an out of range board, PON or ONU ID
+var ErrInvalidSlaRequest = errors.New("invalid SLA request")
+
+// SlaFilter selects the ONU of an SLA report, zero values match any
+type SlaFilter struct {
+	Board int
+	PON   int
+	ID    int
+}
+
+type SlaUseCaseInterface interface {
+	GetReport(ctx context.Context, window string, filter SlaFilter) (model.SlaReport, error)
+	Run(ctx context.Context)
+}
+
+// slaTotals are the up and down time of one or more ONU within a report window
+type slaTotals struct {
+	up               time.Duration
+	down             time.Duration
+	outages          int
+	restored         int
+	restoredDuration time.Duration // Duration of the restored outages, from the start of each outage
+	byReason         map[string]time.Duration
+}
+
+type slaUsecase struct {
+	onuUsecase       OnuUseCaseInterface
+	snmpRepository   repository.SnmpRepositoryInterface
+	statusRepository repository.OnuStatusRepositoryInterface
+	source           <-chan model.OnuEvent
+	cancelSource     func()
+	cfg              *config.Config
+	now              func() time.Time
+
+	lastStatus map[[3]int]string // Last recorded status, keyed by board, PON and ONU ID
+}
+
+// NewSlaUsecase returns the usecase recording ONU status transitions from the ONU events of broker
+// and reporting availability, outages and downtime per ONU and per PON
+func NewSlaUsecase(
+	onuUsecase OnuUseCaseInterface, snmpRepository repository.SnmpRepositoryInterface,
+	statusRepository repository.OnuStatusRepositoryInterface, broker *pubsub.Broker[model.OnuEvent], cfg *config.Config,
+) SlaUseCaseInterface {
+
+	// Subscribe now so no event published before Run is started is lost
+	source, cancelSource := broker.Subscribe(slaSourceBuffer)
+
+	return &slaUsecase{
+		onuUsecase:       onuUsecase,
+		snmpRepository:   snmpRepository,
+		statusRepository: statusRepository,
+		source:           source,
+		cancelSource:     cancelSource,
+		cfg:              cfg,
+		now:              time.Now,
+		lastStatus:       make(map[[3]int]string),
+	}
+}
+
+// Run records ONU status transitions until ctx is done
+func (u *slaUsecase) Run(ctx context.Context) {
+
+	defer u.cancelSource()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-u.source:
+			if !ok {
+				return
+			}
+			u.record(ctx, event)
+		}
+	}
+}
+
+// record is a method to save the status of an ONU status event when it differs from the last recorded status
+func (u *slaUsecase) record(ctx context.Context, event model.OnuEvent) {
+
+	if event.Type != OnuEventStatus || event.Status == "" {
+		return
+	}
+
+	key := [3]int{event.Board, event.PON, event.ID}
+	redisKey := getSlaTransitionKey(event.Board, event.PON, event.ID)
+
+	// Traps and polling both report a change, the last status survives restarts in Redis
+	previous, known := u.lastStatus[key]
+	firstSeen := false
+	if !known {
+		transitions, err := u.statusRepository.GetOnuStatusTransitions(ctx, redisKey, u.now())
+		if err == nil && len(transitions) > 0 {
+			previous = transitions[len(transitions)-1].Status
+		}
+		firstSeen = err == nil && len(transitions) == 0 // A new ONU, or the first poll of an ONU
+	}
+	if previous == event.Status {
+		u.lastStatus[key] = event.Status
+		return
+	}
+
+	at, err := time.Parse(time.RFC3339, event.Timestamp)
+	if err != nil {
+		at = u.now()
+	}
+
+	transition := model.OnuStatusTransition{Status: event.Status, Timestamp: at.Format(time.RFC3339), FirstSeen: firstSeen}
+	if !onuUpStatuses[event.Status] {
+		transition.OfflineReason = u.getOfflineReason(event.Board, event.PON, event.ID, event.Status)
+	}
+
+	err = u.statusRepository.AddOnuStatusTransition(ctx, redisKey, at, slaTransitionRetention, transition)
+	if err != nil {
+		log.Error().Msgf("Failed to save status transition of Board ID: %d, PON ID: %d and ONU ID: %d: %s",
+			event.Board, event.PON, event.ID, err.Error()) // Log error message to logger
+		return
+	}
+
+	u.lastStatus[key] = event.Status
+}
+
+// getOfflineReason is a method to read the last offline reason of an ONU, the status stands in when it is unknown
+func (u *slaUsecase) getOfflineReason(boardID, ponID, onuID int, status string) string {
+
+	if u.cfg.OltCfg.OnuLastOfflineReasonAllPon == "" {
+		return status
+	}
+
+	oid := fmt.Sprintf("%s%s.%d.%d", u.cfg.OltCfg.BaseOID1, u.cfg.OltCfg.OnuLastOfflineReasonAllPon,
+		utils.GetGponOltIfIndex(boardID, ponID), onuID)
+	result, err := u.snmpRepository.Get([]string{oid})
+	if err != nil || len(result.Variables) == 0 {
+		return status
+	}
+
+	reason := utils.ExtractLastOfflineReason(result.Variables[0].Value)
+	if reason == "Unknown" {
+		return status
+	}
+	return reason
+}
+
+// GetReport returns the availability, outages and downtime of the selected ONU and their PON within the window
+// ending now, ONU without recorded transitions keep their current status for the whole window
+func (u *slaUsecase) GetReport(ctx context.Context, window string, filter SlaFilter) (model.SlaReport, error) {
+
+	if window == "" {
+		window = slaDefaultWindow
+	}
+	duration, ok := slaWindows[window]
+	if !ok {
+		return model.SlaReport{}, fmt.Errorf("%w: window must be day, week or month", ErrInvalidSlaRequest)
+	}
+	if filter.Board < 0 || filter.Board > maxBoardID || filter.PON < 0 || filter.PON > maxPonID ||
+		filter.ID < 0 || filter.ID > maxOnuID {
+		return model.SlaReport{}, fmt.Errorf("%w: board, PON or ONU ID out of range", ErrInvalidSlaRequest)
+	}
+
+	to := u.now()
+	from := to.Add(-duration)
+
+	report := model.SlaReport{
+		Olt:         u.cfg.StreamCfg.OltName,
+		Window:      window,
+		From:        from.Format(time.RFC3339),
+		To:          to.Format(time.RFC3339),
+		GeneratedAt: to.Format(time.RFC3339),
+		Pons:        make([]model.PonSla, 0),
+		Onus:        make([]model.OnuSla, 0),
+	}
+
+	boardIDs, ponIDs := selectedIDs(filter.Board, maxBoardID), selectedIDs(filter.PON, maxPonID)
+	failed := 0
+
+	for _, boardID := range boardIDs {
+		for _, ponID := range ponIDs {
+			// ONU lists are cached per PON
+			onuInfoList, err := u.onuUsecase.GetByBoardIDAndPonID(ctx, boardID, ponID)
+			if err != nil {
+				log.Error().Msgf("Failed to get ONU of Board ID: %d and PON ID: %d: %s",
+					boardID, ponID, err.Error()) // Log error message to logger
+				if errors.Is(err, ErrSnmpBudgetExceeded) {
+					return model.SlaReport{}, err // The other PON would wait for the SNMP budget too
+				}
+				failed++
+				continue
+			}
+
+			ponTotals := slaTotals{byReason: make(map[string]time.Duration)}
+			onuCount := 0
+
+			for _, onuInfo := range onuInfoList {
+				if filter.ID != 0 && onuInfo.ID != filter.ID {
+					continue
+				}
+
+				transitions, err := u.statusRepository.GetOnuStatusTransitions(
+					ctx, getSlaTransitionKey(onuInfo.Board, onuInfo.PON, onuInfo.ID), from,
+				)
+				if err != nil {
+					return model.SlaReport{}, err
+				}
+
+				totals := computeSlaTotals(onuInfo.Status, transitions, from, to)
+				ponTotals.add(totals)
+				onuCount++
+
+				report.Onus = append(report.Onus, model.OnuSla{
+					Board:               onuInfo.Board,
+					PON:                 onuInfo.PON,
+					ID:                  onuInfo.ID,
+					Name:                onuInfo.Name,
+					SerialNumber:        onuInfo.SerialNumber,
+					Status:              onuInfo.Status,
+					AvailabilityPercent: totals.availability(),
+					Outages:             totals.outages,
+					DowntimeSeconds:     durationSeconds(totals.down),
+					MttrSeconds:         durationSeconds(totals.mttr()),
+					DowntimeByReason:    totals.downtimeByReason(),
+				})
+			}
+
+			if onuCount == 0 {
+				continue
+			}
+
+			report.Pons = append(report.Pons, model.PonSla{
+				Board:               boardID,
+				PON:                 ponID,
+				OnuCount:            onuCount,
+				AvailabilityPercent: ponTotals.availability(),
+				Outages:             ponTotals.outages,
+				DowntimeSeconds:     durationSeconds(ponTotals.down),
+				MttrSeconds:         durationSeconds(ponTotals.mttr()),
+				DowntimeByReason:    ponTotals.downtimeByReason(),
+			})
+		}
+	}
+
+	if failed == len(boardIDs)*len(ponIDs) {
+		return model.SlaReport{}, fmt.Errorf("failed to get ONU of all selected PON")
+	}
+
+	return report, nil
+}
+
+// computeSlaTotals is a function to sum the up and down time of an ONU between from and to, transitions are
+// oldest first and may start with the last transition before from
+func computeSlaTotals(status string, transitions []model.OnuStatusTransition, from, to time.Time) slaTotals {
+
+	totals := slaTotals{byReason: make(map[string]time.Duration)}
+
+	// The status at from is the last transition before from, the opposite of the first transition within the
+	// window when older transitions are no longer kept, or the current status without any transition,
+	// an ONU first seen within the window is only counted from then
+	up, reason, outageStart, cursor := onuUpStatuses[status], status, from, from
+	if len(transitions) > 0 {
+		first := transitions[0]
+		at, _ := time.Parse(time.RFC3339, first.Timestamp)
+		switch {
+		case at.Before(from):
+			up, reason, outageStart = onuUpStatuses[first.Status], getTransitionReason(first), at
+			transitions = transitions[1:]
+		case first.FirstSeen:
+			if at.After(to) {
+				return totals
+			}
+			up, reason, outageStart, cursor = onuUpStatuses[first.Status], getTransitionReason(first), at, at
+			transitions = transitions[1:]
+		default:
+			up, reason = !onuUpStatuses[first.Status], "Unknown"
+		}
+	}
+	if !up {
+		totals.outages++ // An outage in progress at from counts for the window
+	}
+
+	for _, transition := range transitions {
+		at, err := time.Parse(time.RFC3339, transition.Timestamp)
+		if err != nil || at.Before(cursor) {
+			continue
+		}
+		if at.After(to) {
+			break
+		}
+
+		totals.addSegment(up, reason, at.Sub(cursor))
+
+		nextUp := onuUpStatuses[transition.Status]
+		switch {
+		case up && !nextUp:
+			totals.outages++
+			outageStart = at
+		case !up && nextUp:
+			totals.restored++
+			totals.restoredDuration += at.Sub(outageStart)
+		}
+
+		up, reason, cursor = nextUp, getTransitionReason(transition), at
+	}
+	totals.addSegment(up, reason, to.Sub(cursor))
+
+	return totals
+}
+
+// getTransitionReason is a function to get the offline reason of a down transition, or its status when unknown
+func getTransitionReason(transition model.OnuStatusTransition) string {
+	if transition.OfflineReason != "" {
+		return transition.OfflineReason
+	}
+	return transition.Status
+}
+
+// addSegment is a method to add a period of up or down time, down time is kept per offline reason
+func (t *slaTotals) addSegment(up bool, reason string, duration time.Duration) {
+	if up {
+		t.up += duration
+		return
+	}
+	t.down += duration
+	t.byReason[reason] += duration
+}
+
+// add is a method to add the totals of another ONU
+func (t *slaTotals) add(other slaTotals) {
+	t.up += other.up
+	t.down += other.down
+	t.outages += other.outages
+	t.restored += other.restored
+	t.restoredDuration += other.restoredDuration
+	for reason, duration := range other.byReason {
+		t.byReason[reason] += duration
+	}
+}
+
+// availability is a method to get the up time as a percentage of the window, rounded to 3 decimals
+func (t *slaTotals) availability() float64 {
+	total := t.up + t.down
+	if total <= 0 {
+		return 100
+	}
+	return math.Round(float64(t.up)/float64(total)*100*1000) / 1000
+}
+
+// mttr is a method to get the mean time to repair of the restored outages
+func (t *slaTotals) mttr() time.Duration {
+	if t.restored == 0 {
+		return 0
+	}
+	return t.restoredDuration / time.Duration(t.restored)
+}
+
+// downtimeByReason is a method to get the down time per offline reason in seconds
+func (t *slaTotals) downtimeByReason() map[string]int64 {
+	byReason := make(map[string]int64, len(t.byReason))
+	for reason, duration := range t.byReason {
+		byReason[reason] = durationSeconds(duration)
+	}
+	return byReason
+}
+
+// durationSeconds is a function to round a duration to whole seconds
+func durationSeconds(duration time.Duration) int64 {
+	return int64(duration.Round(time.Second) / time.Second)
+}
+
+// getSlaTransitionKey is a function to get the Redis key of the status transitions of an ONU
+func getSlaTransitionKey(boardID, ponID, onuID int) string {
+	return fmt.Sprintf("board_%d_pon_%d_onu_%d_status_log", boardID, ponID, onuID)
+}
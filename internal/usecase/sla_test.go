@@ -0,0 +1,166 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/internal/model"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/pkg/pubsub"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// fakeOnuStatusRepo is an in-memory implementation of OnuStatusRepositoryInterface
+type fakeOnuStatusRepo struct {
+	statuses map[string][]model.OnuStatusTransition
+}
+
+func newFakeOnuStatusRepo() *fakeOnuStatusRepo {
+	return &fakeOnuStatusRepo{statuses: make(map[string][]model.OnuStatusTransition)}
+}
+
+// AddOnuStatusTransition keeps all transitions in order, timestamps are compared by GetOnuStatusTransitions
+func (f *fakeOnuStatusRepo) AddOnuStatusTransition(
+	_ context.Context, key string, _ time.Time, _ time.Duration, transition model.OnuStatusTransition,
+) error {
+	f.statuses[key] = append(f.statuses[key], transition)
+	return nil
+}
+
+func (f *fakeOnuStatusRepo) GetOnuStatusTransitions(
+	_ context.Context, key string, since time.Time,
+) ([]model.OnuStatusTransition, error) {
+	transitions := make([]model.OnuStatusTransition, 0)
+	for _, transition := range f.statuses[key] {
+		timestamp, _ := time.Parse(time.RFC3339, transition.Timestamp)
+		if timestamp.Before(since) {
+			transitions = append(transitions[:0], transition) // Only the last transition before since is kept
+			continue
+		}
+		transitions = append(transitions, transition)
+	}
+	return transitions, nil
+}
+
+func TestComputeSlaTotals(t *testing.T) {
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+	at := func(offset time.Duration) string { return from.Add(offset).Format(time.RFC3339) }
+
+	// Online before the window, LOS for an hour and powered off for the last 4 hours
+	totals := computeSlaTotals("Dying Gasp", []model.OnuStatusTransition{
+		{Status: "Online", Timestamp: at(-time.Hour)},
+		{Status: "LOS", OfflineReason: "LOSi", Timestamp: at(time.Hour)},
+		{Status: "Online", Timestamp: at(2 * time.Hour)},
+		{Status: "Dying Gasp", OfflineReason: "PowerOff", Timestamp: at(20 * time.Hour)},
+	}, from, to)
+	assert.Equal(t, 19*time.Hour, totals.up)
+	assert.Equal(t, 5*time.Hour, totals.down)
+	assert.Equal(t, 2, totals.outages)
+	assert.Equal(t, time.Hour, totals.mttr())
+	assert.Equal(t, 79.167, totals.availability())
+	assert.Equal(t, map[string]int64{"LOSi": 3600, "PowerOff": 14400}, totals.downtimeByReason())
+
+	// Without transitions the current status holds for the whole window
+	totals = computeSlaTotals("LOS", nil, from, to)
+	assert.Equal(t, 0.0, totals.availability())
+	assert.Equal(t, 1, totals.outages)
+	assert.Equal(t, map[string]int64{"LOS": 86400}, totals.downtimeByReason())
+
+	// When older transitions are no longer kept the status before the first transition is its opposite
+	totals = computeSlaTotals("Online", []model.OnuStatusTransition{
+		{Status: "Online", Timestamp: at(6 * time.Hour)},
+	}, from, to)
+	assert.Equal(t, 75.0, totals.availability())
+	assert.Equal(t, 6*time.Hour, totals.mttr())
+	assert.Equal(t, map[string]int64{"Unknown": 21600}, totals.downtimeByReason())
+
+	// An ONU first seen within the window is only counted from then
+	totals = computeSlaTotals("Online", []model.OnuStatusTransition{
+		{Status: "Online", Timestamp: at(6 * time.Hour), FirstSeen: true},
+	}, from, to)
+	assert.Equal(t, 18*time.Hour, totals.up)
+	assert.Equal(t, 100.0, totals.availability())
+	assert.Equal(t, 0, totals.outages)
+	assert.Empty(t, totals.downtimeByReason())
+
+	totals = computeSlaTotals("Online", []model.OnuStatusTransition{
+		{Status: "LOS", OfflineReason: "LOSi", Timestamp: at(6 * time.Hour), FirstSeen: true},
+		{Status: "Online", Timestamp: at(12 * time.Hour)},
+	}, from, to)
+	assert.Equal(t, 66.667, totals.availability())
+	assert.Equal(t, 1, totals.outages)
+	assert.Equal(t, 6*time.Hour, totals.mttr())
+	assert.Equal(t, map[string]int64{"LOSi": 21600}, totals.downtimeByReason())
+
+	totals = computeSlaTotals("Online", []model.OnuStatusTransition{
+		{Status: "Online", Timestamp: at(25 * time.Hour), FirstSeen: true},
+	}, from, to)
+	assert.Equal(t, time.Duration(0), totals.up+totals.down)
+}
+
+func TestSlaRecordAndReport(t *testing.T) {
+	now := time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC)
+	source := &fakeOnuListSource{lists: map[[2]int][]model.ONUInfoPerBoard{
+		{1, 1}: {
+			{Board: 1, PON: 1, ID: 1, Name: "budi", SerialNumber: "ZTEGC0000001", Status: "Online"},
+			{Board: 1, PON: 1, ID: 2, Name: "siti", SerialNumber: "ZTEGC0000002", Status: "Online"},
+		},
+	}}
+	agent := newFakeSnmpAgent()
+	agent.values[testBaseOID1+".500.10.2.3.8.1.7.285278465.1"] = 9 // PowerOff
+	statusRepo := newFakeOnuStatusRepo()
+	cfg := newTestConfig()
+	cfg.StreamCfg.OltName = "olt-1"
+	cfg.OltCfg.OnuLastOfflineReasonAllPon = ".500.10.2.3.8.1.7"
+
+	u := NewSlaUsecase(source, agent, statusRepo, pubsub.NewBroker[model.OnuEvent](), cfg).(*slaUsecase)
+	u.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	event := func(status, source string, offset time.Duration) model.OnuEvent {
+		return model.OnuEvent{Type: OnuEventStatus, Board: 1, PON: 1, ID: 1, Status: status, Source: source,
+			Timestamp: now.Add(offset).Format(time.RFC3339)}
+	}
+
+	// The same change reported by a trap and then by polling is recorded once
+	u.record(ctx, event("Online", "trap", -30*time.Hour))
+	u.record(ctx, event("Dying Gasp", "trap", -12*time.Hour))
+	u.record(ctx, event("Dying Gasp", "poll", -12*time.Hour+time.Minute))
+	u.record(ctx, event("Online", "poll", -6*time.Hour))
+	u.record(ctx, model.OnuEvent{Type: OnuEventOptical, Board: 1, PON: 1, ID: 1, RxPower: "-20.00"})
+
+	transitions := statusRepo.statuses["board_1_pon_1_onu_1_status_log"]
+	assert.Len(t, transitions, 3)
+	assert.True(t, transitions[0].FirstSeen)
+	assert.False(t, transitions[1].FirstSeen)
+	assert.Equal(t, "PowerOff", transitions[1].OfflineReason)
+	assert.Empty(t, transitions[2].OfflineReason)
+
+	report, err := u.GetReport(ctx, "", SlaFilter{Board: 1, PON: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, "olt-1", report.Olt)
+	assert.Equal(t, "day", report.Window)
+	assert.Len(t, report.Onus, 2)
+	assert.Equal(t, 75.0, report.Onus[0].AvailabilityPercent)
+	assert.Equal(t, int64(21600), report.Onus[0].MttrSeconds)
+	assert.Equal(t, map[string]int64{"PowerOff": 21600}, report.Onus[0].DowntimeByReason)
+	assert.Equal(t, 100.0, report.Onus[1].AvailabilityPercent)
+
+	assert.Equal(t, []model.PonSla{{
+		Board: 1, PON: 1, OnuCount: 2, AvailabilityPercent: 87.5, Outages: 1, DowntimeSeconds: 21600,
+		MttrSeconds: 21600, DowntimeByReason: map[string]int64{"PowerOff": 21600},
+	}}, report.Pons)
+
+	// A single ONU, invalid windows and IDs, and a failing PON
+	report, err = u.GetReport(ctx, "week", SlaFilter{Board: 1, PON: 1, ID: 2})
+	assert.NoError(t, err)
+	assert.Len(t, report.Onus, 1)
+
+	_, err = u.GetReport(ctx, "year", SlaFilter{})
+	assert.True(t, errors.Is(err, ErrInvalidSlaRequest))
+	_, err = u.GetReport(ctx, "day", SlaFilter{PON: 9})
+	assert.True(t, errors.Is(err, ErrInvalidSlaRequest))
+	_, err = u.GetReport(ctx, "day", SlaFilter{Board: 2, PON: 1})
+	assert.Error(t, err)
+}
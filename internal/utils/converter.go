@@ -43,12 +43,24 @@ func ConvertDurationToString(duration time.Duration) string {
 	return strconv.Itoa(days) + " days " + strconv.Itoa(hours) + " hours " + strconv.Itoa(minutes) + " minutes " + strconv.Itoa(seconds) + " seconds"
 }
 
-// ConvertByteArrayToDateTime Convert byte array to human-readable date time
+// ConvertByteArrayToDateTime Convert byte array to human-readable date time, in the timezone of the DateAndTime
+// or UTC when it has none
 func ConvertByteArrayToDateTime(byteArray []byte) (string, error) {
 
-	// Check if byteArray length is exactly 8
-	if len(byteArray) != 8 {
-		return "", errors.New("invalid byte array length: expected 8 bytes")
+	datetime, err := ParseDateAndTime(byteArray, time.UTC)
+	if err != nil {
+		return "", err
+	}
+
+	return datetime.Format("2006-01-02 15:04:05"), nil
+}
+
+// ParseDateAndTime Convert an RFC 2579 DateAndTime to time, the 8 byte form has no timezone and is read in location
+func ParseDateAndTime(byteArray []byte, location *time.Location) (time.Time, error) {
+
+	// Check if byteArray length is 8, or 11 with the direction and offset from UTC
+	if len(byteArray) != 8 && len(byteArray) != 11 {
+		return time.Time{}, errors.New("invalid byte array length: expected 8 or 11 bytes")
 	}
 
 	// Extract the year from the first two bytes
@@ -60,27 +72,68 @@ func ConvertByteArrayToDateTime(byteArray []byte) (string, error) {
 	hour := int(byteArray[4])         // Hour
 	minute := int(byteArray[5])       // Minute
 	second := int(byteArray[6])       // Second
+	deciSecond := int(byteArray[7])   // Deci-seconds
 
 	// Validate extracted values
 	if month < 1 || month > 12 {
-		return "", fmt.Errorf("invalid month: %d", month)
+		return time.Time{}, fmt.Errorf("invalid month: %d", month)
 	}
 	if day < 1 || day > 31 {
-		return "", fmt.Errorf("invalid day: %d", day)
+		return time.Time{}, fmt.Errorf("invalid day: %d", day)
 	}
 	if hour < 0 || hour > 23 {
-		return "", fmt.Errorf("invalid hour: %d", hour)
+		return time.Time{}, fmt.Errorf("invalid hour: %d", hour)
 	}
 	if minute < 0 || minute > 59 {
-		return "", fmt.Errorf("invalid minute: %d", minute)
+		return time.Time{}, fmt.Errorf("invalid minute: %d", minute)
 	}
 	if second < 0 || second > 59 {
-		return "", fmt.Errorf("invalid second: %d", second)
+		return time.Time{}, fmt.Errorf("invalid second: %d", second)
+	}
+	if deciSecond > 9 {
+		return time.Time{}, fmt.Errorf("invalid deci-second: %d", deciSecond)
 	}
 
-	// Create a time.Time object UTC
-	datetime := time.Date(year, month, day, hour, minute, second, 0, time.UTC)
+	if location == nil {
+		location = time.UTC
+	}
 
-	// Convert to Unix epoch time (seconds since Jan 1, 1970)
-	return datetime.Format("2006-01-02 15:04:05"), nil
+	// The 11 byte form carries its own offset from UTC
+	if len(byteArray) == 11 {
+		direction := byteArray[8]        // '+' or '-'
+		offsetHours := int(byteArray[9]) // Hours from UTC
+		offsetMinutes := int(byteArray[10])
+
+		if direction != '+' && direction != '-' {
+			return time.Time{}, fmt.Errorf("invalid direction from UTC: %d", direction)
+		}
+		if offsetHours > 14 || offsetMinutes > 59 {
+			return time.Time{}, fmt.Errorf("invalid offset from UTC: %d:%d", offsetHours, offsetMinutes)
+		}
+
+		offset := offsetHours*3600 + offsetMinutes*60
+		if direction == '-' {
+			offset = -offset
+		}
+		location = time.FixedZone(fmt.Sprintf("UTC%c%02d:%02d", direction, offsetHours, offsetMinutes), offset)
+	}
+
+	return time.Date(year, month, day, hour, minute, second, deciSecond*int(time.Second/10), location), nil
+}
+
+// LoadTimezone Convert an IANA timezone name such as Asia/Jakarta, or an offset from UTC such as +07:00, to a location,
+// an empty name is UTC
+func LoadTimezone(name string) (*time.Location, error) {
+
+	if name == "" {
+		return time.UTC, nil
+	}
+
+	// Offset from UTC, e.g. +07:00 or -03:30
+	if offset, err := time.Parse("-07:00", name); err == nil {
+		_, seconds := offset.Zone()
+		return time.FixedZone("UTC"+name, seconds), nil
+	}
+
+	return time.LoadLocation(name)
 }
@@ -165,3 +165,66 @@ func TestConvertByteArrayToDateTime(t *testing.T) {
 		})
 	}
 }
+
+func TestParseDateAndTime(t *testing.T) {
+	jakarta := time.FixedZone("WIB", 7*3600)
+
+	// The 8 byte form is read in the given location
+	datetime, err := ParseDateAndTime([]byte{0x07, 0xe8, 0x05, 0x01, 0x0a, 0x1e, 0x00, 0x05}, jakarta)
+	assert.NoError(t, err)
+	assert.Equal(t, "2024-05-01T10:30:00.5+07:00", datetime.Format(time.RFC3339Nano))
+	assert.Equal(t, "2024-05-01T03:30:00.5Z", datetime.UTC().Format(time.RFC3339Nano))
+
+	// Without a location the 8 byte form is UTC
+	datetime, err = ParseDateAndTime([]byte{0x07, 0xe8, 0x05, 0x01, 0x0a, 0x1e, 0x00, 0x00}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "2024-05-01T10:30:00Z", datetime.Format(time.RFC3339))
+
+	// The 11 byte form carries its own offset, which wins over the location
+	datetime, err = ParseDateAndTime([]byte{0x07, 0xe8, 0x05, 0x01, 0x0a, 0x1e, 0x00, 0x00, '-', 0x03, 0x1e}, jakarta)
+	assert.NoError(t, err)
+	assert.Equal(t, "2024-05-01T10:30:00-03:30", datetime.Format(time.RFC3339))
+	assert.Equal(t, "2024-05-01T14:00:00Z", datetime.UTC().Format(time.RFC3339))
+
+	invalid := [][]byte{
+		{0x07, 0xe8, 0x05, 0x01, 0x0a, 0x1e, 0x00, 0x0a},                  // Deci-seconds
+		{0x07, 0xe8, 0x05, 0x01, 0x0a, 0x1e, 0x00, 0x00, 'x', 0x07, 0x00}, // Direction
+		{0x07, 0xe8, 0x05, 0x01, 0x0a, 0x1e, 0x00, 0x00, '+', 0x0f, 0x00}, // Offset hours
+		{0x07, 0xe8, 0x05, 0x01, 0x0a, 0x1e, 0x00, 0x00, '+', 0x07, 0x3c}, // Offset minutes
+		{0x07, 0xe8, 0x05, 0x01, 0x0a, 0x1e, 0x00, 0x00, '+', 0x07},       // Length
+		{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, '+', 0x00, 0x00}, // Never set
+	}
+	for _, byteArray := range invalid {
+		_, err := ParseDateAndTime(byteArray, nil)
+		assert.Error(t, err, byteArray)
+	}
+
+	// Human-readable date time keeps the wall clock of the offset
+	result, err := ConvertByteArrayToDateTime([]byte{0x07, 0xe8, 0x05, 0x01, 0x0a, 0x1e, 0x00, 0x00, '+', 0x07, 0x00})
+	assert.NoError(t, err)
+	assert.Equal(t, "2024-05-01 10:30:00", result)
+}
+
+func TestLoadTimezone(t *testing.T) {
+	location, err := LoadTimezone("")
+	assert.NoError(t, err)
+	assert.Equal(t, time.UTC, location)
+
+	location, err = LoadTimezone("+07:00")
+	assert.NoError(t, err)
+	_, offset := time.Date(2024, 5, 1, 0, 0, 0, 0, location).Zone()
+	assert.Equal(t, 7*3600, offset)
+
+	location, err = LoadTimezone("-03:30")
+	assert.NoError(t, err)
+	_, offset = time.Date(2024, 5, 1, 0, 0, 0, 0, location).Zone()
+	assert.Equal(t, -(3*3600 + 30*60), offset)
+
+	location, err = LoadTimezone("Asia/Jakarta")
+	assert.NoError(t, err)
+	_, offset = time.Date(2024, 5, 1, 0, 0, 0, 0, location).Zone()
+	assert.Equal(t, 7*3600, offset)
+
+	_, err = LoadTimezone("Mars/Olympus")
+	assert.Error(t, err)
+}
@@ -3,6 +3,8 @@ package utils
 import (
 	"encoding/json"
 	"net/http"
+	"strconv"
+	"time"
 )
 
 func SendJSONResponse(w http.ResponseWriter, statusCode int, response interface{}) {
@@ -40,3 +42,55 @@ func ErrorNotFound(w http.ResponseWriter, err error) {
 	}
 	SendJSONResponse(w, http.StatusNotFound, webResponse)
 }
+
+func ErrorConflict(w http.ResponseWriter, err error) {
+	webResponse := ErrorResponse{
+		Code:    http.StatusConflict,
+		Status:  "Conflict",
+		Message: err.Error(),
+	}
+	SendJSONResponse(w, http.StatusConflict, webResponse)
+}
+
+func ErrorServiceUnavailable(w http.ResponseWriter, err error) {
+	webResponse := ErrorResponse{
+		Code:    http.StatusServiceUnavailable,
+		Status:  "Service Unavailable",
+		Message: err.Error(),
+	}
+	SendJSONResponse(w, http.StatusServiceUnavailable, webResponse)
+}
+
+func ErrorUnauthorized(w http.ResponseWriter, err error) {
+	webResponse := ErrorResponse{
+		Code:    http.StatusUnauthorized,
+		Status:  "Unauthorized",
+		Message: err.Error(),
+	}
+	SendJSONResponse(w, http.StatusUnauthorized, webResponse)
+}
+
+func ErrorForbidden(w http.ResponseWriter, err error) {
+	webResponse := ErrorResponse{
+		Code:    http.StatusForbidden,
+		Status:  "Forbidden",
+		Message: err.Error(),
+	}
+	SendJSONResponse(w, http.StatusForbidden, webResponse)
+}
+
+// ErrorTooManyRequests sends error 429 with a Retry-After header in whole seconds, at least 1
+func ErrorTooManyRequests(w http.ResponseWriter, err error, retryAfter time.Duration) {
+	retryAfterSeconds := int64((retryAfter + time.Second - 1) / time.Second)
+	if retryAfterSeconds < 1 {
+		retryAfterSeconds = 1
+	}
+	w.Header().Set("Retry-After", strconv.FormatInt(retryAfterSeconds, 10))
+
+	webResponse := ErrorResponse{
+		Code:    http.StatusTooManyRequests,
+		Status:  "Too Many Requests",
+		Message: err.Error(),
+	}
+	SendJSONResponse(w, http.StatusTooManyRequests, webResponse)
+}
@@ -170,3 +170,193 @@ func ExtractGponOpticalDistance(oidValue interface{}) string {
 
 	return strconv.Itoa(intValue)
 }
+
+// ExtractUniPortAdminState function is used to extract the admin state of an ONU ethernet UNI port
+func ExtractUniPortAdminState(oidValue interface{}) string {
+	// Check if oidValue is not an integer
+	intValue, ok := oidValue.(int)
+	if !ok {
+		return "Unknown"
+	}
+
+	switch intValue {
+	case 1:
+		return "Unlock"
+	case 2:
+		return "Lock"
+	default:
+		return "Unknown"
+	}
+}
+
+// ExtractUniPortOperState function is used to extract the link state of an ONU ethernet UNI port
+func ExtractUniPortOperState(oidValue interface{}) string {
+	// Check if oidValue is not an integer
+	intValue, ok := oidValue.(int)
+	if !ok {
+		return "Unknown"
+	}
+
+	switch intValue {
+	case 1:
+		return "Up"
+	case 2:
+		return "Down"
+	default:
+		return "Unknown"
+	}
+}
+
+// ExtractUniPortSpeedDuplex function is used to extract the negotiated speed and duplex of an ONU ethernet UNI port
+func ExtractUniPortSpeedDuplex(oidValue interface{}) (string, string) {
+	// Check if oidValue is not an integer
+	intValue, ok := oidValue.(int)
+	if !ok {
+		return "Unknown", "Unknown"
+	}
+
+	switch intValue {
+	case 1:
+		return "10M", "Half"
+	case 2:
+		return "10M", "Full"
+	case 3:
+		return "100M", "Half"
+	case 4:
+		return "100M", "Full"
+	case 5:
+		return "1000M", "Half"
+	case 6:
+		return "1000M", "Full"
+	default:
+		return "Unknown", "Unknown"
+	}
+}
+
+// ExtractMacAddress function is used to extract a MAC address from OID value
+func ExtractMacAddress(oidValue interface{}) string {
+	var value []byte
+
+	switch v := oidValue.(type) {
+	case []byte:
+		value = v
+	case string:
+		value = []byte(v)
+	default:
+		return "" // Return empty string if the OID is invalid or empty
+	}
+
+	// MAC address is returned as 6 octets
+	if len(value) != 6 {
+		return ""
+	}
+
+	return fmt.Sprintf("%02x:%02x:%02x:%02x:%02x:%02x", value[0], value[1], value[2], value[3], value[4], value[5])
+}
+
+// ExtractTruthValue function is used to extract SNMP TruthValue (1 = true, 2 = false) from OID value
+func ExtractTruthValue(oidValue interface{}) bool {
+	// Check if oidValue is not an integer
+	intValue, ok := oidValue.(int)
+	if !ok {
+		return false
+	}
+
+	return intValue == 1
+}
+
+// ExtractOnuAlarmType function is used to extract the alarm type of an ONU alarm trap
+func ExtractOnuAlarmType(oidValue interface{}) string {
+	// Check if oidValue is not an integer
+	intValue, ok := oidValue.(int)
+	if !ok {
+		return "Unknown"
+	}
+
+	switch intValue {
+	case 1:
+		return "LOS"
+	case 2:
+		return "LOSi"
+	case 3:
+		return "LOFi"
+	case 4:
+		return "Dying Gasp"
+	case 5:
+		return "SFi"
+	case 6:
+		return "SDi"
+	case 7:
+		return "RDIi"
+	case 8:
+		return "LOAMi"
+	case 9:
+		return "Low RX Power"
+	case 10:
+		return "High RX Power"
+	case 11:
+		return "Auth Failed"
+	default:
+		return "Unknown"
+	}
+}
+
+// ExtractAlarmSeverity function is used to extract the severity of an ONU alarm trap
+func ExtractAlarmSeverity(oidValue interface{}) string {
+	// Check if oidValue is not an integer
+	intValue, ok := oidValue.(int)
+	if !ok {
+		return "Unknown"
+	}
+
+	switch intValue {
+	case 1:
+		return "Critical"
+	case 2:
+		return "Major"
+	case 3:
+		return "Minor"
+	case 4:
+		return "Warning"
+	case 5:
+		return "Info"
+	case 6:
+		return "Cleared"
+	default:
+		return "Unknown"
+	}
+}
+
+// ExtractOltAlarmType function is used to extract the alarm type and category of an OLT active alarm code
+func ExtractOltAlarmType(oidValue interface{}) (string, string) {
+	// Check if oidValue is not an integer
+	intValue, ok := oidValue.(int)
+	if !ok {
+		return "Unknown", "other"
+	}
+
+	switch intValue {
+	case 1:
+		return "PON LOS", "pon"
+	case 2:
+		return "ONU LOS", "onu"
+	case 3:
+		return "ONU Dying Gasp", "onu"
+	case 4:
+		return "Rogue ONU", "pon"
+	case 5:
+		return "ONU Low RX Power", "onu"
+	case 6:
+		return "Card Offline", "card"
+	case 7:
+		return "Card Fault", "card"
+	case 8:
+		return "Fan Fault", "fan"
+	case 9:
+		return "Power Fault", "power"
+	case 10:
+		return "High Temperature", "environment"
+	default:
+		return "Unknown", "other"
+	}
+}
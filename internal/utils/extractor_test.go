@@ -284,3 +284,173 @@ func TestExtractGponOpticalDistance(t *testing.T) {
 		})
 	}
 }
+
+func TestExtractUniPortAdminState(t *testing.T) {
+	testCases := []struct {
+		oidValue interface{}
+		expected string
+	}{
+		{1, "Unlock"},
+		{2, "Lock"},
+		{3, "Unknown"},
+		{"invalid", "Unknown"},
+		{nil, "Unknown"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("OIDValue: %v", tc.oidValue), func(t *testing.T) {
+			assert.Equal(t, tc.expected, ExtractUniPortAdminState(tc.oidValue))
+		})
+	}
+}
+
+func TestExtractUniPortOperState(t *testing.T) {
+	testCases := []struct {
+		oidValue interface{}
+		expected string
+	}{
+		{1, "Up"},
+		{2, "Down"},
+		{3, "Unknown"},
+		{"invalid", "Unknown"},
+		{nil, "Unknown"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("OIDValue: %v", tc.oidValue), func(t *testing.T) {
+			assert.Equal(t, tc.expected, ExtractUniPortOperState(tc.oidValue))
+		})
+	}
+}
+
+func TestExtractUniPortSpeedDuplex(t *testing.T) {
+	testCases := []struct {
+		oidValue       interface{}
+		expectedSpeed  string
+		expectedDuplex string
+	}{
+		{1, "10M", "Half"},
+		{2, "10M", "Full"},
+		{3, "100M", "Half"},
+		{4, "100M", "Full"},
+		{5, "1000M", "Half"},
+		{6, "1000M", "Full"},
+		{7, "Unknown", "Unknown"},
+		{"invalid", "Unknown", "Unknown"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("OIDValue: %v", tc.oidValue), func(t *testing.T) {
+			speed, duplex := ExtractUniPortSpeedDuplex(tc.oidValue)
+			assert.Equal(t, tc.expectedSpeed, speed)
+			assert.Equal(t, tc.expectedDuplex, duplex)
+		})
+	}
+}
+
+func TestExtractMacAddress(t *testing.T) {
+	testCases := []struct {
+		name     string
+		oidValue interface{}
+		expected string
+	}{
+		{"Byte slice", []byte{0x00, 0x1a, 0x2b, 0x3c, 0x4d, 0x5e}, "00:1a:2b:3c:4d:5e"},
+		{"String", string([]byte{0xf4, 0x6d, 0x04, 0x01, 0x02, 0x03}), "f4:6d:04:01:02:03"},
+		{"Invalid length", []byte{0x00, 0x1a, 0x2b}, ""},
+		{"Invalid type", 10, ""},
+		{"Nil value", nil, ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, ExtractMacAddress(tc.oidValue))
+		})
+	}
+}
+
+func TestExtractTruthValue(t *testing.T) {
+	testCases := []struct {
+		oidValue interface{}
+		expected bool
+	}{
+		{1, true},
+		{2, false},
+		{0, false},
+		{"invalid", false},
+		{nil, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("OIDValue: %v", tc.oidValue), func(t *testing.T) {
+			assert.Equal(t, tc.expected, ExtractTruthValue(tc.oidValue))
+		})
+	}
+}
+
+func TestExtractOnuAlarmType(t *testing.T) {
+	testCases := []struct {
+		oidValue interface{}
+		expected string
+	}{
+		{1, "LOS"},
+		{4, "Dying Gasp"},
+		{9, "Low RX Power"},
+		{11, "Auth Failed"},
+		{12, "Unknown"},
+		{"invalid", "Unknown"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("OIDValue: %v", tc.oidValue), func(t *testing.T) {
+			assert.Equal(t, tc.expected, ExtractOnuAlarmType(tc.oidValue))
+		})
+	}
+}
+
+func TestExtractAlarmSeverity(t *testing.T) {
+	testCases := []struct {
+		oidValue interface{}
+		expected string
+	}{
+		{1, "Critical"},
+		{2, "Major"},
+		{3, "Minor"},
+		{4, "Warning"},
+		{5, "Info"},
+		{6, "Cleared"},
+		{0, "Unknown"},
+		{nil, "Unknown"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("OIDValue: %v", tc.oidValue), func(t *testing.T) {
+			assert.Equal(t, tc.expected, ExtractAlarmSeverity(tc.oidValue))
+		})
+	}
+}
+
+func TestExtractOltAlarmType(t *testing.T) {
+	testCases := []struct {
+		oidValue         interface{}
+		expectedType     string
+		expectedCategory string
+	}{
+		{1, "PON LOS", "pon"},
+		{3, "ONU Dying Gasp", "onu"},
+		{4, "Rogue ONU", "pon"},
+		{7, "Card Fault", "card"},
+		{8, "Fan Fault", "fan"},
+		{9, "Power Fault", "power"},
+		{10, "High Temperature", "environment"},
+		{99, "Unknown", "other"},
+		{"invalid", "Unknown", "other"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("OIDValue: %v", tc.oidValue), func(t *testing.T) {
+			alarmType, category := ExtractOltAlarmType(tc.oidValue)
+			assert.Equal(t, tc.expectedType, alarmType)
+			assert.Equal(t, tc.expectedCategory, category)
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"strconv"
+	"strings"
+)
+
+// GetPonPortIndex returns the PON port index used by the remote ONU management tables under base_oid_2
+// example: board 1 pon 1 = 268501248, board 2 pon 8 = 268568576
+func GetPonPortIndex(boardID, ponID int) int {
+	return 0x10000000 | boardID<<16 | ponID<<8
+}
+
+// GetGponOltIfIndex returns the gpon-olt interface ifIndex used by the ONU tables under base_oid_1
+// example: board 1 pon 1 = 285278465, board 2 pon 8 = 285278728
+func GetGponOltIfIndex(boardID, ponID int) int {
+	return 0x11010000 | boardID<<8 | ponID
+}
+
+// DecodeIfIndex returns the board and PON of a gpon-olt ifIndex or a PON port index, it is the inverse of
+// GetGponOltIfIndex and GetPonPortIndex
+// example: 285278465 = board 1 pon 1, 268568576 = board 2 pon 8
+func DecodeIfIndex(ifIndex int) (boardID, ponID int, ok bool) {
+	switch {
+	case ifIndex&^0xFFFF == 0x11010000:
+		boardID, ponID = ifIndex>>8&0xFF, ifIndex&0xFF
+	case ifIndex&^0xFFFF00 == 0x10000000:
+		boardID, ponID = ifIndex>>16&0xFF, ifIndex>>8&0xFF
+	default:
+		return 0, 0, false
+	}
+
+	// Board and PON are numbered from 1
+	if boardID == 0 || ponID == 0 {
+		return 0, 0, false
+	}
+
+	return boardID, ponID, true
+}
+
+// ExtractOIDIndex returns the numeric components of oid that follow baseOID
+// example: ExtractOIDIndex(".1.2.3.10.20", ".1.2.3") = [10 20]
+func ExtractOIDIndex(oid, baseOID string) []int {
+	// Make sure the OID is below the base OID
+	if !strings.HasPrefix(oid, baseOID+".") {
+		return nil
+	}
+
+	parts := strings.Split(strings.TrimPrefix(oid, baseOID+"."), ".")
+	index := make([]int, 0, len(parts))
+	for _, part := range parts {
+		value, err := strconv.Atoi(part)
+		if err != nil {
+			return nil // Return nil if the index contains a non-numeric component
+		}
+		index = append(index, value)
+	}
+
+	return index
+}
@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetPonPortIndex(t *testing.T) {
+	testCases := []struct {
+		boardID  int
+		ponID    int
+		expected int
+	}{
+		{1, 1, 268501248},
+		{1, 8, 268503040},
+		{2, 1, 268566784},
+		{2, 8, 268568576},
+	}
+
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("Board %d PON %d", tc.boardID, tc.ponID), func(t *testing.T) {
+			assert.Equal(t, tc.expected, GetPonPortIndex(tc.boardID, tc.ponID))
+		})
+	}
+}
+
+func TestGetGponOltIfIndex(t *testing.T) {
+	testCases := []struct {
+		boardID  int
+		ponID    int
+		expected int
+	}{
+		{1, 1, 285278465},
+		{1, 8, 285278472},
+		{2, 1, 285278721},
+		{2, 8, 285278728},
+	}
+
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("Board %d PON %d", tc.boardID, tc.ponID), func(t *testing.T) {
+			assert.Equal(t, tc.expected, GetGponOltIfIndex(tc.boardID, tc.ponID))
+		})
+	}
+}
+
+func TestDecodeIfIndex(t *testing.T) {
+	testCases := []struct {
+		name    string
+		ifIndex int
+		boardID int
+		ponID   int
+		ok      bool
+	}{
+		{"gpon-olt board 1 pon 1", 285278465, 1, 1, true},
+		{"gpon-olt board 2 pon 8", 285278728, 2, 8, true},
+		{"PON port board 1 pon 1", 268501248, 1, 1, true},
+		{"PON port board 2 pon 8", 268568576, 2, 8, true},
+		{"PON port with ONU byte", 268501249, 0, 0, false},
+		{"Zero board", 285278209, 0, 0, false},
+		{"Unknown encoding", 1, 0, 0, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			boardID, ponID, ok := DecodeIfIndex(tc.ifIndex)
+			assert.Equal(t, tc.ok, ok)
+			assert.Equal(t, tc.boardID, boardID)
+			assert.Equal(t, tc.ponID, ponID)
+		})
+	}
+}
+
+func TestExtractOIDIndex(t *testing.T) {
+	testCases := []struct {
+		name     string
+		oid      string
+		baseOID  string
+		expected []int
+	}{
+		{"Single component", ".1.2.3.4", ".1.2.3", []int{4}},
+		{"Multiple components", ".1.2.3.268501248.5.1", ".1.2.3", []int{268501248, 5, 1}},
+		{"Different base", ".1.2.4.4", ".1.2.3", nil},
+		{"Prefix is not a full component", ".1.2.34.4", ".1.2.3", nil},
+		{"Same as base", ".1.2.3", ".1.2.3", nil},
+		{"Non numeric component", ".1.2.3.a", ".1.2.3", nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, ExtractOIDIndex(tc.oid, tc.baseOID))
+		})
+	}
+}
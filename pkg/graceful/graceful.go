@@ -16,7 +16,12 @@ func Shutdown(ctx context.Context, server *http.Server) error {
 	ch := make(chan error, 1)
 
 	go func() {
-		err := server.ListenAndServe()
+		var err error
+		if server.TLSConfig != nil {
+			err = server.ListenAndServeTLS("", "") // Certificates come from TLSConfig
+		} else {
+			err = server.ListenAndServe()
+		}
 		if err != nil && !errors.Is(http.ErrServerClosed, err) {
 			ch <- fmt.Errorf("failed to start server: %v", err)
 		}
@@ -0,0 +1,247 @@
+package jwt
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	_ "crypto/sha256" // Registers SHA-256 for crypto.Hash
+	_ "crypto/sha512" // Registers SHA-384 and SHA-512 for crypto.Hash
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+var (
+	ErrMalformed            = errors.New("malformed token")
+	ErrUnsupportedAlgorithm = errors.New("unsupported signing algorithm")
+	ErrKeyNotFound          = errors.New("signing key not found")
+	ErrInvalidSignature     = errors.New("invalid signature")
+	ErrExpired              = errors.New("token is expired")
+	ErrMissingExpiration    = errors.New("token has no exp claim")
+	ErrNotValidYet          = errors.New("token is not valid yet")
+	ErrInvalidIssuer        = errors.New("invalid issuer")
+	ErrInvalidAudience      = errors.New("invalid audience")
+)
+
+// algorithms are the supported signing algorithms by hash, "none" is never accepted
+var algorithms = map[string]crypto.Hash{
+	"HS256": crypto.SHA256, "HS384": crypto.SHA384, "HS512": crypto.SHA512,
+	"RS256": crypto.SHA256, "RS384": crypto.SHA384, "RS512": crypto.SHA512,
+	"PS256": crypto.SHA256, "PS384": crypto.SHA384, "PS512": crypto.SHA512,
+	"ES256": crypto.SHA256, "ES384": crypto.SHA384, "ES512": crypto.SHA512,
+}
+
+// ecdsaCurveBits are the curve sizes of the ECDSA algorithms, ES512 uses P-521
+var ecdsaCurveBits = map[string]int{"ES256": 256, "ES384": 384, "ES512": 521}
+
+// KeySet returns the key verifying a token signed with kid, an HMAC secret as []byte or a public key
+type KeySet interface {
+	Key(ctx context.Context, kid string) (interface{}, error)
+}
+
+// Claims are the claims of a verified token
+type Claims map[string]interface{}
+
+// String returns a string claim, empty if the claim is missing or not a string
+func (c Claims) String(name string) string {
+	value, _ := c[name].(string)
+	return value
+}
+
+// Strings returns the values of a string or string array claim, a path with dots selects a nested claim,
+// e.g. realm_access.roles, and a string is split on spaces like the OAuth scope claim
+func (c Claims) Strings(path string) []string {
+	var value interface{} = map[string]interface{}(c)
+	for _, name := range strings.Split(path, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = object[name]
+	}
+
+	switch value := value.(type) {
+	case string:
+		return strings.Fields(value)
+	case []interface{}:
+		values := make([]string, 0, len(value))
+		for _, item := range value {
+			if item, ok := item.(string); ok {
+				values = append(values, item)
+			}
+		}
+		return values
+	default:
+		return nil
+	}
+}
+
+type header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// Verifier verifies the signature and registered claims of compact JWS tokens
+type Verifier struct {
+	keySet    KeySet
+	issuer    string        // Required iss claim, any when empty
+	audience  string        // Required aud claim value, any when empty
+	clockSkew time.Duration // Tolerance of exp, nbf and iat for clocks of the issuer and this server
+	now       func() time.Time
+}
+
+// NewVerifier returns a verifier of tokens signed with the keys of keySet
+func NewVerifier(keySet KeySet, issuer, audience string, clockSkew time.Duration) *Verifier {
+	return &Verifier{
+		keySet:    keySet,
+		issuer:    issuer,
+		audience:  audience,
+		clockSkew: clockSkew,
+		now:       time.Now,
+	}
+}
+
+// Verify returns the claims of a token after checking its signature, exp, nbf, iat, iss and aud
+func (v *Verifier) Verify(ctx context.Context, token string) (Claims, error) {
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: expected 3 parts", ErrMalformed)
+	}
+
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil {
+		return nil, fmt.Errorf("%w: header: %v", ErrMalformed, err)
+	}
+	if _, ok := algorithms[h.Alg]; !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedAlgorithm, h.Alg)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: signature: %v", ErrMalformed, err)
+	}
+
+	key, err := v.keySet.Key(ctx, h.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifySignature(h.Alg, key, []byte(parts[0]+"."+parts[1]), signature); err != nil {
+		return nil, err
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("%w: claims: %v", ErrMalformed, err)
+	}
+
+	return claims, v.validateClaims(claims)
+}
+
+// validateClaims is a method to check the time, issuer and audience claims of a token, a token must expire
+func (v *Verifier) validateClaims(claims Claims) error {
+
+	now := v.now()
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return ErrMissingExpiration
+	}
+	if !now.Before(unixTime(exp).Add(v.clockSkew)) {
+		return ErrExpired
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(v.clockSkew).Before(unixTime(nbf)) {
+		return ErrNotValidYet
+	}
+	if iat, ok := claims["iat"].(float64); ok && now.Add(v.clockSkew).Before(unixTime(iat)) {
+		return fmt.Errorf("%w: issued in the future", ErrNotValidYet)
+	}
+
+	if v.issuer != "" && claims.String("iss") != v.issuer {
+		return fmt.Errorf("%w: %q", ErrInvalidIssuer, claims.String("iss"))
+	}
+
+	if v.audience != "" {
+		for _, audience := range claims.Strings("aud") {
+			if audience == v.audience {
+				return nil
+			}
+		}
+		return ErrInvalidAudience
+	}
+
+	return nil
+}
+
+// verifySignature is a function to verify the signature of a token with a key matching its algorithm,
+// so a token can't pick HMAC to be verified with a public key as secret
+func verifySignature(alg string, key interface{}, signed, signature []byte) error {
+
+	hash := algorithms[alg]
+	hasher := hash.New()
+	hasher.Write(signed)
+	digest := hasher.Sum(nil)
+
+	switch alg[:2] {
+	case "HS":
+		secret, ok := key.([]byte)
+		if !ok {
+			return fmt.Errorf("%w: %s needs an HMAC secret", ErrKeyNotFound, alg)
+		}
+		mac := hmac.New(hash.New, secret)
+		mac.Write(signed)
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return ErrInvalidSignature
+		}
+	case "RS", "PS":
+		publicKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("%w: %s needs an RSA key", ErrKeyNotFound, alg)
+		}
+		var err error
+		if alg[:2] == "RS" {
+			err = rsa.VerifyPKCS1v15(publicKey, hash, digest, signature)
+		} else {
+			err = rsa.VerifyPSS(publicKey, hash, digest, signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		}
+		if err != nil {
+			return ErrInvalidSignature
+		}
+	case "ES":
+		publicKey, ok := key.(*ecdsa.PublicKey)
+		if !ok || publicKey.Curve.Params().BitSize != ecdsaCurveBits[alg] {
+			return fmt.Errorf("%w: %s needs an ECDSA key of curve P-%d", ErrKeyNotFound, alg, ecdsaCurveBits[alg])
+		}
+		// The signature is r and s as fixed size big-endian integers
+		size := (ecdsaCurveBits[alg] + 7) / 8
+		if len(signature) != 2*size {
+			return ErrInvalidSignature
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(publicKey, digest, r, s) {
+			return ErrInvalidSignature
+		}
+	}
+
+	return nil
+}
+
+// decodeSegment is a function to decode a base64url encoded JSON segment of a token
+func decodeSegment(segment string, value interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, value)
+}
+
+// unixTime is a function to convert a NumericDate claim to time
+func unixTime(seconds float64) time.Time {
+	return time.Unix(0, int64(seconds*float64(time.Second)))
+}
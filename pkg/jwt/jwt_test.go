@@ -0,0 +1,156 @@
+package jwt
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+var testNow = time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+// signToken returns a compact JWS of claims signed with key, an HMAC secret as []byte or a private key
+func signToken(t *testing.T, alg, kid string, key interface{}, claims map[string]interface{}) string {
+	header, err := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	require.NoError(t, err)
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	hash := algorithms[alg]
+	hasher := hash.New()
+	hasher.Write([]byte(signed))
+	digest := hasher.Sum(nil)
+
+	var signature []byte
+	switch key := key.(type) {
+	case []byte:
+		mac := hmac.New(hash.New, key)
+		mac.Write([]byte(signed))
+		signature = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		if alg[:2] == "PS" {
+			signature, err = rsa.SignPSS(rand.Reader, key, hash, digest,
+				&rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		} else {
+			signature, err = rsa.SignPKCS1v15(rand.Reader, key, hash, digest)
+		}
+		require.NoError(t, err)
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, key, digest)
+		require.NoError(t, err)
+		size := (key.Curve.Params().BitSize + 7) / 8
+		signature = make([]byte, 2*size)
+		r.FillBytes(signature[:size])
+		s.FillBytes(signature[size:])
+	}
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func newTestVerifier(keys map[string]interface{}) *Verifier {
+	verifier := NewVerifier(NewStaticKeySet(keys), "https://sso.example.com", "olt-api", time.Minute)
+	verifier.now = func() time.Time { return testNow }
+	return verifier
+}
+
+func TestVerifyAlgorithms(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	secret := []byte("0123456789abcdef0123456789abcdef")
+
+	verifier := newTestVerifier(map[string]interface{}{"rsa": &rsaKey.PublicKey, "ec": &ecKey.PublicKey, "hs": secret})
+	claims := map[string]interface{}{
+		"sub": "alice", "iss": "https://sso.example.com", "aud": []string{"portal", "olt-api"},
+		"exp": testNow.Add(time.Hour).Unix(), "realm_access": map[string]interface{}{"roles": []string{"operator"}},
+	}
+
+	tests := []struct {
+		alg string
+		kid string
+		key interface{}
+	}{
+		{"RS256", "rsa", rsaKey},
+		{"PS384", "rsa", rsaKey},
+		{"ES256", "ec", ecKey},
+		{"HS256", "hs", secret},
+	}
+	for _, test := range tests {
+		verified, err := verifier.Verify(context.Background(), signToken(t, test.alg, test.kid, test.key, claims))
+		assert.NoError(t, err, test.alg)
+		assert.Equal(t, "alice", verified.String("sub"), test.alg)
+		assert.Equal(t, []string{"operator"}, verified.Strings("realm_access.roles"), test.alg)
+	}
+
+	// A token can't pick HMAC to be verified with the RSA key
+	_, err = verifier.Verify(context.Background(), signToken(t, "HS256", "rsa", []byte("public key"), claims))
+	assert.True(t, errors.Is(err, ErrKeyNotFound), err)
+
+	// A token signed by another key is rejected
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, err = verifier.Verify(context.Background(), signToken(t, "ES256", "ec", otherKey, claims))
+	assert.True(t, errors.Is(err, ErrInvalidSignature), err)
+
+	// ES384 needs a P-384 key
+	_, err = verifier.Verify(context.Background(), signToken(t, "ES384", "ec", ecKey, claims))
+	assert.True(t, errors.Is(err, ErrKeyNotFound), err)
+
+	_, err = verifier.Verify(context.Background(), "eyJhbGciOiJub25lIn0.e30.")
+	assert.True(t, errors.Is(err, ErrUnsupportedAlgorithm), err)
+
+	_, err = verifier.Verify(context.Background(), "not-a-token")
+	assert.True(t, errors.Is(err, ErrMalformed), err)
+}
+
+func TestVerifyClaims(t *testing.T) {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	verifier := newTestVerifier(map[string]interface{}{"hs": secret})
+
+	tests := []struct {
+		claims map[string]interface{}
+		err    error
+	}{
+		{map[string]interface{}{"iss": "https://sso.example.com", "aud": "olt-api", "exp": testNow.Add(time.Hour).Unix()}, nil},
+		{map[string]interface{}{"iss": "https://sso.example.com", "aud": "olt-api"}, ErrMissingExpiration},
+		{map[string]interface{}{"iss": "https://sso.example.com", "aud": "olt-api",
+			"exp": testNow.Add(-30 * time.Second).Unix()}, nil}, // Within the clock skew
+		{map[string]interface{}{"iss": "https://sso.example.com", "aud": "olt-api",
+			"exp": testNow.Add(-time.Minute).Unix()}, ErrExpired},
+		{map[string]interface{}{"iss": "https://sso.example.com", "aud": "olt-api",
+			"nbf": testNow.Add(30 * time.Second).Unix(), "exp": testNow.Add(time.Hour).Unix()}, nil},
+		{map[string]interface{}{"iss": "https://sso.example.com", "aud": "olt-api",
+			"nbf": testNow.Add(2 * time.Minute).Unix(), "exp": testNow.Add(time.Hour).Unix()}, ErrNotValidYet},
+		{map[string]interface{}{"iss": "https://sso.example.com", "aud": "olt-api",
+			"iat": testNow.Add(2 * time.Minute).Unix(), "exp": testNow.Add(time.Hour).Unix()}, ErrNotValidYet},
+		{map[string]interface{}{"iss": "https://other.example.com", "aud": "olt-api", "exp": testNow.Add(time.Hour).Unix()}, ErrInvalidIssuer},
+		{map[string]interface{}{"iss": "https://sso.example.com", "aud": "portal", "exp": testNow.Add(time.Hour).Unix()}, ErrInvalidAudience},
+	}
+	for _, test := range tests {
+		_, err := verifier.Verify(context.Background(), signToken(t, "HS256", "", secret, test.claims))
+		if test.err == nil {
+			assert.NoError(t, err, "%v", test.claims)
+		} else {
+			assert.True(t, errors.Is(err, test.err), "%v: %v", test.claims, err)
+		}
+	}
+}
+
+func TestClaimsStrings(t *testing.T) {
+	claims := Claims{"scope": "openid profile", "groups": []interface{}{"noc", 7}, "sub": "alice"}
+	assert.Equal(t, []string{"openid", "profile"}, claims.Strings("scope"))
+	assert.Equal(t, []string{"noc"}, claims.Strings("groups"))
+	assert.Nil(t, claims.Strings("sub.roles"))
+	assert.Nil(t, claims.Strings("roles"))
+}
@@ -0,0 +1,268 @@
+package jwt
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var ErrKeySetUnavailable = errors.New("JWKS is unavailable")
+
+// maxJwksSize limits the JWKS document read from the JWKS URL
+const maxJwksSize = 1 << 20
+
+// StaticKeySet holds keys of config by kid, a token without kid is verified with the only key
+type StaticKeySet struct {
+	keys map[string]interface{}
+}
+
+// NewStaticKeySet returns a key set of HMAC secrets as []byte and public keys by kid
+func NewStaticKeySet(keys map[string]interface{}) *StaticKeySet {
+	return &StaticKeySet{keys: keys}
+}
+
+func (s *StaticKeySet) Key(_ context.Context, kid string) (interface{}, error) {
+	return selectKey(s.keys, kid)
+}
+
+// RemoteKeySet fetches keys from a JWKS URL and caches them, keys are fetched again when they are older than
+// the refresh interval or a token has an unknown kid, so rotated keys are picked up without a restart
+type RemoteKeySet struct {
+	url              string
+	client           *http.Client
+	refreshInterval  time.Duration
+	minFetchInterval time.Duration // Fetches happen at most this often, tokens with random kid can't flood the JWKS URL
+
+	mu          sync.Mutex
+	keys        map[string]interface{}
+	fetchedAt   time.Time
+	attemptedAt time.Time
+	fetchErr    error
+	fetching    chan struct{} // Closed when the fetch in progress is done, nil without fetch
+	now         func() time.Time
+}
+
+// NewRemoteKeySet returns a key set of the JWKS at url, keys are fetched on the first token
+func NewRemoteKeySet(url string, refreshInterval time.Duration) *RemoteKeySet {
+	return &RemoteKeySet{
+		url:              url,
+		client:           &http.Client{Timeout: 10 * time.Second},
+		refreshInterval:  refreshInterval,
+		minFetchInterval: 30 * time.Second,
+		now:              time.Now,
+	}
+}
+
+// Key returns the key of kid, cached keys are kept while the JWKS URL is unreachable, concurrent requests share
+// one fetch that isn't canceled with the request
+func (r *RemoteKeySet) Key(ctx context.Context, kid string) (interface{}, error) {
+
+	r.mu.Lock()
+	now := r.now()
+	_, err := selectKey(r.keys, kid)
+	stale := r.keys == nil || now.Sub(r.fetchedAt) >= r.refreshInterval
+	if (stale || err != nil) && r.fetching == nil &&
+		(r.attemptedAt.IsZero() || now.Sub(r.attemptedAt) >= r.minFetchInterval) {
+		r.attemptedAt = now
+		r.fetching = make(chan struct{})
+		go r.refresh(r.fetching)
+	}
+	fetching := r.fetching
+	r.mu.Unlock()
+
+	// Wait for the fetch in progress without holding the lock, so requests with cached keys aren't blocked
+	if fetching != nil && (stale || err != nil) {
+		select {
+		case <-fetching:
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%w: %v", ErrKeySetUnavailable, ctx.Err())
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.keys == nil {
+		return nil, fmt.Errorf("%w: %v", ErrKeySetUnavailable, r.fetchErr)
+	}
+
+	return selectKey(r.keys, kid)
+}
+
+// refresh is a method to fetch the keys of the JWKS URL and close done, the fetch is bounded by the client timeout
+func (r *RemoteKeySet) refresh(done chan struct{}) {
+
+	keys, err := r.fetch(context.Background())
+
+	r.mu.Lock()
+	if err != nil {
+		r.fetchErr = err
+	} else {
+		r.keys, r.fetchedAt, r.fetchErr = keys, r.now(), nil
+	}
+	r.fetching = nil
+	r.mu.Unlock()
+
+	close(done)
+}
+
+// jwk is a JSON Web Key, only public RSA and EC signing keys are used
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// fetch is a method to get the keys of the JWKS URL by kid
+func (r *RemoteKeySet) fetch(ctx context.Context) (map[string]interface{}, error) {
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, r.url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := r.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", response.Status)
+	}
+
+	var jwks struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.NewDecoder(io.LimitReader(response.Body, maxJwksSize)).Decode(&jwks); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]interface{}, len(jwks.Keys))
+	for _, key := range jwks.Keys {
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+		publicKey, err := parseJWK(key)
+		if err != nil {
+			continue // Keys of other types don't verify tokens of this app
+		}
+		keys[key.Kid] = publicKey
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no RSA or EC signing keys")
+	}
+
+	return keys, nil
+}
+
+// parseJWK is a function to get the public key of an RSA or EC JSON Web Key
+func parseJWK(key jwk) (interface{}, error) {
+	switch key.Kty {
+	case "RSA":
+		n, err := decodeBigInt(key.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(key.E)
+		if err != nil || !e.IsInt64() {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		curves := map[string]elliptic.Curve{"P-256": elliptic.P256(), "P-384": elliptic.P384(), "P-521": elliptic.P521()}
+		curve, ok := curves[key.Crv]
+		if !ok {
+			return nil, fmt.Errorf("unsupported curve %q", key.Crv)
+		}
+		x, err := decodeBigInt(key.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(key.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("EC point is not on the curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", key.Kty)
+	}
+}
+
+// ParsePublicKeyPEM is a function to parse a PEM encoded RSA or ECDSA public key or certificate
+func ParsePublicKeyPEM(data []byte) (interface{}, error) {
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	var publicKey interface{}
+	var err error
+	switch block.Type {
+	case "PUBLIC KEY":
+		publicKey, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		publicKey, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	case "CERTIFICATE":
+		var certificate *x509.Certificate
+		certificate, err = x509.ParseCertificate(block.Bytes)
+		if err == nil {
+			publicKey = certificate.PublicKey
+		}
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch publicKey.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey:
+		return publicKey, nil
+	default:
+		return nil, errors.New("public key must be RSA or ECDSA")
+	}
+}
+
+// selectKey is a function to get the key of kid, a token without kid uses the only key
+func selectKey(keys map[string]interface{}, kid string) (interface{}, error) {
+	if key, ok := keys[kid]; ok {
+		return key, nil
+	}
+	if kid == "" && len(keys) == 1 {
+		for _, key := range keys {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: kid %q", ErrKeyNotFound, kid)
+}
+
+// decodeBigInt is a function to decode a base64url encoded big-endian integer of a JSON Web Key
+func decodeBigInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(data) == 0 {
+		return nil, errors.New("invalid key parameter")
+	}
+	return new(big.Int).SetBytes(data), nil
+}
@@ -0,0 +1,186 @@
+package jwt
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeJwksServer serves the public keys of a JWKS document that can be rotated and counts fetches
+type fakeJwksServer struct {
+	mu      sync.Mutex
+	keys    []map[string]string
+	fetches int
+	down    bool
+	release chan struct{} // Responses wait until it is closed when not nil
+}
+
+func (f *fakeJwksServer) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	if f.release != nil {
+		<-f.release
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.fetches++
+	if f.down {
+		w.WriteHeader(http.StatusBadGateway)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"keys": f.keys})
+}
+
+func (f *fakeJwksServer) setKeys(keys ...map[string]string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.keys = keys
+}
+
+func rsaJWK(kid string, key *rsa.PublicKey) map[string]string {
+	return map[string]string{
+		"kty": "RSA", "kid": kid, "use": "sig", "alg": "RS256",
+		"n": base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+func ecJWK(kid string, key *ecdsa.PublicKey) map[string]string {
+	size := (key.Curve.Params().BitSize + 7) / 8
+	x, y := make([]byte, size), make([]byte, size)
+	key.X.FillBytes(x)
+	key.Y.FillBytes(y)
+	return map[string]string{
+		"kty": "EC", "kid": kid, "crv": key.Curve.Params().Name,
+		"x": base64.RawURLEncoding.EncodeToString(x), "y": base64.RawURLEncoding.EncodeToString(y),
+	}
+}
+
+func TestRemoteKeySetRotation(t *testing.T) {
+	oldKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	newKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	jwks := &fakeJwksServer{}
+	jwks.setKeys(rsaJWK("2024-04", &oldKey.PublicKey), map[string]string{"kty": "RSA", "kid": "enc", "use": "enc"})
+	server := httptest.NewServer(jwks)
+	defer server.Close()
+
+	now := testNow
+	keySet := NewRemoteKeySet(server.URL, 15*time.Minute)
+	keySet.now = func() time.Time { return now }
+	verifier := NewVerifier(keySet, "", "", 0)
+	verifier.now = keySet.now
+	claims := map[string]interface{}{"sub": "alice", "exp": testNow.Add(24 * time.Hour).Unix()}
+	ctx := context.Background()
+
+	// Keys are fetched once and cached
+	for i := 0; i < 3; i++ {
+		_, err = verifier.Verify(ctx, signToken(t, "RS256", "2024-04", oldKey, claims))
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 1, jwks.fetches)
+
+	// A rotated key is fetched on its first token, unknown kid fetch at most every 30 seconds
+	jwks.setKeys(rsaJWK("2024-04", &oldKey.PublicKey), ecJWK("2024-05", &newKey.PublicKey))
+	now = now.Add(time.Minute)
+	_, err = verifier.Verify(ctx, signToken(t, "ES256", "2024-05", newKey, claims))
+	assert.NoError(t, err)
+	_, err = verifier.Verify(ctx, signToken(t, "ES256", "random", newKey, claims))
+	assert.True(t, errors.Is(err, ErrKeyNotFound), err)
+	assert.Equal(t, 2, jwks.fetches)
+
+	// Cached keys are kept while the JWKS URL is unreachable
+	jwks.mu.Lock()
+	jwks.down = true
+	jwks.mu.Unlock()
+	now = now.Add(time.Hour)
+	_, err = verifier.Verify(ctx, signToken(t, "ES256", "2024-05", newKey, claims))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, jwks.fetches)
+}
+
+func TestRemoteKeySetSharedFetch(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	jwks := &fakeJwksServer{release: make(chan struct{})}
+	jwks.setKeys(rsaJWK("2024-04", &key.PublicKey))
+	server := httptest.NewServer(jwks)
+	defer server.Close()
+
+	keySet := NewRemoteKeySet(server.URL, 15*time.Minute)
+
+	// A canceled request stops waiting, the fetch goes on for the other requests
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = keySet.Key(canceled, "2024-04")
+	assert.True(t, errors.Is(err, ErrKeySetUnavailable), err)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 5)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := keySet.Key(context.Background(), "2024-04")
+			errs <- err
+		}()
+	}
+	close(jwks.release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 1, jwks.fetches)
+}
+
+func TestRemoteKeySetUnavailable(t *testing.T) {
+	jwks := &fakeJwksServer{down: true}
+	server := httptest.NewServer(jwks)
+	defer server.Close()
+
+	keySet := NewRemoteKeySet(server.URL, 15*time.Minute)
+	_, err := keySet.Key(context.Background(), "2024-04")
+	assert.True(t, errors.Is(err, ErrKeySetUnavailable), err)
+}
+
+func TestParsePublicKeyPEM(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&ecKey.PublicKey)
+	require.NoError(t, err)
+
+	publicKey, err := ParsePublicKeyPEM(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	assert.NoError(t, err)
+	assert.True(t, ecKey.PublicKey.Equal(publicKey))
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	publicKey, err = ParsePublicKeyPEM(pem.EncodeToMemory(&pem.Block{
+		Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(&rsaKey.PublicKey),
+	}))
+	assert.NoError(t, err)
+	assert.True(t, rsaKey.PublicKey.Equal(publicKey))
+
+	_, err = ParsePublicKeyPEM([]byte("not a key"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,60 @@
+package jwt
+
+import (
+	"errors"
+	"fmt"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/config"
+	"time"
+)
+
+var ErrNotConfigured = errors.New("jwt authentication is not enabled")
+
+// SetupVerifier is a function to set up the bearer token verifier, it returns ErrNotConfigured if it is not enabled
+func SetupVerifier(cfg *config.Config) (*Verifier, error) {
+
+	jwtCfg := cfg.AuthCfg.Jwt
+
+	if !jwtCfg.Enabled {
+		return nil, ErrNotConfigured
+	}
+
+	if jwtCfg.ClockSkew < 0 {
+		return nil, errors.New("jwt clock_skew must not be negative")
+	}
+
+	var keySet KeySet
+	switch {
+	case jwtCfg.JwksURL != "" && len(jwtCfg.Keys) > 0:
+		return nil, errors.New("jwt needs either jwks_url or keys, not both")
+	case jwtCfg.JwksURL != "":
+		refreshInterval := time.Duration(jwtCfg.JwksRefreshInterval) * time.Second
+		if refreshInterval <= 0 {
+			refreshInterval = 15 * time.Minute
+		}
+		keySet = NewRemoteKeySet(jwtCfg.JwksURL, refreshInterval)
+	case len(jwtCfg.Keys) > 0:
+		keys := make(map[string]interface{}, len(jwtCfg.Keys))
+		for _, keyCfg := range jwtCfg.Keys {
+			if _, ok := keys[keyCfg.Kid]; ok {
+				return nil, fmt.Errorf("jwt key %q is defined twice", keyCfg.Kid)
+			}
+			switch {
+			case keyCfg.PublicKey != "" && keyCfg.Secret != "":
+				return nil, fmt.Errorf("jwt key %q needs either public_key or secret, not both", keyCfg.Kid)
+			case keyCfg.Secret != "":
+				keys[keyCfg.Kid] = []byte(keyCfg.Secret)
+			default:
+				publicKey, err := ParsePublicKeyPEM([]byte(keyCfg.PublicKey))
+				if err != nil {
+					return nil, fmt.Errorf("jwt key %q: %w", keyCfg.Kid, err)
+				}
+				keys[keyCfg.Kid] = publicKey
+			}
+		}
+		keySet = NewStaticKeySet(keys)
+	default:
+		return nil, errors.New("jwt needs jwks_url or keys")
+	}
+
+	return NewVerifier(keySet, jwtCfg.Issuer, jwtCfg.Audience, time.Duration(jwtCfg.ClockSkew)*time.Second), nil
+}
@@ -0,0 +1,186 @@
+// Package oltcli drives the ZTE C320 command line over Telnet or SSH for the operations SNMP cannot do.
+package oltcli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	ProtocolTelnet = "telnet"
+	ProtocolSSH    = "ssh"
+
+	defaultDialTimeout    = 10 * time.Second
+	defaultCommandTimeout = 30 * time.Second
+)
+
+var (
+	ErrNotConfigured = errors.New("olt cli is not configured")
+	ErrAuthFailed    = errors.New("olt cli authentication failed")
+	ErrClosed        = errors.New("olt cli driver is closed")
+	ErrNoHostKey     = errors.New("olt cli ssh needs a host key fingerprint or insecure ignore host key")
+)
+
+var (
+	// defaultPromptRegex matches "ZXAN#", "ZXAN>" and "ZXAN(config)#" at the end of the output
+	defaultPromptRegex = regexp.MustCompile(`(?:^|\n)[\w.\-]+(?:\([\w.\-/:]+\))?[#>] ?$`)
+	usernameRegex      = regexp.MustCompile(`(?i)(?:username|login): ?$`)
+	passwordRegex      = regexp.MustCompile(`(?i)password: ?$`)
+	moreRegex          = regexp.MustCompile(` ?-+ ?[Mm]ore ?-+ ?$`)
+	commandErrorRegex  = regexp.MustCompile(`(?m)^%\s*(?:Error|Code|Invalid|Incomplete|Unknown|Ambiguous).*$`)
+)
+
+// Driver runs commands on the OLT CLI and returns the output without the echoed command and the prompt
+type Driver interface {
+	Run(ctx context.Context, command string) (string, error)
+	Close() error
+}
+
+// Config is the connection configuration of a Driver
+type Config struct {
+	Protocol              string        // "telnet" or "ssh"
+	Host                  string        // OLT management IP address
+	Port                  int           // 23 for telnet and 22 for ssh if zero
+	Username              string        // CLI username
+	Password              string        // CLI password
+	HostKeyFingerprint    string        // SSH host key SHA256 fingerprint
+	InsecureIgnoreHostKey bool          // Accept any SSH host key when no fingerprint is set
+	Prompt                string        // Prompt regular expression, defaultPromptRegex is used if empty
+	DialTimeout           time.Duration // Timeout to connect and login
+	CommandTimeout        time.Duration // Timeout of a single command when the context has no deadline
+}
+
+// CommandError is returned when the OLT rejects a command
+type CommandError struct {
+	Command string
+	Message string
+}
+
+func (e *CommandError) Error() string {
+	return fmt.Sprintf("olt cli command %q failed: %s", e.Command, e.Message)
+}
+
+// dialer opens an authenticated shell on the OLT
+type dialer func(ctx context.Context, cfg Config, prompt *regexp.Regexp) (*shell, error)
+
+type driver struct {
+	cfg    Config
+	prompt *regexp.Regexp
+	dial   dialer
+
+	mu     sync.Mutex
+	shell  *shell
+	closed bool
+}
+
+// NewDriver returns a Driver for the given config, the connection is opened on the first command
+func NewDriver(cfg Config) (Driver, error) {
+
+	if cfg.Host == "" {
+		return nil, ErrNotConfigured
+	}
+
+	d := &driver{cfg: cfg, prompt: defaultPromptRegex}
+
+	switch strings.ToLower(cfg.Protocol) {
+	case ProtocolTelnet, "":
+		d.dial = dialTelnet
+		if d.cfg.Port == 0 {
+			d.cfg.Port = 23
+		}
+	case ProtocolSSH:
+		if cfg.HostKeyFingerprint == "" && !cfg.InsecureIgnoreHostKey {
+			return nil, ErrNoHostKey
+		}
+		d.dial = dialSSH
+		if d.cfg.Port == 0 {
+			d.cfg.Port = 22
+		}
+	default:
+		return nil, fmt.Errorf("unsupported olt cli protocol %q", cfg.Protocol)
+	}
+
+	if cfg.Prompt != "" {
+		prompt, err := regexp.Compile(cfg.Prompt)
+		if err != nil {
+			return nil, fmt.Errorf("invalid olt cli prompt: %w", err)
+		}
+		d.prompt = prompt
+	}
+
+	if d.cfg.DialTimeout <= 0 {
+		d.cfg.DialTimeout = defaultDialTimeout
+	}
+
+	if d.cfg.CommandTimeout <= 0 {
+		d.cfg.CommandTimeout = defaultCommandTimeout
+	}
+
+	return d, nil
+}
+
+// Run runs a command, commands are serialized because the CLI session is a single terminal
+func (d *driver) Run(ctx context.Context, command string) (string, error) {
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.closed {
+		return "", ErrClosed
+	}
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d.cfg.CommandTimeout)
+		defer cancel()
+	}
+
+	// Connect lazily, and again after a broken session
+	if d.shell == nil {
+		dialCtx, cancel := context.WithTimeout(ctx, d.cfg.DialTimeout)
+		sh, err := d.dial(dialCtx, d.cfg, d.prompt)
+		cancel()
+		if err != nil {
+			return "", err
+		}
+		d.shell = sh
+	}
+
+	output, err := d.shell.run(ctx, command)
+	if err != nil {
+		// The session state is unknown after a timeout or transport error, drop it
+		_ = d.shell.close()
+		d.shell = nil
+		return "", err
+	}
+
+	// Report error messages of the OLT as CommandError
+	if message := commandErrorRegex.FindString(output); message != "" {
+		return "", &CommandError{Command: command, Message: strings.TrimSpace(message)}
+	}
+
+	return output, nil
+}
+
+// Close closes the CLI session, the driver cannot be used afterwards
+func (d *driver) Close() error {
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.closed = true
+
+	if d.shell == nil {
+		return nil
+	}
+
+	err := d.shell.close()
+	d.shell = nil
+
+	return err
+}
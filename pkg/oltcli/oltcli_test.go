@@ -0,0 +1,451 @@
+package oltcli
+
+import (
+	"bufio"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testDetailInfoOutput = `ONU interface:          gpon-onu_1/2/1:1
+Name:                   customer-001
+Type:                   ZTE-F660
+State:                  ready
+Admin state:            enable
+Phase state:            working
+Config state:           success
+Authentication mode:    sn
+Serial number:          ZTEGC1234567
+Description:            Jl. Merdeka No. 1
+Vport mode:             gemport
+DBA Mode:               Hybrid
+ONU Distance:           1100m
+Online Duration:        0h 15m 30s
+--------------------------------------------
+   Authpass Time          OfflineTime             Cause
+   1   2023-10-01 10:00:00    2023-10-01 09:00:00     DyingGasp
+   2   2023-10-02 08:00:00    0000-00-00 00:00:00
+   3   0000-00-00 00:00:00    0000-00-00 00:00:00`
+
+// fakeCli is a scripted ZTE CLI, it pages the output every pagerLines lines like the OLT does
+type fakeCli struct {
+	username   string
+	password   string
+	responses  map[string]string
+	pagerLines int
+	telnet     bool
+
+	mu          sync.Mutex
+	connections int
+	negotiation [][]byte // Telnet replies received from the client
+}
+
+func newFakeCli(telnet bool) *fakeCli {
+	return &fakeCli{
+		username: "zte",
+		password: "secret",
+		responses: map[string]string{
+			"terminal length 0":                          "",
+			"show gpon onu detail-info gpon-onu_1/2/1:1": testDetailInfoOutput,
+		},
+		pagerLines: 5,
+		telnet:     telnet,
+	}
+}
+
+// serve runs one CLI session on rw, login is skipped for sessions already authenticated by SSH
+func (f *fakeCli) serve(rw io.ReadWriter, authenticated bool) {
+
+	f.mu.Lock()
+	f.connections++
+	f.mu.Unlock()
+
+	reader := bufio.NewReader(rw)
+	newline := "\n"
+	if f.telnet {
+		newline = "\r\n"
+		// Offer echo and suppress go ahead, ask for window size
+		_, _ = rw.Write([]byte{telnetIAC, telnetWILL, telnetOptionEcho, telnetIAC, telnetWILL,
+			telnetOptionSuppressGoAhead, telnetIAC, telnetDO, 31})
+	}
+
+	write := func(s string) {
+		_, _ = io.WriteString(rw, strings.ReplaceAll(s, "\n", newline))
+	}
+
+	// Login
+	for !authenticated {
+		write("\nUsername:")
+		username, err := f.readLine(reader)
+		if err != nil {
+			return
+		}
+		write("\nPassword:")
+		password, err := f.readLine(reader)
+		if err != nil {
+			return
+		}
+		if username == f.username && password == f.password {
+			break
+		}
+		write("\n% Authentication failed.\n")
+	}
+
+	write("\nZXAN#")
+
+	for {
+		command, err := f.readLine(reader)
+		if err != nil {
+			return
+		}
+		write(command + "\n") // Echo
+
+		if command == "sleep" {
+			continue // Never answer
+		}
+
+		output, ok := f.responses[command]
+		if !ok {
+			output = "          ^\n%Error 20200: Invalid input detected at '^' marker."
+		}
+
+		if output != "" {
+			for i, line := range strings.Split(output, "\n") {
+				if i > 0 && f.pagerLines > 0 && i%f.pagerLines == 0 {
+					write(" --More-- ")
+					if !f.waitForSpace(reader) {
+						return
+					}
+					// Erase the pager prompt like the OLT does
+					write(strings.Repeat("\b", 10) + strings.Repeat(" ", 10) + strings.Repeat("\b", 10))
+				}
+				write(line + "\n")
+			}
+		}
+
+		write("ZXAN#")
+	}
+}
+
+// readByte reads a data byte, recording and skipping Telnet negotiation replies
+func (f *fakeCli) readByte(reader *bufio.Reader) (byte, error) {
+	for {
+		b, err := reader.ReadByte()
+		if err != nil || !f.telnet || b != telnetIAC {
+			return b, err
+		}
+		reply := []byte{b, 0, 0}
+		if _, err := io.ReadFull(reader, reply[1:]); err != nil {
+			return 0, err
+		}
+		f.mu.Lock()
+		f.negotiation = append(f.negotiation, reply)
+		f.mu.Unlock()
+	}
+}
+
+func (f *fakeCli) readLine(reader *bufio.Reader) (string, error) {
+	var line []byte
+	for {
+		b, err := f.readByte(reader)
+		if err != nil {
+			return "", err
+		}
+		if b == '\n' {
+			return strings.TrimRight(string(line), "\r"), nil
+		}
+		line = append(line, b)
+	}
+}
+
+func (f *fakeCli) waitForSpace(reader *bufio.Reader) bool {
+	for {
+		b, err := f.readByte(reader)
+		if err != nil {
+			return false
+		}
+		if b == ' ' {
+			return true
+		}
+	}
+}
+
+func (f *fakeCli) connectionCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.connections
+}
+
+// startTelnetServer serves the fake CLI over plain TCP and returns the port
+func startTelnetServer(t *testing.T, cli *fakeCli) int {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				cli.serve(conn, false)
+			}()
+		}
+	}()
+
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+// startSSHServer serves the fake CLI over SSH and returns the port and host key fingerprint
+func startSSHServer(t *testing.T, cli *fakeCli) (int, string) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(privateKey)
+	assert.NoError(t, err)
+
+	serverConfig := &ssh.ServerConfig{
+		PasswordCallback: func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if conn.User() == cli.username && string(password) == cli.password {
+				return nil, nil
+			}
+			return nil, errors.New("access denied")
+		},
+	}
+	serverConfig.AddHostKey(signer)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveSSHConn(conn, serverConfig, cli)
+		}
+	}()
+
+	return listener.Addr().(*net.TCPAddr).Port, ssh.FingerprintSHA256(signer.PublicKey())
+}
+
+func serveSSHConn(conn net.Conn, serverConfig *ssh.ServerConfig, cli *fakeCli) {
+	defer conn.Close()
+
+	_, channels, requests, err := ssh.NewServerConn(conn, serverConfig)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(requests)
+
+	for newChannel := range channels {
+		if newChannel.ChannelType() != "session" {
+			_ = newChannel.Reject(ssh.UnknownChannelType, "session only")
+			continue
+		}
+		channel, channelRequests, err := newChannel.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			for request := range channelRequests {
+				_ = request.Reply(request.Type == "pty-req" || request.Type == "shell", nil)
+			}
+		}()
+		go func() {
+			defer channel.Close()
+			cli.serve(channel, true)
+		}()
+	}
+}
+
+func newTestDriver(t *testing.T, protocol string, port int, fingerprint string) Driver {
+	d, err := NewDriver(Config{
+		Protocol:           protocol,
+		Host:               "127.0.0.1",
+		Port:               port,
+		Username:           "zte",
+		Password:           "secret",
+		HostKeyFingerprint: fingerprint,
+		DialTimeout:        2 * time.Second,
+		CommandTimeout:     2 * time.Second,
+	})
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = d.Close() })
+	return d
+}
+
+func TestNewDriver(t *testing.T) {
+	_, err := NewDriver(Config{})
+	assert.ErrorIs(t, err, ErrNotConfigured)
+
+	_, err = NewDriver(Config{Host: "127.0.0.1", Protocol: "rlogin"})
+	assert.Error(t, err)
+
+	_, err = NewDriver(Config{Host: "127.0.0.1", Prompt: "("})
+	assert.Error(t, err)
+
+	// SSH needs a host key fingerprint unless any host key is explicitly accepted
+	_, err = NewDriver(Config{Host: "127.0.0.1", Protocol: ProtocolSSH})
+	assert.ErrorIs(t, err, ErrNoHostKey)
+
+	d, err := NewDriver(Config{Host: "127.0.0.1", Protocol: ProtocolSSH, InsecureIgnoreHostKey: true})
+	assert.NoError(t, err)
+	_ = d.Close()
+}
+
+func TestTelnetDriverRun(t *testing.T) {
+	cli := newFakeCli(true)
+	port := startTelnetServer(t, cli)
+	d := newTestDriver(t, ProtocolTelnet, port, "")
+
+	// Connection is opened on the first command
+	assert.Equal(t, 0, cli.connectionCount())
+
+	output, err := d.Run(context.Background(), "show gpon onu detail-info gpon-onu_1/2/1:1")
+	assert.NoError(t, err)
+	assert.Equal(t, testDetailInfoOutput, output)
+
+	// Commands reuse the session
+	output, err = d.Run(context.Background(), "show gpon onu detail-info gpon-onu_1/2/1:1")
+	assert.NoError(t, err)
+	assert.Equal(t, testDetailInfoOutput, output)
+	assert.Equal(t, 1, cli.connectionCount())
+
+	// Echo and suppress go ahead are accepted, window size is refused
+	cli.mu.Lock()
+	assert.Equal(t, [][]byte{
+		{telnetIAC, telnetDO, telnetOptionEcho},
+		{telnetIAC, telnetDO, telnetOptionSuppressGoAhead},
+		{telnetIAC, telnetWONT, 31},
+	}, cli.negotiation)
+	cli.mu.Unlock()
+}
+
+func TestTelnetDriverCommandError(t *testing.T) {
+	cli := newFakeCli(true)
+	port := startTelnetServer(t, cli)
+	d := newTestDriver(t, ProtocolTelnet, port, "")
+
+	_, err := d.Run(context.Background(), "show nonsense")
+
+	var commandErr *CommandError
+	assert.True(t, errors.As(err, &commandErr))
+	assert.Equal(t, "show nonsense", commandErr.Command)
+	assert.Equal(t, "%Error 20200: Invalid input detected at '^' marker.", commandErr.Message)
+}
+
+func TestTelnetDriverAuthFailed(t *testing.T) {
+	cli := newFakeCli(true)
+	cli.password = "another"
+	port := startTelnetServer(t, cli)
+	d := newTestDriver(t, ProtocolTelnet, port, "")
+
+	_, err := d.Run(context.Background(), "show gpon onu detail-info gpon-onu_1/2/1:1")
+	assert.ErrorIs(t, err, ErrAuthFailed)
+}
+
+func TestTelnetDriverTimeoutReconnects(t *testing.T) {
+	cli := newFakeCli(true)
+	port := startTelnetServer(t, cli)
+	d := newTestDriver(t, ProtocolTelnet, port, "")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	_, err := d.Run(ctx, "sleep")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// The broken session is replaced on the next command
+	output, err := d.Run(context.Background(), "show gpon onu detail-info gpon-onu_1/2/1:1")
+	assert.NoError(t, err)
+	assert.Equal(t, testDetailInfoOutput, output)
+	assert.Equal(t, 2, cli.connectionCount())
+}
+
+func TestDriverClosed(t *testing.T) {
+	d, err := NewDriver(Config{Host: "127.0.0.1", Port: 1})
+	assert.NoError(t, err)
+	assert.NoError(t, d.Close())
+
+	_, err = d.Run(context.Background(), "show version")
+	assert.ErrorIs(t, err, ErrClosed)
+}
+
+func TestSSHDriverRun(t *testing.T) {
+	cli := newFakeCli(false)
+	port, fingerprint := startSSHServer(t, cli)
+	d := newTestDriver(t, ProtocolSSH, port, fingerprint)
+
+	output, err := d.Run(context.Background(), "show gpon onu detail-info gpon-onu_1/2/1:1")
+	assert.NoError(t, err)
+	assert.Equal(t, testDetailInfoOutput, output)
+	assert.Equal(t, 1, cli.connectionCount())
+}
+
+func TestSSHDriverHostKeyMismatch(t *testing.T) {
+	cli := newFakeCli(false)
+	port, _ := startSSHServer(t, cli)
+	d := newTestDriver(t, ProtocolSSH, port, "SHA256:"+strings.Repeat("A", 43))
+
+	_, err := d.Run(context.Background(), "show version")
+	assert.ErrorContains(t, err, "host key mismatch")
+
+	// Any host key is accepted only when explicitly enabled
+	d, err = NewDriver(Config{
+		Protocol:              ProtocolSSH,
+		Host:                  "127.0.0.1",
+		Port:                  port,
+		Username:              "zte",
+		Password:              "secret",
+		InsecureIgnoreHostKey: true,
+		DialTimeout:           2 * time.Second,
+		CommandTimeout:        2 * time.Second,
+	})
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = d.Close() })
+	output, err := d.Run(context.Background(), "show gpon onu detail-info gpon-onu_1/2/1:1")
+	assert.NoError(t, err)
+	assert.Equal(t, testDetailInfoOutput, output)
+}
+
+func TestSSHDriverAuthFailed(t *testing.T) {
+	cli := newFakeCli(false)
+	cli.password = "another"
+	port, fingerprint := startSSHServer(t, cli)
+	d := newTestDriver(t, ProtocolSSH, port, fingerprint)
+
+	_, err := d.Run(context.Background(), "show version")
+	assert.ErrorIs(t, err, ErrAuthFailed)
+}
+
+func TestCleanOutput(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{"line1\r\nline2\r\n", "line1\nline2\n"},
+		{"\x1b[1;32mZXAN#\x1b[0m", "ZXAN#"},
+		{"abc\b\bd", "ad"},
+		{"line1\n\b\bline2", "line1\nline2"},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			assert.Equal(t, tc.expected, cleanOutput(tc.input))
+		})
+	}
+}
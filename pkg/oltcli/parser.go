@@ -0,0 +1,294 @@
+package oltcli
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	keyValueRegex    = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9 +/_\-]*?):\s*(.*)$`)
+	separatorRegex   = regexp.MustCompile(`^-{5,}$`)
+	authHistoryRegex = regexp.MustCompile(
+		`^\s*(\d+)\s+(\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2})\s+(\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2})\s*(\S*)`,
+	)
+	onuStateRegex = regexp.MustCompile(`^\s*\d+/(\d+)/(\d+):(\d+)\s+(\S+)\s+(\S+)\s+(\S+)\s+(\S+)`)
+)
+
+// ErrNoOnuInterface is returned when the running-config has no gpon-onu interface, the ONU is not configured
+var ErrNoOnuInterface = errors.New("no gpon-onu interface in running-config output")
+
+// emptyTime is shown by the OLT for a time that has not happened yet
+const emptyTime = "0000-00-00 00:00:00"
+
+type OnuDetailInfo struct {
+	Interface          string           `json:"interface"`
+	Name               string           `json:"name"`
+	Type               string           `json:"type"`
+	State              string           `json:"state"`
+	AdminState         string           `json:"admin_state"`
+	PhaseState         string           `json:"phase_state"`
+	ConfigState        string           `json:"config_state"`
+	AuthenticationMode string           `json:"authentication_mode"`
+	SerialNumber       string           `json:"serial_number"`
+	Description        string           `json:"description"`
+	VportMode          string           `json:"vport_mode"`
+	DBAMode            string           `json:"dba_mode"`
+	LineProfile        string           `json:"line_profile"`
+	ServiceProfile     string           `json:"service_profile"`
+	Distance           string           `json:"distance"`
+	OnlineDuration     string           `json:"online_duration"`
+	History            []OnuAuthHistory `json:"history"`
+}
+
+type OnuAuthHistory struct {
+	AuthpassTime string `json:"authpass_time"`
+	OfflineTime  string `json:"offline_time,omitempty"`
+	Cause        string `json:"cause,omitempty"`
+}
+
+type OnuState struct {
+	Board      int    `json:"board"`
+	PON        int    `json:"pon"`
+	ID         int    `json:"onu_id"`
+	AdminState string `json:"admin_state"`
+	OmccState  string `json:"omcc_state"`
+	PhaseState string `json:"phase_state"`
+	Channel    string `json:"channel"`
+}
+
+// GponOltInterface returns the CLI name of a PON port, example: gpon-olt_1/2/1
+func GponOltInterface(boardID, ponID int) string {
+	return fmt.Sprintf("gpon-olt_1/%d/%d", boardID, ponID)
+}
+
+// GponOnuInterface returns the CLI name of an ONU, example: gpon-onu_1/2/1:10
+func GponOnuInterface(boardID, ponID, onuID int) string {
+	return fmt.Sprintf("gpon-onu_1/%d/%d:%d", boardID, ponID, onuID)
+}
+
+// ParseOnuDetailInfo parses the output of "show gpon onu detail-info gpon-onu_1/b/p:o"
+func ParseOnuDetailInfo(output string) (OnuDetailInfo, error) {
+
+	var info OnuDetailInfo
+	fields := make(map[string]string)
+	inHistory := false
+
+	for _, line := range strings.Split(output, "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		// Key and value lines end at the separator, the authentication history follows it
+		if separatorRegex.MatchString(trimmed) {
+			inHistory = true
+			continue
+		}
+
+		if inHistory {
+			match := authHistoryRegex.FindStringSubmatch(line)
+			if match == nil || match[2] == emptyTime {
+				continue
+			}
+			history := OnuAuthHistory{AuthpassTime: match[2], Cause: match[4]}
+			if match[3] != emptyTime {
+				history.OfflineTime = match[3]
+			}
+			info.History = append(info.History, history)
+			continue
+		}
+
+		if match := keyValueRegex.FindStringSubmatch(trimmed); match != nil {
+			fields[strings.ToLower(match[1])] = strings.TrimSpace(match[2])
+		}
+	}
+
+	if len(fields) == 0 {
+		return info, fmt.Errorf("unexpected onu detail-info output")
+	}
+
+	info.Interface = fields["onu interface"]
+	info.Name = fields["name"]
+	info.Type = fields["type"]
+	info.State = fields["state"]
+	info.AdminState = fields["admin state"]
+	info.PhaseState = fields["phase state"]
+	info.ConfigState = fields["config state"]
+	info.AuthenticationMode = fields["authentication mode"]
+	info.SerialNumber = fields["serial number"]
+	info.Description = fields["description"]
+	info.VportMode = fields["vport mode"]
+	info.DBAMode = fields["dba mode"]
+	info.LineProfile = fields["line profile"]
+	info.ServiceProfile = fields["service profile"]
+	info.Distance = fields["onu distance"]
+	info.OnlineDuration = fields["online duration"]
+
+	return info, nil
+}
+
+// ParseOnuStateList parses the output of "show gpon onu state gpon-olt_1/b/p"
+func ParseOnuStateList(output string) []OnuState {
+
+	onuStateList := make([]OnuState, 0)
+
+	for _, line := range strings.Split(output, "\n") {
+		match := onuStateRegex.FindStringSubmatch(line)
+		if match == nil {
+			continue // Header, separator and summary lines
+		}
+
+		boardID, _ := strconv.Atoi(match[1])
+		ponID, _ := strconv.Atoi(match[2])
+		onuID, _ := strconv.Atoi(match[3])
+
+		onuStateList = append(onuStateList, OnuState{
+			Board:      boardID,
+			PON:        ponID,
+			ID:         onuID,
+			AdminState: match[4],
+			OmccState:  match[5],
+			PhaseState: match[6],
+			Channel:    match[7],
+		})
+	}
+
+	return onuStateList
+}
+
+var (
+	onuInterfaceRegex = regexp.MustCompile(`^interface (gpon-onu_\S+)$`)
+	tcontRegex        = regexp.MustCompile(`^tcont (\d+)(?: name (\S+))?(?: profile (\S+))?`)
+	gemportRegex      = regexp.MustCompile(`^gemport (\d+)(?: name (\S+))?.*? tcont (\d+)`)
+	gemportLimitRegex = regexp.MustCompile(`^gemport (\d+) traffic-limit(?: upstream (\S+))?(?: downstream (\S+))?`)
+)
+
+type OnuRunningConfig struct {
+	Interface    string
+	Name         string
+	Description  string
+	Tconts       []Tcont
+	Gemports     []Gemport
+	ServicePorts []ServicePort
+}
+
+type Tcont struct {
+	ID      int
+	Name    string
+	Profile string
+}
+
+type Gemport struct {
+	ID                int
+	Name              string
+	Tcont             int
+	UpstreamProfile   string
+	DownstreamProfile string
+}
+
+type ServicePort struct {
+	ID        int
+	Vport     int    // vport, or gemport when the ONU uses gemport vport mode
+	UserVlan  int    // 0 when the user side is untagged
+	UserEtype string // PPPOE, IP, ... empty when any ethertype
+	Vlan      int
+	Svlan     int // 0 when there is no outer VLAN
+	NewCos    int // -1 when the CoS is not remarked
+}
+
+// ParseOnuRunningConfig parses the output of "show running-config interface gpon-onu_1/b/p:o"
+func ParseOnuRunningConfig(output string) (OnuRunningConfig, error) {
+
+	var runningConfig OnuRunningConfig
+	gemportIndex := make(map[int]int) // Gemport ID to index in Gemports
+
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+
+		if match := onuInterfaceRegex.FindStringSubmatch(line); match != nil {
+			runningConfig.Interface = match[1]
+			continue
+		}
+
+		if runningConfig.Interface == "" {
+			continue // "Building configuration..." and anything before the interface
+		}
+
+		switch {
+		case strings.HasPrefix(line, "name "):
+			runningConfig.Name = strings.TrimPrefix(line, "name ")
+		case strings.HasPrefix(line, "description "):
+			runningConfig.Description = strings.TrimPrefix(line, "description ")
+		case strings.HasPrefix(line, "tcont "):
+			if match := tcontRegex.FindStringSubmatch(line); match != nil {
+				id, _ := strconv.Atoi(match[1])
+				runningConfig.Tconts = append(runningConfig.Tconts, Tcont{ID: id, Name: match[2], Profile: match[3]})
+			}
+		case strings.HasPrefix(line, "gemport "):
+			if match := gemportLimitRegex.FindStringSubmatch(line); match != nil {
+				id, _ := strconv.Atoi(match[1])
+				if i, ok := gemportIndex[id]; ok {
+					runningConfig.Gemports[i].UpstreamProfile = match[2]
+					runningConfig.Gemports[i].DownstreamProfile = match[3]
+				}
+			} else if match := gemportRegex.FindStringSubmatch(line); match != nil {
+				id, _ := strconv.Atoi(match[1])
+				tcont, _ := strconv.Atoi(match[3])
+				gemportIndex[id] = len(runningConfig.Gemports)
+				runningConfig.Gemports = append(runningConfig.Gemports, Gemport{ID: id, Name: match[2], Tcont: tcont})
+			}
+		case strings.HasPrefix(line, "service-port "):
+			if servicePort, ok := parseServicePort(line); ok {
+				runningConfig.ServicePorts = append(runningConfig.ServicePorts, servicePort)
+			}
+		}
+	}
+
+	if runningConfig.Interface == "" {
+		return runningConfig, ErrNoOnuInterface
+	}
+
+	return runningConfig, nil
+}
+
+// parseServicePort parses "service-port 1 vport 1 user-vlan 100 user-etype PPPOE vlan 100 svlan 1000"
+func parseServicePort(line string) (ServicePort, bool) {
+
+	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		return ServicePort{}, false
+	}
+
+	id, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return ServicePort{}, false
+	}
+
+	servicePort := ServicePort{ID: id, NewCos: -1}
+
+	// The rest of the line is keyword and value pairs
+	for i := 2; i+1 < len(fields); i++ {
+		value := fields[i+1]
+		number, _ := strconv.Atoi(value)
+
+		switch fields[i] {
+		case "vport", "gemport":
+			servicePort.Vport = number
+		case "user-vlan":
+			servicePort.UserVlan = number // "untag" is 0
+		case "user-etype":
+			servicePort.UserEtype = value
+		case "vlan":
+			servicePort.Vlan = number
+		case "svlan":
+			servicePort.Svlan = number
+		case "new-cos":
+			servicePort.NewCos = number
+		default:
+			continue // Keyword without value or unknown keyword, look at the next field
+		}
+		i++
+	}
+
+	return servicePort, true
+}
@@ -0,0 +1,100 @@
+package oltcli
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParseOnuDetailInfo(t *testing.T) {
+	info, err := ParseOnuDetailInfo(testDetailInfoOutput)
+
+	assert.NoError(t, err)
+	assert.Equal(t, OnuDetailInfo{
+		Interface:          "gpon-onu_1/2/1:1",
+		Name:               "customer-001",
+		Type:               "ZTE-F660",
+		State:              "ready",
+		AdminState:         "enable",
+		PhaseState:         "working",
+		ConfigState:        "success",
+		AuthenticationMode: "sn",
+		SerialNumber:       "ZTEGC1234567",
+		Description:        "Jl. Merdeka No. 1",
+		VportMode:          "gemport",
+		DBAMode:            "Hybrid",
+		Distance:           "1100m",
+		OnlineDuration:     "0h 15m 30s",
+		History: []OnuAuthHistory{
+			{AuthpassTime: "2023-10-01 10:00:00", OfflineTime: "2023-10-01 09:00:00", Cause: "DyingGasp"},
+			{AuthpassTime: "2023-10-02 08:00:00"},
+		},
+	}, info)
+
+	_, err = ParseOnuDetailInfo("")
+	assert.Error(t, err)
+}
+
+func TestParseOnuStateList(t *testing.T) {
+	output := `OnuIndex   Admin State  OMCC State  Phase State  Channel
+--------------------------------------------------------------
+1/2/1:1     enable       enable      working      1(GPON)
+1/2/1:12    enable       disable     OffLine      1(GPON)
+ONU Number: 2/2`
+
+	assert.Equal(t, []OnuState{
+		{Board: 2, PON: 1, ID: 1, AdminState: "enable", OmccState: "enable", PhaseState: "working", Channel: "1(GPON)"},
+		{Board: 2, PON: 1, ID: 12, AdminState: "enable", OmccState: "disable", PhaseState: "OffLine",
+			Channel: "1(GPON)"},
+	}, ParseOnuStateList(output))
+
+	assert.Empty(t, ParseOnuStateList(""))
+}
+
+func TestInterfaceName(t *testing.T) {
+	assert.Equal(t, "gpon-olt_1/2/8", GponOltInterface(2, 8))
+	assert.Equal(t, "gpon-onu_1/1/3:128", GponOnuInterface(1, 3, 128))
+}
+
+func TestParseOnuRunningConfig(t *testing.T) {
+	output := `Building configuration...
+interface gpon-onu_1/2/1:1
+  name customer-001
+  description Jl. Merdeka No. 1
+  tcont 1 name HSI profile UP-100M
+  tcont 2 profile 1G
+  gemport 1 name HSI tcont 1
+  gemport 1 traffic-limit upstream UP-100M downstream DOWN-100M
+  gemport 2 unicast tcont 2 dir both
+  switchport mode hybrid vport 1
+  service-port 1 vport 1 user-vlan 100 vlan 100
+  service-port 2 vport 2 user-vlan 200 user-etype PPPOE vlan 300 svlan 1000 new-cos 5
+  service-port 3 gemport 2 user-vlan untag vlan 400
+  port-identification format DSL-FORUM-PON vport 1
+!
+end`
+
+	runningConfig, err := ParseOnuRunningConfig(output)
+
+	assert.NoError(t, err)
+	assert.Equal(t, OnuRunningConfig{
+		Interface:   "gpon-onu_1/2/1:1",
+		Name:        "customer-001",
+		Description: "Jl. Merdeka No. 1",
+		Tconts: []Tcont{
+			{ID: 1, Name: "HSI", Profile: "UP-100M"},
+			{ID: 2, Profile: "1G"},
+		},
+		Gemports: []Gemport{
+			{ID: 1, Name: "HSI", Tcont: 1, UpstreamProfile: "UP-100M", DownstreamProfile: "DOWN-100M"},
+			{ID: 2, Tcont: 2},
+		},
+		ServicePorts: []ServicePort{
+			{ID: 1, Vport: 1, UserVlan: 100, Vlan: 100, NewCos: -1},
+			{ID: 2, Vport: 2, UserVlan: 200, UserEtype: "PPPOE", Vlan: 300, Svlan: 1000, NewCos: 5},
+			{ID: 3, Vport: 2, UserVlan: 0, Vlan: 400, NewCos: -1},
+		},
+	}, runningConfig)
+
+	_, err = ParseOnuRunningConfig("Building configuration...\n!\nend")
+	assert.ErrorIs(t, err, ErrNoOnuInterface)
+}
@@ -0,0 +1,31 @@
+package oltcli
+
+import (
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/config"
+	"github.com/rs/zerolog/log"
+	"strings"
+	"time"
+)
+
+// SetupCliDriver is a function to set up the OLT CLI driver, it returns ErrNotConfigured if no CLI host is set
+func SetupCliDriver(cfg *config.Config) (Driver, error) {
+
+	cliCfg := cfg.CliCfg
+
+	if cliCfg.Host != "" && strings.EqualFold(cliCfg.Protocol, ProtocolSSH) && cliCfg.HostKeyFingerprint == "" &&
+		cliCfg.InsecureIgnoreHostKey {
+		log.Warn().Msg("OLT CLI SSH host key is not checked, set CliCfg.host_key_fingerprint to check it")
+	}
+
+	return NewDriver(Config{
+		Protocol:              cliCfg.Protocol,
+		Host:                  cliCfg.Host,
+		Port:                  cliCfg.Port,
+		Username:              cliCfg.Username,
+		Password:              cliCfg.Password,
+		HostKeyFingerprint:    cliCfg.HostKeyFingerprint,
+		InsecureIgnoreHostKey: cliCfg.InsecureIgnoreHostKey,
+		DialTimeout:           time.Duration(cliCfg.DialTimeout) * time.Second,
+		CommandTimeout:        time.Duration(cliCfg.CommandTimeout) * time.Second,
+	})
+}
@@ -0,0 +1,194 @@
+package oltcli
+
+import (
+	"context"
+	"errors"
+	"io"
+	"regexp"
+	"strings"
+)
+
+var ansiEscapeRegex = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]`)
+
+// pagerPlaceholder stands in for an answered pager prompt until the OLT erases it
+const pagerPlaceholder = "\x01"
+
+// shell is an interactive CLI session on top of a Telnet connection or an SSH channel
+type shell struct {
+	writer  io.Writer
+	closer  io.Closer
+	newline string
+	prompt  *regexp.Regexp
+
+	chunks  chan []byte
+	readErr error // Set by the read loop before chunks is closed
+}
+
+func newShell(reader io.Reader, writer io.Writer, closer io.Closer, newline string, prompt *regexp.Regexp) *shell {
+
+	s := &shell{
+		writer:  writer,
+		closer:  closer,
+		newline: newline,
+		prompt:  prompt,
+		chunks:  make(chan []byte, 64),
+	}
+
+	// Read in the background so every wait can be bounded by a context
+	go func() {
+		defer close(s.chunks)
+		for {
+			buf := make([]byte, 4096)
+			n, err := reader.Read(buf)
+			if n > 0 {
+				s.chunks <- buf[:n]
+			}
+			if err != nil {
+				s.readErr = err
+				return
+			}
+		}
+	}()
+
+	return s
+}
+
+// send writes a line to the CLI
+func (s *shell) send(line string) error {
+	_, err := io.WriteString(s.writer, line+s.newline)
+	return err
+}
+
+// expect reads until the output ends with one of the patterns, answering the pager on the way.
+// It returns the output up to the match and the index of the matched pattern.
+func (s *shell) expect(ctx context.Context, patterns ...*regexp.Regexp) (string, int, error) {
+
+	output := ""
+
+	for {
+		// Keep the pager going until the command is done. The pager prompt is replaced with placeholders
+		// of the same length, so the backspaces the OLT sends to erase it do not erase the output.
+		if loc := moreRegex.FindStringIndex(output); loc != nil {
+			output = output[:loc[0]] + strings.Repeat(pagerPlaceholder, loc[1]-loc[0])
+			if _, err := io.WriteString(s.writer, " "); err != nil {
+				return output, -1, err
+			}
+		}
+
+		for i, pattern := range patterns {
+			if visible := strings.ReplaceAll(output, pagerPlaceholder, ""); pattern.MatchString(visible) {
+				return visible, i, nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return output, -1, ctx.Err()
+		case chunk, ok := <-s.chunks:
+			if !ok {
+				if s.readErr == nil || errors.Is(s.readErr, io.EOF) {
+					return output, -1, io.ErrUnexpectedEOF
+				}
+				return output, -1, s.readErr
+			}
+			output = cleanOutput(output + string(chunk))
+		}
+	}
+}
+
+// login answers the username and password prompts and waits for the CLI prompt
+func (s *shell) login(ctx context.Context, username, password string) error {
+
+	step := 0 // 0 before the username is sent, 1 before the password is sent, 2 after
+	for {
+		_, matched, err := s.expect(ctx, usernameRegex, passwordRegex, s.prompt)
+		if err != nil {
+			return err
+		}
+
+		switch matched {
+		case 0:
+			if step > 0 {
+				return ErrAuthFailed // Asked for the username again
+			}
+			if err := s.send(username); err != nil {
+				return err
+			}
+			step = 1
+		case 1:
+			if step > 1 {
+				return ErrAuthFailed // Asked for the password again
+			}
+			if err := s.send(password); err != nil {
+				return err
+			}
+			step = 2
+		default:
+			return nil
+		}
+	}
+}
+
+// run sends a command and returns its output without the echoed command and the prompt
+func (s *shell) run(ctx context.Context, command string) (string, error) {
+
+	// Drop anything left over from the previous command
+	for len(s.chunks) > 0 {
+		<-s.chunks
+	}
+
+	if err := s.send(command); err != nil {
+		return "", err
+	}
+
+	output, _, err := s.expect(ctx, s.prompt)
+	if err != nil {
+		return "", err
+	}
+
+	// Remove the prompt line
+	if i := strings.LastIndex(output, "\n"); i >= 0 {
+		output = output[:i]
+	} else {
+		output = ""
+	}
+
+	// Remove the echoed command
+	if i := strings.Index(output, "\n"); i >= 0 && strings.Contains(output[:i], strings.TrimSpace(command)) {
+		output = output[i+1:]
+	} else if strings.Contains(output, strings.TrimSpace(command)) && !strings.Contains(output, "\n") {
+		output = ""
+	}
+
+	return strings.Trim(output, "\n"), nil
+}
+
+func (s *shell) close() error {
+	return s.closer.Close()
+}
+
+// cleanOutput removes carriage returns and terminal escapes, and applies backspaces
+func cleanOutput(output string) string {
+
+	output = ansiEscapeRegex.ReplaceAllString(output, "")
+	output = strings.ReplaceAll(output, "\r\n", "\n")
+	output = strings.ReplaceAll(output, "\r", "")
+	output = strings.ReplaceAll(output, "\x00", "")
+
+	if !strings.Contains(output, "\b") {
+		return output
+	}
+
+	cleaned := make([]rune, 0, len(output))
+	for _, r := range output {
+		if r == '\b' {
+			if len(cleaned) > 0 && cleaned[len(cleaned)-1] != '\n' {
+				cleaned = cleaned[:len(cleaned)-1]
+			}
+			continue
+		}
+		cleaned = append(cleaned, r)
+	}
+
+	return string(cleaned)
+}
@@ -0,0 +1,138 @@
+package oltcli
+
+import (
+	"context"
+	"fmt"
+	"golang.org/x/crypto/ssh"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sshTerminalWidth is wide enough to keep the OLT from wrapping table rows
+const sshTerminalWidth = 512
+
+// dialSSH connects to the OLT with SSH, opens an interactive shell and waits for the prompt
+func dialSSH(ctx context.Context, cfg Config, prompt *regexp.Regexp) (*shell, error) {
+
+	clientConfig := &ssh.ClientConfig{
+		User: cfg.Username,
+		Auth: []ssh.AuthMethod{
+			ssh.Password(cfg.Password),
+			// Some firmware only offers keyboard-interactive, answer every question with the password
+			ssh.KeyboardInteractive(func(_, _ string, questions []string, _ []bool) ([]string, error) {
+				answers := make([]string, len(questions))
+				for i := range answers {
+					answers[i] = cfg.Password
+				}
+				return answers, nil
+			}),
+		},
+		HostKeyCallback: hostKeyCallback(cfg.HostKeyFingerprint, cfg.InsecureIgnoreHostKey),
+	}
+
+	address := net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port))
+
+	var netDialer net.Dialer
+	conn, err := netDialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, err
+	}
+
+	// The SSH handshake does not take a context, bound it with the connection deadline
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	sshConn, channels, requests, err := ssh.NewClientConn(conn, address, clientConfig)
+	if err != nil {
+		_ = conn.Close()
+		if isAuthError(err) {
+			return nil, fmt.Errorf("%w: %s", ErrAuthFailed, err.Error())
+		}
+		return nil, err
+	}
+	_ = conn.SetDeadline(time.Time{})
+
+	client := ssh.NewClient(sshConn, channels, requests)
+
+	session, err := client.NewSession()
+	if err != nil {
+		_ = client.Close()
+		return nil, err
+	}
+
+	// Closing the client also closes the session
+	sh, err := startSSHShell(ctx, client, session, cfg, prompt)
+	if err != nil {
+		_ = client.Close()
+		return nil, err
+	}
+
+	return sh, nil
+}
+
+func startSSHShell(
+	ctx context.Context, client *ssh.Client, session *ssh.Session, cfg Config, prompt *regexp.Regexp,
+) (*shell, error) {
+
+	modes := ssh.TerminalModes{
+		ssh.ECHO:          1,
+		ssh.TTY_OP_ISPEED: 38400,
+		ssh.TTY_OP_OSPEED: 38400,
+	}
+	if err := session.RequestPty("vt100", 0, sshTerminalWidth, modes); err != nil {
+		return nil, err
+	}
+
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := session.Shell(); err != nil {
+		return nil, err
+	}
+
+	sh := newShell(stdout, stdin, client, "\n", prompt)
+
+	// Already authenticated, login only waits for the prompt unless the OLT asks again
+	if err := startShell(ctx, sh, cfg); err != nil {
+		return nil, err
+	}
+
+	return sh, nil
+}
+
+// hostKeyCallback checks the host key against a SHA256 fingerprint, any host key is accepted only without fingerprint
+// and with insecureIgnoreHostKey
+func hostKeyCallback(fingerprint string, insecureIgnoreHostKey bool) ssh.HostKeyCallback {
+
+	if fingerprint == "" {
+		if insecureIgnoreHostKey {
+			return ssh.InsecureIgnoreHostKey() // #nosec G106 -- Explicitly enabled in the config
+		}
+		return func(_ string, _ net.Addr, _ ssh.PublicKey) error {
+			return ErrNoHostKey
+		}
+	}
+
+	return func(_ string, _ net.Addr, key ssh.PublicKey) error {
+		if actual := ssh.FingerprintSHA256(key); actual != fingerprint {
+			return fmt.Errorf("olt cli host key mismatch: got %s, want %s", actual, fingerprint)
+		}
+		return nil
+	}
+}
+
+// isAuthError reports whether the handshake failed because every authentication method was rejected
+func isAuthError(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "unable to authenticate")
+}
@@ -0,0 +1,153 @@
+package oltcli
+
+import (
+	"context"
+	"io"
+	"net"
+	"regexp"
+	"strconv"
+	"sync"
+)
+
+// Telnet commands and options (RFC 854, RFC 857, RFC 858)
+const (
+	telnetSE   = 240
+	telnetSB   = 250
+	telnetWILL = 251
+	telnetWONT = 252
+	telnetDO   = 253
+	telnetDONT = 254
+	telnetIAC  = 255
+
+	telnetOptionEcho            = 1
+	telnetOptionSuppressGoAhead = 3
+)
+
+// dialTelnet connects to the OLT with Telnet and logs in
+func dialTelnet(ctx context.Context, cfg Config, prompt *regexp.Regexp) (*shell, error) {
+
+	var netDialer net.Dialer
+	conn, err := netDialer.DialContext(ctx, "tcp", net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)))
+	if err != nil {
+		return nil, err
+	}
+
+	writer := &lockedWriter{writer: conn}
+	reader := &telnetReader{reader: conn, writer: writer}
+
+	sh := newShell(reader, writer, conn, "\r\n", prompt)
+	if err := startShell(ctx, sh, cfg); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	return sh, nil
+}
+
+// startShell logs in and disables the pager for the rest of the session
+func startShell(ctx context.Context, sh *shell, cfg Config) error {
+
+	if err := sh.login(ctx, cfg.Username, cfg.Password); err != nil {
+		return err
+	}
+
+	// The pager is still handled if the OLT does not accept this command
+	_, err := sh.run(ctx, "terminal length 0")
+
+	return err
+}
+
+// lockedWriter serializes writes of the shell and the Telnet negotiation replies
+type lockedWriter struct {
+	mu     sync.Mutex
+	writer io.Writer
+}
+
+func (w *lockedWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.writer.Write(p)
+}
+
+// telnetReader strips Telnet commands from the data stream and refuses every option except
+// echo and suppress go ahead offered by the OLT
+type telnetReader struct {
+	reader io.Reader
+	writer io.Writer
+
+	state   int  // 0 data, 1 after IAC, 2 after WILL/WONT/DO/DONT, 3 in subnegotiation, 4 after IAC in subnegotiation
+	command byte // Pending WILL/WONT/DO/DONT
+}
+
+func (t *telnetReader) Read(p []byte) (int, error) {
+
+	for {
+		n, err := t.reader.Read(p)
+		data := p[:0]
+
+		for _, b := range p[:n] {
+			switch t.state {
+			case 0:
+				if b == telnetIAC {
+					t.state = 1
+					continue
+				}
+				data = append(data, b)
+			case 1:
+				switch b {
+				case telnetIAC:
+					data = append(data, b) // Escaped 0xFF
+					t.state = 0
+				case telnetWILL, telnetWONT, telnetDO, telnetDONT:
+					t.command = b
+					t.state = 2
+				case telnetSB:
+					t.state = 3
+				default:
+					t.state = 0 // Other commands carry no option
+				}
+			case 2:
+				if replyErr := t.reply(t.command, b); replyErr != nil && err == nil {
+					err = replyErr
+				}
+				t.state = 0
+			case 3:
+				if b == telnetIAC {
+					t.state = 4
+				}
+			case 4:
+				if b == telnetSE {
+					t.state = 0
+				} else {
+					t.state = 3
+				}
+			}
+		}
+
+		// Only return when there is data or an error, a read of only Telnet commands is not EOF
+		if len(data) > 0 || err != nil {
+			return len(data), err
+		}
+	}
+}
+
+// reply answers an option negotiation
+func (t *telnetReader) reply(command, option byte) error {
+
+	var answer byte
+	switch command {
+	case telnetWILL:
+		answer = telnetDONT
+		if option == telnetOptionEcho || option == telnetOptionSuppressGoAhead {
+			answer = telnetDO
+		}
+	case telnetDO:
+		answer = telnetWONT
+	default:
+		return nil // WONT and DONT need no answer
+	}
+
+	_, err := t.writer.Write([]byte{telnetIAC, answer, option})
+
+	return err
+}
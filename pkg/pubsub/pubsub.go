@@ -0,0 +1,81 @@
+package pubsub
+
+import (
+	"sync"
+)
+
+// Broker is an in-process publish/subscribe broker that fans out every published message to all subscribers
+type Broker[T any] struct {
+	mu          sync.RWMutex
+	subscribers map[chan T]struct{}
+	closed      bool
+}
+
+// NewBroker returns an empty broker
+func NewBroker[T any]() *Broker[T] {
+	return &Broker[T]{subscribers: make(map[chan T]struct{})}
+}
+
+// Subscribe returns a channel receiving published messages and a function to cancel the subscription,
+// buffer is the number of messages kept for a slow subscriber before new messages are dropped for it
+func (b *Broker[T]) Subscribe(buffer int) (<-chan T, func()) {
+	ch := make(chan T, buffer)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// A closed broker never publishes again, so return a closed channel
+	if b.closed {
+		close(ch)
+		return ch, func() {}
+	}
+
+	b.subscribers[ch] = struct{}{}
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+
+			if _, ok := b.subscribers[ch]; ok {
+				delete(b.subscribers, ch)
+				close(ch)
+			}
+		})
+	}
+}
+
+// Publish sends msg to every subscriber without blocking and returns the number of subscribers that received it
+func (b *Broker[T]) Publish(msg T) int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	delivered := 0
+	for ch := range b.subscribers {
+		select {
+		case ch <- msg:
+			delivered++
+		default:
+			// Subscriber buffer is full, drop the message instead of blocking the publisher
+		}
+	}
+
+	return delivered
+}
+
+// Close closes every subscriber channel, Publish is a no-op after Close
+func (b *Broker[T]) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+	b.closed = true
+
+	for ch := range b.subscribers {
+		delete(b.subscribers, ch)
+		close(ch)
+	}
+}
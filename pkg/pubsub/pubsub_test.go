@@ -0,0 +1,55 @@
+package pubsub
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestBrokerPublish(t *testing.T) {
+	broker := NewBroker[string]()
+
+	first, cancelFirst := broker.Subscribe(1)
+	second, cancelSecond := broker.Subscribe(1)
+	defer cancelSecond()
+
+	assert.Equal(t, 2, broker.Publish("online"))
+	assert.Equal(t, "online", <-first)
+	assert.Equal(t, "online", <-second)
+
+	// Cancelled subscriber channel is closed and no longer receives messages
+	cancelFirst()
+	cancelFirst()
+	_, ok := <-first
+	assert.False(t, ok)
+	assert.Equal(t, 1, broker.Publish("offline"))
+	assert.Equal(t, "offline", <-second)
+}
+
+func TestBrokerSlowSubscriber(t *testing.T) {
+	broker := NewBroker[int]()
+
+	ch, cancel := broker.Subscribe(1)
+	defer cancel()
+
+	// Second message is dropped because the buffer is full
+	assert.Equal(t, 1, broker.Publish(1))
+	assert.Equal(t, 0, broker.Publish(2))
+	assert.Equal(t, 1, <-ch)
+}
+
+func TestBrokerClose(t *testing.T) {
+	broker := NewBroker[int]()
+
+	ch, cancel := broker.Subscribe(1)
+	broker.Close()
+	cancel()
+
+	_, ok := <-ch
+	assert.False(t, ok)
+	assert.Equal(t, 0, broker.Publish(1))
+
+	// Subscribing after Close returns a closed channel
+	late, _ := broker.Subscribe(1)
+	_, ok = <-late
+	assert.False(t, ok)
+}
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"crypto/tls"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"os"
+	"sync"
+	"time"
+)
+
+// CertificateReloader serves a TLS certificate and key pair, the files are checked at most once every interval during
+// handshakes and loaded again when they changed, the last good pair is kept when loading fails
+type CertificateReloader struct {
+	certFile string
+	keyFile  string
+	interval time.Duration
+	now      func() time.Time
+
+	mu          sync.Mutex
+	certificate *tls.Certificate
+	modTime     time.Time // Latest modification time of the files of certificate
+	checkedAt   time.Time
+}
+
+// NewCertificateReloader loads the certificate and key pair, it fails when they can't be loaded
+func NewCertificateReloader(certFile, keyFile string, interval time.Duration) (*CertificateReloader, error) {
+	r := &CertificateReloader{certFile: certFile, keyFile: keyFile, interval: interval, now: time.Now}
+
+	modTime, err := r.getModTime()
+	if err != nil {
+		return nil, err
+	}
+	if err := r.load(modTime); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// GetCertificate returns the current certificate, it is used as tls.Config.GetCertificate
+func (r *CertificateReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	if r.interval > 0 && now.Sub(r.checkedAt) >= r.interval {
+		r.checkedAt = now
+
+		modTime, err := r.getModTime()
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to check TLS certificate, keeping the loaded one")
+		} else if !modTime.Equal(r.modTime) {
+			if err := r.load(modTime); err != nil {
+				log.Error().Err(err).Msg("Failed to reload TLS certificate, keeping the loaded one")
+			} else {
+				log.Info().Msg("Reloaded TLS certificate " + r.certFile)
+			}
+		}
+	}
+
+	return r.certificate, nil
+}
+
+// load is a method to load the certificate and key pair, the caller holds mu unless r isn't shared yet
+func (r *CertificateReloader) load(modTime time.Time) error {
+	certificate, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+
+	r.certificate = &certificate
+	r.modTime = modTime
+	r.checkedAt = r.now()
+	return nil
+}
+
+// getModTime is a method to get the latest modification time of the certificate and key files
+func (r *CertificateReloader) getModTime() (time.Time, error) {
+	var modTime time.Time
+	for _, file := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("failed to read TLS certificate: %w", err)
+		}
+		if info.ModTime().After(modTime) {
+			modTime = info.ModTime()
+		}
+	}
+	return modTime, nil
+}
@@ -0,0 +1,93 @@
+package server
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/config"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// NewServer is a function to create the HTTP server of a server config, TLS certificates are loaded here,
+// serve it with ListenAndServeTLS("", "") when TLSConfig is set
+func NewServer(serverCfg config.ServerConfig, handler http.Handler) (*http.Server, error) {
+
+	if serverCfg.Port == "" {
+		return nil, errors.New("server port is not set")
+	}
+
+	for name, value := range map[string]int{
+		"read_timeout":        serverCfg.ReadTimeout,
+		"read_header_timeout": serverCfg.ReadHeaderTimeout,
+		"write_timeout":       serverCfg.WriteTimeout,
+		"idle_timeout":        serverCfg.IdleTimeout,
+		"max_header_bytes":    serverCfg.MaxHeaderBytes,
+	} {
+		if value < 0 {
+			return nil, fmt.Errorf("server %s must not be negative", name)
+		}
+	}
+
+	server := &http.Server{
+		Addr:              net.JoinHostPort(serverCfg.Host, serverCfg.Port),
+		Handler:           handler,
+		ReadTimeout:       time.Duration(serverCfg.ReadTimeout) * time.Second,
+		ReadHeaderTimeout: time.Duration(serverCfg.ReadHeaderTimeout) * time.Second,
+		WriteTimeout:      time.Duration(serverCfg.WriteTimeout) * time.Second,
+		IdleTimeout:       time.Duration(serverCfg.IdleTimeout) * time.Second,
+		MaxHeaderBytes:    serverCfg.MaxHeaderBytes, // 0 is the 1 MiB default of net/http
+	}
+
+	if serverCfg.TLS.Enabled {
+		if serverCfg.TLS.CertFile == "" || serverCfg.TLS.KeyFile == "" {
+			return nil, errors.New("server tls needs cert_file and key_file")
+		}
+		reloadInterval := time.Duration(serverCfg.TLS.ReloadInterval) * time.Second
+		if reloadInterval <= 0 {
+			reloadInterval = time.Minute
+		}
+
+		reloader, err := NewCertificateReloader(serverCfg.TLS.CertFile, serverCfg.TLS.KeyFile, reloadInterval)
+		if err != nil {
+			return nil, err
+		}
+		server.TLSConfig = &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: reloader.GetCertificate,
+		}
+	}
+
+	return server, nil
+}
+
+// ParseTrustedProxies is a function to parse IPs and CIDRs of trusted proxies, an IP is a network of one address
+func ParseTrustedProxies(proxies []string) ([]*net.IPNet, error) {
+
+	networks := make([]*net.IPNet, 0, len(proxies))
+	for _, proxy := range proxies {
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q, it must be an IP or CIDR", proxy)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip = ip.To4()
+				bits = 8 * net.IPv4len
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q, it must be an IP or CIDR", proxy)
+		}
+		networks = append(networks, network)
+	}
+
+	return networks, nil
+}
@@ -0,0 +1,133 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeCertificate writes a self-signed certificate of a common name and its key to certFile and keyFile
+func writeCertificate(t *testing.T, certFile, keyFile, commonName string, modTime time.Time) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0o600))
+	require.NoError(t, os.Chtimes(certFile, modTime, modTime))
+	require.NoError(t, os.Chtimes(keyFile, modTime, modTime))
+}
+
+func getCommonName(t *testing.T, reloader *CertificateReloader) string {
+	certificate, err := reloader.GetCertificate(&tls.ClientHelloInfo{})
+	require.NoError(t, err)
+	leaf, err := x509.ParseCertificate(certificate.Certificate[0])
+	require.NoError(t, err)
+	return leaf.Subject.CommonName
+}
+
+func TestCertificateReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	modTime := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	writeCertificate(t, certFile, keyFile, "first", modTime)
+
+	reloader, err := NewCertificateReloader(certFile, keyFile, time.Minute)
+	require.NoError(t, err)
+	now := time.Now()
+	reloader.now = func() time.Time { return now }
+	assert.Equal(t, "first", getCommonName(t, reloader))
+
+	// Renewed files are loaded on the first handshake after the interval
+	writeCertificate(t, certFile, keyFile, "renewed", modTime.Add(time.Hour))
+	assert.Equal(t, "first", getCommonName(t, reloader))
+	now = now.Add(time.Minute)
+	assert.Equal(t, "renewed", getCommonName(t, reloader))
+
+	// A broken pair, e.g. the certificate is written but the key isn't yet, keeps the loaded certificate
+	require.NoError(t, os.WriteFile(keyFile, []byte("not a key"), 0o600))
+	now = now.Add(time.Minute)
+	assert.Equal(t, "renewed", getCommonName(t, reloader))
+
+	_, err = NewCertificateReloader(certFile, keyFile, time.Minute)
+	assert.Error(t, err)
+	_, err = NewCertificateReloader(filepath.Join(dir, "missing.crt"), keyFile, time.Minute)
+	assert.Error(t, err)
+}
+
+func TestNewServer(t *testing.T) {
+	handler := http.NotFoundHandler()
+
+	server, err := NewServer(config.ServerConfig{
+		Port: "8081", ReadTimeout: 30, ReadHeaderTimeout: 10, IdleTimeout: 120, MaxHeaderBytes: 65536,
+	}, handler)
+	require.NoError(t, err)
+	assert.Equal(t, ":8081", server.Addr)
+	assert.Equal(t, 30*time.Second, server.ReadTimeout)
+	assert.Equal(t, 10*time.Second, server.ReadHeaderTimeout)
+	assert.Equal(t, time.Duration(0), server.WriteTimeout)
+	assert.Equal(t, 120*time.Second, server.IdleTimeout)
+	assert.Equal(t, 65536, server.MaxHeaderBytes)
+	assert.Nil(t, server.TLSConfig)
+
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	writeCertificate(t, certFile, keyFile, "api", time.Now())
+	server, err = NewServer(config.ServerConfig{Host: "::1", Port: "8443", TLS: config.ServerTLSConfig{
+		Enabled: true, CertFile: certFile, KeyFile: keyFile,
+	}}, handler)
+	require.NoError(t, err)
+	assert.Equal(t, "[::1]:8443", server.Addr)
+	require.NotNil(t, server.TLSConfig)
+	assert.NotNil(t, server.TLSConfig.GetCertificate)
+
+	invalid := []config.ServerConfig{
+		{},
+		{Port: "8081", IdleTimeout: -1},
+		{Port: "8443", TLS: config.ServerTLSConfig{Enabled: true}},
+		{Port: "8443", TLS: config.ServerTLSConfig{Enabled: true, CertFile: keyFile, KeyFile: certFile}},
+	}
+	for _, serverCfg := range invalid {
+		_, err := NewServer(serverCfg, handler)
+		assert.Error(t, err, "%+v", serverCfg)
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	networks, err := ParseTrustedProxies([]string{"10.0.0.0/8", "192.0.2.1", "2001:db8::1"})
+	require.NoError(t, err)
+	require.Len(t, networks, 3)
+	assert.True(t, networks[0].Contains(net.ParseIP("10.1.2.3")))
+	assert.True(t, networks[1].Contains(net.ParseIP("192.0.2.1")))
+	assert.False(t, networks[1].Contains(net.ParseIP("192.0.2.2")))
+	assert.True(t, networks[2].Contains(net.ParseIP("2001:db8::1")))
+
+	for _, proxy := range []string{"proxy.example.com", "10.0.0.0/33", ""} {
+		_, err := ParseTrustedProxies([]string{proxy})
+		assert.Error(t, err, proxy)
+	}
+}
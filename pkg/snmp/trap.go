@@ -0,0 +1,164 @@
+package snmp
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/gosnmp/gosnmp"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/config"
+	"github.com/rs/zerolog/log"
+	"net"
+	"strings"
+)
+
+var ErrTrapNotConfigured = errors.New("snmp trap listener is not enabled")
+
+// TrapHandler is called for every accepted trap or inform
+type TrapHandler func(packet *gosnmp.SnmpPacket, addr *net.UDPAddr)
+
+// TrapListener receives SNMP v2c and v3 traps and informs from the OLT
+type TrapListener struct {
+	listener  *gosnmp.TrapListener
+	handler   TrapHandler
+	address   string
+	community string
+	username  string
+}
+
+// SetupTrapListener is a function to set up the trap listener, it returns ErrTrapNotConfigured if it is not enabled
+func SetupTrapListener(cfg *config.Config, handler TrapHandler) (*TrapListener, error) {
+
+	trapCfg := cfg.TrapCfg
+
+	if !trapCfg.Enabled {
+		return nil, ErrTrapNotConfigured
+	}
+
+	return NewTrapListener(trapCfg, handler)
+}
+
+// NewTrapListener returns a trap listener for the given config, SNMP v3 is accepted when a username is set
+func NewTrapListener(trapCfg config.TrapConfig, handler TrapHandler) (*TrapListener, error) {
+
+	params := &gosnmp.GoSNMP{
+		Version:   gosnmp.Version2c,
+		Community: trapCfg.Community,
+		Logger:    gosnmp.Logger{},
+	}
+
+	if trapCfg.Username != "" {
+		securityParameters, msgFlags, err := getUsmSecurityParameters(trapCfg)
+		if err != nil {
+			return nil, err
+		}
+		params.Version = gosnmp.Version3
+		params.SecurityModel = gosnmp.UserSecurityModel
+		params.MsgFlags = msgFlags
+		params.SecurityParameters = securityParameters
+	}
+
+	address := trapCfg.Address
+	if address == "" {
+		address = "0.0.0.0:162"
+	}
+
+	t := &TrapListener{
+		listener:  gosnmp.NewTrapListener(),
+		handler:   handler,
+		address:   address,
+		community: trapCfg.Community,
+		username:  trapCfg.Username,
+	}
+	t.listener.Params = params
+	t.listener.OnNewTrap = t.onNewTrap
+
+	return t, nil
+}
+
+// Listen receives traps until Close is called
+func (t *TrapListener) Listen() error {
+	return t.listener.Listen(t.address)
+}
+
+// Listening returns a channel that receives a value once the listener is ready
+func (t *TrapListener) Listening() <-chan bool {
+	return t.listener.Listening()
+}
+
+// Close stops the listener, it must only be called after Listening has fired
+func (t *TrapListener) Close() {
+	t.listener.Close()
+}
+
+// onNewTrap drops traps with a wrong community or user before passing them to the handler
+func (t *TrapListener) onNewTrap(packet *gosnmp.SnmpPacket, addr *net.UDPAddr) {
+	switch packet.Version {
+	case gosnmp.Version1, gosnmp.Version2c:
+		if t.community != "" && packet.Community != t.community {
+			log.Warn().Msg("Dropped SNMP trap with unknown community from " + addr.String())
+			return
+		}
+	case gosnmp.Version3:
+		// Authentication and decryption are already checked by gosnmp against the configured user
+		securityParameters, ok := packet.SecurityParameters.(*gosnmp.UsmSecurityParameters)
+		if !ok || securityParameters.UserName != t.username {
+			log.Warn().Msg("Dropped SNMP trap with unknown user from " + addr.String())
+			return
+		}
+	}
+
+	t.handler(packet, addr)
+}
+
+// getUsmSecurityParameters is a function to build the SNMP v3 user security parameters
+func getUsmSecurityParameters(trapCfg config.TrapConfig) (*gosnmp.UsmSecurityParameters, gosnmp.SnmpV3MsgFlags, error) {
+
+	// Traps are sent by the OLT, so the OLT engine ID is the authoritative engine ID
+	engineID, err := hex.DecodeString(strings.TrimPrefix(strings.ToLower(trapCfg.EngineID), "0x"))
+	if err != nil || len(engineID) < 5 || len(engineID) > 32 {
+		return nil, 0, fmt.Errorf("invalid trap engine id %q, must be 5-32 bytes in hex", trapCfg.EngineID)
+	}
+
+	securityParameters := &gosnmp.UsmSecurityParameters{
+		UserName:                 trapCfg.Username,
+		AuthoritativeEngineID:    string(engineID),
+		AuthenticationProtocol:   gosnmp.NoAuth,
+		PrivacyProtocol:          gosnmp.NoPriv,
+		AuthenticationPassphrase: trapCfg.AuthPassphrase,
+		PrivacyPassphrase:        trapCfg.PrivPassphrase,
+	}
+	msgFlags := gosnmp.NoAuthNoPriv
+
+	if trapCfg.AuthPassphrase != "" {
+		switch strings.ToUpper(trapCfg.AuthProtocol) {
+		case "MD5":
+			securityParameters.AuthenticationProtocol = gosnmp.MD5
+		case "SHA", "":
+			securityParameters.AuthenticationProtocol = gosnmp.SHA
+		case "SHA256":
+			securityParameters.AuthenticationProtocol = gosnmp.SHA256
+		case "SHA512":
+			securityParameters.AuthenticationProtocol = gosnmp.SHA512
+		default:
+			return nil, 0, fmt.Errorf("unsupported trap auth protocol %q", trapCfg.AuthProtocol)
+		}
+		msgFlags = gosnmp.AuthNoPriv
+	}
+
+	if trapCfg.PrivPassphrase != "" {
+		if msgFlags == gosnmp.NoAuthNoPriv {
+			return nil, 0, errors.New("trap privacy requires an auth passphrase")
+		}
+		switch strings.ToUpper(trapCfg.PrivProtocol) {
+		case "DES":
+			securityParameters.PrivacyProtocol = gosnmp.DES
+		case "AES", "":
+			securityParameters.PrivacyProtocol = gosnmp.AES
+		default:
+			return nil, 0, fmt.Errorf("unsupported trap priv protocol %q", trapCfg.PrivProtocol)
+		}
+		msgFlags = gosnmp.AuthPriv
+	}
+
+	return securityParameters, msgFlags, nil
+}
@@ -0,0 +1,140 @@
+package snmp
+
+import (
+	"github.com/gosnmp/gosnmp"
+	"github.com/megadata-dev/go-snmp-olt-zte-c320/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net"
+	"testing"
+	"time"
+)
+
+const (
+	testTrapOID     = ".1.3.6.1.4.1.3902.1082.500.10.2.3.0.1"
+	testTrapEngine  = "80001f8880c320c320"
+	testTrapTimeout = 2 * time.Second
+)
+
+// startTestTrapListener starts a trap listener on a free localhost port and returns the port and received traps
+func startTestTrapListener(t *testing.T, trapCfg config.TrapConfig) (uint16, <-chan *gosnmp.SnmpPacket) {
+	// Reserve a free UDP port for the listener
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := conn.LocalAddr().(*net.UDPAddr).Port
+	require.NoError(t, conn.Close())
+
+	received := make(chan *gosnmp.SnmpPacket, 10)
+	trapCfg.Address = conn.LocalAddr().String()
+	listener, err := NewTrapListener(trapCfg, func(packet *gosnmp.SnmpPacket, _ *net.UDPAddr) {
+		received <- packet
+	})
+	require.NoError(t, err)
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- listener.Listen()
+	}()
+
+	select {
+	case <-listener.Listening():
+	case err := <-errCh:
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(listener.Close)
+
+	return uint16(port), received
+}
+
+// sendTestTrap sends a state change trap to the listener
+func sendTestTrap(t *testing.T, sender *gosnmp.GoSNMP) {
+	require.NoError(t, sender.Connect())
+	defer sender.Conn.Close()
+
+	_, err := sender.SendTrap(gosnmp.SnmpTrap{Variables: []gosnmp.SnmpPDU{
+		{Name: ".1.3.6.1.6.3.1.1.4.1.0", Type: gosnmp.ObjectIdentifier, Value: testTrapOID},
+		{Name: ".1.3.6.1.4.1.3902.1082.500.10.2.3.8.1.4.285278465.1", Type: gosnmp.Integer, Value: 4},
+	}})
+	require.NoError(t, err)
+}
+
+func TestTrapListenerV2c(t *testing.T) {
+	port, received := startTestTrapListener(t, config.TrapConfig{Community: "public"})
+
+	// Trap with a wrong community is dropped
+	sendTestTrap(t, &gosnmp.GoSNMP{Target: "127.0.0.1", Port: port, Community: "private",
+		Version: gosnmp.Version2c, Timeout: testTrapTimeout})
+	sendTestTrap(t, &gosnmp.GoSNMP{Target: "127.0.0.1", Port: port, Community: "public",
+		Version: gosnmp.Version2c, Timeout: testTrapTimeout})
+
+	select {
+	case packet := <-received:
+		assert.Equal(t, "public", packet.Community)
+		assert.Equal(t, testTrapOID, packet.Variables[1].Value)
+		assert.Equal(t, 4, packet.Variables[2].Value)
+	case <-time.After(testTrapTimeout):
+		t.Fatal("trap not received")
+	}
+
+	select {
+	case packet := <-received:
+		t.Fatalf("unexpected trap with community %q", packet.Community)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestTrapListenerV3(t *testing.T) {
+	trapCfg := config.TrapConfig{
+		Community:      "public",
+		Username:       "trapuser",
+		AuthProtocol:   "SHA",
+		AuthPassphrase: "authpass1",
+		PrivProtocol:   "AES",
+		PrivPassphrase: "privpass1",
+		EngineID:       testTrapEngine,
+	}
+	port, received := startTestTrapListener(t, trapCfg)
+
+	securityParameters, msgFlags, err := getUsmSecurityParameters(trapCfg)
+	require.NoError(t, err)
+	securityParameters.AuthoritativeEngineBoots = 1
+	securityParameters.AuthoritativeEngineTime = 1
+
+	sendTestTrap(t, &gosnmp.GoSNMP{Target: "127.0.0.1", Port: port, Version: gosnmp.Version3,
+		SecurityModel: gosnmp.UserSecurityModel, MsgFlags: msgFlags, SecurityParameters: securityParameters,
+		Timeout: testTrapTimeout})
+
+	select {
+	case packet := <-received:
+		assert.Equal(t, gosnmp.Version3, packet.Version)
+		assert.Equal(t, testTrapOID, packet.Variables[1].Value)
+	case <-time.After(testTrapTimeout):
+		t.Fatal("trap not received")
+	}
+}
+
+func TestNewTrapListenerInvalidConfig(t *testing.T) {
+	testCases := []struct {
+		name    string
+		trapCfg config.TrapConfig
+	}{
+		{"Missing engine ID", config.TrapConfig{Username: "trapuser"}},
+		{"Invalid engine ID", config.TrapConfig{Username: "trapuser", EngineID: "zz"}},
+		{"Unsupported auth protocol", config.TrapConfig{Username: "trapuser", EngineID: testTrapEngine,
+			AuthProtocol: "SHA1024", AuthPassphrase: "authpass1"}},
+		{"Privacy without auth", config.TrapConfig{Username: "trapuser", EngineID: testTrapEngine,
+			PrivPassphrase: "privpass1"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewTrapListener(tc.trapCfg, func(*gosnmp.SnmpPacket, *net.UDPAddr) {})
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestSetupTrapListenerDisabled(t *testing.T) {
+	_, err := SetupTrapListener(&config.Config{}, func(*gosnmp.SnmpPacket, *net.UDPAddr) {})
+	assert.ErrorIs(t, err, ErrTrapNotConfigured)
+}